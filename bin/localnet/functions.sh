@@ -28,13 +28,23 @@ setupInfra () {
     fi
 
 
-    ${dockerComposeCmd} up -d broker geth
+    ${dockerComposeCmd} up -d geth
     if [ ! $? -eq 0 ]; then
         print_error "Error starting other services"
         cleanup "$@"
         exit 1
     fi
 
+    # nodes running embedded broker need none
+    if ${dockerComposeCmd} config --services | grep -q "^broker$"; then
+        ${dockerComposeCmd} up -d broker
+        if [ ! $? -eq 0 ]; then
+            print_error "Error starting broker"
+            cleanup "$@"
+            exit 1
+        fi
+    fi
+
     ${dockerComposeCmd} up -d db # start database first - it takes about 10 sec untils db startsup, and otherwise db migration fails
     if [ ! $? -eq 0 ]; then
         print_error "Db startup failed"
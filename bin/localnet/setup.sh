@@ -4,4 +4,4 @@ set -e
 
 source bin/localnet/functions.sh
 
-setup "localnet" "bin/localnet/publish-ports.yml"
\ No newline at end of file
+setup "localnet" "bin/localnet/broker.yml" "bin/localnet/publish-ports.yml"
\ No newline at end of file
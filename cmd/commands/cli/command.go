@@ -33,6 +33,8 @@ import (
 	"github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	proxy_service "github.com/mysteriumnetwork/node/services/proxy/service"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
@@ -48,6 +50,7 @@ const serviceHelp = `service <action> [args]
 	status	<ServiceID>
 	list
 	sessions
+	kill	<SessionID> [deny]
 
 	example: service start 0x7d5ee3557775aed0b85d691b036769c17349db23 openvpn --openvpn.port=1194 --openvpn.proto=UDP`
 
@@ -57,7 +60,10 @@ func NewCommand() *cli.Command {
 		Name:  cliCommandName,
 		Usage: "Starts a CLI client with a Tequilapi",
 		Action: func(ctx *cli.Context) error {
-			nodeOptions := cmd.ParseFlagsNode(ctx)
+			nodeOptions, err := cmd.ParseFlagsNode(ctx)
+			if err != nil {
+				return err
+			}
 			cmdCLI := &cliApp{
 				historyFile: filepath.Join(nodeOptions.Directories.Data, ".cli_history"),
 				tequilapi:   tequilapi_client.NewClient(nodeOptions.TequilapiAddress, nodeOptions.TequilapiPort),
@@ -209,6 +215,12 @@ func (c *cliApp) service(argsString string) {
 		c.serviceList()
 	case "sessions":
 		c.serviceSessions()
+	case "kill":
+		if len(args) < 2 {
+			fmt.Println(serviceHelp)
+			return
+		}
+		c.serviceSessionTerminate(args[1], len(args) > 2 && args[2] == "deny")
 	default:
 		info(fmt.Sprintf("Unknown action provided: %s", action))
 		fmt.Println(serviceHelp)
@@ -271,6 +283,15 @@ func (c *cliApp) serviceSessions() {
 	}
 }
 
+func (c *cliApp) serviceSessionTerminate(id string, deny bool) {
+	if err := c.tequilapi.ServiceSessionTerminate(id, deny); err != nil {
+		info("Failed to terminate session: ", err)
+		return
+	}
+
+	status("Terminated", "ID: "+id)
+}
+
 func (c *cliApp) serviceGet(id string) {
 	service, err := c.tequilapi.Service(id)
 	if err != nil {
@@ -288,7 +309,7 @@ func (c *cliApp) connect(argsString string) {
 	args := strings.Fields(argsString)
 
 	if len(args) < 3 {
-		info("Please type in the provider identity. Connect <consumer-identity> <provider-identity> <service-type> [disable-kill-switch]")
+		info("Please type in the provider identity. Connect <consumer-identity> <provider-identity> <service-type> [disable-kill-switch] [issuer-identity]")
 		return
 	}
 
@@ -306,6 +327,9 @@ func (c *cliApp) connect(argsString string) {
 	}
 
 	connectOptions := tequilapi_client.ConnectOptions{DisableKillSwitch: disableKill}
+	if len(args) > 4 {
+		connectOptions.IssuerID = args[4]
+	}
 
 	if consumerID == "new" {
 		id, err := c.tequilapi.NewIdentity(identityDefaultPassphrase)
@@ -422,6 +446,9 @@ func (c *cliApp) status() {
 			warn(err)
 		} else {
 			info("Proposal:", status.Proposal)
+			if len(status.Capabilities) > 0 {
+				info("Capabilities:", strings.Join(status.Capabilities, ", "))
+			}
 			info(fmt.Sprintf("Connection duration: %ds", statistics.Duration))
 			info("Bytes sent:", statistics.BytesSent)
 			info("Bytes received:", statistics.BytesReceived)
@@ -441,6 +468,9 @@ func (c *cliApp) healthcheck() {
 	info(fmt.Sprintf("Version: %v", healthcheck.Version))
 	buildString := metadata.FormatString(healthcheck.BuildInfo.Commit, healthcheck.BuildInfo.Branch, healthcheck.BuildInfo.BuildNumber)
 	info(buildString)
+	if healthcheck.Broker != "" {
+		info(fmt.Sprintf("Broker: %v", healthcheck.Broker))
+	}
 }
 
 func (c *cliApp) proposals(filter string) {
@@ -654,11 +684,13 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []tequilapi_
 				readline.PcItem("noop"),
 				readline.PcItem("openvpn"),
 				readline.PcItem("wireguard"),
+				readline.PcItem("proxy"),
 			)),
 			readline.PcItem("stop"),
 			readline.PcItem("list"),
 			readline.PcItem("status"),
 			readline.PcItem("sessions"),
+			readline.PcItem("kill"),
 		),
 		readline.PcItem(
 			"identities",
@@ -705,6 +737,8 @@ func parseServiceOptions(serviceType string, args ...string) (service.Options, e
 	var flags []cli.Flag
 	openvpn_service.RegisterFlags(&flags)
 	wireguard_service.RegisterFlags(&flags)
+	proxy_service.RegisterFlags(&flags)
+	noop.RegisterFlags(&flags)
 
 	set := flag.NewFlagSet("", flag.ContinueOnError)
 	for _, f := range flags {
@@ -719,11 +753,13 @@ func parseServiceOptions(serviceType string, args ...string) (service.Options, e
 
 	switch serviceType {
 	case noop.ServiceType:
-		return noop.ParseFlags(ctx), nil
+		return noop.ParseFlags(ctx)
 	case wireguard.ServiceType:
-		return wireguard_service.ParseFlags(ctx), nil
+		return wireguard_service.ParseFlags(ctx)
 	case openvpn.ServiceType:
-		return openvpn_service.ParseFlags(ctx), nil
+		return openvpn_service.ParseFlags(ctx)
+	case proxy.ServiceType:
+		return proxy_service.ParseFlags(ctx)
 	}
 
 	return nil, errors.New("service type not found")
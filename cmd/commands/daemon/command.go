@@ -32,7 +32,11 @@ func NewCommand() *cli.Command {
 		ArgsUsage: " ",
 		Action: func(ctx *cli.Context) error {
 			errorChannel := make(chan error, 2)
-			if err := di.Bootstrap(cmd.ParseFlagsNode(ctx)); err != nil {
+			nodeOptions, err := cmd.ParseFlagsNode(ctx)
+			if err != nil {
+				return err
+			}
+			if err := di.Bootstrap(nodeOptions); err != nil {
 				return err
 			}
 			go func() { errorChannel <- di.Node.Wait() }()
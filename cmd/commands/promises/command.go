@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package promises
+
+import (
+	"io"
+	"os"
+
+	"github.com/mysteriumnetwork/node/cmd"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/mysteriumnetwork/node/session/promise/ledger"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	issuerFlag = cli.StringFlag{
+		Name:  "issuer",
+		Usage: "List only promises of the given issuer",
+	}
+	consumerFlag = cli.StringFlag{
+		Name:  "consumer",
+		Usage: "List only promises of the given consumer",
+	}
+	formatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Export format: json or csv",
+		Value: string(ledger.FormatJSON),
+	}
+	outputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "File to export promises to, standard output if not given",
+	}
+)
+
+// NewCommand function creates promises command
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "promises",
+		Usage:     "Exports promises received by provider and verifies their signatures",
+		ArgsUsage: " ",
+		Description: "Reads promises directly from node database, so works without running node. " +
+			"While node is running use tequilapi GET /promises instead",
+		Flags: []cli.Flag{issuerFlag, consumerFlag, formatFlag, outputFlag},
+		Action: func(ctx *cli.Context) error {
+			format, err := ledger.ParseFormat(ctx.String(formatFlag.Name))
+			if err != nil {
+				return err
+			}
+
+			storage, err := boltdb.NewReadOnlyStorage(cmd.ParseFlagsDirectory(ctx).Storage)
+			if err != nil {
+				return errors.Wrap(err, "is node running?")
+			}
+			defer storage.Close()
+
+			entries, err := ledger.NewLedger(promise.NewStorage(storage)).Entries(ledger.Filter{
+				Issuer:   identity.FromAddress(ctx.String(issuerFlag.Name)),
+				Consumer: identity.FromAddress(ctx.String(consumerFlag.Name)),
+			})
+			if err != nil {
+				return err
+			}
+
+			return export(ctx.App.Writer, ctx.String(outputFlag.Name), format, entries)
+		},
+	}
+}
+
+func export(stdout io.Writer, output string, format ledger.Format, entries []ledger.Entry) error {
+	if output == "" {
+		return ledger.Export(stdout, format, entries)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := ledger.Export(file, format, entries); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -28,7 +28,9 @@ import (
 	"github.com/mysteriumnetwork/node/identity"
 	identity_selector "github.com/mysteriumnetwork/node/identity/selector"
 	"github.com/mysteriumnetwork/node/metadata"
+	"github.com/mysteriumnetwork/node/services/noop"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	proxy_service "github.com/mysteriumnetwork/node/services/proxy/service"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/tequilapi/client"
 	"github.com/urfave/cli"
@@ -68,7 +70,10 @@ func NewCommand(licenseCommandName string) *cli.Command {
 			}
 
 			errorChannel := make(chan error)
-			nodeOptions := cmd.ParseFlagsNode(ctx)
+			nodeOptions, err := cmd.ParseFlagsNode(ctx)
+			if err != nil {
+				return err
+			}
 			if err := di.Bootstrap(nodeOptions); err != nil {
 				return err
 			}
@@ -160,6 +165,8 @@ func registerFlags(flags *[]cli.Flag) {
 	)
 	openvpn_service.RegisterFlags(flags)
 	wireguard_service.RegisterFlags(flags)
+	proxy_service.RegisterFlags(flags)
+	noop.RegisterFlags(flags)
 }
 
 // parseFlags function fills in service command options from CLI context
@@ -172,7 +179,7 @@ func parseFlags(ctx *cli.Context) service.OptionsIdentity {
 
 func parseFlagsByServiceType(ctx *cli.Context, serviceType string) (service.Options, error) {
 	if f, ok := serviceTypesFlagsParser[serviceType]; ok {
-		return f(ctx), nil
+		return f(ctx)
 	}
 	return service.OptionsIdentity{}, fmt.Errorf("unknown service type: %q", serviceType)
 }
@@ -22,17 +22,20 @@ import (
 	"github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	proxy_service "github.com/mysteriumnetwork/node/services/proxy/service"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/urfave/cli"
 )
 
 var (
-	serviceTypes = []string{"openvpn", "wireguard", "noop"}
+	serviceTypes = []string{"openvpn", "wireguard", "proxy", "noop"}
 
-	serviceTypesFlagsParser = map[string]func(ctx *cli.Context) service.Options{
+	serviceTypesFlagsParser = map[string]func(ctx *cli.Context) (service.Options, error){
 		noop.ServiceType:      noop.ParseFlags,
 		openvpn.ServiceType:   openvpn_service.ParseFlags,
 		wireguard.ServiceType: wireguard_service.ParseFlags,
+		proxy.ServiceType:     proxy_service.ParseFlags,
 	}
 )
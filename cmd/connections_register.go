@@ -28,6 +28,7 @@ import (
 func (di *Dependencies) registerConnections(nodeOptions node.Options) {
 	di.registerOpenvpnConnection(nodeOptions)
 	di.registerNoopConnection()
+	di.registerProxyConnection()
 	di.registerWireguardConnection()
 }
 
@@ -25,4 +25,5 @@ import (
 
 func (di *Dependencies) registerConnections(nodeOptions node.Options) {
 	di.registerNoopConnection()
+	di.registerProxyConnection()
 }
@@ -29,6 +29,8 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/mysteriumnetwork/node/blockchain"
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/communication/direct"
+	"github.com/mysteriumnetwork/node/communication/nats/broker"
 	nats_dialog "github.com/mysteriumnetwork/node/communication/nats/dialog"
 	nats_discovery "github.com/mysteriumnetwork/node/communication/nats/discovery"
 	consumer_session "github.com/mysteriumnetwork/node/consumer/session"
@@ -49,21 +51,24 @@ import (
 	"github.com/mysteriumnetwork/node/market/mysterium"
 	"github.com/mysteriumnetwork/node/metadata"
 	"github.com/mysteriumnetwork/node/metrics"
-	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/nat"
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	"github.com/mysteriumnetwork/node/nat/traversal/config"
 	service_noop "github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
-	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
+	service_proxy "github.com/mysteriumnetwork/node/services/proxy"
+	proxy_connection "github.com/mysteriumnetwork/node/services/proxy/connection"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
 	session_payment "github.com/mysteriumnetwork/node/session/payment"
 	payment_factory "github.com/mysteriumnetwork/node/session/payment/factory"
 	payments_noop "github.com/mysteriumnetwork/node/session/payment/noop"
 	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/mysteriumnetwork/node/session/promise/ledger"
+	"github.com/mysteriumnetwork/node/session/promise/settlement"
 	"github.com/mysteriumnetwork/node/session/promise/validators"
+	"github.com/mysteriumnetwork/node/session/trial"
 	"github.com/mysteriumnetwork/node/tequilapi"
 	tequilapi_endpoints "github.com/mysteriumnetwork/node/tequilapi/endpoints"
 	"github.com/mysteriumnetwork/node/utils"
@@ -103,6 +108,11 @@ type NatEventSender interface {
 	ConsumeNATEvent(event traversal.Event)
 }
 
+// DialogRejectionsSender is responsible for sending rejected dialog requests to metrics server
+type DialogRejectionsSender interface {
+	ConsumeRejection(event nats_dialog.DialogRejectedEvent)
+}
+
 // Dependencies is DI container for top level components which is reused in several places
 type Dependencies struct {
 	Node *node.Node
@@ -111,6 +121,7 @@ type Dependencies struct {
 	MysteriumAPI         *mysterium.MysteriumAPI
 	MysteriumMorqaClient market_metrics.QualityOracle
 	EtherClient          *ethclient.Client
+	EmbeddedBroker       *broker.Embedded
 
 	NATService           nat.NATService
 	Storage              Storage
@@ -130,19 +141,31 @@ type Dependencies struct {
 	StatisticsReporter *statistics.SessionStatisticsReporter
 	SessionStorage     *consumer_session.Storage
 
-	EventBus EventBus.Bus
+	EventBus      EventBus.Bus
+	BrokerTracker *nats_discovery.BrokerTracker
 
 	ConnectionManager  connection.Manager
 	ConnectionRegistry *connection.Registry
+	SpendingTracker    *connection.SpendingTracker
 
 	ServicesManager       *service.Manager
 	ServiceRegistry       *service.Registry
 	ServiceSessionStorage *session.StorageMemory
+	ServiceSessionReaper  *session.Reaper
+	ServiceDenylist       *session.Denylist
+	ServiceViolations     *session_payment.ViolationTracker
+	ServiceTrialGranter   *trial.Granter
+	ServiceTerminator     *session.Terminator
+	ServiceSubnets        *ip.SubnetAllocator
+	DirectServer          *direct.Server
+	PromiseSettler        *settlement.Settler
 
 	NATPinger      NatPinger
 	NATTracker     NatEventTracker
 	NATEventSender NatEventSender
 
+	DialogRejectionsSender DialogRejectionsSender
+
 	LastSessionShutdown chan struct{}
 
 	MetricsSender *metrics.Sender
@@ -152,6 +175,7 @@ type Dependencies struct {
 func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 	logconfig.Bootstrap()
 	nats_discovery.Bootstrap()
+	direct.Bootstrap()
 
 	log.Infof("Starting Mysterium Node (%s)", metadata.VersionAsString())
 
@@ -213,6 +237,11 @@ func (di *Dependencies) registerNoopConnection() {
 	di.ConnectionRegistry.Register(service_noop.ServiceType, service_noop.NewConnectionCreator())
 }
 
+func (di *Dependencies) registerProxyConnection() {
+	service_proxy.Bootstrap()
+	di.ConnectionRegistry.Register(service_proxy.ServiceType, proxy_connection.NewConnectionCreator(proxy_connection.DefaultLocalAddress))
+}
+
 // Shutdown stops container
 func (di *Dependencies) Shutdown() (err error) {
 	var errs []error
@@ -230,6 +259,15 @@ func (di *Dependencies) Shutdown() (err error) {
 			errs = append(errs, err)
 		}
 	}
+	if di.ServiceSessionReaper != nil {
+		di.ServiceSessionReaper.Stop()
+	}
+	if di.PromiseSettler != nil {
+		di.PromiseSettler.Stop()
+	}
+	if di.DirectServer != nil {
+		di.DirectServer.Stop()
+	}
 	if di.NATService != nil {
 		if err := di.NATService.Disable(); err != nil {
 			errs = append(errs, err)
@@ -241,6 +279,9 @@ func (di *Dependencies) Shutdown() (err error) {
 			errs = append(errs, err)
 		}
 	}
+	if di.EmbeddedBroker != nil {
+		di.EmbeddedBroker.Stop()
+	}
 	if di.Storage != nil {
 		if err := di.Storage.Close(); err != nil {
 			errs = append(errs, err)
@@ -293,12 +334,46 @@ func (di *Dependencies) subscribeEventConsumers() error {
 	if err != nil {
 		return err
 	}
+
+	// dialog events
+	err = di.EventBus.Subscribe(nats_dialog.DialogRejectedEventTopic, di.DialogRejectionsSender.ConsumeRejection)
+	if err != nil {
+		return err
+	}
+
+	// broker connection events
+	err = di.EventBus.Subscribe(nats_discovery.BrokerEventTopic, di.BrokerTracker.ConsumeBrokerEvent)
+	if err != nil {
+		return err
+	}
 	return di.EventBus.Subscribe(traversal.EventTopic, di.NATTracker.ConsumeNATEvent)
 }
 
+// newRequestPolicies combines configured timeouts and retries of dialog requests by endpoint
+func newRequestPolicies(options node.OptionsDialog) communication.RequestPolicies {
+	policies := make(communication.RequestPolicies)
+	for endpoint, timeout := range options.RequestTimeouts {
+		policy := policies[communication.RequestEndpoint(endpoint)]
+		policy.Timeout = timeout
+		policies[communication.RequestEndpoint(endpoint)] = policy
+	}
+	for endpoint, retries := range options.RequestRetries {
+		policy := policies[communication.RequestEndpoint(endpoint)]
+		policy.Retries = retries
+		policies[communication.RequestEndpoint(endpoint)] = policy
+	}
+	return policies
+}
+
 func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options) {
+	requestPolicies := newRequestPolicies(nodeOptions.Dialog)
 	dialogFactory := func(consumerID, providerID identity.Identity, contact market.Contact) (communication.Dialog, error) {
-		dialogEstablisher := nats_dialog.NewDialogEstablisher(consumerID, di.SignerFactory(consumerID))
+		dialogEstablisher := nats_dialog.NewDialogEstablisher(
+			consumerID,
+			di.SignerFactory(consumerID),
+			nodeOptions.Dialog.KeepaliveInterval,
+			requestPolicies,
+		)
 		return dialogEstablisher.EstablishDialog(providerID, contact)
 	}
 
@@ -313,6 +388,18 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options) {
 	di.SessionStorage = consumer_session.NewSessionStorage(di.Storage, di.StatisticsTracker)
 	di.PromiseStorage = promise.NewStorage(di.Storage)
 	di.EventBus = EventBus.New()
+	di.BrokerTracker = nats_discovery.NewBrokerTracker()
+
+	di.SpendingTracker = connection.NewSpendingTracker(
+		di.Storage,
+		connection.SpendingLimits{
+			PerSession: nodeOptions.Spending.PerSession,
+			PerDay:     nodeOptions.Spending.PerDay,
+			PerMonth:   nodeOptions.Spending.PerMonth,
+		},
+		nodeOptions.Spending.WarningThresholds,
+		di.EventBus,
+	)
 
 	di.ConnectionRegistry = connection.NewRegistry()
 	di.ConnectionManager = connection.NewManager(
@@ -321,18 +408,21 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options) {
 		di.ConnectionRegistry.CreateConnection,
 		di.EventBus,
 		di.IPResolver,
+		di.SpendingTracker.NewSessionGuard,
+		di.IdentityManager,
 	)
 
-	router := tequilapi.NewAPIRouter()
+	router := tequilapi.NewAPIRouter(di.BrokerTracker)
 	tequilapi_endpoints.AddRouteForStop(router, utils.SoftKiller(di.Shutdown))
 	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager)
-	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.IPResolver, di.StatisticsTracker, di.MysteriumAPI)
+	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.IPResolver, di.StatisticsTracker, di.SpendingTracker, di.MysteriumAPI)
 	tequilapi_endpoints.AddRoutesForConnectionSessions(router, di.SessionStorage)
 	tequilapi_endpoints.AddRoutesForLocation(router, di.ConnectionManager, di.LocationDetector, di.LocationOriginal)
 	tequilapi_endpoints.AddRoutesForProposals(router, di.MysteriumAPI, di.MysteriumMorqaClient)
 	tequilapi_endpoints.AddRoutesForService(router, di.ServicesManager, serviceTypesRequestParser)
-	tequilapi_endpoints.AddRoutesForServiceSessions(router, di.ServiceSessionStorage)
+	tequilapi_endpoints.AddRoutesForServiceSessions(router, di.ServiceSessionStorage, di.ServiceTerminator)
 	tequilapi_endpoints.AddRoutesForPayout(router, di.IdentityManager, di.SignerFactory, di.MysteriumAPI)
+	tequilapi_endpoints.AddRoutesForPromises(router, ledger.NewLedger(di.PromiseStorage))
 	identity_registry.AddIdentityRegistrationEndpoint(router, di.IdentityRegistration, di.IdentityRegistry)
 
 	corsPolicy := tequilapi.NewMysteriumCorsPolicy()
@@ -349,6 +439,9 @@ func newSessionManagerFactory(
 	natPingerChan func(json.RawMessage),
 	lastSessionShutdown chan struct{},
 	natTracker NatEventTracker,
+	denylist *session.Denylist,
+	violations session_payment.ViolationRecorder,
+	trialGranter *trial.Granter,
 ) session.ManagerFactory {
 	return func(dialog communication.Dialog) *session.Manager {
 		providerBalanceTrackerFactory := func(consumerID, receiverID, issuerID identity.Identity) (session.BalanceTracker, error) {
@@ -359,20 +452,29 @@ func newSessionManagerFactory(
 				return payments_noop.NewSessionBalance(), nil
 			}
 
+			paymentDefinition, err := session.PaymentDefinition(proposal)
+			if err != nil {
+				return nil, err
+			}
+			if !communication.CapabilitiesOf(dialog).Has(communication.CapabilityPaymentPromises) {
+				if paymentDefinition.Price.Amount == 0 {
+					return payments_noop.NewSessionBalance(), nil
+				}
+				return nil, session_payment.ErrPromisesNotNegotiated
+			}
 			timeTracker := session.NewTracker(time.Now)
-			// TODO: set the time and proper payment info
-			payment := dto.PaymentPerTime{
-				Price: money.Money{
-					Currency: money.CurrencyMyst,
-					Amount:   uint64(0),
-				},
-				Duration: time.Minute,
+			amountCalc := session.AmountCalc{PaymentDef: paymentDefinition}
+
+			// credit has to be granted before balance tracker loads the promise state
+			trialAmount := nodeOptions.Trial.Amount + amountCalc.CoveringAmount(nodeOptions.Trial.Duration).Amount
+			if _, err := trialGranter.Grant(consumerID, receiverID, issuerID, trialAmount); err != nil {
+				log.Warn("Failed to grant trial to consumer ", consumerID.Address, ": ", err)
 			}
-			amountCalc := session.AmountCalc{PaymentDef: payment}
+
 			sender := balance.NewBalanceSender(dialog)
 			promiseChan := make(chan promise.Message, 1)
 			listener := promise.NewListener(promiseChan)
-			err := dialog.Receive(listener.GetConsumer())
+			err = dialog.Receive(listener.GetConsumer())
 			if err != nil {
 				return nil, err
 			}
@@ -380,7 +482,7 @@ func newSessionManagerFactory(
 			// TODO: the ints and times here need to be passed in as well, or defined as constants
 			tracker := balance.NewBalanceTracker(&timeTracker, amountCalc, 0)
 			validator := validators.NewIssuedPromiseValidator(consumerID, receiverID, issuerID)
-			return session_payment.NewSessionBalance(sender, tracker, promiseChan, payment_factory.BalanceSendPeriod, payment_factory.PromiseWaitTimeout, validator, promiseStorage, consumerID, receiverID, issuerID), nil
+			return session_payment.NewSessionBalance(sender, tracker, promiseChan, payment_factory.BalanceSendPeriod, payment_factory.PromiseWaitTimeout, validator, promiseStorage, violations, payment_factory.MaxPromiseAmountIncrease(amountCalc), consumerID, receiverID, issuerID), nil
 		}
 		return session.NewManager(
 			proposal,
@@ -390,6 +492,9 @@ func newSessionManagerFactory(
 			natPingerChan,
 			lastSessionShutdown,
 			natTracker,
+			session.DefaultResumeGracePeriod,
+			dialog,
+			denylist,
 		)
 	}
 }
@@ -414,6 +519,21 @@ func (di *Dependencies) bootstrapNetworkComponents(options node.OptionsNetwork)
 		network.BrokerAddress = options.BrokerAddress
 	}
 
+	if options.BrokerEmbedded {
+		di.EmbeddedBroker = broker.NewEmbedded(options.BrokerEmbeddedAddress)
+		if err := di.EmbeddedBroker.Start(); err != nil {
+			return err
+		}
+		// peers are told the broker address, so it has to be given explicitly when broker listens on all interfaces
+		if options.BrokerAddress == metadata.DefaultNetwork.BrokerAddress {
+			brokerAddress, err := di.EmbeddedBroker.AdvertisedAddress()
+			if err != nil {
+				return err
+			}
+			network.BrokerAddress = brokerAddress
+		}
+	}
+
 	normalizedAddress := common.HexToAddress(options.EtherPaymentsAddress)
 	if normalizedAddress != metadata.DefaultNetwork.PaymentsContractAddress {
 		network.PaymentsContractAddress = normalizedAddress
@@ -472,6 +592,7 @@ func (di *Dependencies) bootstrapLocationComponents(options node.OptionsLocation
 func (di *Dependencies) bootstrapMetrics(options node.Options) {
 	appVersion := metadata.VersionAsString()
 	di.MetricsSender = metrics.NewSender(options.DisableMetrics, options.MetricsAddress, appVersion)
+	di.DialogRejectionsSender = nats_dialog.NewRejectionsSender(di.MetricsSender, nats_dialog.DefaultRejectionsInterval)
 }
 
 func (di *Dependencies) bootstrapNATComponents(options node.Options) {
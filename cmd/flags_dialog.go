@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	nats_dialog "github.com/mysteriumnetwork/node/communication/nats/dialog"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	dialogKeepaliveIntervalFlag = cli.DurationFlag{
+		Name:  "dialog.keepalive-interval",
+		Usage: "How often dialog peers exchange heartbeats to detect the other one going away (0 disables)",
+		Value: nats_dialog.DefaultKeepaliveInterval,
+	}
+	dialogRequestTimeoutsFlag = cli.StringFlag{
+		Name:  "dialog.request-timeouts",
+		Usage: "Comma separated timeouts of dialog requests by endpoint, e.g. session-create=30s,session-destroy=5s",
+		Value: "",
+	}
+	dialogRequestRetriesFlag = cli.StringFlag{
+		Name:  "dialog.request-retries",
+		Usage: "Comma separated retries of timed out dialog requests by endpoint, only idempotent ones should be retried",
+		Value: "session-destroy=1",
+	}
+	dialogPeerRateFlag = cli.Float64Flag{
+		Name:  "dialog.peer-rate",
+		Usage: "How many dialogs per second a single consumer may ask for (0 disables the limit)",
+		Value: nats_dialog.DefaultDialogLimits.PeerRate,
+	}
+	dialogPeerBurstFlag = cli.IntFlag{
+		Name:  "dialog.peer-burst",
+		Usage: "How many dialogs a single consumer may ask for at once",
+		Value: nats_dialog.DefaultDialogLimits.PeerBurst,
+	}
+	dialogRateFlag = cli.Float64Flag{
+		Name:  "dialog.rate",
+		Usage: "How many dialogs per second all consumers together may ask for (0 disables the limit)",
+		Value: nats_dialog.DefaultDialogLimits.Rate,
+	}
+	dialogBurstFlag = cli.IntFlag{
+		Name:  "dialog.burst",
+		Usage: "How many dialogs all consumers together may ask for at once",
+		Value: nats_dialog.DefaultDialogLimits.Burst,
+	}
+	dialogMaxOpenFlag = cli.IntFlag{
+		Name:  "dialog.max-open",
+		Usage: "How many dialogs may be open at the same time (0 disables the limit)",
+		Value: nats_dialog.DefaultDialogLimits.MaxDialogs,
+	}
+)
+
+// RegisterFlagsDialog function register dialog flags to flag list
+func RegisterFlagsDialog(flags *[]cli.Flag) {
+	*flags = append(
+		*flags,
+		dialogKeepaliveIntervalFlag,
+		dialogRequestTimeoutsFlag,
+		dialogRequestRetriesFlag,
+		dialogPeerRateFlag,
+		dialogPeerBurstFlag,
+		dialogRateFlag,
+		dialogBurstFlag,
+		dialogMaxOpenFlag,
+	)
+}
+
+// ParseFlagsDialog function fills in dialog options from CLI context
+func ParseFlagsDialog(ctx *cli.Context) (node.OptionsDialog, error) {
+	timeouts, err := parseRequestTimeouts(ctx.GlobalString(dialogRequestTimeoutsFlag.Name))
+	if err != nil {
+		return node.OptionsDialog{}, fmt.Errorf("invalid %s: %s", dialogRequestTimeoutsFlag.Name, err)
+	}
+	retries, err := parseRequestRetries(ctx.GlobalString(dialogRequestRetriesFlag.Name))
+	if err != nil {
+		return node.OptionsDialog{}, fmt.Errorf("invalid %s: %s", dialogRequestRetriesFlag.Name, err)
+	}
+
+	return node.OptionsDialog{
+		KeepaliveInterval: ctx.GlobalDuration(dialogKeepaliveIntervalFlag.Name),
+		RequestTimeouts:   timeouts,
+		RequestRetries:    retries,
+		PeerRate:          ctx.GlobalFloat64(dialogPeerRateFlag.Name),
+		PeerBurst:         ctx.GlobalInt(dialogPeerBurstFlag.Name),
+		Rate:              ctx.GlobalFloat64(dialogRateFlag.Name),
+		Burst:             ctx.GlobalInt(dialogBurstFlag.Name),
+		MaxOpen:           ctx.GlobalInt(dialogMaxOpenFlag.Name),
+	}, nil
+}
+
+func parseRequestTimeouts(value string) (map[string]time.Duration, error) {
+	values, err := parseEndpointValues(value)
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts := make(map[string]time.Duration)
+	for endpoint, field := range values {
+		timeout, err := time.ParseDuration(field)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("timeout of '%s' is not a positive duration: '%s'", endpoint, field)
+		}
+		timeouts[endpoint] = timeout
+	}
+	return timeouts, nil
+}
+
+func parseRequestRetries(value string) (map[string]int, error) {
+	values, err := parseEndpointValues(value)
+	if err != nil {
+		return nil, err
+	}
+
+	retries := make(map[string]int)
+	for endpoint, field := range values {
+		count, err := strconv.Atoi(field)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("retries of '%s' is not a non-negative number: '%s'", endpoint, field)
+		}
+		retries[endpoint] = count
+	}
+	return retries, nil
+}
+
+// parseEndpointValues splits comma separated list of endpoint=value pairs
+func parseEndpointValues(value string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		pair := strings.SplitN(field, "=", 2)
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+			return nil, fmt.Errorf("expected endpoint=value, got '%s'", field)
+		}
+		values[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+	return values, nil
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"github.com/mysteriumnetwork/node/communication/direct"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	directPortFlag = cli.IntFlag{
+		Name:  "direct.port",
+		Usage: "Port for accepting dialogs of consumers directly, without the broker (0 disables)",
+		Value: direct.DefaultPort,
+	}
+)
+
+// RegisterFlagsDirect function register direct dialog flags to flag list
+func RegisterFlagsDirect(flags *[]cli.Flag) {
+	*flags = append(*flags, directPortFlag)
+}
+
+// ParseFlagsDirect function fills in direct dialog options from CLI context
+func ParseFlagsDirect(ctx *cli.Context) node.OptionsDirect {
+	return node.OptionsDirect{
+		Port: ctx.GlobalInt(directPortFlag.Name),
+	}
+}
@@ -18,6 +18,9 @@
 package cmd
 
 import (
+	"fmt"
+
+	"github.com/mysteriumnetwork/node/communication/nats/discovery"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/metadata"
 	"github.com/urfave/cli"
@@ -45,9 +48,18 @@ var (
 	}
 	brokerAddressFlag = cli.StringFlag{
 		Name:  "broker-address",
-		Usage: "`URI` of message broker",
+		Usage: "`URI` of message broker, several comma separated URIs to fail over between them",
 		Value: metadata.DefaultNetwork.BrokerAddress,
 	}
+	brokerEmbeddedFlag = cli.BoolFlag{
+		Name:  "broker.embedded",
+		Usage: "Runs message broker inside the node, which is used unless broker-address is given. Peers of a private network can use it as their broker",
+	}
+	brokerEmbeddedAddressFlag = cli.StringFlag{
+		Name:  "broker.embedded.address",
+		Usage: "Address embedded message broker listens on. Broker does not authenticate peers, listen on public interfaces within trusted networks only and give broker-address peers reach it at",
+		Value: fmt.Sprintf("127.0.0.1:%d", discovery.BrokerPort),
+	}
 
 	etherRPCFlag = cli.StringFlag{
 		Name:  "ether.client.rpc",
@@ -80,6 +92,7 @@ func RegisterFlagsNetwork(flags *[]cli.Flag) {
 		identityCheckFlag,
 		natPunchingFlag,
 		discoveryAddressFlag, brokerAddressFlag,
+		brokerEmbeddedFlag, brokerEmbeddedAddressFlag,
 		etherRPCFlag, etherContractPaymentsFlag,
 		qualityOracleFlag,
 	)
@@ -97,6 +110,9 @@ func ParseFlagsNetwork(ctx *cli.Context) node.OptionsNetwork {
 		ctx.GlobalString(discoveryAddressFlag.Name),
 		ctx.GlobalString(brokerAddressFlag.Name),
 
+		ctx.GlobalBool(brokerEmbeddedFlag.Name),
+		ctx.GlobalString(brokerEmbeddedAddressFlag.Name),
+
 		ctx.GlobalString(etherRPCFlag.Name),
 		ctx.GlobalString(etherContractPaymentsFlag.Name),
 
@@ -69,12 +69,24 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 	RegisterFlagsNetwork(flags)
 	openvpn_core.RegisterFlags(flags)
 	RegisterFlagsLocation(flags)
+	RegisterFlagsSession(flags)
+	RegisterFlagsSpending(flags)
+	RegisterFlagsSettlement(flags)
+	RegisterFlagsTrial(flags)
+	RegisterFlagsPayments(flags)
+	RegisterFlagsDirect(flags)
+	RegisterFlagsDialog(flags)
 
 	return nil
 }
 
 // ParseFlagsNode function fills in node options from CLI context
-func ParseFlagsNode(ctx *cli.Context) node.Options {
+func ParseFlagsNode(ctx *cli.Context) (node.Options, error) {
+	dialog, err := ParseFlagsDialog(ctx)
+	if err != nil {
+		return node.Options{}, err
+	}
+
 	return node.Options{
 		Directories: ParseFlagsDirectory(ctx),
 
@@ -88,8 +100,15 @@ func ParseFlagsNode(ctx *cli.Context) node.Options {
 
 		Openvpn:        wrapper{nodeOptions: openvpn_core.ParseFlags(ctx)},
 		Location:       ParseFlagsLocation(ctx),
+		Session:        ParseFlagsSession(ctx),
+		Spending:       ParseFlagsSpending(ctx),
+		Settlement:     ParseFlagsSettlement(ctx),
+		Trial:          ParseFlagsTrial(ctx),
+		Payments:       ParseFlagsPayments(ctx),
+		Direct:         ParseFlagsDirect(ctx),
+		Dialog:         dialog,
 		OptionsNetwork: ParseFlagsNetwork(ctx),
-	}
+	}, nil
 }
 
 // TODO this struct will disappear when we unify go-openvpn embedded lib and external process based session creation/handling
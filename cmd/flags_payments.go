@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	paymentsPrepayWindowFlag = cli.DurationFlag{
+		Name:  "payments.prepay-window",
+		Usage: "Service time consumer pays providers in advance (e.g. 2m), kept between the provider charge period and an hour",
+		Value: time.Minute,
+	}
+)
+
+// RegisterFlagsPayments function register consumer payment flags to flag list
+func RegisterFlagsPayments(flags *[]cli.Flag) {
+	*flags = append(*flags, paymentsPrepayWindowFlag)
+}
+
+// ParseFlagsPayments function fills in consumer payment options from CLI context
+func ParseFlagsPayments(ctx *cli.Context) node.OptionsPayments {
+	return node.OptionsPayments{
+		PrepayWindow: ctx.GlobalDuration(paymentsPrepayWindowFlag.Name),
+	}
+}
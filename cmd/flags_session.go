@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	sessionIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "session.idle-timeout",
+		Usage: "Destroy provider sessions when consumer stops paying for this long (e.g. 30m). Zero disables the limit",
+		Value: 0,
+	}
+	sessionMaxDurationFlag = cli.DurationFlag{
+		Name:  "session.max-duration",
+		Usage: "Destroy provider sessions lasting longer than this (e.g. 24h). Zero disables the limit",
+		Value: 0,
+	}
+	sessionViolationThresholdFlag = cli.Uint64Flag{
+		Name:  "session.violation-threshold",
+		Usage: "Deny consumers after sending this many invalid promises. Zero never denies consumers",
+		Value: 3,
+	}
+)
+
+// RegisterFlagsSession function register session limit flags to flag list
+func RegisterFlagsSession(flags *[]cli.Flag) {
+	*flags = append(*flags, sessionIdleTimeoutFlag, sessionMaxDurationFlag, sessionViolationThresholdFlag)
+}
+
+// ParseFlagsSession function fills in session limit options from CLI context
+func ParseFlagsSession(ctx *cli.Context) node.OptionsSession {
+	return node.OptionsSession{
+		IdleTimeout:        ctx.GlobalDuration(sessionIdleTimeoutFlag.Name),
+		MaxDuration:        ctx.GlobalDuration(sessionMaxDurationFlag.Name),
+		ViolationThreshold: ctx.GlobalUint64(sessionViolationThresholdFlag.Name),
+	}
+}
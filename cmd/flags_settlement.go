@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/session/promise/settlement"
+	"github.com/urfave/cli"
+)
+
+var (
+	settlementEnabledFlag = cli.BoolFlag{
+		Name:  "settlement.enabled",
+		Usage: "Redeem promises received from consumers in the payments contract",
+	}
+	settlementIntervalFlag = cli.DurationFlag{
+		Name:  "settlement.interval",
+		Usage: "How often received promises are checked for settlement",
+		Value: settlement.DefaultInterval,
+	}
+)
+
+// RegisterFlagsSettlement function register promise settlement flags to flag list
+func RegisterFlagsSettlement(flags *[]cli.Flag) {
+	*flags = append(*flags, settlementEnabledFlag, settlementIntervalFlag)
+}
+
+// ParseFlagsSettlement function fills in promise settlement options from CLI context
+func ParseFlagsSettlement(ctx *cli.Context) node.OptionsSettlement {
+	return node.OptionsSettlement{
+		Enabled:  ctx.GlobalBool(settlementEnabledFlag.Name),
+		Interval: ctx.GlobalDuration(settlementIntervalFlag.Name),
+	}
+}
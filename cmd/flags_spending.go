@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/urfave/cli"
+)
+
+var (
+	spendingSessionLimitFlag = cli.Float64Flag{
+		Name:  "spending.session-limit",
+		Usage: "Maximum amount of MYST to pay for a single session. Zero disables the limit",
+		Value: 0,
+	}
+	spendingDailyLimitFlag = cli.Float64Flag{
+		Name:  "spending.daily-limit",
+		Usage: "Maximum amount of MYST to pay during a day. Zero disables the limit",
+		Value: 0,
+	}
+	spendingMonthlyLimitFlag = cli.Float64Flag{
+		Name:  "spending.monthly-limit",
+		Usage: "Maximum amount of MYST to pay during a month. Zero disables the limit",
+		Value: 0,
+	}
+	spendingWarningThresholdsFlag = cli.StringFlag{
+		Name:  "spending.warning-thresholds",
+		Usage: "Comma separated fractions of spending limits to warn at",
+		Value: "0.8,0.95",
+	}
+)
+
+// RegisterFlagsSpending function register spending limit flags to flag list
+func RegisterFlagsSpending(flags *[]cli.Flag) {
+	*flags = append(*flags, spendingSessionLimitFlag, spendingDailyLimitFlag, spendingMonthlyLimitFlag, spendingWarningThresholdsFlag)
+}
+
+// ParseFlagsSpending function fills in spending limit options from CLI context
+func ParseFlagsSpending(ctx *cli.Context) node.OptionsSpending {
+	return node.OptionsSpending{
+		PerSession:        money.NewMoney(ctx.GlobalFloat64(spendingSessionLimitFlag.Name), money.CurrencyMyst),
+		PerDay:            money.NewMoney(ctx.GlobalFloat64(spendingDailyLimitFlag.Name), money.CurrencyMyst),
+		PerMonth:          money.NewMoney(ctx.GlobalFloat64(spendingMonthlyLimitFlag.Name), money.CurrencyMyst),
+		WarningThresholds: parseWarningThresholds(ctx.GlobalString(spendingWarningThresholdsFlag.Name)),
+	}
+}
+
+func parseWarningThresholds(value string) []float64 {
+	var thresholds []float64
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		threshold, err := strconv.ParseFloat(field, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			log.Warn("Ignoring invalid spending warning threshold: ", field)
+			continue
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return thresholds
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	trialAmountFlag = cli.Uint64Flag{
+		Name:  "trial.amount",
+		Usage: "Credit granted once to every new consumer, so services can be tried before paying",
+		Value: 0,
+	}
+	trialDurationFlag = cli.DurationFlag{
+		Name:  "trial.duration",
+		Usage: "Service time granted once to every new consumer (e.g. 10m), converted to credit by the service price",
+		Value: 0,
+	}
+)
+
+// RegisterFlagsTrial function register trial allowance flags to flag list
+func RegisterFlagsTrial(flags *[]cli.Flag) {
+	*flags = append(*flags, trialAmountFlag, trialDurationFlag)
+}
+
+// ParseFlagsTrial function fills in trial allowance options from CLI context
+func ParseFlagsTrial(ctx *cli.Context) node.OptionsTrial {
+	return node.OptionsTrial{
+		Amount:   ctx.GlobalUint64(trialAmountFlag.Name),
+		Duration: ctx.GlobalDuration(trialDurationFlag.Name),
+	}
+}
@@ -25,6 +25,7 @@ import (
 	command_cli "github.com/mysteriumnetwork/node/cmd/commands/cli"
 	"github.com/mysteriumnetwork/node/cmd/commands/daemon"
 	"github.com/mysteriumnetwork/node/cmd/commands/license"
+	"github.com/mysteriumnetwork/node/cmd/commands/promises"
 	"github.com/mysteriumnetwork/node/cmd/commands/service"
 	"github.com/mysteriumnetwork/node/cmd/commands/version"
 	"github.com/mysteriumnetwork/node/metadata"
@@ -36,12 +37,13 @@ var (
 		"run command 'license --warranty'",
 		"run command 'license --conditions'",
 	)
-	versionSummary = metadata.VersionAsSummary(licenseCopyright)
-	daemonCommand  = daemon.NewCommand()
-	versionCommand = version.NewCommand(versionSummary)
-	licenseCommand = license.NewCommand(licenseCopyright)
-	serviceCommand = service.NewCommand(licenseCommand.Name)
-	cliCommand     = command_cli.NewCommand()
+	versionSummary  = metadata.VersionAsSummary(licenseCopyright)
+	daemonCommand   = daemon.NewCommand()
+	versionCommand  = version.NewCommand(versionSummary)
+	licenseCommand  = license.NewCommand(licenseCopyright)
+	serviceCommand  = service.NewCommand(licenseCommand.Name)
+	cliCommand      = command_cli.NewCommand()
+	promisesCommand = promises.NewCommand()
 )
 
 func main() {
@@ -80,6 +82,7 @@ func NewCommand() (*cli.App, error) {
 		*serviceCommand,
 		*daemonCommand,
 		*cliCommand,
+		*promisesCommand,
 	}
 
 	return app, nil
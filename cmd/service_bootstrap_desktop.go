@@ -20,10 +20,15 @@
 package cmd
 
 import (
+	"net"
+	"strconv"
+
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/communication/direct"
 	nats_dialog "github.com/mysteriumnetwork/node/communication/nats/dialog"
 	nats_discovery "github.com/mysteriumnetwork/node/communication/nats/discovery"
+	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/core/service"
@@ -36,13 +41,21 @@ import (
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_discovery "github.com/mysteriumnetwork/node/services/openvpn/discovery"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	service_proxy "github.com/mysteriumnetwork/node/services/proxy"
+	proxy_service "github.com/mysteriumnetwork/node/services/proxy/service"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/session"
+	session_payment "github.com/mysteriumnetwork/node/session/payment"
+	"github.com/mysteriumnetwork/node/session/promise/settlement"
+	"github.com/mysteriumnetwork/node/session/trial"
 )
 
 const logPrefix = "[service bootstrap] "
 
+// serviceSubnetPool is the range of private addresses shared between VPN services of the provider
+var serviceSubnetPool = net.IPNet{IP: net.IPv4(10, 8, 0, 0).To4(), Mask: net.CIDRMask(13, 32)}
+
 // bootstrapServices loads all the components required for running services
 func (di *Dependencies) bootstrapServices(nodeOptions node.Options) {
 	di.bootstrapServiceComponents(nodeOptions)
@@ -50,6 +63,7 @@ func (di *Dependencies) bootstrapServices(nodeOptions node.Options) {
 	di.bootstrapServiceOpenvpn(nodeOptions)
 	di.bootstrapServiceNoop(nodeOptions)
 	di.bootstrapServiceWireguard(nodeOptions)
+	di.bootstrapServiceProxy(nodeOptions)
 }
 
 func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
@@ -73,7 +87,7 @@ func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
 					di.EventBus)
 			}
 
-			return wireguard_service.NewManager(locationInfo, di.NATService, mapPort, wgOptions),
+			return wireguard_service.NewManager(locationInfo, di.NATService, mapPort, wgOptions, di.ServiceSubnets),
 				wireguard_service.GetProposal(locationInfo.Country), nil
 		},
 	)
@@ -114,19 +128,24 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 		currentLocation := market.Location{Country: locationInfo.Country}
 		transportOptions := serviceOptions.(openvpn_service.Options)
 
-		mapPort := func() func() {
+		mapPort := func(protocol string, port int) func() {
 			return mapping.GetPortMappingFunc(
 				locationInfo.PubIP,
 				locationInfo.OutIP,
-				transportOptions.Protocol,
-				transportOptions.Port,
+				protocol,
+				port,
 				"Myst node OpenVPN port mapping",
 				di.EventBus)
 		}
 
-		proposal := openvpn_discovery.NewServiceProposalWithLocation(currentLocation, transportOptions.Protocol)
+		var protocols []string
+		for _, listener := range transportOptions.AllListeners() {
+			protocols = append(protocols, listener.Protocol)
+		}
+
+		proposal := openvpn_discovery.NewServiceProposalWithLocation(currentLocation, protocols)
 		natService := nat.NewService()
-		return openvpn_service.NewManager(nodeOptions, transportOptions, locationInfo, di.ServiceSessionStorage, natService, di.NATPinger, mapPort, di.LastSessionShutdown, di.NATTracker), proposal, nil
+		return openvpn_service.NewManager(nodeOptions, transportOptions, locationInfo, di.ServiceSessionStorage, natService, di.NATPinger, mapPort, di.LastSessionShutdown, di.NATTracker, di.ServiceSubnets), proposal, nil
 	}
 	di.ServiceRegistry.Register(service_openvpn.ServiceType, createService)
 }
@@ -140,7 +159,34 @@ func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
 				return nil, market.ServiceProposal{}, err
 			}
 
-			return service_noop.NewManager(), service_noop.GetProposal(locationInfo.Country), nil
+			noopOptions := serviceOptions.(service_noop.Options)
+			return service_noop.NewManager(locationInfo.PubIP, noopOptions), service_noop.GetProposal(locationInfo.Country), nil
+		},
+	)
+}
+
+func (di *Dependencies) bootstrapServiceProxy(nodeOptions node.Options) {
+	di.ServiceRegistry.Register(
+		service_proxy.ServiceType,
+		func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
+			locationInfo, err := di.resolveIPsAndLocation()
+			if err != nil {
+				return nil, market.ServiceProposal{}, err
+			}
+
+			proxyOptions := serviceOptions.(proxy_service.Options)
+
+			mapPort := func(port int) func() {
+				return mapping.GetPortMappingFunc(
+					locationInfo.PubIP,
+					locationInfo.OutIP,
+					"TCP",
+					port,
+					"Myst node proxy port mapping",
+					di.EventBus)
+			}
+
+			return proxy_service.NewManager(locationInfo, mapPort, proxyOptions), proxy_service.GetProposal(locationInfo.Country), nil
 		},
 	)
 }
@@ -153,18 +199,69 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) {
 	}
 	di.ServiceRegistry = service.NewRegistry()
 	di.ServiceSessionStorage = session.NewStorageMemory()
+	di.ServiceSessionReaper = session.NewReaper(di.ServiceSessionStorage, nodeOptions.Session.IdleTimeout, nodeOptions.Session.MaxDuration)
+	di.ServiceSessionReaper.Start()
+	denylist, err := session.NewPersistentDenylist(di.Storage)
+	if err != nil {
+		log.Warn(logPrefix, "Failed to load consumer denylist, starting with empty one: ", err)
+		denylist = session.NewDenylist()
+	}
+	di.ServiceDenylist = denylist
+	di.ServiceViolations = session_payment.NewViolationTracker(nodeOptions.Session.ViolationThreshold, di.ServiceDenylist)
+	di.ServiceTrialGranter = trial.NewGranter(di.Storage, di.PromiseStorage)
+	di.ServiceTerminator = session.NewTerminator(di.ServiceSessionStorage, di.ServiceDenylist)
+	di.ServiceSubnets = ip.NewSubnetAllocator(serviceSubnetPool)
+	if nodeOptions.Settlement.Enabled {
+		di.bootstrapPromiseSettler(nodeOptions.Settlement)
+	}
+
+	di.bootstrapDirectServer(nodeOptions.Direct)
 
+	// limits are shared by waiters of all services, so that together they stay within them
+	dialogLimiter := nats_dialog.NewDialogLimiter(nats_dialog.DialogLimits{
+		PeerRate:   nodeOptions.Dialog.PeerRate,
+		PeerBurst:  nodeOptions.Dialog.PeerBurst,
+		Rate:       nodeOptions.Dialog.Rate,
+		Burst:      nodeOptions.Dialog.Burst,
+		MaxDialogs: nodeOptions.Dialog.MaxOpen,
+	})
+	dialogLimiter.AddListener(func(event nats_dialog.DialogRejectedEvent) {
+		di.EventBus.Publish(nats_dialog.DialogRejectedEventTopic, event)
+	})
+	requestPolicies := newRequestPolicies(nodeOptions.Dialog)
 	newDialogWaiter := func(providerID identity.Identity, serviceType string) (communication.DialogWaiter, error) {
 		address, err := nats_discovery.NewAddressFromHostAndID(di.NetworkDefinition.BrokerAddress, providerID, serviceType)
 		if err != nil {
 			return nil, err
 		}
+		address.AddListener(func(event nats_discovery.BrokerEvent) {
+			di.EventBus.Publish(nats_discovery.BrokerEventTopic, event)
+		})
 
-		return nats_dialog.NewDialogWaiter(
-			address,
-			di.SignerFactory(providerID),
-			di.IdentityRegistry,
-		), nil
+		// broker contact is advertised first, as released consumers dial only the first contact of proposal.
+		// Consumers knowing direct contacts pick them by type, falling back to the broker
+		waiters := []communication.DialogWaiter{
+			nats_dialog.NewDialogWaiter(
+				address,
+				di.SignerFactory(providerID),
+				di.IdentityRegistry,
+				nodeOptions.Dialog.KeepaliveInterval,
+				requestPolicies,
+				dialogLimiter,
+			),
+		}
+		if di.DirectServer != nil {
+			waiters = append(waiters, nats_dialog.NewDialogWaiter(
+				direct.NewServerAddress(di.DirectServer, address.GetTopic()),
+				di.SignerFactory(providerID),
+				di.IdentityRegistry,
+				nodeOptions.Dialog.KeepaliveInterval,
+				requestPolicies,
+				dialogLimiter,
+			))
+		}
+
+		return communication.NewDialogWaiterGroup(waiters...), nil
 	}
 	newDialogHandler := func(proposal market.ServiceProposal, configProvider session.ConfigNegotiator) communication.DialogHandler {
 		sessionManagerFactory := newSessionManagerFactory(
@@ -173,8 +270,11 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) {
 			nodeOptions,
 			di.NATPinger.PingTarget,
 			di.LastSessionShutdown,
-			di.NATTracker)
-		return session.NewDialogHandler(sessionManagerFactory, configProvider.ProvideConfig, di.PromiseStorage, identity.FromAddress(proposal.ProviderID))
+			di.NATTracker,
+			di.ServiceDenylist,
+			di.ServiceViolations,
+			di.ServiceTrialGranter)
+		return session.NewDialogHandler(sessionManagerFactory, configProvider.ProvideConfig, di.PromiseStorage, identity.FromAddress(proposal.ProviderID), di.ServiceDenylist)
 	}
 	newDiscovery := func() service.Discovery {
 		return registry.NewService(di.IdentityRegistry, di.IdentityRegistration, di.MysteriumAPI, di.SignerFactory)
@@ -187,3 +287,41 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) {
 		di.NATPinger,
 	)
 }
+
+// bootstrapPromiseSettler starts redeeming promises received by provider in the payments contract
+func (di *Dependencies) bootstrapPromiseSettler(options node.OptionsSettlement) {
+	clearer, err := settlement.NewContractClearer(
+		di.NetworkDefinition.PaymentsContractAddress,
+		di.EtherClient,
+		settlement.NewKeystoreTransactor(di.Keystore),
+	)
+	if err != nil {
+		log.Error(logPrefix, "Promise settlement disabled: ", err)
+		return
+	}
+
+	di.PromiseSettler = settlement.NewSettler(di.PromiseStorage, clearer, di.EtherClient, di.SignerFactory, options.Interval)
+	di.PromiseSettler.Start()
+}
+
+// bootstrapDirectServer starts accepting dialogs of consumers directly, provider stays reachable through the broker only when it fails
+func (di *Dependencies) bootstrapDirectServer(options node.OptionsDirect) {
+	if options.Port == 0 {
+		return
+	}
+
+	pubIP, err := di.IPResolver.GetPublicIP()
+	if err != nil {
+		log.Warn(logPrefix, "Direct dialogs disabled, failed to resolve public IP: ", err)
+		return
+	}
+
+	port := strconv.Itoa(options.Port)
+	server := direct.NewServer(net.JoinHostPort("", port), net.JoinHostPort(pubIP, port))
+	if err := server.Start(); err != nil {
+		log.Warn(logPrefix, "Direct dialogs disabled, failed to start server: ", err)
+		return
+	}
+
+	di.DirectServer = server
+}
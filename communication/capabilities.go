@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import "sort"
+
+// Capability names a feature peers agree on using while creating the dialog
+type Capability string
+
+// Capabilities of the dialog itself, peers agree on them by negotiating the dialog version, codec and keepalive
+const (
+	// CapabilityEncryption means payloads are encrypted with the key agreed on dialog creation
+	CapabilityEncryption = Capability("encryption")
+	// CapabilityReplayProtection means replayed messages are rejected
+	CapabilityReplayProtection = Capability("replay-protection")
+	// CapabilityBinaryCodec means payloads are packed with the compact binary codec
+	CapabilityBinaryCodec = Capability("codec-binary")
+	// CapabilityKeepalive means peers exchange heartbeats and tell each other when dialog is closed
+	CapabilityKeepalive = Capability("keepalive")
+)
+
+// Capabilities of the layers on top of the dialog, peers offer them in dialog creation and use the ones both support
+const (
+	// CapabilitySessionResume means suspended sessions may be resumed over the new dialog
+	CapabilitySessionResume = Capability("session-resume")
+	// CapabilityPaymentPromises means service is paid for with promises
+	CapabilityPaymentPromises = Capability("payment-promises")
+)
+
+// SupportedCapabilities are the features this node offers to peers in dialog creation
+var SupportedCapabilities = NewCapabilities(CapabilitySessionResume, CapabilityPaymentPromises)
+
+// LegacyCapabilities are the features assumed of peers, which do not exchange capabilities yet
+var LegacyCapabilities = NewCapabilities(CapabilitySessionResume, CapabilityPaymentPromises)
+
+// Capabilities is a sorted set of features
+type Capabilities []Capability
+
+// NewCapabilities creates set of the given features
+func NewCapabilities(capabilities ...Capability) Capabilities {
+	set := make(Capabilities, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if capability != "" && !set.Has(capability) {
+			set = append(set, capability)
+		}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+	return set
+}
+
+// ParseCapabilities creates set of the features named by peer
+func ParseCapabilities(names []string) Capabilities {
+	capabilities := make([]Capability, len(names))
+	for i, name := range names {
+		capabilities[i] = Capability(name)
+	}
+	return NewCapabilities(capabilities...)
+}
+
+// Has checks whether the feature is in the set
+func (capabilities Capabilities) Has(capability Capability) bool {
+	for _, existing := range capabilities {
+		if existing == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersect returns features which are in both sets
+func (capabilities Capabilities) Intersect(other Capabilities) Capabilities {
+	common := make([]Capability, 0)
+	for _, capability := range capabilities {
+		if other.Has(capability) {
+			common = append(common, capability)
+		}
+	}
+	return NewCapabilities(common...)
+}
+
+// Union returns features which are in either of the sets
+func (capabilities Capabilities) Union(other Capabilities) Capabilities {
+	all := append(append([]Capability{}, capabilities...), other...)
+	return NewCapabilities(all...)
+}
+
+// Without returns features which are not in the other set
+func (capabilities Capabilities) Without(other Capabilities) Capabilities {
+	rest := make([]Capability, 0)
+	for _, capability := range capabilities {
+		if !other.Has(capability) {
+			rest = append(rest, capability)
+		}
+	}
+	return NewCapabilities(rest...)
+}
+
+// Strings returns names of the features
+func (capabilities Capabilities) Strings() []string {
+	names := make([]string, len(capabilities))
+	for i, capability := range capabilities {
+		names[i] = string(capability)
+	}
+	return names
+}
+
+// CapableDialog is implemented by dialogs which know the features peers agreed on
+type CapableDialog interface {
+	Capabilities() Capabilities
+}
+
+// AssumingDialog is implemented by dialogs which assumed some of their features, as peer did not exchange them
+type AssumingDialog interface {
+	AssumedCapabilities() Capabilities
+}
+
+// CapabilitiesOf returns features peers of the dialog agreed on, dialogs not telling them are assumed to have the legacy ones
+func CapabilitiesOf(dialog Dialog) Capabilities {
+	if capable, ok := dialog.(CapableDialog); ok {
+		return capable.Capabilities()
+	}
+	return LegacyCapabilities
+}
+
+// NegotiatedCapabilitiesOf returns features peers of the dialog explicitly agreed on, leaving out the assumed ones
+func NegotiatedCapabilitiesOf(dialog Dialog) Capabilities {
+	capable, ok := dialog.(CapableDialog)
+	if !ok {
+		return NewCapabilities()
+	}
+	if assuming, ok := dialog.(AssumingDialog); ok {
+		return capable.Capabilities().Without(assuming.AssumedCapabilities())
+	}
+	return capable.Capabilities()
+}
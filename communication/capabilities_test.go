@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCapabilities_SortsAndDropsDuplicates(t *testing.T) {
+	capabilities := NewCapabilities(CapabilitySessionResume, CapabilityEncryption, CapabilitySessionResume, "")
+
+	assert.Equal(t, Capabilities{CapabilityEncryption, CapabilitySessionResume}, capabilities)
+	assert.Equal(t, []string{"encryption", "session-resume"}, capabilities.Strings())
+}
+
+func TestCapabilities_Intersect(t *testing.T) {
+	own := NewCapabilities(CapabilitySessionResume, CapabilityPaymentPromises)
+	peer := ParseCapabilities([]string{"payment-promises", "unknown-feature"})
+
+	assert.Equal(t, Capabilities{CapabilityPaymentPromises}, own.Intersect(peer))
+	assert.Equal(t, Capabilities{}, own.Intersect(nil))
+}
+
+func TestCapabilities_Union(t *testing.T) {
+	negotiated := NewCapabilities(CapabilityKeepalive).Union(NewCapabilities(CapabilityEncryption, CapabilityKeepalive))
+
+	assert.Equal(t, Capabilities{CapabilityEncryption, CapabilityKeepalive}, negotiated)
+	assert.True(t, negotiated.Has(CapabilityKeepalive))
+	assert.False(t, negotiated.Has(CapabilitySessionResume))
+}
+
+func TestCapabilities_Without(t *testing.T) {
+	all := NewCapabilities(CapabilityKeepalive, CapabilitySessionResume, CapabilityPaymentPromises)
+
+	assert.Equal(t, Capabilities{CapabilityKeepalive}, all.Without(LegacyCapabilities))
+	assert.Equal(t, all, all.Without(nil))
+}
+
+func TestNegotiatedCapabilitiesOf_LeavesOutAssumed(t *testing.T) {
+	assert.Equal(t, Capabilities{}, NegotiatedCapabilitiesOf(&dialogFake{}))
+	assert.Equal(t, LegacyCapabilities, CapabilitiesOf(&dialogFake{}))
+
+	capable := &capableDialogFake{capabilities: NewCapabilities(CapabilityKeepalive, CapabilityPaymentPromises)}
+	assert.Equal(t, capable.capabilities, NegotiatedCapabilitiesOf(capable))
+
+	assuming := &assumingDialogFake{capableDialogFake: *capable, assumed: NewCapabilities(CapabilityPaymentPromises)}
+	assert.Equal(t, Capabilities{CapabilityKeepalive}, NegotiatedCapabilitiesOf(assuming))
+	assert.Equal(t, capable.capabilities, CapabilitiesOf(assuming))
+}
+
+type dialogFake struct {
+	Dialog
+}
+
+type capableDialogFake struct {
+	dialogFake
+	capabilities Capabilities
+}
+
+func (dialog *capableDialogFake) Capabilities() Capabilities {
+	return dialog.capabilities
+}
+
+type assumingDialogFake struct {
+	capableDialogFake
+	assumed Capabilities
+}
+
+func (dialog *assumingDialogFake) AssumedCapabilities() Capabilities {
+	return dialog.assumed
+}
@@ -0,0 +1,457 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// NewCodecBinary returns codec which:
+//   - encodes/decodes payloads to compact binary format, similar to protocol buffers
+//   - numbers struct fields by their order, so messages may be extended only by appending new fields
+//   - skips zero fields and fields unknown to the decoding peer
+//   - falls back to JSON for interfaces and types having their own JSON encoding
+func NewCodecBinary() *codecBinary {
+	return &codecBinary{}
+}
+
+type codecBinary struct{}
+
+// wire types tell how encoded value is laid out, so peers skip fields they do not know
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+var errBinaryTruncated = errors.New("binary payload truncated")
+
+var (
+	typeBytes             = reflect.TypeOf([]byte(nil))
+	typeJSONMarshaler     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	typeJSONUnmarshaler   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	typeBinaryMarshaler   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	typeBinaryUnmarshaler = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+func (codec *codecBinary) Pack(payloadPtr interface{}) ([]byte, error) {
+	if payloadPtr == nil {
+		return []byte{}, nil
+	}
+
+	value := reflect.ValueOf(payloadPtr)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return []byte{}, nil
+		}
+		value = value.Elem()
+	}
+
+	if isBinaryMessage(value.Type()) {
+		return appendBinaryFields([]byte{}, value)
+	}
+	return appendBinaryValue([]byte{}, value)
+}
+
+func (codec *codecBinary) Unpack(data []byte, payloadPtr interface{}) error {
+	value := reflect.ValueOf(payloadPtr)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("Cant unpack to payload: %#v", payloadPtr)
+	}
+	value = value.Elem()
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	if isBinaryMessage(value.Type()) {
+		return readBinaryFields(data, value)
+	}
+	_, err := readBinaryValue(data, value)
+	return err
+}
+
+// isBinaryMessage tells whether type is encoded field by field
+func isBinaryMessage(valueType reflect.Type) bool {
+	return valueType.Kind() == reflect.Struct && !isBinaryCustom(valueType)
+}
+
+// isBinaryCustom tells whether type is encoded by its own marshaling methods
+func isBinaryCustom(valueType reflect.Type) bool {
+	if valueType.Kind() == reflect.Interface {
+		return true
+	}
+	if valueType.Kind() == reflect.Slice && valueType.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+
+	pointerType := reflect.PtrTo(valueType)
+	return usesBinaryMarshaling(valueType) ||
+		pointerType.Implements(typeJSONMarshaler) || pointerType.Implements(typeJSONUnmarshaler)
+}
+
+// usesBinaryMarshaling tells whether custom type is encoded by its own binary marshaling, preferred to JSON
+func usesBinaryMarshaling(valueType reflect.Type) bool {
+	pointerType := reflect.PtrTo(valueType)
+	return pointerType.Implements(typeBinaryMarshaler) && pointerType.Implements(typeBinaryUnmarshaler)
+}
+
+func binaryWireType(valueType reflect.Type) uint64 {
+	if valueType.Kind() == reflect.Ptr {
+		return binaryWireType(valueType.Elem())
+	}
+	if isBinaryCustom(valueType) {
+		return wireBytes
+	}
+
+	switch valueType.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return wireVarint
+	case reflect.Float32, reflect.Float64:
+		return wireFixed64
+	default:
+		return wireBytes
+	}
+}
+
+// binaryFields returns fields of the struct in the order they are numbered
+func binaryFields(value reflect.Value) []reflect.Value {
+	valueType := value.Type()
+	fields := make([]reflect.Value, 0, valueType.NumField())
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		exported := field.PkgPath == ""
+		// exported fields of embedded unexported structs are encoded like JSON does
+		embedded := field.Anonymous && field.Type.Kind() == reflect.Struct && !isBinaryCustom(field.Type)
+		if !exported && !embedded {
+			continue
+		}
+		fields = append(fields, value.Field(i))
+	}
+	return fields
+}
+
+func appendBinaryFields(data []byte, value reflect.Value) ([]byte, error) {
+	for i, field := range binaryFields(value) {
+		kind := field.Kind()
+		if (kind == reflect.Ptr || kind == reflect.Interface || kind == reflect.Map) && field.IsNil() {
+			continue
+		}
+
+		fieldData, err := appendBinaryValue([]byte{}, field)
+		if err != nil {
+			return nil, err
+		}
+		// zero values are skipped, pointers are kept to tell empty values from missing ones
+		if kind != reflect.Ptr && isBinaryZero(fieldData) {
+			continue
+		}
+
+		data = appendUvarint(data, uint64(i+1)<<3|binaryWireType(field.Type()))
+		data = append(data, fieldData...)
+	}
+	return data, nil
+}
+
+func isBinaryZero(data []byte) bool {
+	if len(data) == 1 && data[0] == 0 {
+		return true
+	}
+	if len(data) == 8 && binary.LittleEndian.Uint64(data) == 0 {
+		return true
+	}
+	return false
+}
+
+func appendBinaryValue(data []byte, value reflect.Value) ([]byte, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value = reflect.New(value.Type().Elem())
+		}
+		value = value.Elem()
+	}
+
+	if isBinaryCustom(value.Type()) {
+		custom, err := marshalBinaryCustom(value)
+		if err != nil {
+			return nil, err
+		}
+		return appendBinaryBytes(data, custom), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			return append(data, 1), nil
+		}
+		return append(data, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendVarint(data, value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUvarint(data, value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return appendFixed64(data, math.Float64bits(value.Float())), nil
+	case reflect.String:
+		return appendBinaryBytes(data, []byte(value.String())), nil
+	case reflect.Struct:
+		fields, err := appendBinaryFields([]byte{}, value)
+		if err != nil {
+			return nil, err
+		}
+		return appendBinaryBytes(data, fields), nil
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			content := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(content), value)
+			return appendBinaryBytes(data, content), nil
+		}
+		items := []byte{}
+		for i := 0; i < value.Len(); i++ {
+			var err error
+			if items, err = appendBinaryValue(items, value.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return appendBinaryBytes(data, items), nil
+	case reflect.Map:
+		entries := []byte{}
+		for _, key := range value.MapKeys() {
+			var err error
+			if entries, err = appendBinaryValue(entries, key); err != nil {
+				return nil, err
+			}
+			if entries, err = appendBinaryValue(entries, value.MapIndex(key)); err != nil {
+				return nil, err
+			}
+		}
+		return appendBinaryBytes(data, entries), nil
+	}
+
+	return nil, fmt.Errorf("Cant pack payload of type: %s", value.Type())
+}
+
+func appendUvarint(data []byte, number uint64) []byte {
+	var buffer [binary.MaxVarintLen64]byte
+	return append(data, buffer[:binary.PutUvarint(buffer[:], number)]...)
+}
+
+func appendVarint(data []byte, number int64) []byte {
+	var buffer [binary.MaxVarintLen64]byte
+	return append(data, buffer[:binary.PutVarint(buffer[:], number)]...)
+}
+
+func appendFixed64(data []byte, number uint64) []byte {
+	var buffer [8]byte
+	binary.LittleEndian.PutUint64(buffer[:], number)
+	return append(data, buffer[:]...)
+}
+
+func appendBinaryBytes(data, value []byte) []byte {
+	data = appendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+func marshalBinaryCustom(value reflect.Value) ([]byte, error) {
+	if !value.CanAddr() {
+		addressable := reflect.New(value.Type()).Elem()
+		addressable.Set(value)
+		value = addressable
+	}
+
+	target := value.Addr().Interface()
+	if usesBinaryMarshaling(value.Type()) {
+		return target.(encoding.BinaryMarshaler).MarshalBinary()
+	}
+	return json.Marshal(target)
+}
+
+func readBinaryFields(data []byte, value reflect.Value) error {
+	fields := binaryFields(value)
+	for len(data) > 0 {
+		tag, size := binary.Uvarint(data)
+		if size <= 0 {
+			return errBinaryTruncated
+		}
+		data = data[size:]
+
+		number, wire := int(tag>>3), tag&7
+		if number < 1 || number > len(fields) || binaryWireType(fields[number-1].Type()) != wire {
+			// field of newer peer, or changed beyond recognition
+			var err error
+			if data, err = skipBinaryValue(data, wire); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var err error
+		if data, err = readBinaryValue(data, fields[number-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipBinaryValue(data []byte, wire uint64) ([]byte, error) {
+	switch wire {
+	case wireVarint:
+		_, size := binary.Uvarint(data)
+		if size <= 0 {
+			return nil, errBinaryTruncated
+		}
+		return data[size:], nil
+	case wireFixed64:
+		if len(data) < 8 {
+			return nil, errBinaryTruncated
+		}
+		return data[8:], nil
+	case wireBytes:
+		_, rest, err := readBinaryBytes(data)
+		return rest, err
+	}
+	return nil, fmt.Errorf("unknown binary wire type: %d", wire)
+}
+
+func readBinaryBytes(data []byte) ([]byte, []byte, error) {
+	length, size := binary.Uvarint(data)
+	if size <= 0 || uint64(len(data)-size) < length {
+		return nil, nil, errBinaryTruncated
+	}
+	return data[size : size+int(length)], data[size+int(length):], nil
+}
+
+func readBinaryValue(data []byte, value reflect.Value) ([]byte, error) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	if isBinaryCustom(value.Type()) {
+		custom, rest, err := readBinaryBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return rest, unmarshalBinaryCustom(custom, value)
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		number, size := binary.Uvarint(data)
+		if size <= 0 {
+			return nil, errBinaryTruncated
+		}
+		value.SetBool(number != 0)
+		return data[size:], nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, size := binary.Varint(data)
+		if size <= 0 {
+			return nil, errBinaryTruncated
+		}
+		value.SetInt(number)
+		return data[size:], nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number, size := binary.Uvarint(data)
+		if size <= 0 {
+			return nil, errBinaryTruncated
+		}
+		value.SetUint(number)
+		return data[size:], nil
+	case reflect.Float32, reflect.Float64:
+		if len(data) < 8 {
+			return nil, errBinaryTruncated
+		}
+		value.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(data)))
+		return data[8:], nil
+	}
+
+	content, rest, err := readBinaryBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(string(content))
+	case reflect.Struct:
+		err = readBinaryFields(content, value)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			value.SetBytes(append([]byte{}, content...))
+			break
+		}
+		items := reflect.MakeSlice(value.Type(), 0, 0)
+		for len(content) > 0 {
+			item := reflect.New(value.Type().Elem()).Elem()
+			if content, err = readBinaryValue(content, item); err != nil {
+				return nil, err
+			}
+			items = reflect.Append(items, item)
+		}
+		value.Set(items)
+	case reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(value, reflect.ValueOf(content))
+			break
+		}
+		for i := 0; len(content) > 0 && i < value.Len(); i++ {
+			if content, err = readBinaryValue(content, value.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+	case reflect.Map:
+		entries := reflect.MakeMap(value.Type())
+		for len(content) > 0 {
+			key := reflect.New(value.Type().Key()).Elem()
+			if content, err = readBinaryValue(content, key); err != nil {
+				return nil, err
+			}
+			item := reflect.New(value.Type().Elem()).Elem()
+			if content, err = readBinaryValue(content, item); err != nil {
+				return nil, err
+			}
+			entries.SetMapIndex(key, item)
+		}
+		value.Set(entries)
+	default:
+		return nil, fmt.Errorf("Cant unpack to payload of type: %s", value.Type())
+	}
+	return rest, err
+}
+
+func unmarshalBinaryCustom(data []byte, value reflect.Value) error {
+	target := value.Addr().Interface()
+	if usesBinaryMarshaling(value.Type()) {
+		return target.(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+	return json.Unmarshal(data, target)
+}
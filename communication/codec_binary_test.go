@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Codec = &codecBinary{}
+
+type binaryInner struct {
+	Amount uint64
+}
+
+type binaryEmbedded struct {
+	Embedded string
+}
+
+type binaryPayload struct {
+	Bool     bool
+	Int      int
+	Negative int64
+	Float    float64
+	String   string
+	Bytes    []byte
+	Raw      json.RawMessage
+	Inner    binaryInner
+	Pointer  *binaryInner
+	Empty    *binaryInner
+	List     []binaryInner
+	Strings  []string
+	Map      map[string]int
+	Time     time.Time
+	Big      *big.Int
+	Any      interface{}
+	Skipped  string `json:"-"`
+	private  string
+	binaryEmbedded
+}
+
+func TestCodecBinaryPackUnpack(t *testing.T) {
+	payload := &binaryPayload{
+		Bool:           true,
+		Int:            123,
+		Negative:       -5,
+		Float:          10.20,
+		String:         `hello "name"`,
+		Bytes:          []byte{0, 1, 2},
+		Raw:            json.RawMessage(`{"port":1194}`),
+		Inner:          binaryInner{Amount: 10},
+		Pointer:        &binaryInner{},
+		List:           []binaryInner{{1}, {}, {3}},
+		Strings:        []string{"a", "", "c"},
+		Map:            map[string]int{"a": 1, "b": 0},
+		Time:           time.Date(2019, 5, 1, 10, 20, 30, 0, time.UTC),
+		Big:            big.NewInt(1000000000000),
+		Any:            map[string]interface{}{"key": "value"},
+		Skipped:        "skipped",
+		private:        "private",
+		binaryEmbedded: binaryEmbedded{Embedded: "embedded"},
+	}
+
+	codec := codecBinary{}
+	data, err := codec.Pack(payload)
+	assert.NoError(t, err)
+
+	var unpacked binaryPayload
+	assert.NoError(t, codec.Unpack(data, &unpacked))
+
+	expected := *payload
+	expected.Skipped = ""
+	expected.private = ""
+	assert.Equal(t, expected, unpacked)
+}
+
+func TestCodecBinaryPackScalars(t *testing.T) {
+	table := []struct {
+		payload      interface{}
+		expectedData []byte
+	}{
+		{nil, []byte{}},
+		{`hello`, []byte{5, 'h', 'e', 'l', 'l', 'o'}},
+		{true, []byte{1}},
+		{10, []byte{20}},
+		{&binaryInner{123}, []byte{1<<3 | wireVarint, 123}},
+		{&binaryInner{}, []byte{}},
+	}
+
+	codec := codecBinary{}
+	for _, tt := range table {
+		data, err := codec.Pack(tt.payload)
+
+		assert.NoError(t, err)
+		assert.Exactly(t, tt.expectedData, data)
+	}
+}
+
+func TestCodecBinaryUnpackToPointer(t *testing.T) {
+	codec := codecBinary{}
+
+	var payload *binaryInner
+	err := codec.Unpack([]byte{1<<3 | wireVarint, 123}, &payload)
+
+	assert.NoError(t, err)
+	assert.Exactly(t, &binaryInner{123}, payload)
+}
+
+func TestCodecBinaryUnpackSkipsUnknownFields(t *testing.T) {
+	type newerPayload struct {
+		Amount uint64
+		Name   string
+		Rate   float64
+	}
+
+	codec := codecBinary{}
+	data, err := codec.Pack(&newerPayload{Amount: 7, Name: "new", Rate: 1.5})
+	assert.NoError(t, err)
+
+	var payload binaryInner
+	assert.NoError(t, codec.Unpack(data, &payload))
+	assert.Exactly(t, binaryInner{7}, payload)
+}
+
+func TestCodecBinaryUnpackTruncated(t *testing.T) {
+	codec := codecBinary{}
+	data, err := codec.Pack(&binaryPayload{String: "hello"})
+	assert.NoError(t, err)
+
+	var payload binaryPayload
+	assert.Equal(t, errBinaryTruncated, codec.Unpack(data[:len(data)-1], &payload))
+}
+
+func TestCodecBinaryUnpackRequiresPointer(t *testing.T) {
+	codec := codecBinary{}
+
+	assert.EqualError(t, codec.Unpack([]byte{}, binaryInner{}), "Cant unpack to payload: communication.binaryInner{Amount:0x0}")
+}
+
+func TestCodecBinaryIsSmallerThanJSON(t *testing.T) {
+	payload := &binaryPayload{Int: 123456, String: "0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea"}
+
+	binaryData, err := NewCodecBinary().Pack(payload)
+	assert.NoError(t, err)
+	jsonData, err := NewCodecJSON().Pack(payload)
+	assert.NoError(t, err)
+
+	assert.True(t, len(binaryData) < len(jsonData)/2)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import (
+	"errors"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+const waiterGroupLogPrefix = "[DialogWaiterGroup] "
+
+// errNoDialogWaiters indicates that none of the grouped waiters could be started
+var errNoDialogWaiters = errors.New("none of dialog waiters started")
+
+// NewDialogWaiterGroup creates DialogWaiter, which waits for dialogs on several transports at once.
+// Contacts of waiters are advertised in the given order, so consumers try the first transport first.
+func NewDialogWaiterGroup(waiters ...DialogWaiter) *dialogWaiterGroup {
+	return &dialogWaiterGroup{
+		waiters: waiters,
+	}
+}
+
+type dialogWaiterGroup struct {
+	waiters []DialogWaiter
+	started []DialogWaiter
+}
+
+// Start starts all waiters, the ones failing to start are skipped until at least one of them works
+func (group *dialogWaiterGroup) Start() (market.ContactList, error) {
+	contacts := market.ContactList{}
+	for _, waiter := range group.waiters {
+		waiterContacts, err := waiter.Start()
+		if err != nil {
+			log.Warn(waiterGroupLogPrefix, "Skipping dialog waiter which failed to start: ", err)
+			continue
+		}
+
+		group.started = append(group.started, waiter)
+		contacts = append(contacts, waiterContacts...)
+	}
+
+	if len(group.started) == 0 {
+		return nil, errNoDialogWaiters
+	}
+	return contacts, nil
+}
+
+// Stop stops all started waiters
+func (group *dialogWaiterGroup) Stop() error {
+	var result error
+	for _, waiter := range group.started {
+		if err := waiter.Stop(); err != nil {
+			result = err
+		}
+	}
+	group.started = nil
+
+	return result
+}
+
+// ServeDialogs passes dialogs of all started waiters to the same handler
+func (group *dialogWaiterGroup) ServeDialogs(handler DialogHandler) error {
+	for _, waiter := range group.started {
+		if err := waiter.ServeDialogs(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
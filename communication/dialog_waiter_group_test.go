@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ DialogWaiter = &dialogWaiterGroup{}
+
+var (
+	contactDirect = market.Contact{Type: "direct/v1"}
+	contactNATS   = market.Contact{Type: "nats/v1"}
+)
+
+func TestDialogWaiterGroup_StartMergesContacts(t *testing.T) {
+	group := NewDialogWaiterGroup(
+		&waiterFake{contacts: market.ContactList{contactDirect}},
+		&waiterFake{contacts: market.ContactList{contactNATS}},
+	)
+
+	contacts, err := group.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, market.ContactList{contactDirect, contactNATS}, contacts)
+}
+
+func TestDialogWaiterGroup_StartSkipsFailedWaiters(t *testing.T) {
+	failed := &waiterFake{startErr: errors.New("port in use")}
+	working := &waiterFake{contacts: market.ContactList{contactNATS}}
+	group := NewDialogWaiterGroup(failed, working)
+
+	contacts, err := group.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, market.ContactList{contactNATS}, contacts)
+
+	assert.NoError(t, group.ServeDialogs(nil))
+	assert.False(t, failed.serving)
+	assert.True(t, working.serving)
+
+	assert.NoError(t, group.Stop())
+	assert.False(t, failed.stopped)
+	assert.True(t, working.stopped)
+}
+
+func TestDialogWaiterGroup_StartFailsWhenNoneStarted(t *testing.T) {
+	group := NewDialogWaiterGroup(
+		&waiterFake{startErr: errors.New("port in use")},
+		&waiterFake{startErr: errors.New("broker down")},
+	)
+
+	contacts, err := group.Start()
+	assert.Equal(t, errNoDialogWaiters, err)
+	assert.Nil(t, contacts)
+}
+
+type waiterFake struct {
+	contacts market.ContactList
+	startErr error
+	serving  bool
+	stopped  bool
+}
+
+func (waiter *waiterFake) Start() (market.ContactList, error) {
+	return waiter.contacts, waiter.startErr
+}
+
+func (waiter *waiterFake) Stop() error {
+	waiter.stopped = true
+	return nil
+}
+
+func (waiter *waiterFake) ServeDialogs(DialogHandler) error {
+	waiter.serving = true
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// NewServerAddress creates address of the topic served by the direct server of this node
+func NewServerAddress(server *Server, topic string) *AddressDirect {
+	return &AddressDirect{
+		server: server,
+		topic:  topic,
+	}
+}
+
+// NewAddressForContact extracts direct address from given contact structure
+func NewAddressForContact(contact market.Contact) (*AddressDirect, error) {
+	if contact.Type != TypeContactDirectV1 {
+		return nil, fmt.Errorf("invalid contact type: %s", contact.Type)
+	}
+
+	contactDirect, ok := contact.Definition.(ContactDirectV1)
+	if !ok {
+		return nil, fmt.Errorf("invalid contact definition: %#v", contact.Definition)
+	}
+
+	return &AddressDirect{
+		peerAddress: contactDirect.Address,
+		topic:       contactDirect.Topic,
+	}, nil
+}
+
+// AddressDirect structure defines details how direct connection can be established
+type AddressDirect struct {
+	server      *Server
+	peerAddress string
+	topic       string
+
+	connection *connection
+}
+
+// Connect links to the peer, or starts using the server of this node
+func (address *AddressDirect) Connect() error {
+	if address.server != nil {
+		address.connection = address.server.newConnection()
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", address.peerAddress, DialTimeout)
+	if err != nil {
+		return err
+	}
+
+	peerLink := newLink(conn)
+	peerHub := newHub()
+	// the only link goes to the peer, which may not have announced its topics yet
+	peerHub.forwardAll = true
+	if err := peerHub.addLink(peerLink, 1); err != nil {
+		conn.Close()
+		return err
+	}
+
+	address.connection = newConnection(peerHub, peerHub.closeLinks)
+	return nil
+}
+
+// Disconnect drops subscriptions made through the address, breaking the link to peer
+func (address *AddressDirect) Disconnect() {
+	if address.connection != nil {
+		address.connection.Close()
+	}
+}
+
+// GetConnection returns currently established connection
+func (address *AddressDirect) GetConnection() nats.Connection {
+	return address.connection
+}
+
+// GetTopic returns topic.
+// Address points to this topic in established connection.
+func (address *AddressDirect) GetTopic() string {
+	return address.topic
+}
+
+// GetContact serializes current address to Contact structure.
+func (address *AddressDirect) GetContact() market.Contact {
+	peerAddress := address.peerAddress
+	if address.server != nil {
+		peerAddress = address.server.PublicAddress()
+	}
+
+	return market.Contact{
+		Type: TypeContactDirectV1,
+		Definition: ContactDirectV1{
+			Topic:   address.topic,
+			Address: peerAddress,
+		},
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/market"
+	nats_lib "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAddressForContact(t *testing.T) {
+	address, err := NewAddressForContact(market.Contact{
+		Type: "direct/v1",
+		Definition: ContactDirectV1{
+			Topic:   "123456",
+			Address: "far-server:4060",
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		&AddressDirect{
+			peerAddress: "far-server:4060",
+			topic:       "123456",
+		},
+		address,
+	)
+}
+
+func TestNewAddressForContact_UnknownType(t *testing.T) {
+	address, err := NewAddressForContact(market.Contact{
+		Type: "nats/v1",
+	})
+
+	assert.EqualError(t, err, "invalid contact type: nats/v1")
+	assert.Nil(t, address)
+}
+
+func TestNewAddressForContact_UnknownDefinition(t *testing.T) {
+	type badContact struct{}
+
+	address, err := NewAddressForContact(market.Contact{
+		Type:       "direct/v1",
+		Definition: badContact{},
+	})
+
+	assert.EqualError(t, err, "invalid contact definition: direct.badContact{}")
+	assert.Nil(t, address)
+}
+
+func TestServerAddress_GetContact(t *testing.T) {
+	address := NewServerAddress(NewServer("0.0.0.0:4060", "1.2.3.4:4060"), "provider1.noop")
+
+	assert.Equal(
+		t,
+		market.Contact{
+			Type: "direct/v1",
+			Definition: ContactDirectV1{
+				Topic:   "provider1.noop",
+				Address: "1.2.3.4:4060",
+			},
+		},
+		address.GetContact(),
+	)
+}
+
+func TestAddress_RequestResponse(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Stop()
+
+	serverAddress := NewServerAddress(server, "provider1.noop")
+	assert.NoError(t, serverAddress.Connect())
+	defer serverAddress.Disconnect()
+
+	_, err := serverAddress.GetConnection().Subscribe("provider1.noop.ping", func(msg *nats_lib.Msg) {
+		serverAddress.GetConnection().Publish(msg.Reply, append([]byte("pong:"), msg.Data...))
+	})
+	assert.NoError(t, err)
+
+	clientAddress, err := NewAddressForContact(serverAddress.GetContact())
+	assert.NoError(t, err)
+	assert.NoError(t, clientAddress.Connect())
+	defer clientAddress.Disconnect()
+
+	response, err := clientAddress.GetConnection().Request("provider1.noop.ping", []byte("1"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong:1", string(response.Data))
+}
+
+func TestAddress_PublishToClient(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Stop()
+
+	serverAddress := NewServerAddress(server, "provider1.noop")
+	assert.NoError(t, serverAddress.Connect())
+	defer serverAddress.Disconnect()
+
+	clientAddress, err := NewAddressForContact(serverAddress.GetContact())
+	assert.NoError(t, err)
+	assert.NoError(t, clientAddress.Connect())
+	defer clientAddress.Disconnect()
+
+	received := make(chan string, 1)
+	_, err = clientAddress.GetConnection().Subscribe("consumer1.noop", func(msg *nats_lib.Msg) {
+		received <- string(msg.Data)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, waitForSubscription(server, "consumer1.noop"))
+	assert.NoError(t, serverAddress.GetConnection().Publish("consumer1.noop", []byte("hello")))
+
+	select {
+	case data := <-received:
+		assert.Equal(t, "hello", data)
+	case <-time.After(time.Second):
+		t.Fatal("message not delivered to client")
+	}
+}
+
+func TestAddress_PeersAreNotRelayed(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Stop()
+
+	serverAddress := NewServerAddress(server, "provider1.noop")
+	contact := serverAddress.GetContact()
+
+	listener, err := NewAddressForContact(contact)
+	assert.NoError(t, err)
+	assert.NoError(t, listener.Connect())
+	defer listener.Disconnect()
+
+	received := make(chan string, 1)
+	_, err = listener.GetConnection().Subscribe("consumer1.noop", func(msg *nats_lib.Msg) {
+		received <- string(msg.Data)
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, waitForSubscription(server, "consumer1.noop"))
+
+	sender, err := NewAddressForContact(contact)
+	assert.NoError(t, err)
+	assert.NoError(t, sender.Connect())
+	defer sender.Disconnect()
+
+	assert.NoError(t, sender.GetConnection().Publish("consumer1.noop", []byte("hello")))
+
+	select {
+	case data := <-received:
+		t.Fatalf("message relayed between peers: %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAddress_DisconnectedConnectionFails(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Stop()
+
+	address, err := NewAddressForContact(NewServerAddress(server, "provider1.noop").GetContact())
+	assert.NoError(t, err)
+	assert.NoError(t, address.Connect())
+	address.Disconnect()
+
+	assert.Equal(t, errConnectionClosed, address.GetConnection().Check())
+	assert.Equal(t, errConnectionClosed, address.GetConnection().Publish("provider1.noop.ping", nil))
+}
+
+func TestAddress_ConnectFailsWithoutServer(t *testing.T) {
+	address := &AddressDirect{peerAddress: "127.0.0.1:1", topic: "provider1.noop"}
+
+	assert.Error(t, address.Connect())
+}
+
+func startTestServer(t *testing.T) *Server {
+	server := NewServer("127.0.0.1:0", "")
+	assert.NoError(t, server.Start())
+	server.publicAddress = server.ListenAddress()
+
+	return server
+}
+
+func waitForSubscription(server *Server, subject string) error {
+	for i := 0; i < 100; i++ {
+		server.hub.lock.RLock()
+		for l := range server.hub.links {
+			if _, subscribed := l.subjects[subject]; subscribed {
+				server.hub.lock.RUnlock()
+				return nil
+			}
+		}
+		server.hub.lock.RUnlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nats_lib.ErrTimeout
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	Bootstrap()
+}
+
+func TestServiceProposalUnserializeDirectContact(t *testing.T) {
+	jsonData := []byte(`{
+		"service_type": "openvpn",
+		"service_definition": {},
+		"payment_method_type": "PER_TIME",
+		"payment_method": {},
+		"provider_contacts": [
+			{
+				"type": "direct/v1",
+				"definition": {
+					"topic": "test-topic",
+					"address": "1.2.3.4:4060"
+				}
+			}
+		]
+	}`)
+
+	var actual market.ServiceProposal
+	err := json.Unmarshal(jsonData, &actual)
+
+	assert.Nil(t, err)
+	assert.Len(t, actual.ProviderContacts, 1)
+	assert.Exactly(
+		t,
+		market.Contact{
+			Type: TypeContactDirectV1,
+			Definition: ContactDirectV1{
+				Topic:   "test-topic",
+				Address: "1.2.3.4:4060",
+			},
+		},
+		actual.ProviderContacts[0],
+	)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication/nats"
+	nats_lib "github.com/nats-io/go-nats"
+)
+
+var errConnectionClosed = errors.New("direct connection closed")
+
+// connection is a view of the hub for a single user, which drops the subscriptions it made when closed
+type connection struct {
+	hub     *hub
+	onClose func()
+
+	lock          sync.Mutex
+	closed        bool
+	subscriptions map[*subscription]struct{}
+}
+
+var _ nats.Connection = &connection{}
+
+func newConnection(hub *hub, onClose func()) *connection {
+	return &connection{
+		hub:           hub,
+		onClose:       onClose,
+		subscriptions: make(map[*subscription]struct{}),
+	}
+}
+
+func (conn *connection) Check() error {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	if conn.closed {
+		return errConnectionClosed
+	}
+	return nil
+}
+
+func (conn *connection) Publish(subject string, payload []byte) error {
+	if err := conn.Check(); err != nil {
+		return err
+	}
+	return conn.hub.publish(subject, "", payload)
+}
+
+func (conn *connection) Subscribe(subject string, handler nats_lib.MsgHandler) (nats.Subscription, error) {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	if conn.closed {
+		return nil, errConnectionClosed
+	}
+	sub := conn.hub.subscribe(subject, handler)
+	conn.subscriptions[sub] = struct{}{}
+	return &connectionSubscription{conn: conn, sub: sub}, nil
+}
+
+func (conn *connection) unsubscribe(sub *subscription) error {
+	conn.lock.Lock()
+	_, exists := conn.subscriptions[sub]
+	delete(conn.subscriptions, sub)
+	conn.lock.Unlock()
+
+	if !exists {
+		return nats_lib.ErrBadSubscription
+	}
+	conn.hub.unsubscribe(sub)
+	return nil
+}
+
+func (conn *connection) Request(subject string, payload []byte, timeout time.Duration) (*nats_lib.Msg, error) {
+	if err := conn.Check(); err != nil {
+		return nil, err
+	}
+	return conn.hub.request(subject, payload, timeout)
+}
+
+func (conn *connection) RequestWithContext(ctx context.Context, subject string, payload []byte) (*nats_lib.Msg, error) {
+	if err := conn.Check(); err != nil {
+		return nil, err
+	}
+	return conn.hub.requestContext(ctx, subject, payload)
+}
+
+func (conn *connection) Close() {
+	conn.lock.Lock()
+	if conn.closed {
+		conn.lock.Unlock()
+		return
+	}
+	conn.closed = true
+	subscriptions := conn.subscriptions
+	conn.subscriptions = make(map[*subscription]struct{})
+	conn.lock.Unlock()
+
+	for sub := range subscriptions {
+		conn.hub.unsubscribe(sub)
+	}
+	if conn.onClose != nil {
+		conn.onClose()
+	}
+}
+
+// connectionSubscription is the handle of subscription made through the connection
+type connectionSubscription struct {
+	conn *connection
+	sub  *subscription
+}
+
+func (handle *connectionSubscription) Unsubscribe() error {
+	return handle.conn.unsubscribe(handle.sub)
+}
+
+func (handle *connectionSubscription) IsValid() bool {
+	return handle.sub.active()
+}
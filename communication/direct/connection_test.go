@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"testing"
+	"time"
+
+	nats_lib "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_UnsubscribeStopsDelivery(t *testing.T) {
+	hub := newHub()
+	conn := newConnection(hub, nil)
+
+	received := make(chan *nats_lib.Msg, 1)
+	subscription, err := conn.Subscribe("provider1.noop", func(msg *nats_lib.Msg) {
+		received <- msg
+	})
+	assert.NoError(t, err)
+	assert.True(t, subscription.IsValid())
+
+	assert.NoError(t, subscription.Unsubscribe())
+	assert.False(t, subscription.IsValid())
+	assert.Equal(t, nats_lib.ErrBadSubscription, subscription.Unsubscribe())
+	assert.Empty(t, hub.local)
+
+	assert.NoError(t, conn.Publish("provider1.noop", []byte("ping")))
+	select {
+	case <-received:
+		t.Fatal("message delivered to unsubscribed handler")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConnection_CloseInvalidatesSubscriptions(t *testing.T) {
+	hub := newHub()
+	conn := newConnection(hub, nil)
+
+	subscription, err := conn.Subscribe("provider1.noop", func(msg *nats_lib.Msg) {})
+	assert.NoError(t, err)
+
+	conn.Close()
+	assert.False(t, subscription.IsValid())
+	assert.Empty(t, hub.local)
+}
+
+func TestConnection_DeliversMessagesInOrder(t *testing.T) {
+	conn := newConnection(newHub(), nil)
+
+	received := make(chan string, 10)
+	_, err := conn.Subscribe("provider1.noop", func(msg *nats_lib.Msg) {
+		received <- string(msg.Data)
+	})
+	assert.NoError(t, err)
+
+	messages := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	for _, message := range messages {
+		assert.NoError(t, conn.Publish("provider1.noop", []byte(message)))
+	}
+	for _, message := range messages {
+		select {
+		case got := <-received:
+			assert.Equal(t, message, got)
+		case <-time.After(time.Second):
+			t.Fatal("message not delivered: ", message)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+// TypeContactDirectV1 defines V1 format for contact of peers reachable directly over TCP
+const TypeContactDirectV1 = "direct/v1"
+
+// ContactDirectV1 is definition of direct contact
+type ContactDirectV1 struct {
+	// Topic on which peer is getting messages
+	Topic string `json:"topic"`
+	// Address of peer in host:port format
+	Address string `json:"address"`
+}
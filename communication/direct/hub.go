@@ -0,0 +1,302 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	nats_lib "github.com/nats-io/go-nats"
+)
+
+const hubLogPrefix = "[direct] "
+
+// subscriptionQueueSize is how many messages wait for the subscription handler, before new ones are dropped
+const subscriptionQueueSize = 256
+
+var errTooManyLinks = errors.New("too many direct links")
+
+// subscription delivers messages of the subject to its handler one by one, in the order they were received
+type subscription struct {
+	subject string
+	handler nats_lib.MsgHandler
+	queue   chan *nats_lib.Msg
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newSubscription(subject string, handler nats_lib.MsgHandler) *subscription {
+	sub := &subscription{
+		subject: subject,
+		handler: handler,
+		queue:   make(chan *nats_lib.Msg, subscriptionQueueSize),
+		done:    make(chan struct{}),
+	}
+	go sub.serve()
+	return sub
+}
+
+func (sub *subscription) serve() {
+	for {
+		select {
+		case msg := <-sub.queue:
+			sub.handler(msg)
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+func (sub *subscription) enqueue(msg *nats_lib.Msg) {
+	select {
+	case sub.queue <- msg:
+	default:
+		log.Warn(hubLogPrefix, "Dropping message of ", sub.subject, ", subscriber is too slow")
+	}
+}
+
+func (sub *subscription) stop() {
+	sub.once.Do(func() { close(sub.done) })
+}
+
+func (sub *subscription) active() bool {
+	select {
+	case <-sub.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// hub delivers messages published by the node to its own subscribers and to linked peers subscribed to the subject.
+// Messages received from a linked peer are delivered to the node subscribers only, they are never relayed to other peers.
+type hub struct {
+	lock  sync.RWMutex
+	local map[string]map[*subscription]struct{}
+	links map[*link]struct{}
+
+	// forwardAll sends every publish to links, not waiting for the peer to announce its subscriptions
+	forwardAll bool
+}
+
+func newHub() *hub {
+	return &hub{
+		local: make(map[string]map[*subscription]struct{}),
+		links: make(map[*link]struct{}),
+	}
+}
+
+func (h *hub) subscribe(subject string, handler nats_lib.MsgHandler) *subscription {
+	sub := newSubscription(subject, handler)
+
+	h.lock.Lock()
+	first := len(h.local[subject]) == 0
+	if first {
+		h.local[subject] = make(map[*subscription]struct{})
+	}
+	h.local[subject][sub] = struct{}{}
+	links := h.linkList()
+	h.lock.Unlock()
+
+	if first {
+		h.announce(links, frame{Op: opSubscribe, Subject: subject})
+	}
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *subscription) {
+	sub.stop()
+
+	h.lock.Lock()
+	subs, exist := h.local[sub.subject]
+	if !exist {
+		h.lock.Unlock()
+		return
+	}
+	delete(subs, sub)
+	last := len(subs) == 0
+	if last {
+		delete(h.local, sub.subject)
+	}
+	links := h.linkList()
+	h.lock.Unlock()
+
+	if last {
+		h.announce(links, frame{Op: opUnsubscribe, Subject: sub.subject})
+	}
+}
+
+func (h *hub) publish(subject, reply string, data []byte) error {
+	h.lock.RLock()
+	var subs []*subscription
+	for sub := range h.local[subject] {
+		subs = append(subs, sub)
+	}
+	var targets []*link
+	for l := range h.links {
+		if _, subscribed := l.subjects[subject]; subscribed || h.forwardAll {
+			targets = append(targets, l)
+		}
+	}
+	h.lock.RUnlock()
+
+	h.deliver(subs, &nats_lib.Msg{Subject: subject, Reply: reply, Data: data})
+
+	var err error
+	for _, l := range targets {
+		if writeErr := l.write(frame{Op: opPublish, Subject: subject, Reply: reply, Data: data}); writeErr != nil {
+			log.Warn(hubLogPrefix, "Failed to publish to ", l.conn.RemoteAddr(), ": ", writeErr)
+			err = writeErr
+		}
+	}
+	return err
+}
+
+func (h *hub) request(subject string, data []byte, timeout time.Duration) (*nats_lib.Msg, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	msg, err := h.requestContext(ctx, subject, data)
+	if err == context.DeadlineExceeded {
+		return nil, nats_lib.ErrTimeout
+	}
+	return msg, err
+}
+
+func (h *hub) requestContext(ctx context.Context, subject string, data []byte) (*nats_lib.Msg, error) {
+	responses := make(chan *nats_lib.Msg, 1)
+	inbox := h.subscribe(nats_lib.NewInbox(), func(msg *nats_lib.Msg) {
+		select {
+		case responses <- msg:
+		default:
+		}
+	})
+	defer h.unsubscribe(inbox)
+
+	if err := h.publish(subject, inbox.subject, data); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-responses:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// addLink starts serving the frames of linked peer, until the link breaks
+func (h *hub) addLink(l *link, maxLinks int) error {
+	h.lock.Lock()
+	if maxLinks > 0 && len(h.links) >= maxLinks {
+		h.lock.Unlock()
+		return errTooManyLinks
+	}
+	h.links[l] = struct{}{}
+	subjects := make([]string, 0, len(h.local))
+	for subject := range h.local {
+		subjects = append(subjects, subject)
+	}
+	h.lock.Unlock()
+
+	for _, subject := range subjects {
+		if err := l.write(frame{Op: opSubscribe, Subject: subject}); err != nil {
+			h.removeLink(l)
+			return err
+		}
+	}
+
+	go func() {
+		if err := l.read(func(f frame) { h.handleFrame(l, f) }); err != nil {
+			log.Debug(hubLogPrefix, "Link to ", l.conn.RemoteAddr(), " broken: ", err)
+		}
+		h.removeLink(l)
+	}()
+	return nil
+}
+
+func (h *hub) removeLink(l *link) {
+	h.lock.Lock()
+	delete(h.links, l)
+	h.lock.Unlock()
+
+	l.close()
+}
+
+func (h *hub) closeLinks() {
+	h.lock.Lock()
+	links := h.linkList()
+	h.links = make(map[*link]struct{})
+	h.lock.Unlock()
+
+	for _, l := range links {
+		l.close()
+	}
+}
+
+func (h *hub) handleFrame(l *link, f frame) {
+	switch f.Op {
+	case opSubscribe:
+		h.lock.Lock()
+		if len(l.subjects) < maxLinkSubjects {
+			l.subjects[f.Subject] = struct{}{}
+		} else {
+			log.Warn(hubLogPrefix, "Ignoring subscription of ", l.conn.RemoteAddr(), ", too many subjects")
+		}
+		h.lock.Unlock()
+	case opUnsubscribe:
+		h.lock.Lock()
+		delete(l.subjects, f.Subject)
+		h.lock.Unlock()
+	case opPublish:
+		h.lock.RLock()
+		var subs []*subscription
+		for sub := range h.local[f.Subject] {
+			subs = append(subs, sub)
+		}
+		h.lock.RUnlock()
+
+		h.deliver(subs, &nats_lib.Msg{Subject: f.Subject, Reply: f.Reply, Data: f.Data})
+	}
+}
+
+func (h *hub) deliver(subs []*subscription, msg *nats_lib.Msg) {
+	for _, sub := range subs {
+		sub.enqueue(msg)
+	}
+}
+
+func (h *hub) announce(links []*link, f frame) {
+	for _, l := range links {
+		if err := l.write(f); err != nil {
+			log.Warn(hubLogPrefix, "Failed to announce subscription to ", l.conn.RemoteAddr(), ": ", err)
+		}
+	}
+}
+
+// linkList returns currently linked peers, must be called holding the lock
+func (h *hub) linkList() []*link {
+	links := make([]*link, 0, len(h.links))
+	for l := range h.links {
+		links = append(links, l)
+	}
+	return links
+}
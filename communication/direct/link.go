@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+// Frame operations of direct links
+const (
+	opSubscribe   = "sub"
+	opUnsubscribe = "unsub"
+	opPublish     = "pub"
+)
+
+// frame is a newline delimited JSON message exchanged between linked peers
+type frame struct {
+	Op      string `json:"op"`
+	Subject string `json:"subject"`
+	Reply   string `json:"reply,omitempty"`
+	Data    []byte `json:"data,omitempty"`
+}
+
+// link is a TCP connection between two peers, announcing subscriptions and carrying published messages
+type link struct {
+	conn net.Conn
+
+	writeLock sync.Mutex
+	encoder   *json.Encoder
+
+	// subjects peer subscribed to, guarded by the hub lock
+	subjects map[string]struct{}
+}
+
+func newLink(conn net.Conn) *link {
+	return &link{
+		conn:     conn,
+		encoder:  json.NewEncoder(conn),
+		subjects: make(map[string]struct{}),
+	}
+}
+
+func (l *link) write(f frame) error {
+	l.writeLock.Lock()
+	defer l.writeLock.Unlock()
+
+	if err := l.conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		return err
+	}
+	return l.encoder.Encode(f)
+}
+
+// read blocks handling frames received from peer until the link breaks
+func (l *link) read(handle func(frame)) error {
+	scanner := bufio.NewScanner(l.conn)
+	scanner.Buffer(make([]byte, 4096), maxFrameSize)
+	for scanner.Scan() {
+		var f frame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return err
+		}
+		handle(f)
+	}
+	return scanner.Err()
+}
+
+func (l *link) close() error {
+	return l.conn.Close()
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package direct
+
+import (
+	"net"
+	"sync"
+
+	log "github.com/cihub/seelog"
+)
+
+// Server accepts direct links of peers, serving topics of all the node services on a single port
+type Server struct {
+	listenAddress string
+	publicAddress string
+	hub           *hub
+
+	lock     sync.Mutex
+	listener net.Listener
+}
+
+// NewServer creates server listening on the given address, peers are told to dial the public address
+func NewServer(listenAddress, publicAddress string) *Server {
+	return &Server{
+		listenAddress: listenAddress,
+		publicAddress: publicAddress,
+		hub:           newHub(),
+	}
+}
+
+// Start starts accepting links of peers
+func (server *Server) Start() error {
+	listener, err := net.Listen("tcp", server.listenAddress)
+	if err != nil {
+		return err
+	}
+
+	server.lock.Lock()
+	server.listener = listener
+	server.lock.Unlock()
+
+	log.Info(hubLogPrefix, "Accepting direct links on: ", listener.Addr())
+	go server.serve(listener)
+	return nil
+}
+
+// Stop stops accepting links and breaks the current ones
+func (server *Server) Stop() {
+	server.lock.Lock()
+	listener := server.listener
+	server.listener = nil
+	server.lock.Unlock()
+
+	if listener != nil {
+		listener.Close()
+	}
+	server.hub.closeLinks()
+}
+
+// PublicAddress returns the address peers reach the server at
+func (server *Server) PublicAddress() string {
+	return server.publicAddress
+}
+
+// ListenAddress returns the address server accepts links on
+func (server *Server) ListenAddress() string {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	if server.listener != nil {
+		return server.listener.Addr().String()
+	}
+	return server.listenAddress
+}
+
+func (server *Server) newConnection() *connection {
+	return newConnection(server.hub, nil)
+}
+
+func (server *Server) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Info(hubLogPrefix, "Stopped accepting direct links: ", err)
+			return
+		}
+
+		if err := server.hub.addLink(newLink(conn), maxLinks); err != nil {
+			log.Warn(hubLogPrefix, "Rejected direct link of ", conn.RemoteAddr(), ": ", err)
+			conn.Close()
+		}
+	}
+}
@@ -18,6 +18,8 @@
 package communication
 
 import (
+	"context"
+
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 )
@@ -27,7 +29,7 @@ import (
 //   - negotiates with Dialog initiator
 //   - finally creates Dialog, when it is accepted
 type DialogWaiter interface {
-	Start() (market.Contact, error)
+	Start() (market.ContactList, error)
 	Stop() error
 	ServeDialogs(DialogHandler) error
 }
@@ -51,6 +53,8 @@ type Dialog interface {
 	Sender
 	Receiver
 	Close() error
+	// Done is closed once dialog ends, either closed by one of the peers or after the peer vanished
+	Done() <-chan struct{}
 }
 
 // Receiver represents interface for:
@@ -67,5 +71,9 @@ type Receiver interface {
 //   - sending and HTTP-like request and waiting for response
 type Sender interface {
 	Send(producer MessageProducer) error
+	// SendContext sends the message unless ctx is done already
+	SendContext(ctx context.Context, producer MessageProducer) error
 	Request(producer RequestProducer) (responsePtr interface{}, err error)
+	// RequestContext waits for the response until ctx is done or the request times out according to policy of its endpoint
+	RequestContext(ctx context.Context, producer RequestProducer) (responsePtr interface{}, err error)
 }
@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/nats-io/gnatsd/server"
+)
+
+const logPrefix = "[NATS.Broker] "
+
+// readyTimeout is how long broker is given to start accepting clients
+const readyTimeout = 10 * time.Second
+
+var (
+	errNotReady           = errors.New("embedded broker failed to start accepting clients")
+	errUnspecifiedAddress = errors.New("embedded broker listens on all interfaces, broker address advertised to peers has to be given")
+)
+
+// NewEmbedded creates NATS broker running inside the node process.
+// Port 0 of the listen address picks a random free port.
+// Broker does not authenticate clients, so it should listen on public interfaces only within trusted networks.
+func NewEmbedded(listenAddress string) *Embedded {
+	return &Embedded{
+		listenAddress: listenAddress,
+	}
+}
+
+// Embedded is NATS broker serving peers, which use it as any other broker
+type Embedded struct {
+	listenAddress string
+	server        *server.Server
+}
+
+// Start starts accepting clients
+func (broker *Embedded) Start() error {
+	host, portValue, err := net.SplitHostPort(broker.listenAddress)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil {
+		return fmt.Errorf("invalid broker port: %s", portValue)
+	}
+	if port == 0 {
+		port = server.RANDOM_PORT
+	}
+
+	broker.server = server.New(&server.Options{
+		Host:   host,
+		Port:   port,
+		NoLog:  true,
+		NoSigs: true,
+	})
+	go broker.server.Start()
+
+	if !broker.server.ReadyForConnections(readyTimeout) {
+		broker.server.Shutdown()
+		return errNotReady
+	}
+
+	log.Info(logPrefix, "Embedded broker accepting clients on: ", broker.ClientAddress())
+	return nil
+}
+
+// Stop disconnects clients and stops the broker
+func (broker *Embedded) Stop() {
+	if broker.server != nil {
+		broker.server.Shutdown()
+	}
+}
+
+// ClientAddress returns URI for clients running on the same host to connect to the broker
+func (broker *Embedded) ClientAddress() string {
+	if broker.server == nil || broker.server.Addr() == nil {
+		return ""
+	}
+
+	addr := broker.server.Addr().(*net.TCPAddr)
+	host := "127.0.0.1"
+	if !addr.IP.IsUnspecified() {
+		host = addr.IP.String()
+	}
+	return "nats://" + net.JoinHostPort(host, strconv.Itoa(addr.Port))
+}
+
+// AdvertisedAddress returns URI for peers to connect to the broker.
+// Broker listening on all interfaces does not know which of them peers reach, so it fails instead of guessing.
+func (broker *Embedded) AdvertisedAddress() (string, error) {
+	if broker.server == nil || broker.server.Addr() == nil {
+		return "", errNotReady
+	}
+
+	addr := broker.server.Addr().(*net.TCPAddr)
+	if addr.IP.IsUnspecified() {
+		return "", errUnspecifiedAddress
+	}
+	return broker.ClientAddress(), nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication/nats/discovery"
+	nats_lib "github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedded_ServesClients(t *testing.T) {
+	broker := NewEmbedded("127.0.0.1:0")
+	require.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	address := discovery.NewAddress("provider1.noop", broker.ClientAddress())
+	require.NoError(t, address.Connect())
+	defer address.Disconnect()
+
+	_, err := address.GetConnection().Subscribe("provider1.noop.ping", func(msg *nats_lib.Msg) {
+		address.GetConnection().Publish(msg.Reply, append([]byte("pong:"), msg.Data...))
+	})
+	assert.NoError(t, err)
+
+	response, err := address.GetConnection().Request("provider1.noop.ping", []byte("1"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong:1", string(response.Data))
+}
+
+func TestEmbedded_AdvertisedInContact(t *testing.T) {
+	broker := NewEmbedded("127.0.0.1:0")
+	assert.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	contact := discovery.NewAddress("provider1.noop", broker.ClientAddress()).GetContact()
+	assert.Equal(
+		t,
+		discovery.ContactNATSV1{
+			Topic:           "provider1.noop",
+			BrokerAddresses: []string{broker.ClientAddress()},
+		},
+		contact.Definition,
+	)
+}
+
+func TestEmbedded_StartFailsWithInvalidAddress(t *testing.T) {
+	broker := NewEmbedded("127.0.0.1")
+	assert.Error(t, broker.Start())
+
+	broker = NewEmbedded("127.0.0.1:port")
+	assert.EqualError(t, broker.Start(), "invalid broker port: port")
+}
+
+func TestEmbedded_AdvertisedAddress(t *testing.T) {
+	broker := NewEmbedded("127.0.0.1:0")
+	assert.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	address, err := broker.AdvertisedAddress()
+	assert.NoError(t, err)
+	assert.Equal(t, broker.ClientAddress(), address)
+}
+
+func TestEmbedded_AdvertisedAddressFailsOnAllInterfaces(t *testing.T) {
+	broker := NewEmbedded("0.0.0.0:0")
+	assert.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	address, err := broker.AdvertisedAddress()
+	assert.Equal(t, errUnspecifiedAddress, err)
+	assert.Empty(t, address)
+}
@@ -18,6 +18,7 @@
 package nats
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -100,7 +101,7 @@ func (conn *connectionFake) Publish(subject string, payload []byte) error {
 	return nil
 }
 
-func (conn *connectionFake) Subscribe(subject string, handler nats.MsgHandler) (*nats.Subscription, error) {
+func (conn *connectionFake) Subscribe(subject string, handler nats.MsgHandler) (Subscription, error) {
 	if conn.errorMock != nil {
 		return nil, conn.errorMock
 	}
@@ -111,6 +112,17 @@ func (conn *connectionFake) Subscribe(subject string, handler nats.MsgHandler) (
 }
 
 func (conn *connectionFake) Request(subject string, payload []byte, timeout time.Duration) (*nats.Msg, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	msg, err := conn.RequestWithContext(ctx, subject, payload)
+	if err == context.DeadlineExceeded {
+		return nil, fmt.Errorf("request '%s' timeout", subject)
+	}
+	return msg, err
+}
+
+func (conn *connectionFake) RequestWithContext(ctx context.Context, subject string, payload []byte) (*nats.Msg, error) {
 	if conn.errorMock != nil {
 		return nil, conn.errorMock
 	}
@@ -131,8 +143,8 @@ func (conn *connectionFake) Request(subject string, payload []byte, timeout time
 	select {
 	case response := <-responseCh:
 		return response, nil
-	case <-time.After(timeout):
-		return nil, fmt.Errorf("request '%s' timeout", subject)
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
@@ -18,6 +18,7 @@
 package nats
 
 import (
+	"context"
 	"time"
 
 	"github.com/nats-io/go-nats"
@@ -27,7 +28,14 @@ import (
 type Connection interface {
 	Check() error
 	Publish(subject string, payload []byte) error
-	Subscribe(subject string, handler nats.MsgHandler) (*nats.Subscription, error)
+	Subscribe(subject string, handler nats.MsgHandler) (Subscription, error)
 	Request(subject string, payload []byte, timeout time.Duration) (*nats.Msg, error)
+	RequestWithContext(ctx context.Context, subject string, payload []byte) (*nats.Msg, error)
 	Close()
 }
+
+// Subscription represents interest of connection in the messages of a subject
+type Subscription interface {
+	Unsubscribe() error
+	IsValid() bool
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/mysteriumnetwork/node/communication"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var errMessageTooShort = errors.New("encrypted message too short")
+
+// NewCodecEncrypted returns codec which:
+//   - encodes/decodes payloads with any packer codec
+//   - seals encoded message with the given symmetric key
+//   - opens decoded message, rejecting tampered ones
+func NewCodecEncrypted(codecPacker communication.Codec, key []byte) (*codecEncrypted, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &codecEncrypted{
+		codecPacker: codecPacker,
+		aead:        aead,
+	}, nil
+}
+
+type codecEncrypted struct {
+	codecPacker communication.Codec
+	aead        cipher.AEAD
+}
+
+func (codec *codecEncrypted) Pack(payloadPtr interface{}) ([]byte, error) {
+	payloadData, err := codec.codecPacker.Pack(payloadPtr)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	nonce := make([]byte, codec.aead.NonceSize(), codec.aead.NonceSize()+len(payloadData)+codec.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return []byte{}, err
+	}
+
+	return codec.aead.Seal(nonce, nonce, payloadData, nil), nil
+}
+
+func (codec *codecEncrypted) Unpack(data []byte, payloadPtr interface{}) error {
+	if len(data) < codec.aead.NonceSize() {
+		return errMessageTooShort
+	}
+
+	nonce, ciphertext := data[:codec.aead.NonceSize()], data[codec.aead.NonceSize():]
+	payloadData, err := codec.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return err
+	}
+
+	return codec.codecPacker.Unpack(payloadData, payloadPtr)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ communication.Codec = &codecEncrypted{}
+
+var testEncryptionKey = bytes.Repeat([]byte{1}, 32)
+
+func TestCodecEncrypted_PackUnpack(t *testing.T) {
+	codec, err := NewCodecEncrypted(communication.NewCodecJSON(), testEncryptionKey)
+	assert.NoError(t, err)
+
+	data, err := codec.Pack(&customPayload{123})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "Field")
+
+	var payload customPayload
+	err = codec.Unpack(data, &payload)
+	assert.NoError(t, err)
+	assert.Equal(t, customPayload{123}, payload)
+}
+
+func TestCodecEncrypted_PackUsesFreshNonces(t *testing.T) {
+	codec, err := NewCodecEncrypted(communication.NewCodecJSON(), testEncryptionKey)
+	assert.NoError(t, err)
+
+	first, err := codec.Pack("data")
+	assert.NoError(t, err)
+	second, err := codec.Pack("data")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestCodecEncrypted_UnpackRejectsTamperedMessage(t *testing.T) {
+	codec, err := NewCodecEncrypted(communication.NewCodecJSON(), testEncryptionKey)
+	assert.NoError(t, err)
+
+	data, err := codec.Pack("data")
+	assert.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+
+	var payload string
+	assert.Error(t, codec.Unpack(data, &payload))
+	assert.Equal(t, errMessageTooShort, codec.Unpack([]byte{1, 2}, &payload))
+}
+
+func TestCodecEncrypted_UnpackRejectsOtherKey(t *testing.T) {
+	codec, err := NewCodecEncrypted(communication.NewCodecJSON(), testEncryptionKey)
+	assert.NoError(t, err)
+	otherCodec, err := NewCodecEncrypted(communication.NewCodecJSON(), bytes.Repeat([]byte{2}, 32))
+	assert.NoError(t, err)
+
+	data, err := codec.Pack("data")
+	assert.NoError(t, err)
+
+	var payload string
+	assert.Error(t, otherCodec.Unpack(data, &payload))
+}
+
+func TestCodecEncrypted_RejectsInvalidKey(t *testing.T) {
+	_, err := NewCodecEncrypted(communication.NewCodecJSON(), []byte("short"))
+	assert.Error(t, err)
+}
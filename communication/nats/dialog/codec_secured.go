@@ -20,6 +20,9 @@ package dialog
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
@@ -41,10 +44,27 @@ func NewCodecSecured(
 	}
 }
 
+// NewCodecSecuredSequenced returns secured codec which also:
+//   - numbers and timestamps encoded messages, signing them together with payload
+//   - rejects decoded messages which are stale or were already received
+func NewCodecSecuredSequenced(
+	codecPacker communication.Codec,
+	signer identity.Signer,
+	verifier identity.Verifier,
+) *codecSecured {
+	codec := NewCodecSecured(codecPacker, signer, verifier)
+	codec.replayGuard = newReplayGuard()
+	return codec
+}
+
 type codecSecured struct {
 	codecPacker communication.Codec
 	signer      identity.Signer
 	verifier    identity.Verifier
+
+	// sequence of the last packed message, used only together with replay guard
+	sequence    uint64
+	replayGuard *replayGuard
 }
 
 func (codec *codecSecured) Pack(payloadPtr interface{}) ([]byte, error) {
@@ -53,15 +73,19 @@ func (codec *codecSecured) Pack(payloadPtr interface{}) ([]byte, error) {
 		return []byte{}, err
 	}
 
-	signature, err := codec.signer.Sign(payloadData)
+	envelope := &messageEnvelope{Payload: payloadData}
+	if codec.replayGuard != nil {
+		envelope.Sequence = atomic.AddUint64(&codec.sequence, 1)
+		envelope.Timestamp = time.Now().UnixNano()
+	}
+
+	signature, err := codec.signer.Sign(envelope.signedMessage())
 	if err != nil {
 		return []byte{}, err
 	}
+	envelope.Signature = signature.Base64()
 
-	return codec.codecPacker.Pack(&messageEnvelope{
-		Payload:   payloadData,
-		Signature: signature.Base64(),
-	})
+	return codec.codecPacker.Pack(envelope)
 }
 
 func (codec *codecSecured) Unpack(data []byte, payloadPtr interface{}) error {
@@ -71,14 +95,50 @@ func (codec *codecSecured) Unpack(data []byte, payloadPtr interface{}) error {
 		return err
 	}
 
-	if !codec.verifier.Verify(envelope.Payload, identity.SignatureBase64(envelope.Signature)) {
+	if !codec.verifier.Verify(envelope.signedMessage(), identity.SignatureBase64(envelope.Signature)) {
 		return fmt.Errorf("invalid message signature '%s'", envelope.Signature)
 	}
 
+	if signed, ok := payloadPtr.(signedPayload); ok {
+		signer, err := identity.NewExtractor().Extract(envelope.signedMessage(), identity.SignatureBase64(envelope.Signature))
+		if err != nil {
+			return fmt.Errorf("invalid message signature '%s'", envelope.Signature)
+		}
+		signed.setSigner(signer)
+	}
+
+	if codec.replayGuard != nil {
+		err := codec.replayGuard.Check(envelope.Sequence, time.Unix(0, envelope.Timestamp), time.Now())
+		if err != nil {
+			return fmt.Errorf("rejected message %d. %s", envelope.Sequence, err)
+		}
+	}
+
 	return codec.codecPacker.Unpack(envelope.Payload, payloadPtr)
 }
 
+// signedPayload is implemented by payloads which need to know the identity that signed them
+type signedPayload interface {
+	setSigner(signer identity.Identity)
+}
+
 type messageEnvelope struct {
 	Payload   json.RawMessage `json:"payload"`
+	Sequence  uint64          `json:"sequence,omitempty"`
+	Timestamp int64           `json:"timestamp,omitempty"`
 	Signature string          `json:"signature"`
 }
+
+// signedMessage returns the message signature is made of, sequenced envelopes sign their sequence and timestamp too
+func (envelope *messageEnvelope) signedMessage() []byte {
+	if envelope.Sequence == 0 && envelope.Timestamp == 0 {
+		return envelope.Payload
+	}
+
+	message := make([]byte, 0, len(envelope.Payload)+42)
+	message = append(message, envelope.Payload...)
+	message = append(message, '\n')
+	message = strconv.AppendUint(message, envelope.Sequence, 10)
+	message = append(message, '\n')
+	return strconv.AppendInt(message, envelope.Timestamp, 10)
+}
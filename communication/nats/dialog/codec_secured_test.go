@@ -18,8 +18,10 @@
 package dialog
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
@@ -226,3 +228,120 @@ func TestCodecSigner_UnpackError(t *testing.T) {
 		assert.EqualError(t, err, tt.expectedError)
 	}
 }
+
+func TestCodecSecuredSequenced_PackUnpack(t *testing.T) {
+	codec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+	peerCodec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+
+	first, err := codec.Pack(&customPayload{1})
+	assert.NoError(t, err)
+	second, err := codec.Pack(&customPayload{2})
+	assert.NoError(t, err)
+
+	var envelope messageEnvelope
+	assert.NoError(t, json.Unmarshal(second, &envelope))
+	assert.Equal(t, uint64(2), envelope.Sequence)
+	assert.InDelta(t, time.Now().UnixNano(), envelope.Timestamp, float64(time.Second))
+
+	var payload customPayload
+	assert.NoError(t, peerCodec.Unpack(second, &payload))
+	assert.Equal(t, customPayload{2}, payload)
+	assert.NoError(t, peerCodec.Unpack(first, &payload))
+	assert.Equal(t, customPayload{1}, payload)
+}
+
+func TestCodecSecuredSequenced_UnpackRejectsReplayedMessage(t *testing.T) {
+	codec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+	peerCodec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+
+	data, err := codec.Pack("data")
+	assert.NoError(t, err)
+
+	var payload string
+	assert.NoError(t, peerCodec.Unpack(data, &payload))
+	assert.EqualError(t, peerCodec.Unpack(data, &payload), "rejected message 1. replayed message")
+}
+
+func TestCodecSecuredSequenced_UnpackRejectsUnsequencedAndStaleMessages(t *testing.T) {
+	codec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+
+	var payload string
+	err := codec.Unpack([]byte(`{
+		"payload": "hello \"name\"",
+		"signature": "c2lnbmVkImhlbGxvIFwibmFtZVwiIg=="
+	}`), &payload)
+	assert.EqualError(t, err, "rejected message 0. stale message")
+
+	stale := &messageEnvelope{
+		Payload:   json.RawMessage(`"data"`),
+		Sequence:  1,
+		Timestamp: time.Now().Add(-time.Hour).UnixNano(),
+	}
+	signature, _ := (&identity.SignerFake{}).Sign(stale.signedMessage())
+	stale.Signature = signature.Base64()
+	data, err := json.Marshal(stale)
+	assert.NoError(t, err)
+	assert.EqualError(t, codec.Unpack(data, &payload), "rejected message 1. stale message")
+}
+
+func TestCodecSecuredSequenced_UnpackRejectsChangedSequence(t *testing.T) {
+	codec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+	peerCodec := NewCodecSecuredSequenced(communication.NewCodecJSON(), &identity.SignerFake{}, &identity.VerifierFake{})
+
+	data, err := codec.Pack("data")
+	assert.NoError(t, err)
+
+	var envelope messageEnvelope
+	assert.NoError(t, json.Unmarshal(data, &envelope))
+	envelope.Sequence = 5
+	data, err = json.Marshal(envelope)
+	assert.NoError(t, err)
+
+	var payload string
+	err = peerCodec.Unpack(data, &payload)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid message signature")
+}
+
+// benchmarkMessage resembles the balance and promise messages sent through dialogs most frequently
+type benchmarkMessage struct {
+	Message struct {
+		Amount     uint64 `json:"amount"`
+		SequenceID uint64 `json:"sequenceID"`
+		Signature  string `json:"signature"`
+	} `json:"promiseMessage"`
+}
+
+func BenchmarkCodecSecured_JSON(b *testing.B) {
+	benchmarkCodecSecured(b, communication.NewCodecJSON())
+}
+
+func BenchmarkCodecSecured_Binary(b *testing.B) {
+	benchmarkCodecSecured(b, communication.NewCodecBinary())
+}
+
+func benchmarkCodecSecured(b *testing.B, codecPacker communication.Codec) {
+	codec := NewCodecSecuredSequenced(codecPacker, &identity.SignerFake{}, &identity.VerifierFake{})
+	peerCodec := NewCodecSecuredSequenced(codecPacker, &identity.SignerFake{}, &identity.VerifierFake{})
+
+	message := &benchmarkMessage{}
+	message.Message.Amount = 1500
+	message.Message.SequenceID = 42
+	message.Message.Signature = "0x5e2e51ad06ca0bbf8a4cf1c4fa47a4ffc5ba2ed5b9cf4cb21b5daf4dba9d8e5c2c7cb4c1d05e8a5f30e93d7bd2dd7a8da2a7d6cfd4bb1a2f9d59c8c6c7b0d1e41c"
+
+	var size int
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, err := codec.Pack(message)
+		if err != nil {
+			b.Fatal(err)
+		}
+		size = len(data)
+
+		var payload benchmarkMessage
+		if err := peerCodec.Unpack(data, &payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(size))
+}
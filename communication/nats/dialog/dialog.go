@@ -18,20 +18,210 @@
 package dialog
 
 import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
 )
 
+const dialogLogPrefix = "[NATS.Dialog] "
+
+func newDialog(
+	peerID identity.Identity,
+	peerAddress PeerAddress,
+	sender communication.Sender,
+	receiver communication.Receiver,
+) *dialog {
+	return &dialog{
+		Sender:      sender,
+		Receiver:    receiver,
+		peerID:      peerID,
+		peerAddress: peerAddress,
+		done:        make(chan struct{}),
+	}
+}
+
 type dialog struct {
+	// lastSeen is accessed atomically, kept first to be 64-bit aligned on 32-bit platforms
+	lastSeen int64
+
 	communication.Sender
 	communication.Receiver
 	peerID identity.Identity
+
+	// peerAddress is owned by dialogs of establisher, which connect to peer for every dialog
+	peerAddress PeerAddress
+	// capabilities peers agreed on while creating the dialog
+	capabilities communication.Capabilities
+	// assumedCapabilities are the ones of peer which did not exchange them, assumed instead of agreed on
+	assumedCapabilities communication.Capabilities
+
+	// keepalive is the heartbeat interval peers agreed on, dialogs with peers not supporting it end only when closed
+	keepalive   time.Duration
+	ownSignals  signalEndpoints
+	peerSignals signalEndpoints
+	done        chan struct{}
+	doneOnce    sync.Once
+	closeOnce   sync.Once
 }
 
 func (dialog *dialog) Close() error {
+	dialog.closeOnce.Do(func() {
+		if dialog.keepalive > 0 && !dialog.isDone() {
+			if err := dialog.Send(&signalProducer{endpoint: dialog.ownSignals.close}); err != nil {
+				log.Warn(dialogLogPrefix, "Failed to tell peer dialog is closed: ", err)
+			}
+		}
+		dialog.finish()
+
+		if dialog.peerAddress != nil {
+			dialog.peerAddress.Disconnect()
+		}
+	})
 	return nil
 }
 
 func (dialog *dialog) PeerID() identity.Identity {
 	return dialog.peerID
 }
+
+// Capabilities returns features peers agreed on while creating the dialog
+func (dialog *dialog) Capabilities() communication.Capabilities {
+	return dialog.capabilities
+}
+
+// AssumedCapabilities returns features assumed of peer, which did not exchange them while creating the dialog
+func (dialog *dialog) AssumedCapabilities() communication.Capabilities {
+	return dialog.assumedCapabilities
+}
+
+// Done is closed when dialog is closed by either of the peers, or the peer stops sending heartbeats
+func (dialog *dialog) Done() <-chan struct{} {
+	return dialog.done
+}
+
+// Receive starts consuming messages of endpoint, every message tells that peer is still there
+func (dialog *dialog) Receive(consumer communication.MessageConsumer) error {
+	return dialog.Receiver.Receive(&seenMessageConsumer{MessageConsumer: consumer, seen: dialog.seen})
+}
+
+// Respond starts answering requests of endpoint, every request tells that peer is still there
+func (dialog *dialog) Respond(consumer communication.RequestConsumer) error {
+	return dialog.Receiver.Respond(&seenRequestConsumer{RequestConsumer: consumer, seen: dialog.seen})
+}
+
+// closeWhenIdle ends dialog once peer sent nothing for the given time, for peers not supporting keepalive
+func (dialog *dialog) closeWhenIdle(timeout time.Duration) {
+	dialog.seen()
+	ticker := time.NewTicker(timeout / keepaliveMissed)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-dialog.done:
+			return
+		case <-ticker.C:
+		}
+
+		lastSeen := time.Unix(0, atomic.LoadInt64(&dialog.lastSeen))
+		if time.Since(lastSeen) > timeout {
+			log.Info(dialogLogPrefix, "Closing dialog idle since ", lastSeen, ": ", dialog.peerID.Address)
+			dialog.Close()
+			return
+		}
+	}
+}
+
+// startKeepalive starts exchanging heartbeats with peer at the agreed interval, 0 stands for peer not supporting them
+func (dialog *dialog) startKeepalive(keepalive time.Duration, ownSignals, peerSignals signalEndpoints) error {
+	if keepalive <= 0 {
+		return nil
+	}
+	dialog.keepalive = keepalive
+	dialog.ownSignals = ownSignals
+	dialog.peerSignals = peerSignals
+
+	dialog.seen()
+	err := dialog.Receive(&signalConsumer{endpoint: peerSignals.heartbeat, handle: dialog.seen})
+	if err != nil {
+		return err
+	}
+	err = dialog.Receive(&signalConsumer{endpoint: peerSignals.close, handle: func() {
+		log.Info(dialogLogPrefix, "Peer closed dialog: ", dialog.peerID.Address)
+		dialog.finish()
+	}})
+	if err != nil {
+		return err
+	}
+
+	go dialog.sendHeartbeats()
+	return nil
+}
+
+func (dialog *dialog) sendHeartbeats() {
+	ticker := time.NewTicker(dialog.keepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-dialog.done:
+			return
+		case <-ticker.C:
+		}
+
+		lastSeen := time.Unix(0, atomic.LoadInt64(&dialog.lastSeen))
+		if time.Since(lastSeen) > keepaliveMissed*dialog.keepalive {
+			log.Warn(dialogLogPrefix, "Peer vanished, no heartbeats since ", lastSeen, ": ", dialog.peerID.Address)
+			dialog.finish()
+			return
+		}
+
+		if err := dialog.Send(&signalProducer{endpoint: dialog.ownSignals.heartbeat}); err != nil {
+			log.Debug(dialogLogPrefix, "Failed to send heartbeat: ", err)
+		}
+	}
+}
+
+func (dialog *dialog) seen() {
+	atomic.StoreInt64(&dialog.lastSeen, time.Now().UnixNano())
+}
+
+func (dialog *dialog) finish() {
+	dialog.doneOnce.Do(func() {
+		if dialog.done != nil {
+			close(dialog.done)
+		}
+	})
+}
+
+type seenMessageConsumer struct {
+	communication.MessageConsumer
+	seen func()
+}
+
+func (consumer *seenMessageConsumer) Consume(messagePtr interface{}) error {
+	consumer.seen()
+	return consumer.MessageConsumer.Consume(messagePtr)
+}
+
+type seenRequestConsumer struct {
+	communication.RequestConsumer
+	seen func()
+}
+
+func (consumer *seenRequestConsumer) Consume(requestPtr interface{}) (interface{}, error) {
+	consumer.seen()
+	return consumer.RequestConsumer.Consume(requestPtr)
+}
+
+func (dialog *dialog) isDone() bool {
+	select {
+	case <-dialog.done:
+		return true
+	default:
+		return false
+	}
+}
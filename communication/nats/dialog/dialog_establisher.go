@@ -19,28 +19,31 @@ package dialog
 
 import (
 	"fmt"
+	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/communication/nats"
-	"github.com/mysteriumnetwork/node/communication/nats/discovery"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 )
 
-// NewDialogEstablisher constructs new DialogEstablisher which works thru NATS connection.
-func NewDialogEstablisher(ID identity.Identity, signer identity.Signer) *dialogEstablisher {
+// NewDialogEstablisher constructs new DialogEstablisher which works thru NATS or direct connection.
+// Peers are asked to exchange heartbeats at the keepalive interval, 0 disables them.
+// Policies define timeouts and retries of requests sent to the peer.
+func NewDialogEstablisher(
+	ID identity.Identity,
+	signer identity.Signer,
+	keepalive time.Duration,
+	policies communication.RequestPolicies,
+) *dialogEstablisher {
 	return &dialogEstablisher{
-		ID:     ID,
-		Signer: signer,
-		peerAddressFactory: func(contact market.Contact) (*discovery.AddressNATS, error) {
-			address, err := discovery.NewAddressForContact(contact)
-			if err == nil {
-				err = address.Connect()
-			}
-
-			return address, err
-		},
+		ID:                 ID,
+		Signer:             signer,
+		Keepalive:          keepalive,
+		Policies:           policies,
+		Capabilities:       communication.SupportedCapabilities,
+		peerAddressFactory: newPeerAddress,
 	}
 }
 
@@ -49,7 +52,10 @@ const establisherLogPrefix = "[NATS.DialogEstablisher] "
 type dialogEstablisher struct {
 	ID                 identity.Identity
 	Signer             identity.Signer
-	peerAddressFactory func(contact market.Contact) (*discovery.AddressNATS, error)
+	Keepalive          time.Duration
+	Policies           communication.RequestPolicies
+	Capabilities       communication.Capabilities
+	peerAddressFactory func(contact market.Contact) (PeerAddress, error)
 }
 
 func (establisher *dialogEstablisher) EstablishDialog(
@@ -63,48 +69,124 @@ func (establisher *dialogEstablisher) EstablishDialog(
 		return nil, fmt.Errorf("failed to connect to: %#v. %s", peerContact, err)
 	}
 
-	peerCodec := establisher.newCodecForPeer(peerID)
+	peerCodec := establisher.newCodecForPeer(peerID, dialogVersionSigned, communication.NewCodecJSON())
+
+	keys, err := newKeyExchange()
+	if err != nil {
+		peerAddress.Disconnect()
+		return nil, err
+	}
 
 	peerSender := establisher.newSenderToPeer(peerAddress, peerCodec)
-	topic, err := establisher.negotiateTopic(peerSender)
+	response, err := establisher.negotiateDialog(peerSender, keys)
+	if err != nil {
+		peerAddress.Disconnect()
+		return nil, err
+	}
+
+	dialogCodec, err := establisher.newDialogCodec(peerID, keys, response)
 	if err != nil {
+		peerAddress.Disconnect()
 		return nil, err
 	}
 
-	dialog := establisher.newDialogToPeer(peerID, peerAddress, peerCodec, topic)
-	log.Info(establisherLogPrefix, fmt.Sprintf("Dialog established with: %#v", peerContact))
+	keepalive := acceptKeepaliveInterval(time.Duration(response.KeepaliveInterval)*time.Millisecond, establisher.Keepalive)
+	version := agreedDialogVersion(response)
+	dialog := establisher.newDialogToPeer(peerID, peerAddress, dialogCodec, response.Topic)
+	capabilities := acceptCapabilities(version, establisher.Capabilities, response.Capabilities)
+	dialog.capabilities = capabilities.Union(dialogCapabilities(version, response.Codec, len(response.PublicKey) > 0, keepalive))
+	if !capabilitiesExchanged(version) {
+		dialog.assumedCapabilities = capabilities
+	}
+	if err := dialog.startKeepalive(keepalive, signalsOfEstablisher, signalsOfWaiter); err != nil {
+		dialog.Close()
+		return nil, fmt.Errorf("failed to start heartbeats. %s", err)
+	}
+	log.Info(establisherLogPrefix, fmt.Sprintf("Dialog established with: %#v, capabilities: %v", peerContact, dialog.capabilities))
 
 	return dialog, nil
 }
 
-func (establisher *dialogEstablisher) negotiateTopic(sender communication.Sender) (string, error) {
+func (establisher *dialogEstablisher) negotiateDialog(sender communication.Sender, keys *keyExchange) (*dialogCreateResponse, error) {
+	publicKey := keys.PublicKey()
+	publicKeySignature, err := establisher.Signer.Sign([]byte(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign dialog key. %s", err)
+	}
+
 	response, err := sender.Request(&dialogCreateProducer{
 		&dialogCreateRequest{
-			PeerID:  establisher.ID.Address,
-			Version: "v1",
+			PeerID:             establisher.ID.Address,
+			Version:            dialogVersionLatest,
+			PublicKey:          publicKey,
+			PublicKeySignature: publicKeySignature.Base64(),
+			Codecs:             payloadCodecs,
+			KeepaliveInterval:  int64(establisher.Keepalive / time.Millisecond),
+			Capabilities:       establisher.Capabilities.Strings(),
 		},
 	})
 	if err != nil {
-		return "", fmt.Errorf("dialog creation error. %s", err)
+		return nil, fmt.Errorf("dialog creation error. %s", err)
 	}
 	if response.(*dialogCreateResponse).Reason != 200 {
-		return "", fmt.Errorf("dialog creation rejected. %#v", response)
+		return nil, fmt.Errorf("dialog creation rejected. %#v", response)
 	}
 
-	return response.(*dialogCreateResponse).Topic, nil
+	return response.(*dialogCreateResponse), nil
 }
 
-func (establisher *dialogEstablisher) newCodecForPeer(peerID identity.Identity) *codecSecured {
+// newDialogCodec secures the dialog as much as peer agreed to, peers of older versions keep it signed only
+func (establisher *dialogEstablisher) newDialogCodec(
+	peerID identity.Identity,
+	keys *keyExchange,
+	response *dialogCreateResponse,
+) (communication.Codec, error) {
+	version := agreedDialogVersion(response)
+	if dialogVersionNumber(version) < dialogVersionNumber(dialogVersionSequenced) {
+		log.Warn(establisherLogPrefix, "Peer does not support replay protected dialogs, version: ", version)
+	}
+
+	peerCodec := establisher.newCodecForPeer(peerID, version, newPayloadCodec(response.Codec))
+	if len(response.PublicKey) == 0 {
+		log.Warn(establisherLogPrefix, "Peer does not support encrypted dialogs, payloads are sent signed only")
+		return peerCodec, nil
+	}
+
+	key, err := keys.SharedKey(response.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("dialog key agreement failed. %s", err)
+	}
+
+	return NewCodecEncrypted(peerCodec, key)
+}
+
+// agreedDialogVersion returns the dialog version peer agreed to
+func agreedDialogVersion(response *dialogCreateResponse) string {
+	if len(response.Version) == 0 && len(response.PublicKey) > 0 {
+		// peers of the first encrypted version do not return the version they agreed to
+		return dialogVersionEncrypted
+	}
+	return response.Version
+}
+
+func (establisher *dialogEstablisher) newCodecForPeer(peerID identity.Identity, version string, payloadCodec communication.Codec) *codecSecured {
+	if dialogVersionNumber(version) >= dialogVersionNumber(dialogVersionSequenced) {
+		return NewCodecSecuredSequenced(
+			payloadCodec,
+			establisher.Signer,
+			identity.NewVerifierIdentity(peerID),
+		)
+	}
 
 	return NewCodecSecured(
-		communication.NewCodecJSON(),
+		payloadCodec,
 		establisher.Signer,
 		identity.NewVerifierIdentity(peerID),
 	)
 }
 
 func (establisher *dialogEstablisher) newSenderToPeer(
-	peerAddress *discovery.AddressNATS,
+	peerAddress PeerAddress,
 	peerCodec *codecSecured,
 ) communication.Sender {
 
@@ -112,13 +194,14 @@ func (establisher *dialogEstablisher) newSenderToPeer(
 		peerAddress.GetConnection(),
 		peerCodec,
 		peerAddress.GetTopic(),
+		establisher.Policies,
 	)
 }
 
 func (establisher *dialogEstablisher) newDialogToPeer(
 	peerID identity.Identity,
-	peerAddress *discovery.AddressNATS,
-	peerCodec *codecSecured,
+	peerAddress PeerAddress,
+	peerCodec communication.Codec,
 	topic string,
 ) *dialog {
 	if len(topic) == 0 {
@@ -126,9 +209,10 @@ func (establisher *dialogEstablisher) newDialogToPeer(
 		topic = peerAddress.GetTopic() + "." + establisher.ID.Address
 	}
 
-	return &dialog{
-		peerID:   peerID,
-		Sender:   nats.NewSender(peerAddress.GetConnection(), peerCodec, topic),
-		Receiver: nats.NewReceiver(peerAddress.GetConnection(), peerCodec, topic),
-	}
+	return newDialog(
+		peerID,
+		peerAddress,
+		nats.NewSender(peerAddress.GetConnection(), peerCodec, topic, establisher.Policies),
+		nats.NewReceiver(peerAddress.GetConnection(), peerCodec, topic),
+	)
 }
@@ -18,7 +18,9 @@
 package dialog
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/communication/nats"
@@ -34,10 +36,14 @@ func TestDialogEstablisher_Factory(t *testing.T) {
 	id := identity.FromAddress("123456")
 	signer := &identity.SignerFake{}
 
-	establisher := NewDialogEstablisher(id, signer)
+	policies := communication.RequestPolicies{"session-create": {Timeout: time.Minute}}
+
+	establisher := NewDialogEstablisher(id, signer, time.Minute, policies)
 	assert.NotNil(t, establisher)
 	assert.Equal(t, id, establisher.ID)
 	assert.Equal(t, signer, establisher.Signer)
+	assert.Equal(t, time.Minute, establisher.Keepalive)
+	assert.Equal(t, policies, establisher.Policies)
 }
 
 func TestDialogEstablisher_EstablishDialog(t *testing.T) {
@@ -68,7 +74,7 @@ func TestDialogEstablisher_EstablishDialog(t *testing.T) {
 	expectedCodec := NewCodecSecured(communication.NewCodecJSON(), signer, identity.NewVerifierIdentity(peerID))
 	assert.Equal(
 		t,
-		nats.NewSender(connection, expectedCodec, "peer-topic."+myID.Address),
+		nats.NewSender(connection, expectedCodec, "peer-topic."+myID.Address, nil),
 		dialog.Sender,
 	)
 	assert.Equal(
@@ -76,6 +82,205 @@ func TestDialogEstablisher_EstablishDialog(t *testing.T) {
 		nats.NewReceiver(connection, expectedCodec, "peer-topic."+myID.Address),
 		dialog.Receiver,
 	)
+	assert.Equal(t, communication.LegacyCapabilities, communication.CapabilitiesOf(dialogInstance))
+	assert.Equal(t, communication.Capabilities{}, communication.NegotiatedCapabilitiesOf(dialogInstance))
+}
+
+func TestDialogEstablisher_EstablishEncryptedDialog(t *testing.T) {
+	myID := identity.FromAddress("0x6B21b441D0D2Fa1d86407977A3a5C6eD90Ff1A62")
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	response, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateResponse{
+		Reason:        200,
+		ReasonMessage: "OK",
+		Topic:         "dialog-topic",
+		PublicKey:     peerKeys.PublicKey(),
+	})
+	assert.NoError(t, err)
+
+	connection := nats.StartConnectionFake()
+	connection.MockResponse("peer-topic.dialog-create", response)
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	establisher := mockEstablisher(myID, connection, signer)
+
+	dialogInstance, err := establisher.EstablishDialog(peerID, market.Contact{})
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var request struct {
+		Payload dialogCreateRequest `json:"payload"`
+	}
+	err = json.Unmarshal(connection.GetLastRequest(), &request)
+	assert.NoError(t, err)
+	assert.Equal(t, dialogVersionLatest, request.Payload.Version)
+	expectedSignature, _ := signer.Sign([]byte(request.Payload.PublicKey))
+	assert.Equal(t, expectedSignature.Base64(), request.Payload.PublicKeySignature)
+
+	key, err := peerKeys.SharedKey(request.Payload.PublicKey)
+	assert.NoError(t, err)
+	expectedCodec, err := NewCodecEncrypted(
+		NewCodecSecured(communication.NewCodecJSON(), signer, identity.NewVerifierIdentity(peerID)),
+		key,
+	)
+	assert.NoError(t, err)
+
+	dialog, ok := dialogInstance.(*dialog)
+	assert.True(t, ok)
+	assert.Equal(t, nats.NewSender(connection, expectedCodec, "dialog-topic", nil), dialog.Sender)
+	assert.Equal(t, nats.NewReceiver(connection, expectedCodec, "dialog-topic"), dialog.Receiver)
+}
+
+func TestDialogEstablisher_EstablishSequencedDialog(t *testing.T) {
+	myID := identity.FromAddress("0x6B21b441D0D2Fa1d86407977A3a5C6eD90Ff1A62")
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	response, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateResponse{
+		Reason:        200,
+		ReasonMessage: "OK",
+		Topic:         "dialog-topic",
+		PublicKey:     peerKeys.PublicKey(),
+		Version:       dialogVersionSequenced,
+	})
+	assert.NoError(t, err)
+
+	connection := nats.StartConnectionFake()
+	connection.MockResponse("peer-topic.dialog-create", response)
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	establisher := mockEstablisher(myID, connection, signer)
+
+	dialogInstance, err := establisher.EstablishDialog(peerID, market.Contact{})
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var request struct {
+		Payload dialogCreateRequest `json:"payload"`
+	}
+	err = json.Unmarshal(connection.GetLastRequest(), &request)
+	assert.NoError(t, err)
+
+	key, err := peerKeys.SharedKey(request.Payload.PublicKey)
+	assert.NoError(t, err)
+	expectedCodec, err := NewCodecEncrypted(
+		NewCodecSecuredSequenced(communication.NewCodecJSON(), signer, identity.NewVerifierIdentity(peerID)),
+		key,
+	)
+	assert.NoError(t, err)
+
+	dialog, ok := dialogInstance.(*dialog)
+	assert.True(t, ok)
+	assert.Equal(t, nats.NewSender(connection, expectedCodec, "dialog-topic", nil), dialog.Sender)
+	assert.Equal(t, nats.NewReceiver(connection, expectedCodec, "dialog-topic"), dialog.Receiver)
+	assert.Equal(
+		t,
+		communication.NewCapabilities(
+			communication.CapabilityEncryption,
+			communication.CapabilityReplayProtection,
+			communication.CapabilitySessionResume,
+			communication.CapabilityPaymentPromises,
+		),
+		dialog.Capabilities(),
+	)
+}
+
+func TestDialogEstablisher_EstablishDialogWithCapabilities(t *testing.T) {
+	myID := identity.FromAddress("0x6B21b441D0D2Fa1d86407977A3a5C6eD90Ff1A62")
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	response, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateResponse{
+		Reason:        200,
+		ReasonMessage: "OK",
+		Topic:         "dialog-topic",
+		PublicKey:     peerKeys.PublicKey(),
+		Version:       dialogVersionCapable,
+		Capabilities:  []string{"session-resume", "unknown-feature"},
+	})
+	assert.NoError(t, err)
+
+	connection := nats.StartConnectionFake()
+	connection.MockResponse("peer-topic.dialog-create", response)
+	defer connection.Close()
+
+	establisher := mockEstablisher(myID, connection, &identity.SignerFake{})
+
+	dialogInstance, err := establisher.EstablishDialog(peerID, market.Contact{})
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var request struct {
+		Payload dialogCreateRequest `json:"payload"`
+	}
+	err = json.Unmarshal(connection.GetLastRequest(), &request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"payment-promises", "session-resume"}, request.Payload.Capabilities)
+
+	assert.Equal(
+		t,
+		communication.NewCapabilities(
+			communication.CapabilityEncryption,
+			communication.CapabilityReplayProtection,
+			communication.CapabilitySessionResume,
+		),
+		communication.CapabilitiesOf(dialogInstance),
+	)
+	assert.Equal(t, communication.CapabilitiesOf(dialogInstance), communication.NegotiatedCapabilitiesOf(dialogInstance))
+}
+
+func TestDialogEstablisher_EstablishBinaryDialog(t *testing.T) {
+	myID := identity.FromAddress("0x6B21b441D0D2Fa1d86407977A3a5C6eD90Ff1A62")
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	response, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateResponse{
+		Reason:        200,
+		ReasonMessage: "OK",
+		Topic:         "dialog-topic",
+		PublicKey:     peerKeys.PublicKey(),
+		Version:       dialogVersionSequenced,
+		Codec:         payloadCodecBinary,
+	})
+	assert.NoError(t, err)
+
+	connection := nats.StartConnectionFake()
+	connection.MockResponse("peer-topic.dialog-create", response)
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	establisher := mockEstablisher(myID, connection, signer)
+
+	dialogInstance, err := establisher.EstablishDialog(peerID, market.Contact{})
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var request struct {
+		Payload dialogCreateRequest `json:"payload"`
+	}
+	err = json.Unmarshal(connection.GetLastRequest(), &request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{payloadCodecBinary, payloadCodecJSON}, request.Payload.Codecs)
+
+	key, err := peerKeys.SharedKey(request.Payload.PublicKey)
+	assert.NoError(t, err)
+	expectedCodec, err := NewCodecEncrypted(
+		NewCodecSecuredSequenced(communication.NewCodecBinary(), signer, identity.NewVerifierIdentity(peerID)),
+		key,
+	)
+	assert.NoError(t, err)
+
+	dialog, ok := dialogInstance.(*dialog)
+	assert.True(t, ok)
+	assert.Equal(t, nats.NewSender(connection, expectedCodec, "dialog-topic", nil), dialog.Sender)
+	assert.Equal(t, nats.NewReceiver(connection, expectedCodec, "dialog-topic"), dialog.Receiver)
 }
 
 func TestDialogEstablisher_CreateDialogWhenResponseHijacked(t *testing.T) {
@@ -104,9 +309,10 @@ func mockEstablisher(ID identity.Identity, connection nats.Connection, signer id
 	peerTopic := "peer-topic"
 
 	return &dialogEstablisher{
-		ID:     ID,
-		Signer: signer,
-		peerAddressFactory: func(contact market.Contact) (*discovery.AddressNATS, error) {
+		ID:           ID,
+		Signer:       signer,
+		Capabilities: communication.SupportedCapabilities,
+		peerAddressFactory: func(contact market.Contact) (PeerAddress, error) {
 			return discovery.NewAddressWithConnection(connection, peerTopic), nil
 		},
 	}
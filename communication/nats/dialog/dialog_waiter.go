@@ -20,6 +20,7 @@ package dialog
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/gofrs/uuid"
@@ -32,41 +33,88 @@ import (
 	"github.com/pkg/errors"
 )
 
-// NewDialogWaiter constructs new DialogWaiter which works through NATS connection.
-func NewDialogWaiter(address *discovery.AddressNATS, signer identity.Signer, identityRegistry registry.IdentityRegistry) *dialogWaiter {
+// NewDialogWaiter constructs new DialogWaiter which works through NATS or direct connection.
+// Peers are offered to exchange heartbeats at the keepalive interval, 0 disables them.
+// Policies define timeouts and retries of requests sent to the peers.
+// Limiter decides which dialog requests are accepted, it may be shared by waiters of all services.
+func NewDialogWaiter(
+	address PeerAddress,
+	signer identity.Signer,
+	identityRegistry registry.IdentityRegistry,
+	keepalive time.Duration,
+	policies communication.RequestPolicies,
+	limiter *DialogLimiter,
+) *dialogWaiter {
 	return &dialogWaiter{
 		address:          address,
 		signer:           signer,
 		dialogs:          make([]communication.Dialog, 0),
 		identityRegistry: identityRegistry,
+		keepalive:        keepalive,
+		policies:         policies,
+		capabilities:     communication.SupportedCapabilities,
+		limiter:          limiter,
+		reconnectWait:    discovery.BrokerReconnectWait,
+		idleTimeout:      idleDialogTimeout,
 	}
 }
 
 const waiterLogPrefix = "[NATS.DialogWaiter] "
 
+// idleDialogTimeout is how long dialog of peer not supporting keepalive stays open without any messages from it
+const idleDialogTimeout = 15 * time.Minute
+
+// errInvalidDialogKey indicates that peer sent a dialog key not signed by its identity
+var errInvalidDialogKey = errors.New("invalid dialog key")
+
 type dialogWaiter struct {
-	address          *discovery.AddressNATS
+	address          PeerAddress
 	signer           identity.Signer
 	dialogs          []communication.Dialog
 	identityRegistry registry.IdentityRegistry
+	keepalive        time.Duration
+	policies         communication.RequestPolicies
+	capabilities     communication.Capabilities
+	limiter          *DialogLimiter
+	reconnectWait    time.Duration
+	// idleTimeout closes dialogs of peers not supporting keepalive, which otherwise would hold their place forever
+	idleTimeout time.Duration
+
+	// receiver answers dialog requests of consumer through the current connection of address
+	receiver communication.Receiver
+	consumer *dialogCreateConsumer
+	stopped  bool
 
 	sync.RWMutex
 }
 
-// Start registers dialogWaiter with broker (NATS) service
-func (waiter *dialogWaiter) Start() (market.Contact, error) {
+// brokerListenable is implemented by addresses which report changes of their broker connection
+type brokerListenable interface {
+	AddListener(listener discovery.BrokerListener)
+}
+
+// Start registers dialogWaiter with broker (NATS) service or direct server
+func (waiter *dialogWaiter) Start() (market.ContactList, error) {
 	log.Info(waiterLogPrefix, "Connecting to: ", waiter.address.GetContact())
 
+	if address, ok := waiter.address.(brokerListenable); ok {
+		address.AddListener(waiter.onBrokerEvent)
+	}
+
 	err := waiter.address.Connect()
 	if err != nil {
-		return market.Contact{}, fmt.Errorf("failed to start my connection with: %v", waiter.address.GetContact())
+		return nil, fmt.Errorf("failed to start my connection with: %v", waiter.address.GetContact())
 	}
 
-	return waiter.address.GetContact(), nil
+	return market.ContactList{waiter.address.GetContact()}, nil
 }
 
-// Stop disconnects dialogWaiter from broker (NATS) service
+// Stop disconnects dialogWaiter from broker (NATS) service or direct server
 func (waiter *dialogWaiter) Stop() error {
+	waiter.Lock()
+	waiter.stopped = true
+	waiter.Unlock()
+
 	waiter.RLock()
 	defer waiter.RUnlock()
 
@@ -80,6 +128,19 @@ func (waiter *dialogWaiter) Stop() error {
 // ServeDialogs starts accepting dialogs initiated by peers
 func (waiter *dialogWaiter) ServeDialogs(dialogHandler communication.DialogHandler) error {
 	createDialog := func(request *dialogCreateRequest) (*dialogCreateResponse, error) {
+		// limits are checked first, so that flooding peers do not get to query the identity registry.
+		// Peers are told apart by the identity that signed the request, as anyone could claim any PeerID
+		if reason, allowed := waiter.limiter.Allow(request.signer.Address); !allowed {
+			log.Warn(waiterLogPrefix, fmt.Sprintf("Rejecting dialog from: '%s'. %s", request.signer.Address, reason))
+			waiter.limiter.Reject(request.signer.Address, reason)
+			return &responseTooManyRequests, nil
+		}
+		if request.signer.Address == "" || identity.FromAddress(request.PeerID) != request.signer {
+			log.Error(waiterLogPrefix, fmt.Sprintf("Rejecting peerID: '%s' not matching the signer: '%s'", request.PeerID, request.signer.Address))
+			waiter.limiter.Reject(request.signer.Address, RejectionInvalidIdentity)
+			return &responseInvalidIdentity, nil
+		}
+
 		valid, err := waiter.validateDialogRequest(request)
 		if err != nil {
 			log.Error(waiterLogPrefix, "Validation check failed: ", err.Error())
@@ -87,6 +148,7 @@ func (waiter *dialogWaiter) ServeDialogs(dialogHandler communication.DialogHandl
 		}
 		if !valid {
 			log.Error(waiterLogPrefix, "Rejecting invalid peerID: ", request.PeerID)
+			waiter.limiter.Reject(request.PeerID, RejectionInvalidIdentity)
 			return &responseInvalidIdentity, nil
 		}
 
@@ -102,44 +164,216 @@ func (waiter *dialogWaiter) ServeDialogs(dialogHandler communication.DialogHandl
 			// TODO this is a compatibility check. It should be removed once all consumers will migrate to the newer version.
 			topic = waiter.address.GetTopic() + "." + peerID.Address
 		}
-		dialog := waiter.newDialogToPeer(peerID, waiter.newCodecForPeer(peerID), topic)
+
+		version := acceptDialogVersion(request.Version)
+		payloadCodec := acceptPayloadCodec(request.Codecs)
+		peerCodec, publicKey, err := waiter.newDialogCodec(peerID, version, payloadCodec, request)
+		if err == errInvalidDialogKey {
+			log.Error(waiterLogPrefix, "Rejecting invalid dialog key of peerID: ", request.PeerID)
+			waiter.limiter.Reject(request.PeerID, RejectionInvalidIdentity)
+			return &responseInvalidIdentity, nil
+		}
+		if err != nil {
+			log.Error(waiterLogPrefix, "Failed to agree on dialog key: ", err)
+			return &responseInternalError, nil
+		}
+
+		if !waiter.limiter.Open() {
+			log.Warn(waiterLogPrefix, fmt.Sprintf("Rejecting dialog from: '%s'. %s", request.PeerID, RejectionTooManyDialogs))
+			waiter.limiter.Reject(request.PeerID, RejectionTooManyDialogs)
+			return &responseTooManyDialogs, nil
+		}
+
+		keepalive := acceptKeepaliveInterval(time.Duration(request.KeepaliveInterval)*time.Millisecond, waiter.keepalive)
+		capabilities := acceptCapabilities(version, waiter.capabilities, request.Capabilities)
+		dialog := waiter.newDialogToPeer(peerID, peerCodec, topic)
+		dialog.capabilities = capabilities.Union(dialogCapabilities(version, payloadCodec, len(publicKey) > 0, keepalive))
+		if !capabilitiesExchanged(version) {
+			dialog.assumedCapabilities = capabilities
+		}
+		// keepalive is started before handing the dialog over, so that the handler never sees it half set up
+		if err := dialog.startKeepalive(keepalive, signalsOfWaiter, signalsOfEstablisher); err != nil {
+			log.Error(waiterLogPrefix, fmt.Sprintf("Failed to start heartbeats with: '%s'. %s", request.PeerID, err))
+			dialog.Close()
+			waiter.limiter.Close()
+			return &responseInternalError, nil
+		}
 		err = dialogHandler.Handle(dialog)
 		if err != nil {
 			log.Error(waiterLogPrefix, fmt.Sprintf("Failed dialog from: '%s'. %s", request.PeerID, err))
+			dialog.Close()
+			waiter.limiter.Close()
 			return &responseInternalError, nil
 		}
 
 		waiter.Lock()
 		waiter.dialogs = append(waiter.dialogs, dialog)
 		waiter.Unlock()
+		go waiter.forgetOnDone(dialog)
+		if keepalive <= 0 && waiter.idleTimeout > 0 {
+			go dialog.closeWhenIdle(waiter.idleTimeout)
+		}
 
-		log.Info(waiterLogPrefix, fmt.Sprintf("Accepted dialog from: '%s'", request.PeerID))
-		return &dialogCreateResponse{
-			Reason:        responseOK.Reason,
-			ReasonMessage: responseOK.ReasonMessage,
-			Topic:         topic,
-		}, nil
+		log.Info(waiterLogPrefix, fmt.Sprintf("Accepted dialog from: '%s' with capabilities: %v", request.PeerID, dialog.capabilities))
+		response := &dialogCreateResponse{
+			Reason:            responseOK.Reason,
+			ReasonMessage:     responseOK.ReasonMessage,
+			Topic:             topic,
+			PublicKey:         publicKey,
+			Version:           version,
+			Codec:             payloadCodec,
+			KeepaliveInterval: int64(keepalive / time.Millisecond),
+		}
+		if capabilitiesExchanged(version) {
+			response.Capabilities = capabilities.Strings()
+		}
+		return response, nil
+	}
+	waiter.Lock()
+	waiter.consumer = &dialogCreateConsumer{createDialog}
+	waiter.Unlock()
+
+	return waiter.subscribe()
+}
+
+// forgetOnDone removes dialog from the open ones once it ends, giving its place back to the limiter
+func (waiter *dialogWaiter) forgetOnDone(dialog *dialog) {
+	<-dialog.Done()
+
+	waiter.Lock()
+	for i, open := range waiter.dialogs {
+		if open == dialog {
+			waiter.dialogs = append(waiter.dialogs[:i], waiter.dialogs[i+1:]...)
+			break
+		}
+	}
+	waiter.Unlock()
+
+	waiter.limiter.Close()
+}
+
+// subscribe starts answering dialog requests, subscription is renewed only if the connection lost it
+func (waiter *dialogWaiter) subscribe() error {
+	waiter.Lock()
+	defer waiter.Unlock()
+
+	if waiter.consumer == nil {
+		return nil
+	}
+	if waiter.receiver == nil {
+		codec := NewCodecSecured(communication.NewCodecJSON(), waiter.signer, identity.NewVerifierSigned())
+		waiter.receiver = nats.NewReceiver(waiter.address.GetConnection(), codec, waiter.address.GetTopic())
 	}
-	codec := NewCodecSecured(communication.NewCodecJSON(), waiter.signer, identity.NewVerifierSigned())
-	receiver := nats.NewReceiver(waiter.address.GetConnection(), codec, waiter.address.GetTopic())
 
-	return receiver.Respond(&dialogCreateConsumer{createDialog})
+	return waiter.receiver.Respond(waiter.consumer)
 }
 
-func (waiter *dialogWaiter) newCodecForPeer(peerID identity.Identity) *codecSecured {
+// onBrokerEvent connects again once NATS client gave up reconnecting, subscriptions survive the reconnects it makes itself
+func (waiter *dialogWaiter) onBrokerEvent(event discovery.BrokerEvent) {
+	if event.Status != discovery.BrokerClosedStatus || waiter.isStopped() {
+		return
+	}
+
+	// dialogs talk through the closed connection, so they are ended for their sessions to be suspended
+	waiter.closeDialogs()
+	go waiter.reconnect()
+}
+
+// closeDialogs closes the open dialogs, they are forgotten once done
+func (waiter *dialogWaiter) closeDialogs() {
+	waiter.RLock()
+	dialogs := append([]communication.Dialog{}, waiter.dialogs...)
+	waiter.RUnlock()
+
+	for _, dialog := range dialogs {
+		dialog.Close()
+	}
+}
+
+// reconnect connects to broker again once NATS client gave up, subscribing to dialog requests through the new connection
+func (waiter *dialogWaiter) reconnect() {
+	for !waiter.isStopped() {
+		time.Sleep(waiter.reconnectWait)
+
+		if err := waiter.address.Connect(); err != nil {
+			log.Warn(waiterLogPrefix, "Failed to reconnect to: ", waiter.address.GetContact(), ". ", err)
+			continue
+		}
+		if waiter.isStopped() {
+			waiter.address.Disconnect()
+			return
+		}
+
+		waiter.Lock()
+		waiter.receiver = nil
+		waiter.Unlock()
+		if err := waiter.subscribe(); err != nil {
+			log.Error(waiterLogPrefix, "Failed to subscribe dialogs after reconnect: ", err)
+		}
+		return
+	}
+}
+
+func (waiter *dialogWaiter) isStopped() bool {
+	waiter.RLock()
+	defer waiter.RUnlock()
+
+	return waiter.stopped
+}
+
+func (waiter *dialogWaiter) newCodecForPeer(peerID identity.Identity, version string, payloadCodec communication.Codec) *codecSecured {
+	if dialogVersionNumber(version) >= dialogVersionNumber(dialogVersionSequenced) {
+		return NewCodecSecuredSequenced(
+			payloadCodec,
+			waiter.signer,
+			identity.NewVerifierIdentity(peerID),
+		)
+	}
+
 	return NewCodecSecured(
-		communication.NewCodecJSON(),
+		payloadCodec,
 		waiter.signer,
 		identity.NewVerifierIdentity(peerID),
 	)
 }
 
-func (waiter *dialogWaiter) newDialogToPeer(peerID identity.Identity, peerCodec *codecSecured, topic string) *dialog {
-	return &dialog{
-		peerID:   peerID,
-		Sender:   nats.NewSender(waiter.address.GetConnection(), peerCodec, topic),
-		Receiver: nats.NewReceiver(waiter.address.GetConnection(), peerCodec, topic),
+// newDialogCodec encrypts the dialog if peer asked to, returning the public key to agree on the encryption key with
+func (waiter *dialogWaiter) newDialogCodec(
+	peerID identity.Identity,
+	version string,
+	payloadCodec string,
+	request *dialogCreateRequest,
+) (communication.Codec, string, error) {
+	peerCodec := waiter.newCodecForPeer(peerID, version, newPayloadCodec(payloadCodec))
+	if dialogVersionNumber(version) < dialogVersionNumber(dialogVersionEncrypted) {
+		return peerCodec, "", nil
+	}
+
+	signature := identity.SignatureBase64(request.PublicKeySignature)
+	if !identity.NewVerifierIdentity(peerID).Verify([]byte(request.PublicKey), signature) {
+		return nil, "", errInvalidDialogKey
 	}
+
+	keys, err := newKeyExchange()
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := keys.SharedKey(request.PublicKey)
+	if err != nil {
+		return nil, "", errInvalidDialogKey
+	}
+
+	encryptedCodec, err := NewCodecEncrypted(peerCodec, key)
+	return encryptedCodec, keys.PublicKey(), err
+}
+
+func (waiter *dialogWaiter) newDialogToPeer(peerID identity.Identity, peerCodec communication.Codec, topic string) *dialog {
+	return newDialog(
+		peerID,
+		nil,
+		nats.NewSender(waiter.address.GetConnection(), peerCodec, topic, waiter.policies),
+		nats.NewReceiver(waiter.address.GetConnection(), peerCodec, topic),
+	)
 }
 
 func (waiter *dialogWaiter) validateDialogRequest(request *dialogCreateRequest) (bool, error) {
@@ -20,6 +20,7 @@ package dialog
 import (
 	"encoding/json"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -29,6 +30,8 @@ import (
 	"github.com/mysteriumnetwork/node/communication/nats/discovery"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/market"
+	nats_lib "github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,26 +41,23 @@ func TestDialogWaiter_Factory(t *testing.T) {
 	address := discovery.NewAddress("custom", "nats://far-server:4222")
 	signer := &identity.SignerFake{}
 
-	waiter := NewDialogWaiter(address, signer, &mockedIdentityRegistry{})
+	waiter := NewDialogWaiter(address, signer, &mockedIdentityRegistry{}, 0, nil, NewDialogLimiter(DialogLimits{}))
 	assert.NotNil(t, waiter)
 	assert.Equal(t, address, waiter.address)
 	assert.Equal(t, signer, waiter.signer)
 }
 
 func TestDialogWaiter_ServeDialogs(t *testing.T) {
-	peerID := identity.FromAddress("0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea")
-
 	connection := nats.StartConnectionFake()
 	defer connection.Close()
 
 	signer := &identity.SignerFake{}
 	waiter, handler := dialogServe(connection, signer)
 	defer waiter.Stop()
+	waiter.capabilities = communication.SupportedCapabilities
 
-	dialogAsk(connection, `{
-		"payload": {"peer_id":"0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea"},
-		"signature": "tl+WbYkJdXD5foaIP3bqVGFHfr6kdd5FzmJAmu1GdpINEnNR3bTto6wgEoke/Fpy4zsWOjrulDVfrc32f5ArTgA="
-	}`)
+	request, peerID := signedDialogRequest(dialogCreateRequest{})
+	dialogAsk(connection, request)
 	dialogInstance, err := dialogWait(handler)
 	defer dialogInstance.Close()
 	assert.NoError(t, err)
@@ -69,14 +69,16 @@ func TestDialogWaiter_ServeDialogs(t *testing.T) {
 	expectedCodec := NewCodecSecured(communication.NewCodecJSON(), signer, identity.NewVerifierIdentity(peerID))
 	assert.Equal(
 		t,
-		nats.NewSender(connection, expectedCodec, "my-topic.0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea"),
+		nats.NewSender(connection, expectedCodec, "my-topic."+peerID.Address, nil),
 		dialog.Sender,
 	)
 	assert.Equal(
 		t,
-		nats.NewReceiver(connection, expectedCodec, "my-topic.0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea"),
+		nats.NewReceiver(connection, expectedCodec, "my-topic."+peerID.Address),
 		dialog.Receiver,
 	)
+	assert.Equal(t, communication.LegacyCapabilities, communication.CapabilitiesOf(dialogInstance))
+	assert.Equal(t, communication.Capabilities{}, communication.NegotiatedCapabilitiesOf(dialogInstance))
 }
 
 func TestDialogWaiter_ServeDialogsTopicUUID(t *testing.T) {
@@ -93,7 +95,7 @@ func TestDialogWaiter_ServeDialogsTopicUUID(t *testing.T) {
 		dialogReceived: make(chan communication.Dialog),
 	}
 
-	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "my-topic"), signer, mockedRegistry)
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "my-topic"), signer, mockedRegistry, 0, nil, NewDialogLimiter(DialogLimits{}))
 
 	err := waiter.ServeDialogs(handler)
 	assert.NoError(t, err)
@@ -102,13 +104,8 @@ func TestDialogWaiter_ServeDialogsTopicUUID(t *testing.T) {
 		dialogWait(handler)
 	}()
 
-	msg, err := connection.Request("my-topic.dialog-create", []byte(`{
-		"payload": {
-			"peer_id":"0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea",
-			"version":"v1"
-		},
-		"signature": "tl+WbYkJdXD5foaIP3bqVGFHfr6kdd5FzmJAmu1GdpINEnNR3bTto6wgEoke/Fpy4zsWOjrulDVfrc32f5ArTgA="
-	}`), 100*time.Millisecond)
+	request, _ := signedDialogRequest(dialogCreateRequest{Version: "v1"})
+	msg, err := connection.Request("my-topic.dialog-create", []byte(request), 100*time.Millisecond)
 	assert.NoError(t, err)
 
 	var response struct {
@@ -122,6 +119,213 @@ func TestDialogWaiter_ServeDialogsTopicUUID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDialogWaiter_ServeDialogsEncrypted(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	waiter, handler := dialogServe(connection, signer)
+	defer waiter.Stop()
+
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	responses := make(chan []byte, 1)
+	go func() {
+		msg, err := connection.Request("my-topic.dialog-create", encryptedDialogRequest(peerSigner, peerSigner, peerID, peerKeys, dialogVersionEncrypted), 100*time.Millisecond)
+		assert.NoError(t, err)
+		responses <- msg.Data
+	}()
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	err = json.Unmarshal(<-responses, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(200), response.Payload.Reason)
+
+	key, err := peerKeys.SharedKey(response.Payload.PublicKey)
+	assert.NoError(t, err)
+	expectedCodec, err := NewCodecEncrypted(
+		NewCodecSecured(communication.NewCodecJSON(), signer, identity.NewVerifierIdentity(peerID)),
+		key,
+	)
+	assert.NoError(t, err)
+
+	dialog, ok := dialogInstance.(*dialog)
+	assert.True(t, ok)
+	assert.Equal(t, nats.NewSender(connection, expectedCodec, response.Payload.Topic, nil), dialog.Sender)
+	assert.Equal(t, nats.NewReceiver(connection, expectedCodec, response.Payload.Topic), dialog.Receiver)
+}
+
+func TestDialogWaiter_ServeDialogsSequenced(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	waiter, handler := dialogServe(connection, signer)
+	defer waiter.Stop()
+
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	responses := make(chan []byte, 1)
+	go func() {
+		msg, err := connection.Request("my-topic.dialog-create", encryptedDialogRequest(peerSigner, peerSigner, peerID, peerKeys, "v7"), 100*time.Millisecond)
+		assert.NoError(t, err)
+		responses <- msg.Data
+	}()
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	err = json.Unmarshal(<-responses, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, dialogVersionLatest, response.Payload.Version)
+
+	key, err := peerKeys.SharedKey(response.Payload.PublicKey)
+	assert.NoError(t, err)
+	expectedCodec, err := NewCodecEncrypted(
+		NewCodecSecuredSequenced(communication.NewCodecJSON(), signer, identity.NewVerifierIdentity(peerID)),
+		key,
+	)
+	assert.NoError(t, err)
+
+	dialog, ok := dialogInstance.(*dialog)
+	assert.True(t, ok)
+	assert.Equal(t, nats.NewSender(connection, expectedCodec, response.Payload.Topic, nil), dialog.Sender)
+}
+
+func TestDialogWaiter_ServeDialogsBinary(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	waiter, handler := dialogServe(connection, signer)
+	defer waiter.Stop()
+
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	responses := make(chan []byte, 1)
+	go func() {
+		request := encryptedDialogRequest(peerSigner, peerSigner, peerID, peerKeys, dialogVersionSequenced, "cbor", payloadCodecBinary, payloadCodecJSON)
+		msg, err := connection.Request("my-topic.dialog-create", request, 100*time.Millisecond)
+		assert.NoError(t, err)
+		responses <- msg.Data
+	}()
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	err = json.Unmarshal(<-responses, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, payloadCodecBinary, response.Payload.Codec)
+
+	key, err := peerKeys.SharedKey(response.Payload.PublicKey)
+	assert.NoError(t, err)
+	expectedCodec, err := NewCodecEncrypted(
+		NewCodecSecuredSequenced(communication.NewCodecBinary(), signer, identity.NewVerifierIdentity(peerID)),
+		key,
+	)
+	assert.NoError(t, err)
+
+	dialog, ok := dialogInstance.(*dialog)
+	assert.True(t, ok)
+	assert.Equal(t, nats.NewSender(connection, expectedCodec, response.Payload.Topic, nil), dialog.Sender)
+}
+
+func TestDialogWaiter_ServeDialogsWithCapabilities(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	waiter, handler := dialogServe(connection, &identity.SignerFake{})
+	defer waiter.Stop()
+	waiter.capabilities = communication.SupportedCapabilities
+
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+	publicKeySignature, err := peerSigner.Sign([]byte(peerKeys.PublicKey()))
+	assert.NoError(t, err)
+	request, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateRequest{
+		PeerID:             peerID.Address,
+		Version:            dialogVersionCapable,
+		PublicKey:          peerKeys.PublicKey(),
+		PublicKeySignature: publicKeySignature.Base64(),
+		Capabilities:       []string{"payment-promises", "unknown-feature"},
+	})
+	assert.NoError(t, err)
+
+	responses := make(chan []byte, 1)
+	go func() {
+		msg, err := connection.Request("my-topic.dialog-create", request, 100*time.Millisecond)
+		assert.NoError(t, err)
+		responses <- msg.Data
+	}()
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	err = json.Unmarshal(<-responses, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, dialogVersionCapable, response.Payload.Version)
+	assert.Equal(t, []string{"payment-promises"}, response.Payload.Capabilities)
+
+	assert.Equal(
+		t,
+		communication.NewCapabilities(
+			communication.CapabilityEncryption,
+			communication.CapabilityReplayProtection,
+			communication.CapabilityPaymentPromises,
+		),
+		communication.CapabilitiesOf(dialogInstance),
+	)
+	assert.Equal(t, communication.CapabilitiesOf(dialogInstance), communication.NegotiatedCapabilitiesOf(dialogInstance))
+}
+
+func TestDialogWaiter_ServeDialogsRejectDialogKeyOfOtherIdentity(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	waiter, handler := dialogServe(connection, &identity.SignerFake{})
+	defer waiter.Stop()
+
+	peerSigner, peerID := newKeySigner()
+	otherSigner, _ := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	msg, err := connection.Request("my-topic.dialog-create", encryptedDialogRequest(peerSigner, otherSigner, peerID, peerKeys, dialogVersionEncrypted), 100*time.Millisecond)
+	assert.NoError(t, err)
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	err = json.Unmarshal(msg.Data, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, responseInvalidIdentity, response.Payload)
+
+	dialogInstance, err := dialogWait(handler)
+	assert.EqualError(t, err, "dialog not received")
+	assert.Nil(t, dialogInstance)
+}
+
 func TestDialogWaiter_ServeDialogsRejectInvalidSignature(t *testing.T) {
 	connection := nats.StartConnectionFake()
 	defer connection.Close()
@@ -153,15 +357,13 @@ func TestDialogWaiter_ServeDialogsRejectUnregisteredConsumers(t *testing.T) {
 		dialogReceived: make(chan communication.Dialog),
 	}
 
-	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), signer, mockedRegistry)
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), signer, mockedRegistry, 0, nil, NewDialogLimiter(DialogLimits{}))
 
 	err := waiter.ServeDialogs(mockeDialogHandler)
 	assert.NoError(t, err)
 
-	msg, err := connection.Request("test-topic.dialog-create", []byte(`{
-		"payload": {"peer_id":"0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea"},
-		"signature": "tl+WbYkJdXD5foaIP3bqVGFHfr6kdd5FzmJAmu1GdpINEnNR3bTto6wgEoke/Fpy4zsWOjrulDVfrc32f5ArTgA="
-	}`), 100*time.Millisecond)
+	request, _ := signedDialogRequest(dialogCreateRequest{})
+	msg, err := connection.Request("test-topic.dialog-create", []byte(request), 100*time.Millisecond)
 	assert.NoError(t, err)
 
 	assert.JSONEq(
@@ -177,6 +379,193 @@ func TestDialogWaiter_ServeDialogsRejectUnregisteredConsumers(t *testing.T) {
 	)
 }
 
+func TestDialogWaiter_ServeDialogsRejectPeerIDOfOtherSigner(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	limiter := NewDialogLimiter(DialogLimits{})
+	var rejections []DialogRejectedEvent
+	limiter.AddListener(func(event DialogRejectedEvent) {
+		rejections = append(rejections, event)
+	})
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), &identity.SignerFake{}, &mockedIdentityRegistry{anyIdentityRegistered: true}, 0, nil, limiter)
+	defer waiter.Stop()
+	handler := &dialogHandler{dialogReceived: make(chan communication.Dialog, 1)}
+	assert.NoError(t, waiter.ServeDialogs(handler))
+
+	_, otherID := newKeySigner()
+	request, signerID := signedDialogRequest(dialogCreateRequest{PeerID: otherID.Address})
+	msg, err := connection.Request("test-topic.dialog-create", []byte(request), 100*time.Millisecond)
+	assert.NoError(t, err)
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	assert.NoError(t, json.Unmarshal(msg.Data, &response))
+	assert.Equal(t, responseInvalidIdentity, response.Payload)
+	assert.Equal(t, []DialogRejectedEvent{{PeerID: signerID.Address, Reason: RejectionInvalidIdentity}}, rejections)
+	assert.Equal(t, 0, waiter.openDialogs())
+}
+
+func TestDialogWaiter_ServeDialogsLimitsPeersBySigner(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	limiter := NewDialogLimiter(DialogLimits{PeerRate: 0.001, PeerBurst: 1})
+	rejections := make(chan DialogRejectedEvent, 2)
+	limiter.AddListener(func(event DialogRejectedEvent) {
+		rejections <- event
+	})
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), &identity.SignerFake{}, &mockedIdentityRegistry{anyIdentityRegistered: true}, 0, nil, limiter)
+	defer waiter.Stop()
+	handler := &dialogHandler{dialogReceived: make(chan communication.Dialog, 1)}
+	assert.NoError(t, waiter.ServeDialogs(handler))
+
+	// every request claims another identity, still they all come from the same signer
+	signer, _ := newKeySigner()
+	codec := NewCodecSecured(communication.NewCodecJSON(), signer, &identity.VerifierFake{})
+	_, claimedID := newKeySigner()
+	request, err := codec.Pack(&dialogCreateRequest{PeerID: claimedID.Address})
+	assert.NoError(t, err)
+	assert.NoError(t, connection.Publish("test-topic.dialog-create", request))
+	select {
+	case event := <-rejections:
+		assert.Equal(t, RejectionInvalidIdentity, event.Reason)
+	case <-time.After(time.Second):
+		t.Fatal("request not rejected")
+	}
+
+	_, claimedID = newKeySigner()
+	request, err = codec.Pack(&dialogCreateRequest{PeerID: claimedID.Address})
+	assert.NoError(t, err)
+	msg, err := connection.Request("test-topic.dialog-create", request, 100*time.Millisecond)
+	assert.NoError(t, err)
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	assert.NoError(t, json.Unmarshal(msg.Data, &response))
+	assert.Equal(t, responseTooManyRequests, response.Payload)
+}
+
+func TestDialogWaiter_ServeDialogsRejectFloodingPeers(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	mockedRegistry := &mockedIdentityRegistry{
+		anyIdentityRegistered: true,
+	}
+	handler := &dialogHandler{
+		dialogReceived: make(chan communication.Dialog, 1),
+	}
+	limiter := NewDialogLimiter(DialogLimits{PeerRate: 0.001, PeerBurst: 1})
+	var rejections []DialogRejectedEvent
+	limiter.AddListener(func(event DialogRejectedEvent) {
+		rejections = append(rejections, event)
+	})
+
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), &identity.SignerFake{}, mockedRegistry, 0, nil, limiter)
+	defer waiter.Stop()
+
+	err := waiter.ServeDialogs(handler)
+	assert.NoError(t, err)
+
+	request, peerID := signedDialogRequest(dialogCreateRequest{})
+	err = connection.Publish("test-topic.dialog-create", []byte(request))
+	assert.NoError(t, err)
+	_, err = dialogWait(handler)
+	assert.NoError(t, err)
+
+	msg, err := connection.Request("test-topic.dialog-create", []byte(request), 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+			"payload":	{
+				"reason":429,
+				"reasonMessage":"Too Many Requests"
+			},
+			"signature":"c2lnbmVkeyJyZWFzb24iOjQyOSwicmVhc29uTWVzc2FnZSI6IlRvbyBNYW55IFJlcXVlc3RzIn0="
+		}`,
+		string(msg.Data),
+	)
+	assert.Equal(
+		t,
+		[]DialogRejectedEvent{{PeerID: peerID.Address, Reason: RejectionPeerRateLimited}},
+		rejections,
+	)
+}
+
+func TestDialogWaiter_ServeDialogsForgetsClosedDialogs(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	waiter, handler := dialogServe(connection, &identity.SignerFake{})
+	defer waiter.Stop()
+	waiter.limiter = NewDialogLimiter(DialogLimits{MaxDialogs: 1})
+
+	request, _ := signedDialogRequest(dialogCreateRequest{})
+	dialogAsk(connection, request)
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+	assert.False(t, waiter.limiter.Open(), "dialog should hold the only place")
+
+	dialogInstance.Close()
+	placeReleased := waiter.limiter.Open()
+	for i := 0; i < 100 && !placeReleased; i++ {
+		time.Sleep(time.Millisecond)
+		placeReleased = waiter.limiter.Open()
+	}
+	assert.True(t, placeReleased, "place of closed dialog should be given back")
+	assert.Equal(t, 0, waiter.openDialogs())
+}
+
+func TestDialogWaiter_ServeDialogsStartsKeepaliveBeforeHandling(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	waiter := &dialogWaiter{
+		address:          discovery.NewAddressWithConnection(connection, "my-topic"),
+		signer:           &identity.SignerFake{},
+		identityRegistry: &mockedIdentityRegistry{anyIdentityRegistered: true},
+		limiter:          NewDialogLimiter(DialogLimits{}),
+		keepalive:        time.Minute,
+	}
+	defer waiter.Stop()
+
+	handler := &keepaliveHandler{keepalives: make(chan time.Duration, 1)}
+	assert.NoError(t, waiter.ServeDialogs(handler))
+
+	peerSigner, peerID := newKeySigner()
+	peerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+	publicKeySignature, err := peerSigner.Sign([]byte(peerKeys.PublicKey()))
+	assert.NoError(t, err)
+	request, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateRequest{
+		PeerID:             peerID.Address,
+		Version:            dialogVersionCapable,
+		PublicKey:          peerKeys.PublicKey(),
+		PublicKeySignature: publicKeySignature.Base64(),
+		KeepaliveInterval:  int64(time.Minute / time.Millisecond),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, connection.Publish("my-topic.dialog-create", request))
+
+	select {
+	case keepalive := <-handler.keepalives:
+		assert.Equal(t, time.Minute, keepalive)
+	case <-time.After(time.Second):
+		t.Fatal("dialog not handled")
+	}
+}
+
+func (waiter *dialogWaiter) openDialogs() int {
+	waiter.RLock()
+	defer waiter.RUnlock()
+
+	return len(waiter.dialogs)
+}
+
 func dialogServe(connection nats.Connection, signer identity.Signer) (waiter *dialogWaiter, handler *dialogHandler) {
 	topic := "my-topic"
 	waiter = &dialogWaiter{
@@ -185,6 +574,7 @@ func dialogServe(connection nats.Connection, signer identity.Signer) (waiter *di
 		identityRegistry: &mockedIdentityRegistry{
 			anyIdentityRegistered: true,
 		},
+		limiter: NewDialogLimiter(DialogLimits{}),
 	}
 	handler = &dialogHandler{
 		dialogReceived: make(chan communication.Dialog),
@@ -198,6 +588,39 @@ func dialogServe(connection nats.Connection, signer identity.Signer) (waiter *di
 	return waiter, handler
 }
 
+func encryptedDialogRequest(signer, keySigner identity.Signer, peerID identity.Identity, keys *keyExchange, version string, codecs ...string) []byte {
+	publicKeySignature, err := keySigner.Sign([]byte(keys.PublicKey()))
+	if err != nil {
+		panic(err)
+	}
+
+	data, err := NewCodecSecured(communication.NewCodecJSON(), signer, &identity.VerifierFake{}).Pack(&dialogCreateRequest{
+		PeerID:             peerID.Address,
+		Version:            version,
+		PublicKey:          keys.PublicKey(),
+		PublicKeySignature: publicKeySignature.Base64(),
+		Codecs:             codecs,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// signedDialogRequest packs the request signed by a new identity, which it claims unless PeerID is given
+func signedDialogRequest(request dialogCreateRequest) (string, identity.Identity) {
+	signer, peerID := newKeySigner()
+	if request.PeerID == "" {
+		request.PeerID = peerID.Address
+	}
+
+	data, err := NewCodecSecured(communication.NewCodecJSON(), signer, &identity.VerifierFake{}).Pack(&request)
+	if err != nil {
+		panic(err)
+	}
+	return string(data), peerID
+}
+
 func dialogAsk(connection nats.Connection, payload string) {
 	err := connection.Publish("my-topic.dialog-create", []byte(payload))
 	if err != nil {
@@ -224,6 +647,16 @@ func (handler *dialogHandler) Handle(dialog communication.Dialog) error {
 	return nil
 }
 
+// keepaliveHandler reports the keepalive interval dialogs had when handed over
+type keepaliveHandler struct {
+	keepalives chan time.Duration
+}
+
+func (handler *keepaliveHandler) Handle(handled communication.Dialog) error {
+	handler.keepalives <- handled.(*dialog).keepalive
+	return nil
+}
+
 type mockedIdentityRegistry struct {
 	anyIdentityRegistered bool
 }
@@ -243,3 +676,158 @@ func (mir *mockedIdentityRegistry) SubscribeToRegistrationEvent(id identity.Iden
 
 //check that we implemented mocked registry correctly
 var _ registry.IdentityRegistry = &mockedIdentityRegistry{}
+
+func TestDialogWaiter_ResubscribesWhenConnectionCloses(t *testing.T) {
+	address := &brokerAddressFake{connections: []*brokerConnectionFake{
+		{Connection: nats.StartConnectionFake()},
+		{Connection: nats.StartConnectionFake()},
+	}}
+	waiter := NewDialogWaiter(address, &identity.SignerFake{}, &mockedIdentityRegistry{anyIdentityRegistered: true}, 0, nil, NewDialogLimiter(DialogLimits{}))
+	waiter.reconnectWait = time.Millisecond
+
+	_, err := waiter.Start()
+	assert.NoError(t, err)
+	assert.NoError(t, waiter.ServeDialogs(&dialogHandler{dialogReceived: make(chan communication.Dialog, 1)}))
+	assert.Equal(t, []string{"my-topic.dialog-create"}, address.connections[0].getSubscribed())
+
+	address.listener(discovery.BrokerEvent{Status: discovery.BrokerClosedStatus, Topic: "my-topic"})
+	for i := 0; i < 100 && len(address.connections[1].getSubscribed()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"my-topic.dialog-create"}, address.connections[1].getSubscribed())
+
+	assert.NoError(t, waiter.Stop())
+	address.listener(discovery.BrokerEvent{Status: discovery.BrokerClosedStatus, Topic: "my-topic"})
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 2, address.getConnects())
+}
+
+func TestDialogWaiter_ClosesDialogsWhenConnectionCloses(t *testing.T) {
+	address := &brokerAddressFake{connections: []*brokerConnectionFake{
+		{Connection: nats.StartConnectionFake()},
+		{Connection: nats.StartConnectionFake()},
+	}}
+	waiter := NewDialogWaiter(address, &identity.SignerFake{}, &mockedIdentityRegistry{anyIdentityRegistered: true}, 0, nil, NewDialogLimiter(DialogLimits{}))
+	waiter.reconnectWait = time.Millisecond
+	defer waiter.Stop()
+
+	_, err := waiter.Start()
+	assert.NoError(t, err)
+	handler := &dialogHandler{dialogReceived: make(chan communication.Dialog, 1)}
+	assert.NoError(t, waiter.ServeDialogs(handler))
+
+	request, _ := signedDialogRequest(dialogCreateRequest{})
+	dialogAsk(address.connections[0], request)
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+
+	address.listener(discovery.BrokerEvent{Status: discovery.BrokerClosedStatus, Topic: "my-topic"})
+	select {
+	case <-dialogInstance.Done():
+	case <-time.After(time.Second):
+		t.Fatal("dialog over closed connection is not done")
+	}
+	for i := 0; i < 100 && waiter.openDialogs() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, waiter.openDialogs())
+}
+
+func TestDialogWaiter_ForgetsIdleDialogsWithoutKeepalive(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "my-topic"), &identity.SignerFake{}, &mockedIdentityRegistry{anyIdentityRegistered: true}, 0, nil, NewDialogLimiter(DialogLimits{MaxDialogs: 1}))
+	waiter.idleTimeout = 30 * time.Millisecond
+	defer waiter.Stop()
+
+	handler := &dialogHandler{dialogReceived: make(chan communication.Dialog, 1)}
+	assert.NoError(t, waiter.ServeDialogs(handler))
+
+	request, _ := signedDialogRequest(dialogCreateRequest{})
+	dialogAsk(connection, request)
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+
+	select {
+	case <-dialogInstance.Done():
+	case <-time.After(time.Second):
+		t.Fatal("idle dialog is not done")
+	}
+	for i := 0; i < 100 && waiter.openDialogs() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, waiter.openDialogs())
+	assert.True(t, waiter.limiter.Open())
+}
+
+// brokerAddressFake hands out the next connection every time it connects
+type brokerAddressFake struct {
+	listener    discovery.BrokerListener
+	connections []*brokerConnectionFake
+
+	lock     sync.Mutex
+	connects int
+}
+
+func (address *brokerAddressFake) AddListener(listener discovery.BrokerListener) {
+	address.listener = listener
+}
+
+func (address *brokerAddressFake) Connect() error {
+	address.lock.Lock()
+	defer address.lock.Unlock()
+
+	if address.connects == len(address.connections) {
+		return errors.New("no more connections")
+	}
+	address.connects++
+	return nil
+}
+
+func (address *brokerAddressFake) Disconnect() {}
+
+func (address *brokerAddressFake) GetConnection() nats.Connection {
+	address.lock.Lock()
+	defer address.lock.Unlock()
+
+	return address.connections[address.connects-1]
+}
+
+func (address *brokerAddressFake) GetTopic() string {
+	return "my-topic"
+}
+
+func (address *brokerAddressFake) GetContact() market.Contact {
+	return market.Contact{}
+}
+
+func (address *brokerAddressFake) getConnects() int {
+	address.lock.Lock()
+	defer address.lock.Unlock()
+
+	return address.connects
+}
+
+// brokerConnectionFake remembers subjects subscribed through it
+type brokerConnectionFake struct {
+	nats.Connection
+
+	lock       sync.Mutex
+	subscribed []string
+}
+
+func (conn *brokerConnectionFake) Subscribe(subject string, handler nats_lib.MsgHandler) (nats.Subscription, error) {
+	conn.lock.Lock()
+	conn.subscribed = append(conn.subscribed, subject)
+	conn.lock.Unlock()
+
+	return conn.Connection.Subscribe(subject, handler)
+}
+
+func (conn *brokerConnectionFake) getSubscribed() []string {
+	conn.lock.Lock()
+	defer conn.lock.Unlock()
+
+	return append([]string{}, conn.subscribed...)
+}
@@ -18,25 +18,151 @@
 package dialog
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/identity"
 )
 
 // Consume is trying to establish new dialog with Provider
 const endpointDialogCreate = communication.RequestEndpoint("dialog-create")
 
+// Dialog versions negotiated by dialogCreateRequest
+const (
+	// dialogVersionSigned dialogs send signed payloads in clear
+	dialogVersionSigned = "v1"
+	// dialogVersionEncrypted dialogs also encrypt signed payloads with the key agreed on dialog creation
+	dialogVersionEncrypted = "v2"
+	// dialogVersionSequenced dialogs also number and timestamp messages to reject replayed ones
+	dialogVersionSequenced = "v3"
+	// dialogVersionCapable dialogs also exchange capabilities peers use on top of the dialog
+	dialogVersionCapable = "v4"
+)
+
+// dialogVersionLatest is the version this peer asks for and accepts at most
+const dialogVersionLatest = dialogVersionCapable
+
+// acceptDialogVersion returns the highest version both the requesting peer and this one support
+func acceptDialogVersion(requested string) string {
+	if dialogVersionNumber(requested) > dialogVersionNumber(dialogVersionLatest) {
+		return dialogVersionLatest
+	}
+	return requested
+}
+
+// dialogVersionNumber returns number of the given dialog version, unknown versions are 0
+func dialogVersionNumber(version string) int {
+	number, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || !strings.HasPrefix(version, "v") {
+		return 0
+	}
+	return number
+}
+
+// Payload codecs negotiated by dialogCreateRequest, peers not offering any use JSON
+const (
+	payloadCodecJSON   = "json"
+	payloadCodecBinary = "binary"
+)
+
+// payloadCodecs are offered to peer in the order of preference
+var payloadCodecs = []string{payloadCodecBinary, payloadCodecJSON}
+
+// acceptPayloadCodec returns the first of the offered codecs this peer supports, empty one stands for JSON
+func acceptPayloadCodec(offered []string) string {
+	for _, name := range offered {
+		for _, supported := range payloadCodecs {
+			if name == supported {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// newPayloadCodec creates codec of the negotiated name
+func newPayloadCodec(name string) communication.Codec {
+	if name == payloadCodecBinary {
+		return communication.NewCodecBinary()
+	}
+	return communication.NewCodecJSON()
+}
+
+// acceptCapabilities returns the offered capabilities peer of the agreed dialog version supports,
+// peers of versions not exchanging them are assumed to support the legacy ones
+func acceptCapabilities(version string, offered communication.Capabilities, peerCapabilities []string) communication.Capabilities {
+	if !capabilitiesExchanged(version) {
+		return offered.Intersect(communication.LegacyCapabilities)
+	}
+	return offered.Intersect(communication.ParseCapabilities(peerCapabilities))
+}
+
+// capabilitiesExchanged checks whether peers of the dialog version tell their capabilities, instead of having them assumed
+func capabilitiesExchanged(version string) bool {
+	return dialogVersionNumber(version) >= dialogVersionNumber(dialogVersionCapable)
+}
+
+// dialogCapabilities returns capabilities of the dialog itself, which peers agreed on by negotiating it
+func dialogCapabilities(version, payloadCodec string, encrypted bool, keepalive time.Duration) communication.Capabilities {
+	capabilities := make([]communication.Capability, 0)
+	if encrypted {
+		capabilities = append(capabilities, communication.CapabilityEncryption)
+	}
+	if dialogVersionNumber(version) >= dialogVersionNumber(dialogVersionSequenced) {
+		capabilities = append(capabilities, communication.CapabilityReplayProtection)
+	}
+	if payloadCodec == payloadCodecBinary {
+		capabilities = append(capabilities, communication.CapabilityBinaryCodec)
+	}
+	if keepalive > 0 {
+		capabilities = append(capabilities, communication.CapabilityKeepalive)
+	}
+	return communication.NewCapabilities(capabilities...)
+}
+
 var (
-	responseOK              = dialogCreateResponse{200, "OK", ""}
-	responseInvalidIdentity = dialogCreateResponse{400, "Invalid Identity", ""}
-	responseInternalError   = dialogCreateResponse{500, "Internal Error", ""}
+	responseOK              = dialogCreateResponse{200, "OK", "", "", "", "", 0, nil}
+	responseInvalidIdentity = dialogCreateResponse{400, "Invalid Identity", "", "", "", "", 0, nil}
+	responseTooManyRequests = dialogCreateResponse{429, "Too Many Requests", "", "", "", "", 0, nil}
+	responseInternalError   = dialogCreateResponse{500, "Internal Error", "", "", "", "", 0, nil}
+	responseTooManyDialogs  = dialogCreateResponse{503, "Too Many Dialogs", "", "", "", "", 0, nil}
 )
 
 type dialogCreateRequest struct {
 	PeerID  string `json:"peer_id"`
 	Version string `json:"version,omitempty"`
+	// PublicKey and its signature by the peer identity are sent to agree on the encryption key of the dialog
+	PublicKey          string `json:"public_key,omitempty"`
+	PublicKeySignature string `json:"public_key_signature,omitempty"`
+	// Codecs peer is able to encode dialog payloads with, in the order of preference
+	Codecs []string `json:"codecs,omitempty"`
+	// KeepaliveInterval in milliseconds peer would like to exchange heartbeats at
+	KeepaliveInterval int64 `json:"keepalive_interval,omitempty"`
+	// Capabilities peer offers to use on top of the dialog
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// signer is the identity recovered from the signature of request, which PeerID is trusted only if it matches
+	signer identity.Identity
+}
+
+func (request *dialogCreateRequest) setSigner(signer identity.Identity) {
+	request.signer = signer
 }
 
 type dialogCreateResponse struct {
 	Reason        uint   `json:"reason"`
 	ReasonMessage string `json:"reasonMessage"`
 	Topic         string `json:"topic,omitempty"`
+	// PublicKey is returned by peers which agree to encrypt the dialog, others keep it signed only
+	PublicKey string `json:"public_key,omitempty"`
+	// Version is the dialog version peer agreed to, older peers do not return it
+	Version string `json:"version,omitempty"`
+	// Codec dialog payloads are encoded with, older peers do not return it and use JSON
+	Codec string `json:"codec,omitempty"`
+	// KeepaliveInterval in milliseconds peers exchange heartbeats at, dialog has no heartbeats if it is missing
+	KeepaliveInterval int64 `json:"keepalive_interval,omitempty"`
+	// Capabilities peer agreed to use on top of the dialog, older peers do not return them
+	Capabilities []string `json:"capabilities,omitempty"`
 }
@@ -20,7 +20,9 @@ package dialog
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/mysteriumnetwork/node/communication"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -85,3 +87,52 @@ func TestRequestUnserialize(t *testing.T) {
 		assert.Exactly(t, test.expectedError, err)
 	}
 }
+
+func TestDialogVersions(t *testing.T) {
+	assert.Equal(t, 0, dialogVersionNumber(""))
+	assert.Equal(t, 0, dialogVersionNumber("3"))
+	assert.Equal(t, 0, dialogVersionNumber("vx"))
+	assert.Equal(t, 1, dialogVersionNumber(dialogVersionSigned))
+	assert.Equal(t, 3, dialogVersionNumber(dialogVersionSequenced))
+
+	assert.Equal(t, "", acceptDialogVersion(""))
+	assert.Equal(t, dialogVersionEncrypted, acceptDialogVersion(dialogVersionEncrypted))
+	assert.Equal(t, dialogVersionLatest, acceptDialogVersion("v9"))
+}
+
+func TestPayloadCodecs(t *testing.T) {
+	assert.Equal(t, "", acceptPayloadCodec(nil))
+	assert.Equal(t, "", acceptPayloadCodec([]string{"cbor"}))
+	assert.Equal(t, payloadCodecJSON, acceptPayloadCodec([]string{"cbor", payloadCodecJSON, payloadCodecBinary}))
+	assert.Equal(t, payloadCodecBinary, acceptPayloadCodec(payloadCodecs))
+
+	assert.Equal(t, communication.NewCodecJSON(), newPayloadCodec(""))
+	assert.Equal(t, communication.NewCodecJSON(), newPayloadCodec(payloadCodecJSON))
+	assert.Equal(t, communication.NewCodecBinary(), newPayloadCodec(payloadCodecBinary))
+}
+
+func TestAcceptCapabilities(t *testing.T) {
+	offered := communication.NewCapabilities(communication.CapabilitySessionResume, communication.CapabilityPaymentPromises)
+
+	assert.Equal(
+		t,
+		communication.Capabilities{communication.CapabilitySessionResume},
+		acceptCapabilities(dialogVersionCapable, offered, []string{"session-resume"}),
+	)
+	assert.Equal(t, communication.Capabilities{}, acceptCapabilities(dialogVersionCapable, offered, nil))
+	assert.Equal(t, communication.LegacyCapabilities, acceptCapabilities(dialogVersionSequenced, offered, nil))
+}
+
+func TestDialogCapabilities(t *testing.T) {
+	assert.Equal(t, communication.Capabilities{}, dialogCapabilities(dialogVersionSigned, "", false, 0))
+	assert.Equal(
+		t,
+		communication.NewCapabilities(
+			communication.CapabilityEncryption,
+			communication.CapabilityReplayProtection,
+			communication.CapabilityBinaryCodec,
+			communication.CapabilityKeepalive,
+		),
+		dialogCapabilities(dialogVersionCapable, payloadCodecBinary, true, time.Minute),
+	)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication"
+)
+
+// DefaultKeepaliveInterval is how often dialog peers tell each other they are alive
+const DefaultKeepaliveInterval = 30 * time.Second
+
+// keepaliveMissed is how many heartbeats peer may miss before it is considered gone
+const keepaliveMissed = 3
+
+// maxKeepaliveInterval caps the interval peer asks for, so that vanished peers are noticed in a reasonable time
+const maxKeepaliveInterval = 5 * time.Minute
+
+// signalEndpoints are where one side of the dialog sends its heartbeats and close message,
+// so that peers subscribed to the same dialog topic do not receive their own signals
+type signalEndpoints struct {
+	heartbeat communication.MessageEndpoint
+	close     communication.MessageEndpoint
+}
+
+var (
+	signalsOfEstablisher = signalEndpoints{
+		heartbeat: communication.MessageEndpoint("dialog-heartbeat-establisher"),
+		close:     communication.MessageEndpoint("dialog-close-establisher"),
+	}
+	signalsOfWaiter = signalEndpoints{
+		heartbeat: communication.MessageEndpoint("dialog-heartbeat-waiter"),
+		close:     communication.MessageEndpoint("dialog-close-waiter"),
+	}
+)
+
+// acceptKeepaliveInterval returns the interval both peers send heartbeats at, 0 if either of them does not support it
+func acceptKeepaliveInterval(requested, own time.Duration) time.Duration {
+	if requested <= 0 || own <= 0 {
+		return 0
+	}
+	if requested > maxKeepaliveInterval {
+		requested = maxKeepaliveInterval
+	}
+	if requested > own {
+		return requested
+	}
+	return own
+}
+
+// dialogSignal is the empty message of heartbeat and close endpoints
+type dialogSignal struct{}
+
+type signalProducer struct {
+	endpoint communication.MessageEndpoint
+}
+
+// GetMessageEndpoint returns endpoint where to send messages
+func (producer *signalProducer) GetMessageEndpoint() communication.MessageEndpoint {
+	return producer.endpoint
+}
+
+// Produce creates message which will be serialized to endpoint
+func (producer *signalProducer) Produce() (messagePtr interface{}) {
+	return &dialogSignal{}
+}
+
+type signalConsumer struct {
+	endpoint communication.MessageEndpoint
+	handle   func()
+}
+
+// GetMessageEndpoint returns endpoint where to receive messages
+func (consumer *signalConsumer) GetMessageEndpoint() communication.MessageEndpoint {
+	return consumer.endpoint
+}
+
+// NewMessage creates struct where message from endpoint will be serialized
+func (consumer *signalConsumer) NewMessage() (messagePtr interface{}) {
+	return &dialogSignal{}
+}
+
+// Consume handles messages from endpoint
+func (consumer *signalConsumer) Consume(messagePtr interface{}) error {
+	consumer.handle()
+	return nil
+}
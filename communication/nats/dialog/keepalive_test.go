@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptKeepaliveInterval(t *testing.T) {
+	assert.Equal(t, time.Duration(0), acceptKeepaliveInterval(0, time.Minute))
+	assert.Equal(t, time.Duration(0), acceptKeepaliveInterval(time.Minute, 0))
+	assert.Equal(t, 2*time.Minute, acceptKeepaliveInterval(2*time.Minute, time.Minute))
+	assert.Equal(t, 2*time.Minute, acceptKeepaliveInterval(time.Minute, 2*time.Minute))
+	assert.Equal(t, maxKeepaliveInterval, acceptKeepaliveInterval(time.Hour, time.Minute))
+	assert.Equal(t, time.Hour, acceptKeepaliveInterval(time.Minute, time.Hour))
+}
+
+func TestDialog_HeartbeatsKeepDialogOpen(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	establisherDialog, waiterDialog := newDialogPair(connection)
+	assert.NoError(t, establisherDialog.startKeepalive(10*time.Millisecond, signalsOfEstablisher, signalsOfWaiter))
+	assert.NoError(t, waiterDialog.startKeepalive(10*time.Millisecond, signalsOfWaiter, signalsOfEstablisher))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, establisherDialog.isDone())
+	assert.False(t, waiterDialog.isDone())
+
+	establisherDialog.Close()
+	waiterDialog.Close()
+}
+
+func TestDialog_EndsWhenPeerStopsHeartbeats(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	establisherDialog, _ := newDialogPair(connection)
+	assert.NoError(t, establisherDialog.startKeepalive(10*time.Millisecond, signalsOfEstablisher, signalsOfWaiter))
+
+	select {
+	case <-establisherDialog.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Dialog did not end after peer stopped sending heartbeats")
+	}
+}
+
+func TestDialog_CloseEndsPeerDialog(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	establisherDialog, waiterDialog := newDialogPair(connection)
+	assert.NoError(t, establisherDialog.startKeepalive(time.Minute, signalsOfEstablisher, signalsOfWaiter))
+	assert.NoError(t, waiterDialog.startKeepalive(time.Minute, signalsOfWaiter, signalsOfEstablisher))
+
+	assert.NoError(t, establisherDialog.Close())
+	assert.True(t, establisherDialog.isDone())
+
+	select {
+	case <-waiterDialog.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Dialog did not end after peer closed it")
+	}
+}
+
+func TestDialog_WithoutKeepaliveEndsWhenClosed(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	establisherDialog, _ := newDialogPair(connection)
+	assert.NoError(t, establisherDialog.startKeepalive(0, signalsOfEstablisher, signalsOfWaiter))
+
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, establisherDialog.isDone())
+
+	assert.NoError(t, establisherDialog.Close())
+	assert.True(t, establisherDialog.isDone())
+}
+
+func TestDialogWaiter_ServeDialogsKeepalive(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	signer := &identity.SignerFake{}
+	waiter, handler := dialogServe(connection, signer)
+	waiter.keepalive = time.Minute
+	defer waiter.Stop()
+
+	peerSigner, peerID := newKeySigner()
+	responses := make(chan []byte, 1)
+	go func() {
+		request, err := NewCodecSecured(communication.NewCodecJSON(), peerSigner, &identity.VerifierFake{}).Pack(&dialogCreateRequest{
+			PeerID:            peerID.Address,
+			Version:           dialogVersionSigned,
+			KeepaliveInterval: int64(2 * time.Minute / time.Millisecond),
+		})
+		assert.NoError(t, err)
+
+		msg, err := connection.Request("my-topic.dialog-create", request, 100*time.Millisecond)
+		assert.NoError(t, err)
+		responses <- msg.Data
+	}()
+	dialogInstance, err := dialogWait(handler)
+	assert.NoError(t, err)
+	defer dialogInstance.Close()
+
+	var response struct {
+		Payload dialogCreateResponse `json:"payload"`
+	}
+	err = json.Unmarshal(<-responses, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2*time.Minute/time.Millisecond), response.Payload.KeepaliveInterval)
+}
+
+func TestDialog_CloseWhenIdle(t *testing.T) {
+	connection := nats.StartConnectionFake()
+	defer connection.Close()
+
+	establisherDialog, waiterDialog := newDialogPair(connection)
+	assert.NoError(t, waiterDialog.Receive(&signalConsumer{endpoint: "test-message", handle: func() {}}))
+	go waiterDialog.closeWhenIdle(30 * time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, establisherDialog.Send(&signalProducer{endpoint: "test-message"}))
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, waiterDialog.isDone())
+
+	select {
+	case <-waiterDialog.Done():
+	case <-time.After(time.Second):
+		t.Fatal("idle dialog is not done")
+	}
+}
+
+func newDialogPair(connection nats.Connection) (establisherDialog, waiterDialog *dialog) {
+	codec := communication.NewCodecJSON()
+	topic := "dialog-topic"
+
+	establisherDialog = newDialog(
+		identity.FromAddress("waiter"),
+		nil,
+		nats.NewSender(connection, codec, topic, nil),
+		nats.NewReceiver(connection, codec, topic),
+	)
+	waiterDialog = newDialog(
+		identity.FromAddress("establisher"),
+		nil,
+		nats.NewSender(connection, codec, topic, nil),
+		nats.NewReceiver(connection, codec, topic),
+	)
+	return establisherDialog, waiterDialog
+}
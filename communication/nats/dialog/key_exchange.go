@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const keyDerivationLabel = "mysterium-dialog-v2"
+
+// keyExchange agrees on the dialog encryption key with ECDH over secp256k1.
+// Identity keys never leave the keystore, so each dialog uses an ephemeral key pair,
+// which is authenticated by the signature of the peer identity instead.
+type keyExchange struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+func newKeyExchange() (*keyExchange, error) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate dialog key")
+	}
+
+	return &keyExchange{privateKey: privateKey}, nil
+}
+
+// PublicKey returns hex encoded compressed public key to send to the peer
+func (exchange *keyExchange) PublicKey() string {
+	return hex.EncodeToString(crypto.CompressPubkey(&exchange.privateKey.PublicKey))
+}
+
+// SharedKey derives the symmetric key from the secret shared with the peer of given public key
+func (exchange *keyExchange) SharedKey(peerPublicKey string) ([]byte, error) {
+	peerKey, err := parsePublicKey(peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, _ := crypto.S256().ScalarMult(peerKey.X, peerKey.Y, exchange.privateKey.D.Bytes())
+	key := sha256.Sum256(append([]byte(keyDerivationLabel), math.PaddedBigBytes(secret, 32)...))
+	return key[:], nil
+}
+
+func parsePublicKey(publicKey string) (*ecdsa.PublicKey, error) {
+	keyBytes, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed dialog public key")
+	}
+
+	key, err := crypto.DecompressPubkey(keyBytes)
+	return key, errors.Wrap(err, "invalid dialog public key")
+}
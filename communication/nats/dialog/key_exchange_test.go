@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyExchange_PeersAgreeOnSharedKey(t *testing.T) {
+	consumerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+	providerKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	consumerKey, err := consumerKeys.SharedKey(providerKeys.PublicKey())
+	assert.NoError(t, err)
+	providerKey, err := providerKeys.SharedKey(consumerKeys.PublicKey())
+	assert.NoError(t, err)
+
+	assert.Len(t, consumerKey, 32)
+	assert.Equal(t, consumerKey, providerKey)
+
+	otherKeys, err := newKeyExchange()
+	assert.NoError(t, err)
+	otherKey, err := otherKeys.SharedKey(providerKeys.PublicKey())
+	assert.NoError(t, err)
+	assert.NotEqual(t, consumerKey, otherKey)
+}
+
+func TestKeyExchange_RejectsInvalidPeerKey(t *testing.T) {
+	keys, err := newKeyExchange()
+	assert.NoError(t, err)
+
+	_, err = keys.SharedKey("not-hex")
+	assert.Error(t, err)
+
+	_, err = keys.SharedKey("02ffff")
+	assert.Error(t, err)
+}
+
+// keySigner signs messages the same way identity keystore does, with a key known to the test
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func newKeySigner() (*keySigner, identity.Identity) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	return &keySigner{key: key}, identity.FromAddress(crypto.PubkeyToAddress(key.PublicKey).Hex())
+}
+
+func (signer *keySigner) Sign(message []byte) (identity.Signature, error) {
+	signature, err := crypto.Sign(crypto.Keccak256(message), signer.key)
+	return identity.SignatureBytes(signature), err
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"sync"
+	"time"
+)
+
+// DialogRejectedEventTopic represents the topic rejected dialog requests are published on
+const DialogRejectedEventTopic = "DialogRejected"
+
+// Reasons of rejecting dialog requests
+const (
+	// RejectionPeerRateLimited is given to peers asking for dialogs more often than the per peer limit allows
+	RejectionPeerRateLimited = "peer_rate_limited"
+	// RejectionRateLimited is given when all peers together ask for dialogs more often than the global limit allows
+	RejectionRateLimited = "rate_limited"
+	// RejectionTooManyDialogs is given when the number of open dialogs reached its cap
+	RejectionTooManyDialogs = "too_many_dialogs"
+	// RejectionInvalidIdentity is given to peers which are not registered or fail to prove their identity
+	RejectionInvalidIdentity = "invalid_identity"
+)
+
+// DialogRejectedEvent represents dialog request rejected by waiter
+type DialogRejectedEvent struct {
+	PeerID string
+	Reason string
+}
+
+// RejectionListener is notified about rejected dialog requests
+type RejectionListener func(event DialogRejectedEvent)
+
+// DialogLimits protect waiters from peers flooding them with dialog requests, zero values disable the limit
+type DialogLimits struct {
+	// PeerRate is how many dialog requests per second a single peer is allowed, PeerBurst of them at once
+	PeerRate  float64
+	PeerBurst int
+	// Rate is how many dialog requests per second all peers together are allowed, Burst of them at once
+	Rate  float64
+	Burst int
+	// MaxDialogs is how many dialogs may be open at the same time
+	MaxDialogs int
+}
+
+// DefaultDialogLimits are the limits of dialog requests providers apply unless configured otherwise
+var DefaultDialogLimits = DialogLimits{
+	PeerRate:   0.5,
+	PeerBurst:  5,
+	Rate:       20,
+	Burst:      100,
+	MaxDialogs: 1000,
+}
+
+// maxTrackedPeers is how many peers are tracked before buckets of the idle ones are forgotten
+const maxTrackedPeers = 1024
+
+// NewDialogLimiter creates limiter of dialog requests, which may be shared by several waiters
+func NewDialogLimiter(limits DialogLimits) *DialogLimiter {
+	return &DialogLimiter{
+		limits: limits,
+		global: newTokenBucket(limits.Rate, limits.Burst),
+		peers:  make(map[string]*tokenBucket),
+		now:    time.Now,
+	}
+}
+
+// DialogLimiter decides whether dialog requests are accepted and reports the rejected ones
+type DialogLimiter struct {
+	limits DialogLimits
+	now    func() time.Time
+
+	lock      sync.Mutex
+	global    *tokenBucket
+	peers     map[string]*tokenBucket
+	open      int
+	listeners []RejectionListener
+}
+
+// AddListener registers listener to be notified about rejected dialog requests
+func (limiter *DialogLimiter) AddListener(listener RejectionListener) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	limiter.listeners = append(limiter.listeners, listener)
+}
+
+// Allow checks whether peer is allowed to ask for a dialog now, returning the reason of rejection otherwise
+func (limiter *DialogLimiter) Allow(peerID string) (string, bool) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	now := limiter.now()
+	if limiter.limits.PeerRate > 0 && limiter.limits.PeerBurst > 0 {
+		bucket, exists := limiter.peers[peerID]
+		if !exists {
+			limiter.forgetIdlePeers(now)
+			bucket = newTokenBucket(limiter.limits.PeerRate, limiter.limits.PeerBurst)
+			limiter.peers[peerID] = bucket
+		}
+		if !bucket.take(now) {
+			return RejectionPeerRateLimited, false
+		}
+	}
+	if limiter.limits.Rate > 0 && limiter.limits.Burst > 0 && !limiter.global.take(now) {
+		return RejectionRateLimited, false
+	}
+	return "", true
+}
+
+// Open reserves place for a new dialog, which has to be given back by Close once the dialog ends
+func (limiter *DialogLimiter) Open() bool {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	if limiter.limits.MaxDialogs > 0 && limiter.open >= limiter.limits.MaxDialogs {
+		return false
+	}
+	limiter.open++
+	return true
+}
+
+// Close gives back place of the ended dialog
+func (limiter *DialogLimiter) Close() {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	if limiter.open > 0 {
+		limiter.open--
+	}
+}
+
+// Reject notifies listeners about dialog request of peer being rejected
+func (limiter *DialogLimiter) Reject(peerID, reason string) {
+	limiter.lock.Lock()
+	listeners := limiter.listeners
+	limiter.lock.Unlock()
+
+	event := DialogRejectedEvent{PeerID: peerID, Reason: reason}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// forgetIdlePeers drops buckets which refilled completely, peers of them are treated the same as new ones
+func (limiter *DialogLimiter) forgetIdlePeers(now time.Time) {
+	if len(limiter.peers) < maxTrackedPeers {
+		return
+	}
+	for peerID, bucket := range limiter.peers {
+		if bucket.full(now) {
+			delete(limiter.peers, peerID)
+		}
+	}
+}
+
+// tokenBucket allows burst of actions at once, refilling at the rate of actions per second
+type tokenBucket struct {
+	rate     float64
+	burst    float64
+	tokens   float64
+	refilled time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (bucket *tokenBucket) take(now time.Time) bool {
+	bucket.refill(now)
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+func (bucket *tokenBucket) full(now time.Time) bool {
+	bucket.refill(now)
+	return bucket.tokens >= bucket.burst
+}
+
+func (bucket *tokenBucket) refill(now time.Time) {
+	if !bucket.refilled.IsZero() {
+		bucket.tokens += now.Sub(bucket.refilled).Seconds() * bucket.rate
+		if bucket.tokens > bucket.burst {
+			bucket.tokens = bucket.burst
+		}
+	}
+	bucket.refilled = now
+}
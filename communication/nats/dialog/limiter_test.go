@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialogLimiter_LimitsRequestsOfPeer(t *testing.T) {
+	now := time.Now()
+	limiter := NewDialogLimiter(DialogLimits{PeerRate: 1, PeerBurst: 2})
+	limiter.now = func() time.Time { return now }
+
+	assertAllowed(t, limiter, "peer-1")
+	assertAllowed(t, limiter, "peer-1")
+	assertRejected(t, limiter, "peer-1", RejectionPeerRateLimited)
+	assertAllowed(t, limiter, "peer-2")
+
+	now = now.Add(time.Second)
+	assertAllowed(t, limiter, "peer-1")
+	assertRejected(t, limiter, "peer-1", RejectionPeerRateLimited)
+}
+
+func TestDialogLimiter_LimitsRequestsOfAllPeers(t *testing.T) {
+	now := time.Now()
+	limiter := NewDialogLimiter(DialogLimits{PeerRate: 1, PeerBurst: 1, Rate: 1, Burst: 2})
+	limiter.now = func() time.Time { return now }
+
+	assertAllowed(t, limiter, "peer-1")
+	assertAllowed(t, limiter, "peer-2")
+	assertRejected(t, limiter, "peer-3", RejectionRateLimited)
+
+	now = now.Add(time.Second)
+	assertAllowed(t, limiter, "peer-4")
+}
+
+func TestDialogLimiter_ForgetsIdlePeers(t *testing.T) {
+	now := time.Now()
+	limiter := NewDialogLimiter(DialogLimits{PeerRate: 1, PeerBurst: 1})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < maxTrackedPeers; i++ {
+		assertAllowed(t, limiter, fmt.Sprintf("peer-%d", i))
+	}
+	assert.Len(t, limiter.peers, maxTrackedPeers)
+
+	now = now.Add(time.Second)
+	assertAllowed(t, limiter, "peer-new")
+	assert.Len(t, limiter.peers, 1)
+}
+
+func TestDialogLimiter_CapsOpenDialogs(t *testing.T) {
+	limiter := NewDialogLimiter(DialogLimits{MaxDialogs: 2})
+
+	assert.True(t, limiter.Open())
+	assert.True(t, limiter.Open())
+	assert.False(t, limiter.Open())
+
+	limiter.Close()
+	assert.True(t, limiter.Open())
+}
+
+func TestDialogLimiter_ZeroLimitsAllowEverything(t *testing.T) {
+	limiter := NewDialogLimiter(DialogLimits{})
+
+	for i := 0; i < 100; i++ {
+		assertAllowed(t, limiter, "peer-1")
+		assert.True(t, limiter.Open())
+	}
+}
+
+func TestDialogLimiter_NotifiesAboutRejections(t *testing.T) {
+	limiter := NewDialogLimiter(DialogLimits{})
+	var events []DialogRejectedEvent
+	limiter.AddListener(func(event DialogRejectedEvent) {
+		events = append(events, event)
+	})
+
+	limiter.Reject("peer-1", RejectionTooManyDialogs)
+	assert.Equal(t, []DialogRejectedEvent{{PeerID: "peer-1", Reason: RejectionTooManyDialogs}}, events)
+}
+
+func assertAllowed(t *testing.T, limiter *DialogLimiter, peerID string) {
+	reason, allowed := limiter.Allow(peerID)
+	assert.True(t, allowed, "peer %s rejected: %s", peerID, reason)
+}
+
+func assertRejected(t *testing.T, limiter *DialogLimiter, peerID, expectedReason string) {
+	reason, allowed := limiter.Allow(peerID)
+	assert.False(t, allowed, "peer %s allowed", peerID)
+	assert.Equal(t, expectedReason, reason)
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"fmt"
+
+	"github.com/mysteriumnetwork/node/communication/direct"
+	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/communication/nats/discovery"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// PeerAddress defines how dialogs reach the topic of a peer, either through NATS broker or by a direct link
+type PeerAddress interface {
+	Connect() error
+	Disconnect()
+	GetConnection() nats.Connection
+	GetTopic() string
+	GetContact() market.Contact
+}
+
+// newPeerAddress connects to the peer by the transport of given contact
+func newPeerAddress(contact market.Contact) (PeerAddress, error) {
+	var address PeerAddress
+	var err error
+
+	switch contact.Type {
+	case discovery.TypeContactNATSV1:
+		address, err = discovery.NewAddressForContact(contact)
+	case direct.TypeContactDirectV1:
+		address, err = direct.NewAddressForContact(contact)
+	default:
+		return nil, fmt.Errorf("invalid contact type: %s", contact.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return address, address.Connect()
+}
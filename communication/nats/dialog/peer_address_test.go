@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/communication/direct"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPeerAddress_UnknownType(t *testing.T) {
+	address, err := newPeerAddress(market.Contact{Type: "natc/v1"})
+
+	assert.EqualError(t, err, "invalid contact type: natc/v1")
+	assert.Nil(t, address)
+}
+
+func TestNewPeerAddress_DirectUnreachable(t *testing.T) {
+	_, err := newPeerAddress(market.Contact{
+		Type:       direct.TypeContactDirectV1,
+		Definition: direct.ContactDirectV1{Topic: "provider1.noop", Address: "127.0.0.1:1"},
+	})
+
+	assert.Error(t, err)
+}
+
+func TestDialog_CloseDisconnectsPeerAddress(t *testing.T) {
+	address := &peerAddressFake{}
+	dialog := &dialog{peerAddress: address}
+
+	assert.NoError(t, dialog.Close())
+	assert.True(t, address.disconnected)
+}
+
+type peerAddressFake struct {
+	PeerAddress
+	disconnected bool
+}
+
+func (address *peerAddressFake) Disconnect() {
+	address.disconnected = true
+}
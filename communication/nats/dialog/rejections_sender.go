@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+const rejectionsSenderLogPrefix = "[NATS.DialogRejections] "
+
+// DefaultRejectionsInterval is how often rejected dialog requests are reported at most
+const DefaultRejectionsInterval = time.Minute
+
+type metricsSender interface {
+	SendDialogRejectionsEvent(rejections map[string]int) error
+}
+
+// NewRejectionsSender returns sender reporting rejected dialog requests to metrics server
+func NewRejectionsSender(metricsSender metricsSender, interval time.Duration) *RejectionsSender {
+	return &RejectionsSender{
+		metricsSender: metricsSender,
+		interval:      interval,
+		rejections:    make(map[string]int),
+		now:           time.Now,
+		afterFunc: func(wait time.Duration, send func()) {
+			time.AfterFunc(wait, send)
+		},
+	}
+}
+
+// RejectionsSender counts rejected dialog requests by reason, sending the counts at most once per interval,
+// so that flooding peers do not make node flood metrics server too
+type RejectionsSender struct {
+	metricsSender metricsSender
+	interval      time.Duration
+	now           func() time.Time
+	afterFunc     func(wait time.Duration, send func())
+
+	lock       sync.Mutex
+	rejections map[string]int
+	lastSent   time.Time
+	// sendScheduled tells that counts rejected within the interval will be sent once it passes
+	sendScheduled bool
+}
+
+// ConsumeRejection counts rejected dialog request, sending the counts if interval passed since the last report.
+// Otherwise they are sent once it passes, so that the last ones are reported even if no more requests are rejected
+func (sender *RejectionsSender) ConsumeRejection(event DialogRejectedEvent) {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	sender.rejections[event.Reason]++
+
+	wait := sender.interval - sender.now().Sub(sender.lastSent)
+	if wait > 0 {
+		if !sender.sendScheduled {
+			sender.sendScheduled = true
+			sender.afterFunc(wait, sender.sendScheduledRejections)
+		}
+		return
+	}
+	sender.send()
+}
+
+func (sender *RejectionsSender) sendScheduledRejections() {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	sender.sendScheduled = false
+	if len(sender.rejections) > 0 {
+		sender.send()
+	}
+}
+
+// send reports the counts, it is called holding the lock
+func (sender *RejectionsSender) send() {
+	rejections := sender.rejections
+	sender.rejections = make(map[string]int)
+	sender.lastSent = sender.now()
+
+	go func() {
+		if err := sender.metricsSender.SendDialogRejectionsEvent(rejections); err != nil {
+			log.Warn(rejectionsSenderLogPrefix, "Failed to send dialog rejections: ", err)
+		}
+	}()
+}
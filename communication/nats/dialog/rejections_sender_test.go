@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectionsSender_SendsCountsOncePerInterval(t *testing.T) {
+	metrics := &mockMetricsSender{sent: make(chan map[string]int, 10)}
+	now := time.Now()
+	sender := NewRejectionsSender(metrics, time.Minute)
+	sender.now = func() time.Time { return now }
+	sender.afterFunc = func(wait time.Duration, send func()) {}
+
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-1", Reason: RejectionRateLimited})
+	assert.Equal(t, map[string]int{RejectionRateLimited: 1}, metrics.wait(t))
+
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-1", Reason: RejectionRateLimited})
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-2", Reason: RejectionTooManyDialogs})
+	assert.Len(t, metrics.sent, 0)
+
+	now = now.Add(time.Minute)
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-3", Reason: RejectionRateLimited})
+	assert.Equal(
+		t,
+		map[string]int{RejectionRateLimited: 2, RejectionTooManyDialogs: 1},
+		metrics.wait(t),
+	)
+}
+
+func TestRejectionsSender_SendsLastCountsOnceIntervalPasses(t *testing.T) {
+	metrics := &mockMetricsSender{sent: make(chan map[string]int, 10)}
+	now := time.Now()
+	sender := NewRejectionsSender(metrics, time.Minute)
+	sender.now = func() time.Time { return now }
+	var scheduled []func()
+	var waits []time.Duration
+	sender.afterFunc = func(wait time.Duration, send func()) {
+		waits = append(waits, wait)
+		scheduled = append(scheduled, send)
+	}
+
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-1", Reason: RejectionRateLimited})
+	assert.Equal(t, map[string]int{RejectionRateLimited: 1}, metrics.wait(t))
+
+	now = now.Add(20 * time.Second)
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-1", Reason: RejectionRateLimited})
+	sender.ConsumeRejection(DialogRejectedEvent{PeerID: "peer-2", Reason: RejectionTooManyDialogs})
+	assert.Equal(t, []time.Duration{40 * time.Second}, waits)
+	assert.Len(t, metrics.sent, 0)
+
+	now = now.Add(40 * time.Second)
+	scheduled[0]()
+	assert.Equal(
+		t,
+		map[string]int{RejectionRateLimited: 1, RejectionTooManyDialogs: 1},
+		metrics.wait(t),
+	)
+}
+
+type mockMetricsSender struct {
+	sent chan map[string]int
+}
+
+func (sender *mockMetricsSender) SendDialogRejectionsEvent(rejections map[string]int) error {
+	sender.sent <- rejections
+	return nil
+}
+
+func (sender *mockMetricsSender) wait(t *testing.T) map[string]int {
+	select {
+	case rejections := <-sender.sent:
+		return rejections
+	case <-time.After(time.Second):
+		t.Fatal("rejections not sent")
+		return nil
+	}
+}
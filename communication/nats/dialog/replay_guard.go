@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// replayWindowSize is how many recent sequence numbers are remembered to detect duplicates,
+	// messages of different endpoints may arrive out of order within it
+	replayWindowSize = 128
+	// replayWindowTime is how far message timestamp may be from the local clock, covering clock skew between peers
+	replayWindowTime = 2 * time.Minute
+)
+
+var (
+	errMessageStale    = errors.New("stale message")
+	errMessageReplayed = errors.New("replayed message")
+)
+
+// replayGuard rejects messages of the dialog direction which were already received or are too old
+type replayGuard struct {
+	windowSize uint64
+	windowTime time.Duration
+
+	lock    sync.Mutex
+	highest uint64
+	seen    map[uint64]struct{}
+}
+
+func newReplayGuard() *replayGuard {
+	return &replayGuard{
+		windowSize: replayWindowSize,
+		windowTime: replayWindowTime,
+		seen:       make(map[uint64]struct{}),
+	}
+}
+
+// Check records the message of given sequence number and timestamp received at the given time,
+// failing if it is a stale or duplicate one
+func (guard *replayGuard) Check(sequence uint64, timestamp, now time.Time) error {
+	age := now.Sub(timestamp)
+	if age > guard.windowTime || age < -guard.windowTime {
+		return errMessageStale
+	}
+
+	guard.lock.Lock()
+	defer guard.lock.Unlock()
+
+	if sequence == 0 || sequence+guard.windowSize <= guard.highest {
+		return errMessageStale
+	}
+	if _, seen := guard.seen[sequence]; seen {
+		return errMessageReplayed
+	}
+
+	guard.seen[sequence] = struct{}{}
+	if sequence > guard.highest {
+		guard.highest = sequence
+		for seenSequence := range guard.seen {
+			if seenSequence+guard.windowSize <= guard.highest {
+				delete(guard.seen, seenSequence)
+			}
+		}
+	}
+	return nil
+}
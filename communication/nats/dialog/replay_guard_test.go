@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayGuard_AcceptsMessagesOutOfOrderWithinWindow(t *testing.T) {
+	guard := newReplayGuard()
+	now := time.Now()
+
+	assert.NoError(t, guard.Check(3, now, now))
+	assert.NoError(t, guard.Check(1, now, now))
+	assert.NoError(t, guard.Check(2, now, now))
+	assert.Equal(t, errMessageReplayed, guard.Check(2, now, now))
+	assert.Equal(t, errMessageReplayed, guard.Check(3, now, now))
+}
+
+func TestReplayGuard_RejectsMessagesBehindWindow(t *testing.T) {
+	guard := newReplayGuard()
+	now := time.Now()
+
+	assert.Equal(t, errMessageStale, guard.Check(0, now, now))
+	assert.NoError(t, guard.Check(replayWindowSize+10, now, now))
+	assert.Equal(t, errMessageStale, guard.Check(10, now, now))
+	assert.NoError(t, guard.Check(11, now, now))
+	assert.Len(t, guard.seen, 2)
+}
+
+func TestReplayGuard_RejectsMessagesOutsideTimeWindow(t *testing.T) {
+	guard := newReplayGuard()
+	now := time.Now()
+
+	assert.Equal(t, errMessageStale, guard.Check(1, now.Add(-replayWindowTime-time.Second), now))
+	assert.Equal(t, errMessageStale, guard.Check(1, now.Add(replayWindowTime+time.Second), now))
+	assert.NoError(t, guard.Check(1, now.Add(-replayWindowTime/2), now))
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/cihub/seelog"
@@ -40,8 +41,31 @@ func NewAddress(topic string, addresses ...string) *AddressNATS {
 	}
 }
 
-// NewAddressFromHostAndID generates NATS address for current node
+// NewAddressFromHostAndID generates NATS address for current node.
+// URI may list several brokers separated by comma, connection fails over between them.
 func NewAddressFromHostAndID(uri string, myID identity.Identity, serviceType string) (*AddressNATS, error) {
+	var servers []string
+	for _, server := range strings.Split(uri, ",") {
+		server = strings.TrimSpace(server)
+		if len(server) == 0 {
+			continue
+		}
+
+		serverURL, err := parseBrokerURL(server)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, serverURL)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no broker address given: %q", uri)
+	}
+
+	topic := fmt.Sprintf("%v.%v", myID.Address, serviceType)
+	return NewAddress(topic, servers...), nil
+}
+
+func parseBrokerURL(uri string) (string, error) {
 	// Add scheme first otherwise url.Parse() fails.
 	var rawurl string
 	if strings.HasPrefix(uri, "nats:") {
@@ -52,15 +76,14 @@ func NewAddressFromHostAndID(uri string, myID identity.Identity, serviceType str
 
 	url, err := url.Parse(rawurl)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	if url.Port() == "" {
 		url.Host = fmt.Sprintf("%s:%d", url.Host, BrokerPort)
 	}
 
-	topic := fmt.Sprintf("%v.%v", myID.Address, serviceType)
-	return NewAddress(topic, url.String()), nil
+	return url.String(), nil
 }
 
 // NewAddressForContact extracts NATS address from given contact structure
@@ -94,6 +117,17 @@ type AddressNATS struct {
 	topic   string
 
 	connection nats.Connection
+
+	listenersLock sync.Mutex
+	listeners     []BrokerListener
+}
+
+// AddListener registers listener of broker connection changes, it is called from NATS client goroutines
+func (address *AddressNATS) AddListener(listener BrokerListener) {
+	address.listenersLock.Lock()
+	defer address.listenersLock.Unlock()
+
+	address.listeners = append(address.listeners, listener)
 }
 
 // Connect establishes connection to broker
@@ -104,18 +138,41 @@ func (address *AddressNATS) Connect() (err error) {
 	options.ReconnectWait = BrokerReconnectWait
 	options.Timeout = BrokerTimeout
 	options.PingInterval = 10 * time.Second
-	options.DisconnectedCB = func(nc *nats_lib.Conn) { log.Warn(natsLogPrefix, "Disconnected") }
-	options.ReconnectedCB = func(nc *nats_lib.Conn) { log.Warn(natsLogPrefix, "Reconnected") }
+	options.DisconnectedCB = func(nc *nats_lib.Conn) {
+		log.Warn(natsLogPrefix, "Disconnected from: ", nc.ConnectedUrl())
+		address.notify(BrokerDisconnectedStatus, nc.ConnectedUrl())
+	}
+	options.ReconnectedCB = func(nc *nats_lib.Conn) {
+		log.Warn(natsLogPrefix, "Reconnected to: ", nc.ConnectedUrl())
+		address.notify(BrokerReconnectedStatus, nc.ConnectedUrl())
+	}
+	options.ClosedCB = func(nc *nats_lib.Conn) {
+		log.Info(natsLogPrefix, "Connection closed, topic: ", address.topic)
+		address.notify(BrokerClosedStatus, "")
+	}
 
 	conn, err := options.Connect()
 	address.connection = connection{conn}
 	if err != nil {
 		address.connection = nil
+		return
 	}
 
+	address.notify(BrokerConnectedStatus, conn.ConnectedUrl())
 	return
 }
 
+func (address *AddressNATS) notify(status, server string) {
+	address.listenersLock.Lock()
+	listeners := append([]BrokerListener{}, address.listeners...)
+	address.listenersLock.Unlock()
+
+	event := BrokerEvent{Status: status, Topic: address.topic, Server: server}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
 // Disconnect stops currently established connection
 func (address *AddressNATS) Disconnect() {
 	if address.connection != nil {
@@ -155,3 +212,11 @@ func (c connection) Check() error {
 	// will be added to queue to be sent after reconnecting.
 	return c.Flush()
 }
+
+func (c connection) Subscribe(subject string, handler nats_lib.MsgHandler) (nats.Subscription, error) {
+	subscription, err := c.Conn.Subscribe(subject, handler)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
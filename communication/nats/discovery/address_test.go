@@ -69,6 +69,40 @@ func TestNewAddressFromHostAndID(t *testing.T) {
 	}
 }
 
+func TestNewAddressFromHostAndID_SeveralBrokers(t *testing.T) {
+	address, err := NewAddressFromHostAndID("127.0.0.1, nats://example.com:4333,", identity.FromAddress("provider1"), "noop")
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		&AddressNATS{
+			servers: []string{"nats://127.0.0.1:4222", "nats://example.com:4333"},
+			topic:   "provider1.noop",
+		},
+		address,
+	)
+	assert.Equal(t, []string{"nats://127.0.0.1:4222", "nats://example.com:4333"}, address.GetContact().Definition.(ContactNATSV1).BrokerAddresses)
+}
+
+func TestNewAddressFromHostAndID_NoBrokers(t *testing.T) {
+	address, err := NewAddressFromHostAndID(" , ", identity.FromAddress("provider1"), "noop")
+
+	assert.EqualError(t, err, `no broker address given: " , "`)
+	assert.Nil(t, address)
+}
+
+func TestAddress_NotifiesListeners(t *testing.T) {
+	address := NewAddress("topic1234")
+
+	var events []BrokerEvent
+	address.AddListener(func(event BrokerEvent) {
+		events = append(events, event)
+	})
+	address.notify(BrokerDisconnectedStatus, "nats://far-server:4222")
+
+	assert.Equal(t, []BrokerEvent{{Status: BrokerDisconnectedStatus, Topic: "topic1234", Server: "nats://far-server:4222"}}, events)
+}
+
 func TestNewAddressForContact(t *testing.T) {
 	address, err := NewAddressForContact(market.Contact{
 		Type: "nats/v1",
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"sync"
+)
+
+// BrokerTracker keeps the last known broker connection status of every address
+type BrokerTracker struct {
+	lock     sync.RWMutex
+	statuses map[string]string
+}
+
+// NewBrokerTracker returns a new instance of broker connection tracker
+func NewBrokerTracker() *BrokerTracker {
+	return &BrokerTracker{statuses: make(map[string]string)}
+}
+
+// ConsumeBrokerEvent consumes a broker connection event
+func (tracker *BrokerTracker) ConsumeBrokerEvent(event BrokerEvent) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if event.Status == BrokerClosedStatus {
+		delete(tracker.statuses, event.Topic)
+		return
+	}
+	tracker.statuses[event.Topic] = event.Status
+}
+
+// Status returns BrokerDisconnectedStatus if any of the addresses lost its broker,
+// BrokerConnectedStatus if all of them are connected and empty status when none are connected at all
+func (tracker *BrokerTracker) Status() string {
+	tracker.lock.RLock()
+	defer tracker.lock.RUnlock()
+
+	if len(tracker.statuses) == 0 {
+		return ""
+	}
+	for _, status := range tracker.statuses {
+		if status == BrokerDisconnectedStatus {
+			return BrokerDisconnectedStatus
+		}
+	}
+	return BrokerConnectedStatus
+}
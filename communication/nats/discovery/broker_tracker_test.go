@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerTracker_Status(t *testing.T) {
+	tracker := NewBrokerTracker()
+	assert.Equal(t, "", tracker.Status())
+
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerConnectedStatus, Topic: "provider.openvpn"})
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerConnectedStatus, Topic: "provider.noop"})
+	assert.Equal(t, BrokerConnectedStatus, tracker.Status())
+
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerDisconnectedStatus, Topic: "provider.noop"})
+	assert.Equal(t, BrokerDisconnectedStatus, tracker.Status())
+
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerReconnectedStatus, Topic: "provider.noop"})
+	assert.Equal(t, BrokerConnectedStatus, tracker.Status())
+
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerDisconnectedStatus, Topic: "provider.noop"})
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerClosedStatus, Topic: "provider.noop"})
+	assert.Equal(t, BrokerConnectedStatus, tracker.Status())
+
+	tracker.ConsumeBrokerEvent(BrokerEvent{Status: BrokerClosedStatus, Topic: "provider.openvpn"})
+	assert.Equal(t, "", tracker.Status())
+}
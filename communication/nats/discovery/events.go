@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+// BrokerEventTopic represents the topic broker connection changes are published on
+const BrokerEventTopic = "Broker"
+
+const (
+	// BrokerConnectedStatus is sent once address connects to broker
+	BrokerConnectedStatus = "Connected"
+	// BrokerDisconnectedStatus is sent when connection to broker drops, NATS client keeps reconnecting
+	BrokerDisconnectedStatus = "Disconnected"
+	// BrokerReconnectedStatus is sent when connection to one of brokers is established again
+	BrokerReconnectedStatus = "Reconnected"
+	// BrokerClosedStatus is sent when connection is closed and will not reconnect anymore
+	BrokerClosedStatus = "Closed"
+)
+
+// BrokerEvent represents change of address connection to broker
+type BrokerEvent struct {
+	Status string
+	Topic  string
+	Server string
+}
+
+// BrokerListener is notified about changes of address connection to broker
+type BrokerListener func(event BrokerEvent)
@@ -21,7 +21,6 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,7 +77,7 @@ func TestMessageBytesReceive(t *testing.T) {
 	receiver := &receiverNATS{
 		connection: connection,
 		codec:      communication.NewCodecBytes(),
-		subs:       make(map[string]*nats.Subscription),
+		subs:       make(map[string]Subscription),
 	}
 
 	consumer := &bytesMessageConsumer{messageReceived: make(chan interface{})}
@@ -21,7 +21,6 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -93,7 +92,7 @@ func TestMessageCustomReceive(t *testing.T) {
 	receiver := &receiverNATS{
 		connection: connection,
 		codec:      communication.NewCodecJSON(),
-		subs:       make(map[string]*nats.Subscription),
+		subs:       make(map[string]Subscription),
 	}
 
 	consumer := &customMessageConsumer{messageReceived: make(chan interface{})}
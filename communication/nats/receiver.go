@@ -36,7 +36,7 @@ func NewReceiver(connection Connection, codec communication.Codec, topic string)
 		connection:   connection,
 		codec:        codec,
 		messageTopic: topic + ".",
-		subs:         make(map[string]*nats.Subscription),
+		subs:         make(map[string]Subscription),
 	}
 }
 
@@ -46,7 +46,7 @@ type receiverNATS struct {
 	messageTopic string
 
 	mu   sync.Mutex
-	subs map[string]*nats.Subscription
+	subs map[string]Subscription
 }
 
 func (receiver *receiverNATS) Receive(consumer communication.MessageConsumer) error {
@@ -21,7 +21,6 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,7 +36,7 @@ func TestReceiverNew(t *testing.T) {
 			connection:   connection,
 			codec:        codec,
 			messageTopic: "custom.",
-			subs:         make(map[string]*nats.Subscription),
+			subs:         make(map[string]Subscription),
 		},
 		NewReceiver(connection, codec, "custom"),
 	)
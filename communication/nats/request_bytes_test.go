@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,9 +48,8 @@ func TestBytesRequest(t *testing.T) {
 	defer connection.Close()
 
 	sender := &senderNATS{
-		connection:     connection,
-		codec:          communication.NewCodecBytes(),
-		timeoutRequest: 100 * time.Millisecond,
+		connection: connection,
+		codec:      communication.NewCodecBytes(),
 	}
 
 	response, err := sender.Request(&bytesRequestProducer{
@@ -87,7 +85,7 @@ func TestBytesRespond(t *testing.T) {
 	receiver := &receiverNATS{
 		connection: connection,
 		codec:      communication.NewCodecBytes(),
-		subs:       make(map[string]*nats.Subscription),
+		subs:       make(map[string]Subscription),
 	}
 
 	consumer := &bytesRequestConsumer{}
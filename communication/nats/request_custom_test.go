@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,9 +55,8 @@ func TestCustomRequest(t *testing.T) {
 	defer connection.Close()
 
 	sender := &senderNATS{
-		connection:     connection,
-		codec:          communication.NewCodecJSON(),
-		timeoutRequest: 100 * time.Millisecond,
+		connection: connection,
+		codec:      communication.NewCodecJSON(),
 	}
 
 	response, err := sender.Request(&customRequestProducer{
@@ -93,7 +91,7 @@ func TestCustomRespond(t *testing.T) {
 	receiver := &receiverNATS{
 		connection: connection,
 		codec:      communication.NewCodecJSON(),
-		subs:       make(map[string]*nats.Subscription),
+		subs:       make(map[string]Subscription),
 	}
 
 	consumer := &customRequestConsumer{}
@@ -18,11 +18,13 @@
 package nats
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
+	nats "github.com/nats-io/go-nats"
 )
 
 const senderLogPrefix = "[NATS.Sender] "
@@ -30,23 +32,32 @@ const senderLogPrefix = "[NATS.Sender] "
 // NewSender constructs new Sender's instance which works thru NATS connection.
 // Codec packs/unpacks messages to byte payloads.
 // Topic (optional) if need to send messages prefixed topic.
-func NewSender(connection Connection, codec communication.Codec, topic string) *senderNATS {
+// Policies define timeouts and retries of requests by endpoint.
+func NewSender(connection Connection, codec communication.Codec, topic string, policies communication.RequestPolicies) *senderNATS {
 	return &senderNATS{
-		connection:     connection,
-		codec:          codec,
-		timeoutRequest: 10 * time.Second,
-		messageTopic:   topic + ".",
+		connection:   connection,
+		codec:        codec,
+		messageTopic: topic + ".",
+		policies:     policies,
 	}
 }
 
 type senderNATS struct {
-	connection     Connection
-	codec          communication.Codec
-	timeoutRequest time.Duration
-	messageTopic   string
+	connection   Connection
+	codec        communication.Codec
+	messageTopic string
+	policies     communication.RequestPolicies
 }
 
 func (sender *senderNATS) Send(producer communication.MessageProducer) error {
+	return sender.SendContext(context.Background(), producer)
+}
+
+func (sender *senderNATS) SendContext(ctx context.Context, producer communication.MessageProducer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	messageTopic := sender.messageTopic + string(producer.GetMessageEndpoint())
 
 	messageData, err := sender.codec.Pack(producer.Produce())
@@ -70,6 +81,10 @@ func (sender *senderNATS) Send(producer communication.MessageProducer) error {
 }
 
 func (sender *senderNATS) Request(producer communication.RequestProducer) (responsePtr interface{}, err error) {
+	return sender.RequestContext(context.Background(), producer)
+}
+
+func (sender *senderNATS) RequestContext(ctx context.Context, producer communication.RequestProducer) (responsePtr interface{}, err error) {
 	requestTopic := sender.messageTopic + string(producer.GetRequestEndpoint())
 	responsePtr = producer.NewResponse()
 
@@ -83,8 +98,23 @@ func (sender *senderNATS) Request(producer communication.RequestProducer) (respo
 		log.Warn(senderLogPrefix, "Connection failed: ", err)
 	}
 
-	log.Debug(senderLogPrefix, fmt.Sprintf("Request '%s' sending: %s", requestTopic, requestData))
-	msg, err := sender.connection.Request(requestTopic, requestData, sender.timeoutRequest)
+	policy := sender.policies.Get(producer.GetRequestEndpoint())
+	var msg *nats.Msg
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt > 0 {
+			log.Warn(senderLogPrefix, fmt.Sprintf("Request '%s' timed out, retrying %d/%d", requestTopic, attempt, policy.Retries))
+		}
+
+		log.Debug(senderLogPrefix, fmt.Sprintf("Request '%s' sending: %s", requestTopic, requestData))
+		msg, err = sender.requestAttempt(ctx, requestTopic, requestData, policy.Timeout)
+		if err != context.DeadlineExceeded || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil && ctx.Err() != nil {
+		// cancelled by the caller, not a failure of the request itself
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		err = fmt.Errorf("failed to send request '%s'. %s", requestTopic, err)
 		return
@@ -100,3 +130,10 @@ func (sender *senderNATS) Request(producer communication.RequestProducer) (respo
 
 	return responsePtr, nil
 }
+
+func (sender *senderNATS) requestAttempt(ctx context.Context, requestTopic string, requestData []byte, timeout time.Duration) (*nats.Msg, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return sender.connection.RequestWithContext(ctx, requestTopic, requestData)
+}
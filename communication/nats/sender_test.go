@@ -18,10 +18,12 @@
 package nats
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/nats-io/go-nats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,15 +32,116 @@ var _ communication.Sender = &senderNATS{}
 func TestSenderNew(t *testing.T) {
 	connection := &connectionFake{}
 	codec := communication.NewCodecFake()
+	policies := communication.RequestPolicies{"custom-request": {Timeout: time.Minute}}
 
 	assert.Equal(
 		t,
 		&senderNATS{
-			connection:     connection,
-			codec:          codec,
-			timeoutRequest: 10 * time.Second,
-			messageTopic:   "custom.",
+			connection:   connection,
+			codec:        codec,
+			messageTopic: "custom.",
+			policies:     policies,
 		},
-		NewSender(connection, codec, "custom"),
+		NewSender(connection, codec, "custom", policies),
 	)
 }
+
+func TestSenderRequestContext_RetriesTimedOutRequests(t *testing.T) {
+	policies := communication.RequestPolicies{"retried-request": {Timeout: time.Millisecond, Retries: 2}}
+	connection := &requestConnectionFake{timeouts: 2}
+	sender := NewSender(connection, communication.NewCodecBytes(), "", policies)
+
+	response, err := sender.RequestContext(context.Background(), &retriedRequestProducer{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("RESPONSE"), *response.(*[]byte))
+	assert.Equal(t, 3, connection.attempts)
+}
+
+func TestSenderRequestContext_FailsWhenRetriesRunOut(t *testing.T) {
+	policies := communication.RequestPolicies{"retried-request": {Timeout: time.Millisecond, Retries: 1}}
+	connection := &requestConnectionFake{timeouts: 2}
+	sender := NewSender(connection, communication.NewCodecBytes(), "", policies)
+
+	_, err := sender.RequestContext(context.Background(), &retriedRequestProducer{})
+	assert.EqualError(t, err, "failed to send request '.retried-request'. context deadline exceeded")
+	assert.Equal(t, 2, connection.attempts)
+}
+
+func TestSenderRequestContext_Cancelled(t *testing.T) {
+	policies := communication.RequestPolicies{"retried-request": {Timeout: time.Minute, Retries: 2}}
+	connection := &requestConnectionFake{timeouts: 1}
+	sender := NewSender(connection, communication.NewCodecBytes(), "", policies)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := sender.RequestContext(ctx, &retriedRequestProducer{})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, connection.attempts)
+}
+
+func TestSenderRequestContext_ReturnsResponseArrivedWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	connection := &requestConnectionFake{beforeResponse: cancel}
+	sender := NewSender(connection, communication.NewCodecBytes(), "", nil)
+
+	response, err := sender.RequestContext(ctx, &retriedRequestProducer{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("RESPONSE"), *response.(*[]byte))
+}
+
+func TestSenderSendContext_Cancelled(t *testing.T) {
+	connection := StartConnectionFake()
+	defer connection.Close()
+	sender := NewSender(connection, communication.NewCodecBytes(), "", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := sender.SendContext(ctx, &bytesMessageProducer{[]byte("MESSAGE")})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []byte{}, connection.GetLastMessage())
+}
+
+type retriedRequestProducer struct{}
+
+func (producer *retriedRequestProducer) GetRequestEndpoint() communication.RequestEndpoint {
+	return communication.RequestEndpoint("retried-request")
+}
+
+func (producer *retriedRequestProducer) NewResponse() (responsePtr interface{}) {
+	var response []byte
+	return &response
+}
+
+func (producer *retriedRequestProducer) Produce() (requestPtr interface{}) {
+	return []byte("REQUEST")
+}
+
+// requestConnectionFake lets the given number of requests time out before responding
+type requestConnectionFake struct {
+	Connection
+	timeouts int
+	attempts int
+	// beforeResponse is called right before responding
+	beforeResponse func()
+}
+
+func (conn *requestConnectionFake) Check() error {
+	return nil
+}
+
+func (conn *requestConnectionFake) RequestWithContext(ctx context.Context, subject string, payload []byte) (*nats.Msg, error) {
+	conn.attempts++
+	if conn.attempts <= conn.timeouts {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if conn.beforeResponse != nil {
+		conn.beforeResponse()
+	}
+	return &nats.Msg{Subject: subject, Data: []byte("RESPONSE")}, nil
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import "time"
+
+// DefaultRequestTimeout is how long sender waits for the response of endpoints without a policy
+const DefaultRequestTimeout = 10 * time.Second
+
+// RequestPolicy defines how long sender waits for the response of endpoint and how many times it retries the request
+type RequestPolicy struct {
+	Timeout time.Duration
+	// Retries is how many times request is repeated after timing out, only idempotent requests should be retried
+	Retries int
+}
+
+// RequestPolicies holds the policies senders apply to requests by endpoint
+type RequestPolicies map[RequestEndpoint]RequestPolicy
+
+// Get returns the policy of endpoint, requests of endpoints without one time out after DefaultRequestTimeout and are not retried
+func (policies RequestPolicies) Get(endpoint RequestEndpoint) RequestPolicy {
+	policy, exists := policies[endpoint]
+	if !exists || policy.Timeout <= 0 {
+		policy.Timeout = DefaultRequestTimeout
+	}
+	return policy
+}
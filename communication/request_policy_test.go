@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package communication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestPolicies_Get(t *testing.T) {
+	endpoint := RequestEndpoint("policy-test")
+	assert.Equal(t, RequestPolicy{Timeout: DefaultRequestTimeout}, RequestPolicies(nil).Get(endpoint))
+
+	policies := RequestPolicies{endpoint: {Timeout: time.Minute, Retries: 2}}
+	assert.Equal(t, RequestPolicy{Timeout: time.Minute, Retries: 2}, policies.Get(endpoint))
+	assert.Equal(t, RequestPolicy{Timeout: DefaultRequestTimeout}, policies.Get("other-endpoint"))
+
+	policies[endpoint] = RequestPolicy{Retries: 1}
+	assert.Equal(t, RequestPolicy{Timeout: DefaultRequestTimeout, Retries: 1}, policies.Get(endpoint))
+}
@@ -27,6 +27,10 @@ import (
 type ConnectParams struct {
 	// kill switch option restricting communication only through VPN
 	DisableKillSwitch bool
+	// spending limits of the connection, non zero limits override the global ones
+	SpendingLimits SpendingLimits
+	// identity signing promises for the session, consumer pays for itself if not set
+	IssuerID identity.Identity
 }
 
 // ConnectOptions represents the params we need to ensure a successful connection
@@ -25,6 +25,8 @@ const (
 	StatisticsEventTopic = "Statistics"
 	// SessionEventTopic represents the session event
 	SessionEventTopic = "Session"
+	// SpendingEventTopic represents the spending warnings topic
+	SpendingEventTopic = "Spending"
 )
 
 // StateEvent is the struct we'll emit on a StateEvent topic event
@@ -38,6 +38,15 @@ type Connection interface {
 	GetConfig() (ConsumerConfig, error)
 }
 
+// ResumableConnection is a connection keeping consumer side secrets which are needed to resume a suspended session
+type ResumableConnection interface {
+	Connection
+	// ResumeState returns consumer side state of the connection bound to the session
+	ResumeState() interface{}
+	// Resume restores the state of previous connection, must be called before GetConfig and Start
+	Resume(state interface{}) error
+}
+
 // StateChannel is the channel we receive state change events on
 type StateChannel chan State
 
@@ -25,12 +25,12 @@ import (
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/communication/direct"
 	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
-	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
@@ -39,6 +39,9 @@ import (
 
 const managerLogPrefix = "[connection-manager] "
 
+// preferredContactTypes are tried before the rest of provider contacts, in the given order
+var preferredContactTypes = []string{direct.TypeContactDirectV1}
+
 var (
 	// ErrNoConnection error indicates that action applied to manager expects active connection (i.e. disconnect)
 	ErrNoConnection = errors.New("no connection exists")
@@ -50,8 +53,15 @@ var (
 	ErrConnectionFailed = errors.New("connection has failed")
 	// ErrUnsupportedServiceType indicates that target proposal contains unsupported service type
 	ErrUnsupportedServiceType = errors.New("unsupported service type in proposal")
+	// ErrProviderContactMissing indicates that target proposal contains no contacts to reach provider through
+	ErrProviderContactMissing = errors.New("no provider contacts in proposal")
+	// ErrIssuerLocked indicates that identity issuing the promises for consumer is not unlocked
+	ErrIssuerLocked = errors.New("issuer identity is locked")
 )
 
+// sessionResumeTimeout is the time consumer tries to resume the session after the connection drops
+const sessionResumeTimeout = session.DefaultResumeGracePeriod
+
 // Creator creates new connection by given options and uses state channel to report state changes
 type Creator func(serviceType string, stateChannel StateChannel, statisticsChannel StatisticsChannel) (Connection, error)
 
@@ -60,6 +70,8 @@ type SessionInfo struct {
 	SessionID  session.ID
 	ConsumerID identity.Identity
 	Proposal   market.ServiceProposal
+	// Capabilities consumer and provider agreed on while creating the dialog
+	Capabilities communication.Capabilities
 }
 
 // Publisher is responsible for publishing given events
@@ -67,6 +79,11 @@ type Publisher interface {
 	Publish(topic string, args ...interface{})
 }
 
+// IdentityUnlockChecker tells whether the identity is able to sign messages
+type IdentityUnlockChecker interface {
+	IsUnlocked(address string) bool
+}
+
 // PaymentIssuer handles the payments for service
 type PaymentIssuer interface {
 	Start() error
@@ -79,7 +96,8 @@ type PaymentIssuerFactory func(
 	paymentDefinition dto.PaymentPerTime,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (PaymentIssuer, error)
+	consumer, provider, issuer identity.Identity,
+	spendingGuard SpendingGuard) (PaymentIssuer, error)
 
 type connectionManager struct {
 	//these are passed on creation
@@ -88,6 +106,8 @@ type connectionManager struct {
 	newConnection        Creator
 	eventPublisher       Publisher
 	resolver             ip.Resolver
+	newSpendingGuard     SpendingGuardCreator
+	unlockChecker        IdentityUnlockChecker
 
 	//these are populated by Connect at runtime
 	ctx         context.Context
@@ -97,9 +117,23 @@ type connectionManager struct {
 	cleanup     []func() error
 	cancel      func()
 
+	// suspendSession asks provider to keep the session resumable while cleaning up the dropped connection
+	suspendSession bool
+	resumable      *resumableSession
+
 	discoLock sync.Mutex
 }
 
+// resumableSession is the suspended session which consumer may re-attach to
+type resumableSession struct {
+	consumerID identity.Identity
+	providerID string
+	proposalID int
+	sessionID  session.ID
+	state      interface{}
+	expiresAt  time.Time
+}
+
 // NewManager creates connection manager with given dependencies
 func NewManager(
 	dialogCreator DialogCreator,
@@ -107,6 +141,8 @@ func NewManager(
 	connectionCreator Creator,
 	eventPublisher Publisher,
 	resolver ip.Resolver,
+	spendingGuardCreator SpendingGuardCreator,
+	unlockChecker IdentityUnlockChecker,
 ) *connectionManager {
 	return &connectionManager{
 		newDialog:            dialogCreator,
@@ -116,6 +152,8 @@ func NewManager(
 		eventPublisher:       eventPublisher,
 		cleanup:              make([]func() error, 0),
 		resolver:             resolver,
+		newSpendingGuard:     spendingGuardCreator,
+		unlockChecker:        unlockChecker,
 	}
 }
 
@@ -134,8 +172,19 @@ func (manager *connectionManager) Connect(consumerID identity.Identity, proposal
 	}()
 
 	providerID := identity.FromAddress(proposal.ProviderID)
+	issuerID := params.IssuerID
+	if issuerID.Address == "" {
+		issuerID = consumerID
+	} else if !manager.unlockChecker.IsUnlocked(issuerID.Address) {
+		return ErrIssuerLocked
+	}
 
-	dialog, err := manager.createDialog(consumerID, providerID, proposal.ProviderContacts[0])
+	paymentDefinition, err := session.PaymentDefinition(proposal)
+	if err != nil {
+		return err
+	}
+
+	dialog, err := manager.createDialog(consumerID, providerID, proposal.ProviderContacts)
 	if err != nil {
 		return err
 	}
@@ -148,12 +197,30 @@ func (manager *connectionManager) Connect(consumerID identity.Identity, proposal
 		return err
 	}
 
-	sessionDTO, paymentInfo, err := manager.createSession(connection, dialog, consumerID, proposal)
+	resume := manager.takeResumableSession(consumerID, proposal)
+	if resume != nil && !communication.CapabilitiesOf(dialog).Has(communication.CapabilitySessionResume) {
+		log.Info(managerLogPrefix, "Provider does not resume sessions, creating new session")
+		resume = nil
+	}
+	if resume != nil && resume.state != nil {
+		resumable, ok := connection.(ResumableConnection)
+		if !ok {
+			resume = nil
+		} else if err := resumable.Resume(resume.state); err != nil {
+			log.Warn(managerLogPrefix, "Failed to restore connection state, creating new session: ", err)
+			resume = nil
+		}
+	}
+
+	sessionDTO, paymentInfo, err := manager.createSession(connection, dialog, consumerID, issuerID, proposal, resume)
+	if err == context.Canceled {
+		return ErrConnectionCancelled
+	}
 	if err != nil {
 		return err
 	}
 
-	err = manager.launchPayments(paymentInfo, dialog, consumerID, providerID)
+	err = manager.launchPayments(paymentInfo, dialog, consumerID, providerID, issuerID, paymentDefinition, params.SpendingLimits)
 	if err != nil {
 		return err
 	}
@@ -165,7 +232,7 @@ func (manager *connectionManager) Connect(consumerID identity.Identity, proposal
 	return err
 }
 
-func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInfo, dialog communication.Dialog, consumerID, providerID identity.Identity) error {
+func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInfo, dialog communication.Dialog, consumerID, providerID, issuerID identity.Identity, payment dto.PaymentPerTime, spendingLimits SpendingLimits) error {
 	var promiseState promise.PaymentInfo
 	if paymentInfo != nil {
 		promiseState.FreeCredit = paymentInfo.FreeCredit
@@ -174,16 +241,8 @@ func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInf
 
 	messageChan := make(chan balance.Message, 1)
 
-	// TODO: set the time and proper payment info
-	payment := dto.PaymentPerTime{
-		Price: money.Money{
-			Currency: money.CurrencyMyst,
-			Amount:   uint64(0),
-		},
-		Duration: time.Minute,
-	}
-
-	payments, err := manager.paymentIssuerFactory(promiseState, payment, messageChan, dialog, consumerID, providerID)
+	spendingGuard := manager.newSpendingGuard(manager.sessionInfo, spendingLimits)
+	payments, err := manager.paymentIssuerFactory(promiseState, payment, messageChan, dialog, consumerID, providerID, issuerID, spendingGuard)
 	if err != nil {
 		return err
 	}
@@ -199,7 +258,7 @@ func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInf
 
 func (manager *connectionManager) cleanConnection() {
 	manager.cancel()
-	for i := len(manager.cleanup) - 1; i > 0; i-- {
+	for i := len(manager.cleanup) - 1; i >= 0; i-- {
 		err := manager.cleanup[i]()
 		if err != nil {
 			log.Warn(managerLogPrefix, "cleanup error:", err)
@@ -208,39 +267,107 @@ func (manager *connectionManager) cleanConnection() {
 	manager.cleanup = make([]func() error, 0)
 }
 
-func (manager *connectionManager) createDialog(consumerID, providerID identity.Identity, contact market.Contact) (communication.Dialog, error) {
-	dialog, err := manager.newDialog(consumerID, providerID, contact)
-	if err != nil {
-		return nil, err
+// createDialog tries provider contacts in the advertised order, falling back to the next one when a contact is unreachable
+func (manager *connectionManager) createDialog(consumerID, providerID identity.Identity, contacts market.ContactList) (communication.Dialog, error) {
+	err := ErrProviderContactMissing
+	for _, contact := range preferContacts(contacts, preferredContactTypes) {
+		var dialog communication.Dialog
+		dialog, err = manager.newDialog(consumerID, providerID, contact)
+		if err != nil {
+			log.Warn(managerLogPrefix, "Failed to create dialog through contact ", contact.Type, ": ", err)
+			continue
+		}
+
+		manager.cleanup = append(manager.cleanup, dialog.Close)
+		return dialog, nil
+	}
+
+	return nil, err
+}
+
+// preferContacts orders contacts of preferred types first, keeping the order of the rest
+func preferContacts(contacts market.ContactList, types []string) market.ContactList {
+	ordered := make(market.ContactList, 0, len(contacts))
+	for _, contactType := range types {
+		for _, contact := range contacts {
+			if contact.Type == contactType {
+				ordered = append(ordered, contact)
+			}
+		}
+	}
+	for _, contact := range contacts {
+		if !isContactOfType(contact, types) {
+			ordered = append(ordered, contact)
+		}
 	}
+	return ordered
+}
 
-	manager.cleanup = append(manager.cleanup, dialog.Close)
-	return dialog, err
+func isContactOfType(contact market.Contact, types []string) bool {
+	for _, contactType := range types {
+		if contact.Type == contactType {
+			return true
+		}
+	}
+	return false
 }
 
-func (manager *connectionManager) createSession(c Connection, dialog communication.Dialog, consumerID identity.Identity, proposal market.ServiceProposal) (session.SessionDto, *promise.PaymentInfo, error) {
+func (manager *connectionManager) createSession(c Connection, dialog communication.Dialog, consumerID, issuerID identity.Identity, proposal market.ServiceProposal, resume *resumableSession) (session.SessionDto, *promise.PaymentInfo, error) {
 	sessionCreateConfig, err := c.GetConfig()
 	if err != nil {
 		return session.SessionDto{}, nil, err
 	}
 
 	consumerInfo := session.ConsumerInfo{
-		// TODO: once we're supporting payments from another identity make the changes accordingly
-		IssuerID: consumerID,
+		IssuerID: issuerID,
 	}
 
-	s, paymentInfo, err := session.RequestSessionCreate(dialog, proposal.ID, sessionCreateConfig, consumerInfo)
+	// provider may answer just after connecting was cancelled, such session is destroyed before the dialog closes
+	orphaned := make(chan session.ID, 1)
+	manager.cleanup = append(manager.cleanup, func() error {
+		if sessionID, ok := <-orphaned; ok {
+			return session.RequestSessionDestroy(dialog, sessionID)
+		}
+		return nil
+	})
+
+	var s session.SessionDto
+	var paymentInfo *promise.PaymentInfo
+	if resume != nil {
+		log.Info(managerLogPrefix, "Resuming session: ", resume.sessionID)
+		s, paymentInfo, err = session.RequestSessionResume(manager.ctx, dialog, resume.sessionID, proposal.ID, sessionCreateConfig, consumerInfo)
+	} else {
+		s, paymentInfo, err = session.RequestSessionCreate(manager.ctx, dialog, proposal.ID, sessionCreateConfig, consumerInfo)
+	}
+	if err == nil && manager.ctx.Err() != nil {
+		orphaned <- s.ID
+		err = manager.ctx.Err()
+	}
+	close(orphaned)
 	if err != nil {
 		return session.SessionDto{}, nil, err
 	}
 
-	manager.cleanup = append(manager.cleanup, func() error { return session.RequestSessionDestroy(dialog, s.ID) })
+	manager.cleanup = append(manager.cleanup, func() error { return manager.endSession(c, dialog, consumerID, proposal, s.ID) })
+
+	err = dialog.Receive(session.NewTerminateConsumer(func(message session.TerminateMessage) {
+		if message.SessionID != s.ID {
+			return
+		}
+		log.Warn(managerLogPrefix, "Provider terminated session ", s.ID, ", reason: ", message.Reason)
+		go logDisconnectError(manager.Disconnect())
+	}))
+	if err != nil {
+		return session.SessionDto{}, nil, err
+	}
 
 	// set the session info for future use
 	manager.sessionInfo = SessionInfo{
 		SessionID:  s.ID,
 		ConsumerID: consumerID,
 		Proposal:   proposal,
+		// assumed capabilities are left out, so that status tells only the ones provider actually agreed on
+		Capabilities: communication.NegotiatedCapabilitiesOf(dialog),
 	}
 
 	manager.eventPublisher.Publish(SessionEventTopic, SessionEvent{
@@ -256,9 +383,26 @@ func (manager *connectionManager) createSession(c Connection, dialog communicati
 		return nil
 	})
 
+	sessionEnded := make(chan struct{})
+	manager.cleanup = append(manager.cleanup, func() error {
+		close(sessionEnded)
+		return nil
+	})
+	go manager.disconnectOnDialogEnd(dialog, sessionEnded)
+
 	return s, paymentInfo, nil
 }
 
+// disconnectOnDialogEnd drops the connection once provider stops responding to the dialog, keeping the session resumable
+func (manager *connectionManager) disconnectOnDialogEnd(dialog communication.Dialog, sessionEnded <-chan struct{}) {
+	select {
+	case <-dialog.Done():
+		log.Warn(managerLogPrefix, "Dialog with provider ended, disconnecting")
+		logDisconnectError(manager.disconnect(true))
+	case <-sessionEnded:
+	}
+}
+
 func (manager *connectionManager) startConnection(
 	connection Connection,
 	consumerID identity.Identity,
@@ -323,6 +467,11 @@ func (manager *connectionManager) setStatus(cs Status) {
 }
 
 func (manager *connectionManager) Disconnect() error {
+	return manager.disconnect(false)
+}
+
+// disconnect closes the connection. Session is suspended for later resume if the connection was dropped.
+func (manager *connectionManager) disconnect(suspendSession bool) error {
 	manager.discoLock.Lock()
 	defer manager.discoLock.Unlock()
 
@@ -330,6 +479,7 @@ func (manager *connectionManager) Disconnect() error {
 		return ErrNoConnection
 	}
 
+	manager.suspendSession = suspendSession
 	manager.setStatus(statusDisconnecting())
 	manager.cleanConnection()
 	manager.setStatus(statusNotConnected())
@@ -340,7 +490,11 @@ func (manager *connectionManager) Disconnect() error {
 func (manager *connectionManager) payForService(payments PaymentIssuer) {
 	err := payments.Start()
 	if err != nil {
-		log.Error(managerLogPrefix, "payment error: ", err)
+		if err == ErrSpendingLimitReached {
+			log.Warn(managerLogPrefix, "spending limit reached, disconnecting")
+		} else {
+			log.Error(managerLogPrefix, "payment error: ", err)
+		}
 		err = manager.Disconnect()
 		if err != nil {
 			log.Error(managerLogPrefix, "could not disconnect gracefully:", err)
@@ -360,7 +514,7 @@ func (manager *connectionManager) connectionWaiter(connection Connection) {
 		log.Info(managerLogPrefix, "Connection exited")
 	}
 
-	logDisconnectError(manager.Disconnect())
+	logDisconnectError(manager.disconnect(true))
 }
 
 func (manager *connectionManager) waitForConnectedState(stateChannel <-chan State, sessionID session.ID) error {
@@ -390,7 +544,7 @@ func (manager *connectionManager) consumeConnectionStates(stateChannel <-chan St
 	}
 
 	log.Debug(managerLogPrefix, "State updater stopCalled")
-	logDisconnectError(manager.Disconnect())
+	logDisconnectError(manager.disconnect(true))
 }
 
 func (manager *connectionManager) consumeStats(statisticsChannel <-chan consumer.SessionStatistics) {
@@ -407,12 +561,48 @@ func (manager *connectionManager) onStateChanged(state State) {
 
 	switch state {
 	case Connected:
-		manager.setStatus(statusConnected(manager.sessionInfo.SessionID, manager.sessionInfo.Proposal))
+		manager.setStatus(statusConnected(manager.sessionInfo.SessionID, manager.sessionInfo.Proposal, manager.sessionInfo.Capabilities))
 	case Reconnecting:
 		manager.setStatus(statusReconnecting())
 	}
 }
 
+// endSession destroys the session or suspends it if the connection was dropped, remembering it for resume
+func (manager *connectionManager) endSession(c Connection, dialog communication.Dialog, consumerID identity.Identity, proposal market.ServiceProposal, sessionID session.ID) error {
+	if !manager.suspendSession {
+		return session.RequestSessionDestroy(dialog, sessionID)
+	}
+
+	resume := &resumableSession{
+		consumerID: consumerID,
+		providerID: proposal.ProviderID,
+		proposalID: proposal.ID,
+		sessionID:  sessionID,
+		expiresAt:  time.Now().Add(sessionResumeTimeout),
+	}
+	if resumable, ok := c.(ResumableConnection); ok {
+		resume.state = resumable.ResumeState()
+	}
+	manager.resumable = resume
+
+	log.Info(managerLogPrefix, "Connection dropped, suspending session: ", sessionID)
+	return session.RequestSessionSuspend(dialog, sessionID)
+}
+
+// takeResumableSession returns the suspended session if it can be resumed for the given proposal
+func (manager *connectionManager) takeResumableSession(consumerID identity.Identity, proposal market.ServiceProposal) *resumableSession {
+	resume := manager.resumable
+	manager.resumable = nil
+
+	if resume == nil || time.Now().After(resume.expiresAt) {
+		return nil
+	}
+	if resume.consumerID != consumerID || resume.providerID != proposal.ProviderID || resume.proposalID != proposal.ID {
+		return nil
+	}
+	return resume
+}
+
 func logDisconnectError(err error) {
 	if err != nil && err != ErrNoConnection {
 		log.Error(managerLogPrefix, "Disconnect error", err)
@@ -28,6 +28,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
@@ -42,9 +43,16 @@ type testContext struct {
 	connManager           *connectionManager
 	mockDialog            *mockDialog
 	MockPaymentIssuer     *MockPaymentIssuer
+	identityManager       *mockUnlockChecker
 	stubPublisher         *StubPublisher
 	mockStatistics        consumer.SessionStatistics
 	fakeResolver          ip.Resolver
+	spendingSessionInfo   SessionInfo
+	spendingLimits        SpendingLimits
+	sessionCreateBlocks   bool
+	sessionCreateAnswers  bool
+	dialogCapabilities    communication.Capabilities
+	assumedCapabilities   communication.Capabilities
 	sync.RWMutex
 }
 
@@ -59,21 +67,35 @@ var (
 		ServiceType:       activeServiceType,
 		ServiceDefinition: &fakeServiceDefinition{},
 	}
-	establishedSessionID = session.ID("session-100")
-	paymentInfo          *promise.PaymentInfo
+	establishedSessionID    = session.ID("session-100")
+	establishedCapabilities = communication.NewCapabilities(
+		communication.CapabilityKeepalive,
+		communication.CapabilitySessionResume,
+		communication.CapabilityPaymentPromises,
+	)
+	paymentInfo *promise.PaymentInfo
 )
 
 func (tc *testContext) SetupTest() {
 	tc.Lock()
 	defer tc.Unlock()
 
+	tc.sessionCreateBlocks = false
+	tc.sessionCreateAnswers = false
+	tc.dialogCapabilities = establishedCapabilities
+	tc.assumedCapabilities = nil
 	tc.stubPublisher = NewStubPublisher()
 	dialogCreator := func(consumer, provider identity.Identity, contact market.Contact) (communication.Dialog, error) {
 		tc.Lock()
 		defer tc.Unlock()
 		tc.mockDialog = &mockDialog{
-			sessionID:   establishedSessionID,
-			paymentInfo: paymentInfo,
+			sessionID:              establishedSessionID,
+			paymentInfo:            paymentInfo,
+			done:                   make(chan struct{}),
+			createBlocks:           tc.sessionCreateBlocks,
+			createAnswersCancelled: tc.sessionCreateAnswers,
+			capabilities:           tc.dialogCapabilities,
+			assumedCapabilities:    tc.assumedCapabilities,
 		}
 		return tc.mockDialog, nil
 	}
@@ -82,10 +104,12 @@ func (tc *testContext) SetupTest() {
 		paymentDefinition dto.PaymentPerTime,
 		messageChan chan balance.Message,
 		dialog communication.Dialog,
-		consumer, provider identity.Identity) (PaymentIssuer, error) {
+		consumer, provider, issuer identity.Identity,
+		spendingGuard SpendingGuard) (PaymentIssuer, error) {
 		tc.MockPaymentIssuer = &MockPaymentIssuer{
 			initialState:      initialState,
 			paymentDefinition: paymentDefinition,
+			issuerID:          issuer,
 			stopChan:          make(chan struct{}),
 		}
 		return tc.MockPaymentIssuer, nil
@@ -120,12 +144,21 @@ func (tc *testContext) SetupTest() {
 		},
 	}
 
+	tc.identityManager = &mockUnlockChecker{}
 	tc.connManager = NewManager(
 		dialogCreator,
 		mockPaymentFactory,
 		tc.fakeConnectionFactory.CreateConnection,
 		tc.stubPublisher,
 		ip.NewResolverMock("1.1.1.1"),
+		func(sessionInfo SessionInfo, limits SpendingLimits) SpendingGuard {
+			tc.Lock()
+			defer tc.Unlock()
+			tc.spendingSessionInfo = sessionInfo
+			tc.spendingLimits = limits
+			return nil
+		},
+		tc.identityManager,
 	)
 }
 
@@ -143,7 +176,53 @@ func (tc *testContext) TestOnConnectErrorStatusIsNotConnected() {
 func (tc *testContext) TestWhenManagerMadeConnectionStatusReturnsConnectedStateAndSessionId() {
 	err := tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
 	assert.NoError(tc.T(), err)
-	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, establishedCapabilities), tc.connManager.Status())
+}
+
+func (tc *testContext) TestStatusLeavesOutAssumedCapabilities() {
+	tc.Lock()
+	tc.dialogCapabilities = communication.LegacyCapabilities
+	tc.assumedCapabilities = communication.LegacyCapabilities
+	tc.Unlock()
+
+	err := tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, communication.Capabilities{}), tc.connManager.Status())
+}
+
+func (tc *testContext) TestConnectFallsBackToNextProviderContact() {
+	directContact := market.Contact{Type: "direct/v1"}
+	natsContact := market.Contact{Type: "nats/v1"}
+	var triedContacts []market.Contact
+	tc.connManager.newDialog = func(consumer, provider identity.Identity, contact market.Contact) (communication.Dialog, error) {
+		tc.Lock()
+		defer tc.Unlock()
+		triedContacts = append(triedContacts, contact)
+		if contact.Type == "direct/v1" {
+			return nil, errors.New("provider unreachable")
+		}
+		tc.mockDialog = &mockDialog{
+			sessionID:    establishedSessionID,
+			paymentInfo:  paymentInfo,
+			capabilities: tc.dialogCapabilities,
+		}
+		return tc.mockDialog, nil
+	}
+
+	proposal := activeProposal
+	proposal.ProviderContacts = market.ContactList{natsContact, directContact}
+	err := tc.connManager.Connect(consumerID, proposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	assert.Equal(tc.T(), []market.Contact{directContact, natsContact}, triedContacts)
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, proposal, establishedCapabilities), tc.connManager.Status())
+}
+
+func (tc *testContext) TestConnectFailsWithoutProviderContacts() {
+	proposal := activeProposal
+	proposal.ProviderContacts = market.ContactList{}
+	err := tc.connManager.Connect(consumerID, proposal, ConnectParams{})
+	assert.Equal(tc.T(), ErrProviderContactMissing, err)
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
 }
 
 func (tc *testContext) TestStatusReportsConnectingWhenConnectionIsInProgress() {
@@ -168,7 +247,7 @@ func (tc *testContext) TestStatusReportsNotConnected() {
 
 	err := tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
 	assert.NoError(tc.T(), err)
-	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, establishedCapabilities), tc.connManager.Status())
 
 	go func() {
 		assert.NoError(tc.T(), tc.connManager.Disconnect())
@@ -204,7 +283,7 @@ func (tc *testContext) TestReconnectingStatusIsReportedWhenOpenVpnGoesIntoReconn
 
 func (tc *testContext) TestDoubleDisconnectResultsInError() {
 	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
-	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, establishedCapabilities), tc.connManager.Status())
 	assert.NoError(tc.T(), tc.connManager.Disconnect())
 	waitABit()
 	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
@@ -213,13 +292,13 @@ func (tc *testContext) TestDoubleDisconnectResultsInError() {
 
 func (tc *testContext) TestTwoConnectDisconnectCyclesReturnNoError() {
 	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
-	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, establishedCapabilities), tc.connManager.Status())
 	assert.NoError(tc.T(), tc.connManager.Disconnect())
 	waitABit()
 	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
 
 	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
-	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, establishedCapabilities), tc.connManager.Status())
 	assert.NoError(tc.T(), tc.connManager.Disconnect())
 	waitABit()
 	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
@@ -233,7 +312,7 @@ func (tc *testContext) TestConnectFailsIfConnectionFactoryReturnsError() {
 
 func (tc *testContext) TestStatusIsConnectedWhenConnectCommandReturnsWithoutError() {
 	tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
-	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal, establishedCapabilities), tc.connManager.Status())
 }
 
 func (tc *testContext) TestConnectingInProgressCanBeCanceled() {
@@ -257,6 +336,52 @@ func (tc *testContext) TestConnectingInProgressCanBeCanceled() {
 	assert.Equal(tc.T(), ErrConnectionCancelled, err)
 }
 
+func (tc *testContext) TestSessionCreationInProgressCanBeCanceled() {
+	tc.Lock()
+	tc.sessionCreateBlocks = true
+	tc.Unlock()
+
+	connectWaiter := &sync.WaitGroup{}
+	connectWaiter.Add(1)
+	var err error
+	go func() {
+		defer connectWaiter.Done()
+		err = tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
+	}()
+
+	waitABit()
+	assert.Equal(tc.T(), statusConnecting(), tc.connManager.Status())
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+
+	connectWaiter.Wait()
+
+	assert.Equal(tc.T(), ErrConnectionCancelled, err)
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) TestSessionCreatedAfterCancelIsDestroyed() {
+	tc.Lock()
+	tc.sessionCreateBlocks = true
+	tc.sessionCreateAnswers = true
+	tc.Unlock()
+
+	connectWaiter := &sync.WaitGroup{}
+	connectWaiter.Add(1)
+	var err error
+	go func() {
+		defer connectWaiter.Done()
+		err = tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
+	}()
+
+	waitABit()
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	connectWaiter.Wait()
+
+	assert.Equal(tc.T(), ErrConnectionCancelled, err)
+	destroyRequest := tc.mockDialog.lastRequest("session-destroy").(*session.DestroyRequest)
+	assert.Equal(tc.T(), string(establishedSessionID), destroyRequest.SessionID)
+}
+
 func (tc *testContext) TestConnectMethodReturnsErrorIfConnectionExitsDuringConnect() {
 	tc.fakeConnectionFactory.mockConnection.onStartReportStates = []fakeState{}
 	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
@@ -373,6 +498,146 @@ func (tc *testContext) Test_ManagerPublishesEvents() {
 	}
 }
 
+func (tc *testContext) Test_ConsumerIssuesPromisesByDefault() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+
+	createRequest := tc.mockDialog.lastRequest("session-create").(*session.CreateRequest)
+	assert.Equal(tc.T(), consumerID, createRequest.ConsumerInfo.IssuerID)
+	assert.Equal(tc.T(), consumerID, tc.MockPaymentIssuer.issuerID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_ThirdPartyIssuerPaysForConsumer() {
+	issuerID := identity.FromAddress("0x0000000000000000000000000000000000000009")
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{IssuerID: issuerID}))
+
+	createRequest := tc.mockDialog.lastRequest("session-create").(*session.CreateRequest)
+	assert.Equal(tc.T(), issuerID, createRequest.ConsumerInfo.IssuerID)
+	assert.Equal(tc.T(), issuerID, tc.MockPaymentIssuer.issuerID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_LockedIssuerIsRejectedBeforeSessionIsCreated() {
+	issuerID := identity.FromAddress("0x0000000000000000000000000000000000000009")
+	tc.identityManager.locked = []string{issuerID.Address}
+	tc.mockDialog = nil
+
+	err := tc.connManager.Connect(consumerID, activeProposal, ConnectParams{IssuerID: issuerID})
+	assert.Equal(tc.T(), ErrIssuerLocked, err)
+	assert.Nil(tc.T(), tc.mockDialog)
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) Test_ProposalPricedNotByTimeIsRejected() {
+	proposal := activeProposal
+	proposal.PaymentMethod = market.UnsupportedPaymentMethod{}
+	tc.mockDialog = nil
+
+	err := tc.connManager.Connect(consumerID, proposal, ConnectParams{})
+	assert.Equal(tc.T(), session.ErrUnsupportedPaymentMethod, err)
+	assert.Nil(tc.T(), tc.mockDialog)
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) Test_DroppedConnectionSessionIsResumedOnReconnect() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	droppedDialog := tc.mockDialog
+
+	assert.NoError(tc.T(), tc.connManager.disconnect(true))
+	waitABit()
+	destroyRequest := droppedDialog.lastRequest("session-destroy").(*session.DestroyRequest)
+	assert.Equal(tc.T(), string(establishedSessionID), destroyRequest.SessionID)
+	assert.True(tc.T(), destroyRequest.Resumable)
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	createRequest := tc.mockDialog.lastRequest("session-create").(*session.CreateRequest)
+	assert.Equal(tc.T(), establishedSessionID, createRequest.SessionID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_DisconnectedSessionIsNotResumed() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	disconnectedDialog := tc.mockDialog
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	waitABit()
+	destroyRequest := disconnectedDialog.lastRequest("session-destroy").(*session.DestroyRequest)
+	assert.False(tc.T(), destroyRequest.Resumable)
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	createRequest := tc.mockDialog.lastRequest("session-create").(*session.CreateRequest)
+	assert.Empty(tc.T(), createRequest.SessionID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_SessionIsNotResumedWhenProviderDoesNotSupportIt() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.NoError(tc.T(), tc.connManager.disconnect(true))
+	waitABit()
+
+	tc.Lock()
+	tc.dialogCapabilities = communication.NewCapabilities(communication.CapabilityPaymentPromises)
+	tc.Unlock()
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	createRequest := tc.mockDialog.lastRequest("session-create").(*session.CreateRequest)
+	assert.Empty(tc.T(), createRequest.SessionID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenProviderTerminatesSession() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	terminateConsumer := tc.mockDialog.receiver("session-terminate")
+	assert.NotNil(tc.T(), terminateConsumer)
+
+	err := terminateConsumer.Consume(&session.TerminateMessage{SessionID: "other-session", Reason: session.TerminateReasonIdle})
+	assert.NoError(tc.T(), err)
+	waitABit()
+	assert.Equal(tc.T(), Connected, tc.connManager.Status().State)
+
+	err = terminateConsumer.Consume(&session.TerminateMessage{SessionID: establishedSessionID, Reason: session.TerminateReasonIdle})
+	assert.NoError(tc.T(), err)
+	waitABit()
+	assert.Equal(tc.T(), NotConnected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenDialogEnds() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	endedDialog := tc.mockDialog
+
+	close(endedDialog.done)
+	waitABit()
+	assert.Equal(tc.T(), NotConnected, tc.connManager.Status().State)
+
+	destroyRequest := endedDialog.lastRequest("session-destroy").(*session.DestroyRequest)
+	assert.True(tc.T(), destroyRequest.Resumable)
+}
+
+func (tc *testContext) Test_ManagerClosesDialogOnDisconnect() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+
+	tc.mockDialog.RLock()
+	defer tc.mockDialog.RUnlock()
+	assert.True(tc.T(), tc.mockDialog.closed)
+}
+
+func (tc *testContext) Test_ManagerCreatesSpendingGuardWithConnectionLimits() {
+	limits := SpendingLimits{PerSession: money.NewMoney(1, money.CurrencyMyst)}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{SpendingLimits: limits}))
+
+	assert.Equal(tc.T(), limits, tc.spendingLimits)
+	assert.Equal(tc.T(), establishedSessionID, tc.spendingSessionInfo.SessionID)
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) Test_ManagerDisconnectsWhenSpendingLimitIsReached() {
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+
+	tc.connManager.payForService(&failingPaymentIssuer{err: ErrSpendingLimitReached})
+	waitABit()
+	assert.Equal(tc.T(), NotConnected, tc.connManager.Status().State)
+}
+
 func TestConnectionManagerSuite(t *testing.T) {
 	suite.Run(t, new(testContext))
 }
@@ -390,6 +655,7 @@ func (fs *fakeServiceDefinition) GetLocation() market.Location { return market.L
 type MockPaymentIssuer struct {
 	initialState      promise.PaymentInfo
 	paymentDefinition dto.PaymentPerTime
+	issuerID          identity.Identity
 	startCalled       bool
 	stopCalled        bool
 	MockError         error
@@ -397,6 +663,16 @@ type MockPaymentIssuer struct {
 	sync.Mutex
 }
 
+type failingPaymentIssuer struct {
+	err error
+}
+
+func (fpi *failingPaymentIssuer) Start() error {
+	return fpi.err
+}
+
+func (fpi *failingPaymentIssuer) Stop() {}
+
 func (mpm *MockPaymentIssuer) Start() error {
 	mpm.Lock()
 	mpm.startCalled = true
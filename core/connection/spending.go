@@ -0,0 +1,286 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/money"
+)
+
+const (
+	spendingLogPrefix  = "[spending-tracker] "
+	spendingBucketName = "spending"
+	spendingDayLayout  = "2006-01-02"
+)
+
+// ErrSpendingLimitReached error indicates that consumer is not allowed to pay any more to the provider
+var ErrSpendingLimitReached = errors.New("spending limit reached")
+
+// Periods spending limits apply to
+const (
+	SpendingPeriodSession = "session"
+	SpendingPeriodDay     = "day"
+	SpendingPeriodMonth   = "month"
+)
+
+// SpendingLimits restricts how much consumer pays to providers, zero amount disables the limit
+type SpendingLimits struct {
+	PerSession money.Money
+	PerDay     money.Money
+	PerMonth   money.Money
+}
+
+// Override returns limits with the non zero limits of given ones replacing the current ones.
+// Daily and monthly limits are shared by all connections, so they can only be lowered.
+func (limits SpendingLimits) Override(overrides SpendingLimits) SpendingLimits {
+	if overrides.PerSession.Amount > 0 {
+		limits.PerSession = overrides.PerSession
+	}
+	limits.PerDay = lowerLimit(limits.PerDay, overrides.PerDay)
+	limits.PerMonth = lowerLimit(limits.PerMonth, overrides.PerMonth)
+	return limits
+}
+
+// lowerLimit returns the stricter of given limits, zero amount meaning no limit
+func lowerLimit(limit, override money.Money) money.Money {
+	if override.Amount > 0 && (limit.Amount == 0 || override.Amount < limit.Amount) {
+		return override
+	}
+	return limit
+}
+
+// SpendingEvent is published when consumer spending crosses one of the warning thresholds of the spending limit
+type SpendingEvent struct {
+	Period      string
+	Threshold   float64
+	Spent       money.Money
+	Limit       money.Money
+	SessionInfo SessionInfo
+}
+
+// SpendingStatistics describes how much consumer has paid to providers
+type SpendingStatistics struct {
+	Session money.Money
+	Day     money.Money
+	Month   money.Money
+	// BalanceDrift is how much more provider thinks is left of the session prepayment than consumer does
+	BalanceDrift int64
+}
+
+// SpendingGuard approves the amounts consumer promises to pay for the session
+// and keeps the difference between provider and consumer accounting of it
+type SpendingGuard interface {
+	Approve(amount uint64) error
+	ReportDrift(drift int64)
+}
+
+// SpendingGuardCreator creates spending guard for the session, given limits override the global ones
+type SpendingGuardCreator func(sessionInfo SessionInfo, limits SpendingLimits) SpendingGuard
+
+// SpendingStorage persists daily spending totals
+type SpendingStorage interface {
+	Store(bucket string, data interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+}
+
+// DailySpending is the total amount consumer paid during the day
+type DailySpending struct {
+	Day    string `storm:"id"`
+	Amount uint64
+}
+
+// SpendingTracker keeps track of consumer spending and enforces spending limits
+type SpendingTracker struct {
+	storage           SpendingStorage
+	limits            SpendingLimits
+	warningThresholds []float64
+	publisher         Publisher
+	now               func() time.Time
+
+	lock    sync.Mutex
+	daily   map[string]uint64
+	current *sessionSpendingGuard
+}
+
+// NewSpendingTracker creates spending tracker enforcing given global limits.
+// Warning thresholds are fractions of the limits to publish SpendingEvent at.
+func NewSpendingTracker(storage SpendingStorage, limits SpendingLimits, warningThresholds []float64, publisher Publisher) *SpendingTracker {
+	return &SpendingTracker{
+		storage:           storage,
+		limits:            limits,
+		warningThresholds: warningThresholds,
+		publisher:         publisher,
+		now:               time.Now,
+	}
+}
+
+// NewSessionGuard starts tracking spending of the new session
+func (tracker *SpendingTracker) NewSessionGuard(sessionInfo SessionInfo, limits SpendingLimits) SpendingGuard {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.current = &sessionSpendingGuard{
+		tracker:     tracker,
+		sessionInfo: sessionInfo,
+		limits:      tracker.limits.Override(limits),
+	}
+	return tracker.current
+}
+
+// Retrieve returns spending of the current session, day and month
+func (tracker *SpendingTracker) Retrieve() SpendingStatistics {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	var sessionSpent uint64
+	var drift int64
+	if tracker.current != nil {
+		sessionSpent = tracker.current.spent
+		drift = tracker.current.drift
+	}
+	daySpent, monthSpent := tracker.periodSpending(tracker.now())
+	return SpendingStatistics{
+		Session:      mystMoney(sessionSpent),
+		Day:          mystMoney(daySpent),
+		Month:        mystMoney(monthSpent),
+		BalanceDrift: drift,
+	}
+}
+
+// periodSpending returns the amounts spent during the day and month of the given time, must be called holding the lock
+func (tracker *SpendingTracker) periodSpending(now time.Time) (daySpent, monthSpent uint64) {
+	if tracker.daily == nil {
+		tracker.daily = tracker.loadDaily()
+	}
+
+	day := now.UTC().Format(spendingDayLayout)
+	month := day[:len("2006-01")]
+	for recordDay, amount := range tracker.daily {
+		if strings.HasPrefix(recordDay, month) {
+			monthSpent += amount
+		}
+	}
+	return tracker.daily[day], monthSpent
+}
+
+func (tracker *SpendingTracker) loadDaily() map[string]uint64 {
+	daily := make(map[string]uint64)
+
+	var records []DailySpending
+	if err := tracker.storage.GetAllFrom(spendingBucketName, &records); err != nil {
+		log.Warn(spendingLogPrefix, "Failed to load spending history: ", err)
+		return daily
+	}
+	for _, record := range records {
+		daily[record.Day] = record.Amount
+	}
+	return daily
+}
+
+func (tracker *SpendingTracker) approve(guard *sessionSpendingGuard, amount uint64) error {
+	tracker.lock.Lock()
+
+	now := tracker.now()
+	daySpent, monthSpent := tracker.periodSpending(now)
+	periods := []struct {
+		name  string
+		spent uint64
+		limit money.Money
+	}{
+		{SpendingPeriodSession, guard.spent, guard.limits.PerSession},
+		{SpendingPeriodDay, daySpent, guard.limits.PerDay},
+		{SpendingPeriodMonth, monthSpent, guard.limits.PerMonth},
+	}
+
+	for _, period := range periods {
+		if period.limit.Amount > 0 && period.spent+amount > period.limit.Amount {
+			tracker.lock.Unlock()
+			log.Warn(spendingLogPrefix, "Spending limit of the ", period.name, " reached: ", period.limit.Amount)
+			return ErrSpendingLimitReached
+		}
+	}
+
+	day := now.UTC().Format(spendingDayLayout)
+	guard.spent += amount
+	tracker.daily[day] += amount
+	if err := tracker.storage.Store(spendingBucketName, &DailySpending{Day: day, Amount: tracker.daily[day]}); err != nil {
+		log.Warn(spendingLogPrefix, "Failed to store spending: ", err)
+	}
+
+	var events []SpendingEvent
+	for _, period := range periods {
+		events = append(events, tracker.crossedWarnings(guard.sessionInfo, period.name, period.spent, period.spent+amount, period.limit)...)
+	}
+	tracker.lock.Unlock()
+
+	for _, event := range events {
+		log.Warn(spendingLogPrefix, "Spent ", event.Spent.Amount, " of the ", event.Period, " limit ", event.Limit.Amount)
+		tracker.publisher.Publish(SpendingEventTopic, event)
+	}
+	return nil
+}
+
+func (tracker *SpendingTracker) crossedWarnings(sessionInfo SessionInfo, period string, before, after uint64, limit money.Money) []SpendingEvent {
+	if limit.Amount == 0 {
+		return nil
+	}
+
+	var events []SpendingEvent
+	for _, threshold := range tracker.warningThresholds {
+		warnAt := threshold * float64(limit.Amount)
+		if float64(before) < warnAt && float64(after) >= warnAt {
+			events = append(events, SpendingEvent{
+				Period:      period,
+				Threshold:   threshold,
+				Spent:       money.Money{Amount: after, Currency: limit.Currency},
+				Limit:       limit,
+				SessionInfo: sessionInfo,
+			})
+		}
+	}
+	return events
+}
+
+type sessionSpendingGuard struct {
+	tracker     *SpendingTracker
+	sessionInfo SessionInfo
+	limits      SpendingLimits
+	spent       uint64
+	drift       int64
+}
+
+// Approve records the amount as spent unless it exceeds any of the limits
+func (guard *sessionSpendingGuard) Approve(amount uint64) error {
+	return guard.tracker.approve(guard, amount)
+}
+
+// ReportDrift records the latest difference between provider and consumer balances of the session
+func (guard *sessionSpendingGuard) ReportDrift(drift int64) {
+	guard.tracker.lock.Lock()
+	defer guard.tracker.lock.Unlock()
+	guard.drift = drift
+}
+
+func mystMoney(amount uint64) money.Money {
+	return money.Money{Amount: amount, Currency: money.CurrencyMyst}
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSpendingStorage struct {
+	records map[string]DailySpending
+}
+
+func (mss *mockSpendingStorage) Store(bucket string, data interface{}) error {
+	record := data.(*DailySpending)
+	mss.records[record.Day] = *record
+	return nil
+}
+
+func (mss *mockSpendingStorage) GetAllFrom(bucket string, data interface{}) error {
+	records := data.(*[]DailySpending)
+	for _, record := range mss.records {
+		*records = append(*records, record)
+	}
+	return nil
+}
+
+func newTestSpendingTracker(storage SpendingStorage, limits SpendingLimits, publisher Publisher, now time.Time) *SpendingTracker {
+	tracker := NewSpendingTracker(storage, limits, []float64{0.5}, publisher)
+	tracker.now = func() time.Time { return now }
+	return tracker
+}
+
+func myst(amount uint64) money.Money {
+	return money.Money{Amount: amount, Currency: money.CurrencyMyst}
+}
+
+func TestSpendingTracker_EnforcesSessionLimit(t *testing.T) {
+	storage := &mockSpendingStorage{records: map[string]DailySpending{}}
+	tracker := newTestSpendingTracker(storage, SpendingLimits{PerSession: myst(300)}, NewStubPublisher(), time.Now())
+
+	guard := tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{})
+	assert.NoError(t, guard.Approve(200))
+	assert.Equal(t, ErrSpendingLimitReached, guard.Approve(200))
+	assert.NoError(t, guard.Approve(100))
+
+	// new session starts from zero
+	guard = tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{})
+	assert.NoError(t, guard.Approve(300))
+
+	assert.Equal(t, SpendingStatistics{Session: myst(300), Day: myst(600), Month: myst(600)}, tracker.Retrieve())
+}
+
+func TestSpendingTracker_ConnectionLimitsOverrideGlobalOnes(t *testing.T) {
+	storage := &mockSpendingStorage{records: map[string]DailySpending{}}
+	tracker := newTestSpendingTracker(storage, SpendingLimits{PerSession: myst(300), PerDay: myst(1000)}, NewStubPublisher(), time.Now())
+
+	guard := tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{PerSession: myst(100)})
+	assert.NoError(t, guard.Approve(100))
+	assert.Equal(t, ErrSpendingLimitReached, guard.Approve(1))
+}
+
+func TestSpendingLimits_OverrideCanNotRaiseDailyAndMonthlyLimits(t *testing.T) {
+	global := SpendingLimits{PerSession: myst(300), PerDay: myst(1000), PerMonth: myst(5000)}
+
+	assert.Equal(
+		t,
+		SpendingLimits{PerSession: myst(500), PerDay: myst(1000), PerMonth: myst(5000)},
+		global.Override(SpendingLimits{PerSession: myst(500), PerDay: myst(2000), PerMonth: myst(9000)}),
+	)
+	assert.Equal(
+		t,
+		SpendingLimits{PerSession: myst(300), PerDay: myst(100), PerMonth: myst(400)},
+		global.Override(SpendingLimits{PerDay: myst(100), PerMonth: myst(400)}),
+	)
+	assert.Equal(
+		t,
+		SpendingLimits{PerDay: myst(100), PerMonth: myst(400)},
+		SpendingLimits{}.Override(SpendingLimits{PerDay: myst(100), PerMonth: myst(400)}),
+	)
+}
+
+func TestSpendingTracker_EnforcesDailyAndMonthlyLimitsAcrossRestarts(t *testing.T) {
+	now := time.Date(2019, time.June, 15, 12, 0, 0, 0, time.UTC)
+	storage := &mockSpendingStorage{records: map[string]DailySpending{
+		"2019-06-15": {Day: "2019-06-15", Amount: 400},
+		"2019-06-01": {Day: "2019-06-01", Amount: 500},
+		"2019-05-31": {Day: "2019-05-31", Amount: 10000},
+	}}
+
+	tracker := newTestSpendingTracker(storage, SpendingLimits{PerDay: myst(500)}, NewStubPublisher(), now)
+	guard := tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{})
+	assert.Equal(t, ErrSpendingLimitReached, guard.Approve(200))
+	assert.NoError(t, guard.Approve(100))
+	assert.Equal(t, DailySpending{Day: "2019-06-15", Amount: 500}, storage.records["2019-06-15"])
+
+	tracker = newTestSpendingTracker(storage, SpendingLimits{PerMonth: myst(1100)}, NewStubPublisher(), now)
+	guard = tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{})
+	assert.Equal(t, ErrSpendingLimitReached, guard.Approve(200))
+	assert.NoError(t, guard.Approve(100))
+}
+
+func TestSpendingTracker_PublishesWarningsOnThresholds(t *testing.T) {
+	storage := &mockSpendingStorage{records: map[string]DailySpending{}}
+	publisher := NewStubPublisher()
+	tracker := newTestSpendingTracker(storage, SpendingLimits{PerSession: myst(100)}, publisher, time.Now())
+	sessionInfo := SessionInfo{SessionID: "session"}
+
+	guard := tracker.NewSessionGuard(sessionInfo, SpendingLimits{})
+	assert.NoError(t, guard.Approve(40))
+	assert.Len(t, publisher.GetEventHistory(), 0)
+
+	assert.NoError(t, guard.Approve(20))
+	history := publisher.GetEventHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, SpendingEventTopic, history[0].calledWithTopic)
+	assert.Equal(
+		t,
+		SpendingEvent{
+			Period:      SpendingPeriodSession,
+			Threshold:   0.5,
+			Spent:       myst(60),
+			Limit:       myst(100),
+			SessionInfo: sessionInfo,
+		},
+		history[0].calledWithArgs[0],
+	)
+
+	assert.NoError(t, guard.Approve(20))
+	assert.Len(t, publisher.GetEventHistory(), 1)
+}
+
+func TestSpendingTracker_RetrievesBalanceDriftOfCurrentSession(t *testing.T) {
+	storage := &mockSpendingStorage{records: map[string]DailySpending{}}
+	tracker := newTestSpendingTracker(storage, SpendingLimits{}, NewStubPublisher(), time.Now())
+
+	guard := tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{})
+	guard.ReportDrift(-15)
+	assert.Equal(t, int64(-15), tracker.Retrieve().BalanceDrift)
+
+	tracker.NewSessionGuard(SessionInfo{}, SpendingLimits{})
+	assert.Equal(t, int64(0), tracker.Retrieve().BalanceDrift)
+}
@@ -18,6 +18,7 @@
 package connection
 
 import (
+	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/session"
 )
@@ -40,19 +41,21 @@ const (
 	Unknown = State("Unknown")
 )
 
-// Status holds connection state, session id and proposal of the connection
+// Status holds connection state, session id, proposal and capabilities of the connection
 type Status struct {
 	State     State
 	SessionID session.ID
 	Proposal  market.ServiceProposal
+	// Capabilities consumer and provider agreed on while creating the dialog
+	Capabilities communication.Capabilities
 }
 
 func statusConnecting() Status {
 	return Status{State: Connecting}
 }
 
-func statusConnected(sessionID session.ID, proposal market.ServiceProposal) Status {
-	return Status{Connected, sessionID, proposal}
+func statusConnected(sessionID session.ID, proposal market.ServiceProposal, capabilities communication.Capabilities) Status {
+	return Status{Connected, sessionID, proposal, capabilities}
 }
 
 func statusNotConnected() Status {
@@ -18,6 +18,7 @@
 package connection
 
 import (
+	"context"
 	"errors"
 	"sync"
 
@@ -222,9 +223,34 @@ type mockDialog struct {
 	sessionID   session.ID
 	paymentInfo *promise.PaymentInfo
 	closed      bool
+	done        chan struct{}
+	// createBlocks makes session creation wait until the request is cancelled
+	createBlocks bool
+	// createAnswersCancelled makes provider answer session creation after the request is cancelled
+	createAnswersCancelled bool
+	capabilities           communication.Capabilities
+	assumedCapabilities    communication.Capabilities
+	requests               map[communication.RequestEndpoint]interface{}
+	receivers              map[communication.MessageEndpoint]communication.MessageConsumer
 	sync.RWMutex
 }
 
+// receiver returns the consumer registered to receive messages from the given endpoint
+func (md *mockDialog) receiver(endpoint communication.MessageEndpoint) communication.MessageConsumer {
+	md.RLock()
+	defer md.RUnlock()
+
+	return md.receivers[endpoint]
+}
+
+// lastRequest returns the last request sent to the given endpoint
+func (md *mockDialog) lastRequest(endpoint communication.RequestEndpoint) interface{} {
+	md.RLock()
+	defer md.RUnlock()
+
+	return md.requests[endpoint]
+}
+
 func (md *mockDialog) PeerID() identity.Identity {
 	md.RLock()
 	defer md.RUnlock()
@@ -250,8 +276,26 @@ func (md *mockDialog) Close() error {
 	return nil
 }
 
+func (md *mockDialog) Capabilities() communication.Capabilities {
+	return md.capabilities
+}
+
+func (md *mockDialog) AssumedCapabilities() communication.Capabilities {
+	return md.assumedCapabilities
+}
+
+func (md *mockDialog) Done() <-chan struct{} {
+	return md.done
+}
+
 func (md *mockDialog) Receive(consumer communication.MessageConsumer) error {
 	md.assertNotClosed()
+	md.Lock()
+	if md.receivers == nil {
+		md.receivers = make(map[communication.MessageEndpoint]communication.MessageConsumer)
+	}
+	md.receivers[consumer.GetMessageEndpoint()] = consumer
+	md.Unlock()
 	return nil
 }
 func (md *mockDialog) Respond(consumer communication.RequestConsumer) error {
@@ -267,10 +311,32 @@ func (md *mockDialog) Send(producer communication.MessageProducer) error {
 	return nil
 }
 
+func (md *mockDialog) SendContext(ctx context.Context, producer communication.MessageProducer) error {
+	return md.Send(producer)
+}
+
 var ErrUnknownRequest = errors.New("unknown request")
 
+func (md *mockDialog) RequestContext(ctx context.Context, producer communication.RequestProducer) (responsePtr interface{}, err error) {
+	if md.createBlocks && producer.GetRequestEndpoint() == communication.RequestEndpoint("session-create") {
+		<-ctx.Done()
+		if md.createAnswersCancelled {
+			return md.Request(producer)
+		}
+		return nil, ctx.Err()
+	}
+	return md.Request(producer)
+}
+
 func (md *mockDialog) Request(producer communication.RequestProducer) (responsePtr interface{}, err error) {
 	md.assertNotClosed()
+	md.Lock()
+	if md.requests == nil {
+		md.requests = make(map[communication.RequestEndpoint]interface{})
+	}
+	md.requests[producer.GetRequestEndpoint()] = producer.Produce()
+	md.Unlock()
+
 	if producer.GetRequestEndpoint() == communication.RequestEndpoint("session-destroy") {
 		return &session.DestroyResponse{
 				Success: true,
@@ -291,3 +357,16 @@ func (md *mockDialog) Request(producer communication.RequestProducer) (responseP
 	}
 	return nil, ErrUnknownRequest
 }
+
+type mockUnlockChecker struct {
+	locked []string
+}
+
+func (checker *mockUnlockChecker) IsUnlocked(address string) bool {
+	for _, locked := range checker.locked {
+		if locked == address {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ip
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+)
+
+// ErrNoFreeSubnets is returned when allocator has no more space left in its network
+var ErrNoFreeSubnets = errors.New("no more unused subnets")
+
+// SubnetAllocator hands out non overlapping IPv4 subnets carved from the given parent network.
+// It is safe for concurrent use, so single allocator might be shared between several services.
+type SubnetAllocator struct {
+	network   net.IPNet
+	allocated []net.IPNet
+	mu        sync.Mutex
+}
+
+// NewSubnetAllocator creates new allocator managing given IPv4 network
+func NewSubnetAllocator(network net.IPNet) *SubnetAllocator {
+	return &SubnetAllocator{
+		network: net.IPNet{IP: network.IP.Mask(network.Mask), Mask: network.Mask},
+	}
+}
+
+// Allocate reserves first free subnet of given prefix length
+func (a *SubnetAllocator) Allocate(prefixLength int) (net.IPNet, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	networkOnes, bits := a.network.Mask.Size()
+	base := a.network.IP.To4()
+	if bits != 32 || base == nil {
+		return net.IPNet{}, errors.New("only IPv4 networks are supported")
+	}
+	if prefixLength < networkOnes || prefixLength > 30 {
+		return net.IPNet{}, errors.New("requested subnet does not fit into allocator network")
+	}
+
+	start := binary.BigEndian.Uint32(base)
+	size := uint32(1) << uint(32-prefixLength)
+	count := uint32(1) << uint(prefixLength-networkOnes)
+	for i := uint32(0); i < count; i++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, start+i*size)
+
+		candidate := net.IPNet{IP: ip, Mask: net.CIDRMask(prefixLength, 32)}
+		if !a.isAllocated(candidate) {
+			a.allocated = append(a.allocated, candidate)
+			return candidate, nil
+		}
+	}
+
+	return net.IPNet{}, ErrNoFreeSubnets
+}
+
+// Release returns previously allocated subnet back to the allocator.
+// Any address within the subnet might be used as long as the mask is preserved.
+func (a *SubnetAllocator) Release(subnet net.IPNet) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	network := subnet.IP.Mask(subnet.Mask)
+	for i, allocated := range a.allocated {
+		if allocated.IP.Equal(network) && allocated.Mask.String() == subnet.Mask.String() {
+			a.allocated = append(a.allocated[:i], a.allocated[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("allocated subnet not found")
+}
+
+// Allocated returns list of currently reserved subnets
+func (a *SubnetAllocator) Allocated() []net.IPNet {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]net.IPNet(nil), a.allocated...)
+}
+
+func (a *SubnetAllocator) isAllocated(candidate net.IPNet) bool {
+	for _, allocated := range a.allocated {
+		if allocated.Contains(candidate.IP) || candidate.Contains(allocated.IP) {
+			return true
+		}
+	}
+	return false
+}
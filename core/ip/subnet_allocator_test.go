@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseNetwork(t *testing.T, cidr string) net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	assert.NoError(t, err)
+	return *network
+}
+
+func TestSubnetAllocator_AllocatesNonOverlappingSubnets(t *testing.T) {
+	allocator := NewSubnetAllocator(parseNetwork(t, "10.8.0.0/15"))
+
+	first, err := allocator.Allocate(24)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.8.0.0/24", first.String())
+
+	second, err := allocator.Allocate(16)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.9.0.0/16", second.String())
+
+	third, err := allocator.Allocate(24)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.8.1.0/24", third.String())
+
+	_, err = allocator.Allocate(16)
+	assert.Equal(t, ErrNoFreeSubnets, err)
+}
+
+func TestSubnetAllocator_ReusesReleasedSubnets(t *testing.T) {
+	allocator := NewSubnetAllocator(parseNetwork(t, "10.8.0.0/23"))
+
+	first, err := allocator.Allocate(24)
+	assert.NoError(t, err)
+	_, err = allocator.Allocate(24)
+	assert.NoError(t, err)
+
+	released := first
+	released.IP = net.ParseIP("10.8.0.1")
+	assert.NoError(t, allocator.Release(released))
+	assert.Len(t, allocator.Allocated(), 1)
+
+	again, err := allocator.Allocate(24)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.8.0.0/24", again.String())
+}
+
+func TestSubnetAllocator_ReleaseUnknownSubnet(t *testing.T) {
+	allocator := NewSubnetAllocator(parseNetwork(t, "10.8.0.0/16"))
+
+	assert.Error(t, allocator.Release(parseNetwork(t, "10.8.0.0/24")))
+}
+
+func TestSubnetAllocator_RejectsTooLargeSubnets(t *testing.T) {
+	allocator := NewSubnetAllocator(parseNetwork(t, "10.8.0.0/16"))
+
+	_, err := allocator.Allocate(8)
+	assert.Error(t, err)
+}
@@ -36,8 +36,15 @@ type Options struct {
 
 	Keystore OptionsKeystore
 
-	Openvpn  Openvpn
-	Location OptionsLocation
+	Openvpn    Openvpn
+	Location   OptionsLocation
+	Session    OptionsSession
+	Spending   OptionsSpending
+	Settlement OptionsSettlement
+	Trial      OptionsTrial
+	Payments   OptionsPayments
+	Direct     OptionsDirect
+	Dialog     OptionsDialog
 	OptionsNetwork
 }
 
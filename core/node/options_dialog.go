@@ -0,0 +1,40 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import "time"
+
+// OptionsDialog describes how dialog peers detect each other going away, how long they wait for each other
+// and how many dialogs provider accepts
+type OptionsDialog struct {
+	// KeepaliveInterval is how often peers exchange heartbeats, 0 disables them
+	KeepaliveInterval time.Duration
+	// RequestTimeouts is how long requests of each endpoint wait for the response
+	RequestTimeouts map[string]time.Duration
+	// RequestRetries is how many times timed out requests of each endpoint are repeated
+	RequestRetries map[string]int
+
+	// PeerRate is how many dialogs per second a single peer may ask for, PeerBurst of them at once
+	PeerRate  float64
+	PeerBurst int
+	// Rate is how many dialogs per second all peers together may ask for, Burst of them at once
+	Rate  float64
+	Burst int
+	// MaxOpen is how many dialogs may be open at the same time
+	MaxOpen int
+}
@@ -28,6 +28,10 @@ type OptionsNetwork struct {
 	DiscoveryAPIAddress string
 	BrokerAddress       string
 
+	// BrokerEmbedded runs NATS broker inside the node, serving it and the peers configured to use it
+	BrokerEmbedded        bool
+	BrokerEmbeddedAddress string
+
 	EtherClientRPC       string
 	EtherPaymentsAddress string
 
@@ -18,6 +18,7 @@
 package noop
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"time"
@@ -43,6 +44,10 @@ func (fd *fakeDialog) Close() error {
 	return nil
 }
 
+func (fd *fakeDialog) Done() <-chan struct{} {
+	return nil
+}
+
 func (fd *fakeDialog) Receive(consumer communication.MessageConsumer) error {
 	if fd.returnError != nil {
 		return fd.returnError
@@ -85,6 +90,14 @@ func (fd *fakeDialog) waitSendMessage() (interface{}, error) {
 	return nil, errors.New("message was not sent")
 }
 
+func (fd *fakeDialog) SendContext(ctx context.Context, producer communication.MessageProducer) error {
+	return fd.Send(producer)
+}
+
 func (fd *fakeDialog) Request(producer communication.RequestProducer) (responsePtr interface{}, err error) {
 	return &promise.Response{Success: true}, nil
 }
+
+func (fd *fakeDialog) RequestContext(ctx context.Context, producer communication.RequestProducer) (responsePtr interface{}, err error) {
+	return fd.Request(producer)
+}
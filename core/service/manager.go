@@ -111,11 +111,11 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	if err != nil {
 		return id, err
 	}
-	providerContact, err := dialogWaiter.Start()
+	providerContacts, err := dialogWaiter.Start()
 	if err != nil {
 		return id, err
 	}
-	proposal.SetProviderContact(providerID, providerContact)
+	proposal.SetProviderContacts(providerID, providerContacts)
 
 	dialogHandler := manager.dialogHandlerFactory(proposal, service)
 	if err = dialogWaiter.ServeDialogs(dialogHandler); err != nil {
@@ -19,6 +19,7 @@ package service
 
 import (
 	"errors"
+	"net"
 	"sync"
 
 	"github.com/gofrs/uuid"
@@ -36,6 +37,11 @@ type RunnableService interface {
 	Stop() error
 }
 
+// SubnetReporter is implemented by services which reserve VPN subnets on the provider
+type SubnetReporter interface {
+	Subnets() []net.IPNet
+}
+
 // Pool is responsible for supervising running instances
 type Pool struct {
 	instances map[ID]*Instance
@@ -175,6 +181,14 @@ func (i *Instance) State() State {
 	return i.state
 }
 
+// Subnets returns VPN subnets reserved by the service instance, if any.
+func (i *Instance) Subnets() []net.IPNet {
+	if service, ok := i.service.(SubnetReporter); ok {
+		return service.Subnets()
+	}
+	return nil
+}
+
 func generateID() (ID, error) {
 	uid, err := uuid.NewV4()
 	if err != nil {
@@ -58,14 +58,14 @@ func (service *serviceFake) ProvideConfig(publicKey json.RawMessage) (session.Se
 }
 
 type mockDialogWaiter struct {
-	contact  market.Contact
+	contacts market.ContactList
 	stopErr  error
 	serveErr error
 	startErr error
 }
 
-func (mdw *mockDialogWaiter) Start() (market.Contact, error) {
-	return mdw.contact, mdw.startErr
+func (mdw *mockDialogWaiter) Start() (market.ContactList, error) {
+	return mdw.contacts, mdw.startErr
 }
 
 func (mdw *mockDialogWaiter) Stop() error {
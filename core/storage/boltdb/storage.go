@@ -19,11 +19,16 @@ package boltdb
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/asdine/storm"
 	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
 )
 
+// readOnlyOpenTimeout limits waiting for database locked by running node
+const readOnlyOpenTimeout = time.Second
+
 // Bolt is a wrapper around boltdb
 type Bolt struct {
 	db *storm.DB
@@ -34,6 +39,15 @@ func NewStorage(path string) (*Bolt, error) {
 	return openDB(filepath.Join(path, "myst.db"))
 }
 
+// NewReadOnlyStorage opens existing BoltDB storage for reading, fails while the database is used by running node
+func NewReadOnlyStorage(path string) (*Bolt, error) {
+	db, err := storm.Open(
+		filepath.Join(path, "myst.db"),
+		storm.BoltOptions(0600, &bolt.Options{ReadOnly: true, Timeout: readOnlyOpenTimeout}),
+	)
+	return &Bolt{db}, errors.Wrap(err, "failed to open boltDB for reading")
+}
+
 // openDB creates new or open existing BoltDB
 func openDB(name string) (*Bolt, error) {
 	db, err := storm.Open(name)
@@ -113,3 +113,25 @@ func Test_StorageGetLast_ErrsOnEmpty(t *testing.T) {
 	err = storage.GetLast(bucket, &result)
 	assert.Equal(t, "not found", err.Error())
 }
+
+func Test_ReadOnlyStorageReadsStoredData(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	storage, err := NewStorage(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, storage.Store(bucket, &myTestType{ID: 1}))
+
+	_, err = NewReadOnlyStorage(dir)
+	assert.Error(t, err, "database locked by writer should not be opened")
+	assert.Nil(t, storage.Close())
+
+	readOnly, err := NewReadOnlyStorage(dir)
+	assert.Nil(t, err)
+	defer readOnly.Close()
+
+	var result []myTestType
+	assert.Nil(t, readOnly.GetAllFrom(bucket, &result))
+	assert.Equal(t, []myTestType{{ID: 1}}, result)
+	assert.Error(t, readOnly.Store(bucket, &myTestType{ID: 2}))
+}
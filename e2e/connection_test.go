@@ -136,6 +136,11 @@ func consumerConnectFlow(t *testing.T, tequilapi *tequilapi_client.Client, consu
 	})
 	assert.NoError(t, err)
 
+	// providers of all versions in compatibility matrix are paid with promises
+	connectedStatus, err := tequilapi.Status()
+	assert.NoError(t, err)
+	assert.Contains(t, connectedStatus.Capabilities, "payment-promises")
+
 	vpnIP, err := tequilapi.GetIP()
 	assert.NoError(t, err)
 	seelog.Info("Changed consumer IP: ", vpnIP)
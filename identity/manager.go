@@ -96,6 +96,17 @@ func (idm *identityManager) Unlock(address string, passphrase string) error {
 	return idm.keystoreManager.Unlock(account, passphrase)
 }
 
+// IsUnlocked tells whether the identity is able to sign messages
+func (idm *identityManager) IsUnlocked(address string) bool {
+	account, err := idm.findAccount(address)
+	if err != nil {
+		return false
+	}
+
+	_, err = idm.keystoreManager.SignHash(account, messageHash([]byte(address)))
+	return err == nil
+}
+
 func (idm *identityManager) findAccount(address string) (accounts.Account, error) {
 	account, err := idm.keystoreManager.Find(addressToAccount(address))
 	if err != nil {
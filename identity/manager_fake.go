@@ -25,18 +25,24 @@ type idmFake struct {
 	existingIdentities   []Identity
 	newIdentity          Identity
 	unlockFails          bool
+	lockedIdentities     []string
 }
 
 // NewIdentityManagerFake creates fake identity manager for testing purposes
 // TODO each caller should use it's own mocked manager part instead of global one
 func NewIdentityManagerFake(existingIdentities []Identity, newIdentity Identity) *idmFake {
-	return &idmFake{"", "", existingIdentities, newIdentity, false}
+	return &idmFake{"", "", existingIdentities, newIdentity, false, nil}
 }
 
 func (fakeIdm *idmFake) MarkUnlockToFail() {
 	fakeIdm.unlockFails = true
 }
 
+// MarkLocked makes the identity report being locked
+func (fakeIdm *idmFake) MarkLocked(address string) {
+	fakeIdm.lockedIdentities = append(fakeIdm.lockedIdentities, address)
+}
+
 func (fakeIdm *idmFake) CreateNewIdentity(_ string) (Identity, error) {
 	return fakeIdm.newIdentity, nil
 }
@@ -63,3 +69,12 @@ func (fakeIdm *idmFake) Unlock(address string, passphrase string) error {
 	}
 	return nil
 }
+
+func (fakeIdm *idmFake) IsUnlocked(address string) bool {
+	for _, locked := range fakeIdm.lockedIdentities {
+		if locked == address {
+			return false
+		}
+	}
+	return true
+}
@@ -95,3 +95,12 @@ func TestManager_HasIdentity(t *testing.T) {
 	assert.True(t, manager.HasIdentity("0x000000000000000000000000000000000000000a"))
 	assert.False(t, manager.HasIdentity("0x000000000000000000000000000000000000000B"))
 }
+
+func TestManager_IsUnlocked(t *testing.T) {
+	manager := newManager("0x000000000000000000000000000000000000000A")
+	assert.True(t, manager.IsUnlocked("0x000000000000000000000000000000000000000A"))
+	assert.False(t, manager.IsUnlocked("0x000000000000000000000000000000000000000B"))
+
+	locked := newManagerWithError(errors.New("authentication needed: password or unlock"))
+	assert.False(t, locked.IsUnlocked("0x000000000000000000000000000000000000000A"))
+}
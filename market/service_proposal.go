@@ -95,13 +95,13 @@ func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// SetProviderContact updates service proposal description with general data
-func (proposal *ServiceProposal) SetProviderContact(providerID identity.Identity, providerContact Contact) {
+// SetProviderContacts updates service proposal description with general data
+func (proposal *ServiceProposal) SetProviderContacts(providerID identity.Identity, providerContacts ContactList) {
 	proposal.Format = proposalFormat
 	// TODO This will be generated later
 	proposal.ID = 1
 	proposal.ProviderID = providerID.Address
-	proposal.ProviderContacts = ContactList{providerContact}
+	proposal.ProviderContacts = providerContacts
 }
 
 // IsSupported returns true if this service proposal can be used for connections by service consumer
@@ -35,9 +35,9 @@ var (
 	paymentMethod     = mockPaymentMethod{}
 )
 
-func Test_ServiceProposal_SetProviderContact(t *testing.T) {
+func Test_ServiceProposal_SetProviderContacts(t *testing.T) {
 	proposal := ServiceProposal{ID: 123, ProviderID: "123"}
-	proposal.SetProviderContact(providerID, providerContact)
+	proposal.SetProviderContacts(providerID, ContactList{providerContact})
 
 	assert.Exactly(
 		t,
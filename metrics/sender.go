@@ -25,6 +25,7 @@ const appName = "myst"
 const startupEventName = "startup"
 const natMappingSuccessEventName = "nat_mapping_success"
 const natMappingFailEventName = "nat_mapping_fail"
+const dialogRejectionsEventName = "dialog_rejections"
 
 // Sender builds events and sends them using given transport
 type Sender struct {
@@ -54,6 +55,10 @@ type natMappingFailContext struct {
 	errorMessage string
 }
 
+type dialogRejectionsContext struct {
+	Rejections map[string]int `json:"rejections"`
+}
+
 // SendStartupEvent sends startup event
 func (sender *Sender) SendStartupEvent() error {
 	return sender.sendEvent(startupEventName, nil)
@@ -70,6 +75,12 @@ func (sender *Sender) SendNATMappingFailEvent(err error) error {
 	return sender.sendEvent(natMappingFailEventName, context)
 }
 
+// SendDialogRejectionsEvent sends event about dialog requests rejected since the last one, counted by reason
+func (sender *Sender) SendDialogRejectionsEvent(rejections map[string]int) error {
+	context := dialogRejectionsContext{Rejections: rejections}
+	return sender.sendEvent(dialogRejectionsEventName, context)
+}
+
 func (sender *Sender) sendEvent(eventName string, context interface{}) error {
 	app := appInfo{Name: appName, Version: sender.AppVersion}
 	event := Event{Application: app, EventName: eventName, CreatedAt: time.Now().Unix(), Context: context}
@@ -106,3 +106,17 @@ func TestSender_SendNATMappingFailEvent_ReturnsTransportErrors(t *testing.T) {
 	err := sender.SendNATMappingFailEvent(errors.New("mock nat mapping error"))
 	assert.Error(t, err)
 }
+
+func TestSender_SendDialogRejectionsEvent_SendsToTransport(t *testing.T) {
+	mockTransport := buildMockEventsTransport(nil)
+	sender := &Sender{Transport: mockTransport, AppVersion: "test version"}
+
+	err := sender.SendDialogRejectionsEvent(map[string]int{"rate_limited": 3})
+	assert.NoError(t, err)
+
+	sentEvent := mockTransport.sentEvent
+	assert.Equal(t, "dialog_rejections", sentEvent.EventName)
+	assert.Equal(t, appInfo{Name: "myst", Version: "test version"}, sentEvent.Application)
+	assert.NotZero(t, sentEvent.CreatedAt)
+	assert.Equal(t, dialogRejectionsContext{Rejections: map[string]int{"rate_limited": 3}}, sentEvent.Context)
+}
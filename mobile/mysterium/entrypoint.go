@@ -22,6 +22,7 @@ import (
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/mysteriumnetwork/node/cmd"
+	nats_dialog "github.com/mysteriumnetwork/node/communication/nats/dialog"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/metadata"
 )
@@ -74,6 +75,10 @@ func NewNode(appPath string, optionsNetwork *MobileNetworkOptions) (*MobileNode,
 			IpifyUrl: "https://api.ipify.org/",
 		},
 
+		Dialog: node.OptionsDialog{
+			KeepaliveInterval: nats_dialog.DefaultKeepaliveInterval,
+		},
+
 		OptionsNetwork: node.OptionsNetwork(*optionsNetwork),
 	})
 	if err != nil {
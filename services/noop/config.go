@@ -0,0 +1,28 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package noop
+
+// ServiceConfig represents Noop service configuration sent to consumer
+type ServiceConfig struct {
+	// Protocol of the loopback payload socket, no traffic is exchanged if empty
+	Protocol string `json:"protocol,omitempty"`
+	// Address of the provider payload socket
+	Address string `json:"address,omitempty"`
+	// DropAfter is the number of seconds after which consumer connection fails
+	DropAfter int `json:"dropAfter,omitempty"`
+}
@@ -18,27 +18,72 @@
 package noop
 
 import (
+	"encoding/json"
+	"io"
+	"net"
 	"sync"
 	"time"
 
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/pkg/errors"
 )
 
-// Connection which does no real tunneling
+const (
+	payloadSize    = 1024
+	payloadTimeout = 5 * time.Second
+)
+
+// ErrConnectionDropped is returned when connection is dropped on provider's request
+var ErrConnectionDropped = errors.New("connection dropped by failure injection")
+
+// Connection which does no real tunneling, but optionally exchanges synthetic payload with provider
 type Connection struct {
-	isRunning      bool
-	noopConnection sync.WaitGroup
-	stateChannel   connection.StateChannel
+	isRunning         bool
+	noopConnection    sync.WaitGroup
+	stateChannel      connection.StateChannel
+	statisticsChannel connection.StatisticsChannel
+	payloadInterval   time.Duration
+
+	stopChannel chan struct{}
+	trafficDone chan struct{}
+	finishOnce  sync.Once
+	err         error
 }
 
 // Start implements the connection.Connection interface
 func (c *Connection) Start(params connection.ConnectOptions) error {
+	var config ServiceConfig
+	if len(params.SessionConfig) > 0 {
+		if err := json.Unmarshal(params.SessionConfig, &config); err != nil {
+			return errors.Wrap(err, "failed to unmarshal connection config")
+		}
+	}
+
 	c.noopConnection.Add(1)
 	c.isRunning = true
+	c.stopChannel = make(chan struct{})
 
 	c.stateChannel <- connection.Connecting
 
-	time.Sleep(5 * time.Second)
+	if config.Protocol == "" {
+		time.Sleep(5 * time.Second)
+		c.stateChannel <- connection.Connected
+		return nil
+	}
+
+	conn, err := net.DialTimeout(config.Protocol, config.Address, payloadTimeout)
+	if err != nil {
+		c.isRunning = false
+		c.stateChannel <- connection.NotConnected
+		c.finish(err)
+		return errors.Wrap(err, "failed to connect to payload socket")
+	}
+
+	c.trafficDone = make(chan struct{})
+	go c.exchangePayload(conn, config)
+
 	c.stateChannel <- connection.Connected
 	return nil
 }
@@ -48,7 +93,7 @@ func (c *Connection) Wait() error {
 	if c.isRunning {
 		c.noopConnection.Wait()
 	}
-	return nil
+	return c.err
 }
 
 // Stop implements the connection.Connection interface
@@ -58,10 +103,16 @@ func (c *Connection) Stop() {
 	}
 
 	c.isRunning = false
+	close(c.stopChannel)
+
 	c.stateChannel <- connection.Disconnecting
-	time.Sleep(2 * time.Second)
+	if c.trafficDone != nil {
+		<-c.trafficDone
+	} else {
+		time.Sleep(2 * time.Second)
+	}
 	c.stateChannel <- connection.NotConnected
-	c.noopConnection.Done()
+	c.finish(nil)
 	close(c.stateChannel)
 }
 
@@ -69,3 +120,60 @@ func (c *Connection) Stop() {
 func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
 	return nil, nil
 }
+
+// finish releases connection waiters, recording the first reason
+func (c *Connection) finish(err error) {
+	c.finishOnce.Do(func() {
+		c.err = err
+		c.noopConnection.Done()
+	})
+}
+
+// exchangePayload periodically sends payload to the provider echo socket and reports the traffic
+func (c *Connection) exchangePayload(conn net.Conn, config ServiceConfig) {
+	defer close(c.trafficDone)
+	defer close(c.statisticsChannel)
+	defer conn.Close()
+
+	var dropTimer <-chan time.Time
+	if config.DropAfter > 0 {
+		dropTimer = time.After(time.Duration(config.DropAfter) * time.Second)
+	}
+
+	payload := make([]byte, payloadSize)
+	echo := make([]byte, payloadSize)
+	var stats consumer.SessionStatistics
+	for {
+		select {
+		case <-time.After(c.payloadInterval):
+			if err := conn.SetDeadline(time.Now().Add(payloadTimeout)); err != nil {
+				c.finish(err)
+				return
+			}
+
+			sent, err := conn.Write(payload)
+			stats.BytesSent += uint64(sent)
+			if err != nil {
+				log.Warn(logPrefix, "Failed to send payload: ", err)
+				c.finish(err)
+				return
+			}
+
+			received, err := io.ReadAtLeast(conn, echo, sent)
+			stats.BytesReceived += uint64(received)
+			if err != nil {
+				log.Warn(logPrefix, "Failed to receive payload: ", err)
+				c.finish(err)
+				return
+			}
+
+			c.statisticsChannel <- stats
+		case <-dropTimer:
+			log.Info(logPrefix, "Dropping connection as requested by provider")
+			c.finish(ErrConnectionDropped)
+			return
+		case <-c.stopChannel:
+			return
+		}
+	}
+}
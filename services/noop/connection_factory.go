@@ -18,6 +18,8 @@
 package noop
 
 import (
+	"time"
+
 	"github.com/mysteriumnetwork/node/core/connection"
 )
 
@@ -27,7 +29,9 @@ type ConnectionFactory struct{}
 // Create creates a new noop connnection
 func (cf *ConnectionFactory) Create(stateChannel connection.StateChannel, statisticsChannel connection.StatisticsChannel) (connection.Connection, error) {
 	return &Connection{
-		stateChannel: stateChannel,
+		stateChannel:      stateChannel,
+		statisticsChannel: statisticsChannel,
+		payloadInterval:   time.Second,
 	}, nil
 }
 
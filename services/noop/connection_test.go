@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package noop
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/stretchr/testify/assert"
+)
+
+func startEchoProvider(t *testing.T, protocol string, dropAfter int) (*Manager, json.RawMessage) {
+	manager := NewManager("", Options{Protocol: protocol, Address: "127.0.0.1:0", DropAfter: dropAfter})
+	go manager.Serve(providerID)
+
+	sessionConfig := waitForConfig(t, manager)
+	configJSON, err := json.Marshal(sessionConfig)
+	assert.NoError(t, err)
+	return manager, configJSON
+}
+
+func newTestConnection() *Connection {
+	return &Connection{
+		stateChannel:      make(connection.StateChannel, 10),
+		statisticsChannel: make(connection.StatisticsChannel, 10),
+		payloadInterval:   time.Millisecond,
+	}
+}
+
+func Test_Connection_ExchangesPayload(t *testing.T) {
+	for _, protocol := range []string{"tcp", "udp"} {
+		manager, configJSON := startEchoProvider(t, protocol, 0)
+
+		conn := newTestConnection()
+		err := conn.Start(connection.ConnectOptions{SessionConfig: configJSON})
+		assert.NoError(t, err)
+		assert.Equal(t, connection.Connecting, <-conn.stateChannel)
+		assert.Equal(t, connection.Connected, <-conn.stateChannel)
+
+		stats := <-conn.statisticsChannel
+		assert.Equal(t, consumer.SessionStatistics{BytesSent: payloadSize, BytesReceived: payloadSize}, stats)
+
+		go func() {
+			for range conn.statisticsChannel {
+			}
+		}()
+		conn.Stop()
+		assert.NoError(t, conn.Wait())
+		manager.Stop()
+	}
+}
+
+func Test_Connection_DropsAfterRequestedTime(t *testing.T) {
+	manager, configJSON := startEchoProvider(t, "tcp", 1)
+	defer manager.Stop()
+
+	conn := newTestConnection()
+	err := conn.Start(connection.ConnectOptions{SessionConfig: configJSON})
+	assert.NoError(t, err)
+
+	go func() {
+		for range conn.statisticsChannel {
+		}
+	}()
+
+	assert.Equal(t, ErrConnectionDropped, conn.Wait())
+}
+
+func Test_Connection_FailsWhenProviderIsUnreachable(t *testing.T) {
+	manager, configJSON := startEchoProvider(t, "tcp", 0)
+	manager.Stop()
+
+	conn := newTestConnection()
+	err := conn.Start(connection.ConnectOptions{SessionConfig: configJSON})
+	assert.Error(t, err)
+	assert.Error(t, conn.Wait())
+}
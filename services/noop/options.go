@@ -24,12 +24,64 @@ import (
 	"github.com/urfave/cli"
 )
 
+// Options describes options of the synthetic Noop service
+type Options struct {
+	// Protocol of the loopback payload socket (udp or tcp). No traffic is exchanged if empty.
+	Protocol string `json:"protocol,omitempty"`
+	// Address for the payload socket to listen on
+	Address string `json:"address,omitempty"`
+	// DropAfter makes consumer connection fail after given number of seconds
+	DropAfter int `json:"dropAfter,omitempty"`
+	// RefuseConfig makes provider refuse every session config request
+	RefuseConfig bool `json:"refuseConfig,omitempty"`
+}
+
+var (
+	protocolFlag = cli.StringFlag{
+		Name:  "noop.proto",
+		Usage: "Protocol of the loopback traffic. Options: { udp, tcp }. No traffic is generated if not set",
+	}
+	addressFlag = cli.StringFlag{
+		Name:  "noop.address",
+		Usage: "Address to listen for the loopback traffic on",
+		Value: defaultOptions.Address,
+	}
+	dropAfterFlag = cli.IntFlag{
+		Name:  "noop.drop-after",
+		Usage: "Drop consumer connections after given number of seconds. Never dropped if not set",
+	}
+	refuseConfigFlag = cli.BoolFlag{
+		Name:  "noop.refuse-config",
+		Usage: "Refuse session config requests of consumers",
+	}
+
+	defaultOptions = Options{
+		Address: "127.0.0.1:0",
+	}
+)
+
+// RegisterFlags function register Noop flags to flag list
+func RegisterFlags(flags *[]cli.Flag) {
+	*flags = append(*flags, protocolFlag, addressFlag, dropAfterFlag, refuseConfigFlag)
+}
+
 // ParseFlags function fills in Noop options from CLI context
-func ParseFlags(_ *cli.Context) service.Options {
-	return nil
+func ParseFlags(ctx *cli.Context) (service.Options, error) {
+	return Options{
+		Protocol:     ctx.String(protocolFlag.Name),
+		Address:      ctx.String(addressFlag.Name),
+		DropAfter:    ctx.Int(dropAfterFlag.Name),
+		RefuseConfig: ctx.Bool(refuseConfigFlag.Name),
+	}, nil
 }
 
 // ParseJSONOptions function fills in Noop options from JSON request
-func ParseJSONOptions(_ *json.RawMessage) (service.Options, error) {
-	return nil, nil
+func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
+	if request == nil {
+		return defaultOptions, nil
+	}
+
+	opts := defaultOptions
+	err := json.Unmarshal(*request, &opts)
+	return opts, err
 }
@@ -28,13 +28,13 @@ func Test_ParseJSONOptions_HandlesNil(t *testing.T) {
 	options, err := ParseJSONOptions(nil)
 
 	assert.NoError(t, err)
-	assert.Equal(t, nil, options)
+	assert.Equal(t, defaultOptions, options)
 }
 
 func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
-	request := json.RawMessage(`{"port": 1123}`)
+	request := json.RawMessage(`{"protocol": "tcp", "dropAfter": 10, "refuseConfig": true}`)
 	options, err := ParseJSONOptions(&request)
 
 	assert.NoError(t, err)
-	assert.Equal(t, nil, options)
+	assert.Equal(t, Options{Protocol: "tcp", Address: "127.0.0.1:0", DropAfter: 10, RefuseConfig: true}, options)
 }
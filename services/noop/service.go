@@ -19,7 +19,8 @@ package noop
 
 import (
 	"encoding/json"
-	"errors"
+	"io"
+	"net"
 	"sync"
 
 	log "github.com/cihub/seelog"
@@ -27,6 +28,7 @@ import (
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/session"
+	"github.com/pkg/errors"
 )
 
 const logPrefix = "[service-noop] "
@@ -34,24 +36,60 @@ const logPrefix = "[service-noop] "
 // ErrAlreadyStarted is the error we return when the start is called multiple times
 var ErrAlreadyStarted = errors.New("Service already started")
 
+// ErrConfigRefused is returned for session config requests when provider is set to refuse them
+var ErrConfigRefused = errors.New("session config refused")
+
 // NewManager creates new instance of Noop service
-func NewManager() *Manager {
-	return &Manager{}
+func NewManager(publicIP string, options Options) *Manager {
+	return &Manager{
+		publicIP: publicIP,
+		options:  options,
+	}
 }
 
 // Manager represents entrypoint for Noop service
 type Manager struct {
-	process sync.WaitGroup
+	process  sync.WaitGroup
+	publicIP string
+	options  Options
+
+	socketLock sync.Mutex
+	socket     io.Closer
+	address    string
 }
 
 // ProvideConfig provides the session configuration
 func (manager *Manager) ProvideConfig(cfg json.RawMessage) (session.ServiceConfiguration, session.DestroyCallback, error) {
-	return nil, nil, nil
+	if manager.options.RefuseConfig {
+		return nil, nil, ErrConfigRefused
+	}
+	if manager.options.Protocol == "" {
+		return nil, nil, nil
+	}
+
+	manager.socketLock.Lock()
+	defer manager.socketLock.Unlock()
+
+	if manager.socket == nil {
+		return nil, nil, errors.New("payload socket is not open yet")
+	}
+
+	return ServiceConfig{
+		Protocol:  manager.options.Protocol,
+		Address:   manager.address,
+		DropAfter: manager.options.DropAfter,
+	}, nil, nil
 }
 
 // Serve starts service - does block
 func (manager *Manager) Serve(providerID identity.Identity) error {
 	manager.process.Add(1)
+	if manager.options.Protocol != "" {
+		if err := manager.openSocket(); err != nil {
+			return err
+		}
+	}
+
 	log.Info(logPrefix, "Noop service started successfully")
 	manager.process.Wait()
 	return nil
@@ -59,11 +97,88 @@ func (manager *Manager) Serve(providerID identity.Identity) error {
 
 // Stop stops service
 func (manager *Manager) Stop() error {
+	manager.socketLock.Lock()
+	if manager.socket != nil {
+		if err := manager.socket.Close(); err != nil {
+			log.Warn(logPrefix, "Failed to close payload socket: ", err)
+		}
+		manager.socket = nil
+	}
+	manager.socketLock.Unlock()
+
 	manager.process.Done()
 	log.Info(logPrefix, "Noop service stopped")
 	return nil
 }
 
+// openSocket starts echoing payload received from consumers on a local socket
+func (manager *Manager) openSocket() error {
+	manager.socketLock.Lock()
+	defer manager.socketLock.Unlock()
+
+	var addr net.Addr
+	switch manager.options.Protocol {
+	case "tcp":
+		listener, err := net.Listen("tcp", manager.options.Address)
+		if err != nil {
+			return errors.Wrap(err, "failed to listen for payload")
+		}
+		go serveStream(listener)
+		manager.socket, addr = listener, listener.Addr()
+	case "udp":
+		conn, err := net.ListenPacket("udp", manager.options.Address)
+		if err != nil {
+			return errors.Wrap(err, "failed to listen for payload")
+		}
+		go servePackets(conn)
+		manager.socket, addr = conn, conn.LocalAddr()
+	default:
+		return errors.Errorf("unsupported payload protocol: %s", manager.options.Protocol)
+	}
+
+	manager.address = advertisedAddress(addr, manager.publicIP)
+	log.Info(logPrefix, "Serving payload on ", manager.options.Protocol, "/", manager.address)
+	return nil
+}
+
+func serveStream(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+	}
+}
+
+func servePackets(conn net.PacketConn) {
+	buffer := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		if _, err := conn.WriteTo(buffer[:n], addr); err != nil {
+			log.Warn(logPrefix, "Failed to echo payload: ", err)
+		}
+	}
+}
+
+// advertisedAddress replaces wildcard listening host with the public one
+func advertisedAddress(addr net.Addr, publicIP string) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() && publicIP != "" {
+		host = publicIP
+	}
+	return net.JoinHostPort(host, port)
+}
+
 // GetProposal returns the proposal for NOOP service for given country
 func GetProposal(country string) market.ServiceProposal {
 	return market.ServiceProposal{
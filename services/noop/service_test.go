@@ -25,6 +25,7 @@ import (
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/session"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,7 +33,7 @@ var (
 	providerID = identity.FromAddress("provider-id")
 )
 
-var _ service.Service = NewManager()
+var _ service.Service = NewManager("", Options{})
 
 func Test_GetProposal(t *testing.T) {
 	country := "LT"
@@ -57,15 +58,48 @@ func Test_GetProposal(t *testing.T) {
 }
 
 func Test_Manager_ProvideConfig(t *testing.T) {
-	manager := NewManager()
+	manager := NewManager("", Options{})
 	sessionConfig, cb, err := manager.ProvideConfig(nil)
 	assert.NoError(t, err)
 	assert.Nil(t, sessionConfig)
 	assert.Nil(t, cb)
 }
 
+func Test_Manager_ProvideConfig_Refused(t *testing.T) {
+	manager := NewManager("", Options{RefuseConfig: true})
+	_, _, err := manager.ProvideConfig(nil)
+	assert.Equal(t, ErrConfigRefused, err)
+}
+
+func Test_Manager_ProvideConfig_PayloadSocket(t *testing.T) {
+	manager := NewManager("", Options{Protocol: "tcp", Address: "127.0.0.1:0", DropAfter: 5})
+	go func() {
+		err := manager.Serve(providerID)
+		assert.NoError(t, err)
+	}()
+	defer manager.Stop()
+
+	config := waitForConfig(t, manager).(ServiceConfig)
+	assert.Equal(t, "tcp", config.Protocol)
+	assert.Equal(t, 5, config.DropAfter)
+	assert.NotEqual(t, "127.0.0.1:0", config.Address)
+}
+
+// waitForConfig polls manager until Serve opens the payload socket
+func waitForConfig(t *testing.T, manager *Manager) session.ServiceConfiguration {
+	for i := 0; i < 1000; i++ {
+		sessionConfig, _, err := manager.ProvideConfig(nil)
+		if err == nil {
+			return sessionConfig
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("payload socket was not opened")
+	return nil
+}
+
 func Test_Manager_Serve_Stop(t *testing.T) {
-	manager := NewManager()
+	manager := NewManager("", Options{})
 	go func() {
 		err := manager.Serve(providerID)
 		assert.NoError(t, err)
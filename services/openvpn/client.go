@@ -106,4 +106,16 @@ type VPNConfig struct {
 	RemoteProtocol  string `json:"protocol"`
 	TLSPresharedKey string `json:"TLSPresharedKey"`
 	CACertificate   string `json:"CACertificate"`
+
+	// Remotes lists alternative server endpoints, tried in given order after the primary one
+	Remotes []VPNRemote `json:"remotes,omitempty"`
+
+	// Options carries tunnel parameters set by provider
+	Options SessionOptions `json:"options"`
+}
+
+// VPNRemote structure represents alternative endpoint of the same VPN server
+type VPNRemote struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
 }
@@ -55,8 +55,38 @@ func (c *ClientConfig) SetClientMode(serverIP string, serverPort, localPort int)
 func (c *ClientConfig) SetProtocol(protocol string) {
 	if protocol == "tcp" {
 		c.SetParam("proto", "tcp-client")
-	} else if protocol == "udp" {
-		c.SetFlag("explicit-exit-notify")
+	}
+}
+
+// SetExitNotify makes client notify server on exit, which openvpn allows only when every remote is reached over udp
+func (c *ClientConfig) SetExitNotify(protocols ...string) {
+	for _, protocol := range protocols {
+		if protocol != "udp" {
+			return
+		}
+	}
+	c.SetFlag("explicit-exit-notify")
+}
+
+// AddRemote adds alternative server endpoint which is tried when the previous ones are unreachable
+func (c *ClientConfig) AddRemote(serverIP string, serverPort int, protocol string) {
+	if protocol == "tcp" {
+		protocol = "tcp-client"
+	}
+	c.SetParam("remote", serverIP, strconv.Itoa(serverPort), protocol)
+}
+
+// SetSessionOptions applies tunnel parameters received from provider, falling back to defaults for unset ones
+func (c *ClientConfig) SetSessionOptions(options SessionOptions) {
+	c.SetKeepAlive(options.keepAlivePing(), options.keepAliveTimeout())
+	for _, dns := range options.dns() {
+		c.SetParam("dhcp-option", "DNS", dns)
+	}
+	if options.MTU > 0 {
+		c.SetParam("tun-mtu", strconv.Itoa(options.MTU))
+	}
+	if options.MSSFix > 0 {
+		c.SetParam("mssfix", strconv.Itoa(options.MSSFix))
 	}
 }
 
@@ -67,15 +97,12 @@ func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConf
 	clientConfig.SetParam("cipher", "AES-256-GCM")
 	clientConfig.SetParam("verb", "3")
 	clientConfig.SetParam("tls-cipher", "TLS-ECDHE-ECDSA-WITH-AES-256-GCM-SHA384")
-	clientConfig.SetKeepAlive(10, 60)
 	clientConfig.SetPingTimerRemote()
 	clientConfig.SetPersistKey()
 
 	clientConfig.SetParam("reneg-sec", "60")
 	clientConfig.SetParam("resolv-retry", "infinite")
 	clientConfig.SetParam("redirect-gateway", "def1", "bypass-dhcp")
-	clientConfig.SetParam("dhcp-option", "DNS", "208.67.222.222")
-	clientConfig.SetParam("dhcp-option", "DNS", "208.67.220.220")
 
 	return &clientConfig
 }
@@ -100,8 +127,15 @@ func NewClientConfigFromSession(sessionConfig []byte, configDir string, runtimeD
 	clientFileConfig.SetReconnectRetry(2)
 	clientFileConfig.SetClientMode(vpnConfig.RemoteIP, vpnConfig.RemotePort, vpnConfig.LocalPort)
 	clientFileConfig.SetProtocol(vpnConfig.RemoteProtocol)
+	protocols := []string{vpnConfig.RemoteProtocol}
+	for _, remote := range vpnConfig.Remotes {
+		clientFileConfig.AddRemote(vpnConfig.RemoteIP, remote.Port, remote.Protocol)
+		protocols = append(protocols, remote.Protocol)
+	}
+	clientFileConfig.SetExitNotify(protocols...)
 	clientFileConfig.SetTLSCACertificate(vpnConfig.CACertificate)
 	clientFileConfig.SetTLSCrypt(vpnConfig.TLSPresharedKey)
+	clientFileConfig.SetSessionOptions(vpnConfig.Options)
 
 	return clientFileConfig, nil
 }
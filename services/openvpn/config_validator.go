@@ -45,6 +45,8 @@ func NewDefaultValidator() *ConfigValidator {
 			validIPFormat,
 			validTLSPresharedKey,
 			validCACertificate,
+			validRemotes,
+			validSessionOptions,
 		},
 	}
 }
@@ -76,6 +78,23 @@ func validPort(config *VPNConfig) error {
 	return nil
 }
 
+// alternative remotes are allowed on well known ports too, i.e. tcp/443 for restrictive networks
+func validRemotes(config *VPNConfig) error {
+	for _, remote := range config.Remotes {
+		if err := validProtocol(&VPNConfig{RemoteProtocol: remote.Protocol}); err != nil {
+			return err
+		}
+		if remote.Port > 65535 || remote.Port < 1 {
+			return errors.New("invalid remote port range, should fall within 1 .. 65535 range")
+		}
+	}
+	return nil
+}
+
+func validSessionOptions(config *VPNConfig) error {
+	return config.Options.Validate()
+}
+
 func validIPFormat(config *VPNConfig) error {
 	parsed := net.ParseIP(config.RemoteIP)
 	if parsed == nil {
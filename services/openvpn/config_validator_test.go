@@ -68,6 +68,8 @@ func TestValidatorReturnsNilErrorOnValidVPNConfig(t *testing.T) {
 		"tcp",
 		tlsTestKey,
 		caCertificate,
+		[]VPNRemote{{Port: 443, Protocol: "tcp"}},
+		SessionOptions{DNS: []string{"1.1.1.1"}, MTU: 1400, MSSFix: 1360},
 	}
 	assert.NoError(t, NewDefaultValidator().IsValid(vpnConfig))
 }
@@ -87,6 +89,31 @@ func TestPortOutOfRangeIsNotAllowed(t *testing.T) {
 	assert.Error(t, validPort(&vpnConfig))
 }
 
+func TestInvalidRemotesAreNotAllowed(t *testing.T) {
+	vpnConfig := VPNConfig{Remotes: []VPNRemote{{Port: 443, Protocol: "fake_protocol"}}}
+	assert.Error(t, validRemotes(&vpnConfig))
+
+	vpnConfig = VPNConfig{Remotes: []VPNRemote{{Port: 0, Protocol: "tcp"}}}
+	assert.Error(t, validRemotes(&vpnConfig))
+}
+
+func TestSessionOptionsOutOfWhitelistAreNotAllowed(t *testing.T) {
+	assert.NoError(t, validSessionOptions(&VPNConfig{}))
+
+	invalid := []SessionOptions{
+		{DNS: []string{"not-an-ip"}},
+		{DNS: []string{"2001:db8::1"}},
+		{DNS: []string{"1.1.1.1", "1.0.0.1", "8.8.8.8", "8.8.4.4", "9.9.9.9"}},
+		{MTU: 9000},
+		{MTU: 1400, MSSFix: 1450},
+		{KeepAlivePing: 30, KeepAliveTimeout: 40},
+		{KeepAliveTimeout: 7200},
+	}
+	for _, options := range invalid {
+		assert.Error(t, validSessionOptions(&VPNConfig{Options: options}), "%+v", options)
+	}
+}
+
 func TestTLSPresharedKeyIsValid(t *testing.T) {
 	vpnConfig := VPNConfig{TLSPresharedKey: tlsTestKey}
 	assert.NoError(t, validTLSPresharedKey(&vpnConfig))
@@ -33,6 +33,9 @@ type ServiceDefinition struct {
 
 	// Transport protocol used by service
 	Protocol string `json:"protocol,omitempty"`
+
+	// All transport protocols the service listens on, in the order of preference
+	Protocols []string `json:"protocols,omitempty"`
 }
 
 // GetLocation returns geographic location of service definition provider
@@ -30,8 +30,13 @@ import (
 // NewServiceProposalWithLocation creates service proposal description for openvpn service
 func NewServiceProposalWithLocation(
 	serviceLocation market.Location,
-	protocol string,
+	protocols []string,
 ) market.ServiceProposal {
+	var protocol string
+	if len(protocols) > 0 {
+		protocol = protocols[0]
+	}
+
 	return market.ServiceProposal{
 		ServiceType: openvpn.ServiceType,
 		ServiceDefinition: dto.ServiceDefinition{
@@ -39,6 +44,7 @@ func NewServiceProposalWithLocation(
 			LocationOriginate: serviceLocation,
 			SessionBandwidth:  dto.Bandwidth(10 * datasize.MB),
 			Protocol:          protocol,
+			Protocols:         protocols,
 		},
 		PaymentMethodType: dto.PaymentMethodPerTime,
 		PaymentMethod: dto.PaymentPerTime{
@@ -29,11 +29,11 @@ import (
 
 var (
 	locationLTTelia = market.Location{"LT", "Vilnius", "AS8764"}
-	protocol        = "tcp"
+	protocols       = []string{"tcp", "udp"}
 )
 
 func Test_NewServiceProposalWithLocation(t *testing.T) {
-	proposal := NewServiceProposalWithLocation(locationLTTelia, protocol)
+	proposal := NewServiceProposalWithLocation(locationLTTelia, protocols)
 
 	assert.Exactly(
 		t,
@@ -44,6 +44,7 @@ func Test_NewServiceProposalWithLocation(t *testing.T) {
 				LocationOriginate: locationLTTelia,
 				SessionBandwidth:  83886080,
 				Protocol:          "tcp",
+				Protocols:         []string{"tcp", "udp"},
 			},
 
 			PaymentMethodType: "PER_TIME",
@@ -55,3 +56,11 @@ func Test_NewServiceProposalWithLocation(t *testing.T) {
 		proposal,
 	)
 }
+
+func Test_NewServiceProposalWithLocation_NoProtocols(t *testing.T) {
+	proposal := NewServiceProposalWithLocation(locationLTTelia, nil)
+
+	definition := proposal.ServiceDefinition.(dto.ServiceDefinition)
+	assert.Equal(t, "", definition.Protocol)
+	assert.Empty(t, definition.Protocols)
+}
@@ -18,6 +18,8 @@
 package openvpn
 
 import (
+	"strconv"
+
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/config"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
 )
@@ -49,6 +51,17 @@ func (c *ServerConfig) SetProtocol(protocol string) {
 	}
 }
 
+// SetSessionOptions applies tunnel parameters which has to match on both ends of the session
+func (c *ServerConfig) SetSessionOptions(options SessionOptions) {
+	c.SetKeepAlive(options.keepAlivePing(), options.keepAliveTimeout())
+	if options.MTU > 0 {
+		c.SetParam("tun-mtu", strconv.Itoa(options.MTU))
+	}
+	if options.MSSFix > 0 {
+		c.SetParam("mssfix", strconv.Itoa(options.MSSFix))
+	}
+}
+
 // NewServerConfig creates server configuration structure from given basic parameters
 func NewServerConfig(
 	runtimeDir string,
@@ -57,6 +70,7 @@ func NewServerConfig(
 	secPrimitives *tls.Primitives,
 	port int,
 	protocol string,
+	sessionOptions SessionOptions,
 ) *ServerConfig {
 	serverConfig := ServerConfig{config.NewConfig(runtimeDir, configDir)}
 	serverConfig.SetServerMode(port, network, netmask)
@@ -76,7 +90,7 @@ func NewServerConfig(
 	serverConfig.SetParam("verify-client-cert", "none")
 	serverConfig.SetParam("tls-cipher", "TLS-ECDHE-ECDSA-WITH-AES-256-GCM-SHA384")
 	serverConfig.SetParam("reneg-sec", "60")
-	serverConfig.SetKeepAlive(10, 60)
+	serverConfig.SetSessionOptions(sessionOptions)
 	serverConfig.SetPingTimerRemote()
 	serverConfig.SetPersistKey()
 
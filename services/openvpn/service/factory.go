@@ -20,12 +20,14 @@ package service
 import (
 	"crypto/x509/pkix"
 	"encoding/json"
+	"net"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/middlewares/server/auth"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/middlewares/state"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
+	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/identity"
@@ -44,13 +46,15 @@ func NewManager(
 	sessionMap openvpn_session.SessionMap,
 	natService nat.NATService,
 	natPinger NATPinger,
-	mapPort func() (releasePortMapping func()),
+	mapPort func(protocol string, port int) (releasePortMapping func()),
 	lastSessionShutdown chan struct{},
 	natEventGetter NATEventGetter,
+	subnetPool *ip.SubnetAllocator,
 ) *Manager {
 	sessionValidator := openvpn_session.NewValidator(sessionMap, identity.NewExtractor())
 
-	serverFactory := newServerFactory(nodeOptions, sessionValidator)
+	listenerServerFactory := newServerFactory(nodeOptions, sessionValidator)
+	serverFactory := listenerServerFactory
 	if lastSessionShutdown != nil {
 		serverFactory = newRestartingServerFactory(nodeOptions, sessionValidator, natPinger, lastSessionShutdown)
 	}
@@ -63,24 +67,26 @@ func NewManager(
 		sessionConfigNegotiatorFactory: newSessionConfigNegotiatorFactory(nodeOptions.OptionsNetwork, serviceOptions, natEventGetter),
 		vpnServerConfigFactory:         newServerConfigFactory(nodeOptions, serviceOptions),
 		vpnServerFactory:               serverFactory,
+		vpnListenerServerFactory:       listenerServerFactory,
 		natPinger:                      natPinger,
 		serviceOptions:                 serviceOptions,
 		mapPort:                        mapPort,
 		natEventGetter:                 natEventGetter,
+		subnetPool:                     subnetPool,
 	}
 }
 
 // newServerConfigFactory returns function generating server config and generates required security primitives
 func newServerConfigFactory(nodeOptions node.Options, serviceOptions Options) ServerConfigFactory {
-	return func(secPrimitives *tls.Primitives) *openvpn_service.ServerConfig {
-		// TODO: check nodeOptions for --openvpn-transport option
+	return func(secPrimitives *tls.Primitives, listener Listener, subnet net.IPNet) *openvpn_service.ServerConfig {
 		return openvpn_service.NewServerConfig(
 			nodeOptions.Directories.Runtime,
 			nodeOptions.Directories.Config,
-			"10.8.0.0", "255.255.255.0",
+			subnet.IP.String(), net.IP(subnet.Mask).String(),
 			secPrimitives,
-			serviceOptions.Port,
-			serviceOptions.Protocol,
+			listener.Port,
+			listener.Protocol,
+			serviceOptions.SessionOptions,
 		)
 	}
 }
@@ -119,6 +125,12 @@ func newRestartingServerFactory(nodeOptions node.Options, sessionValidator *open
 func newSessionConfigNegotiatorFactory(networkOptions node.OptionsNetwork, serviceOptions Options, natEventGetter NATEventGetter) SessionConfigNegotiatorFactory {
 	return func(secPrimitives *tls.Primitives, outboundIP, publicIP string) session.ConfigNegotiator {
 		serverIP := vpnServerIP(serviceOptions, outboundIP, publicIP, networkOptions.Localnet)
+
+		var remotes []openvpn_service.VPNRemote
+		for _, listener := range serviceOptions.Listeners {
+			remotes = append(remotes, openvpn_service.VPNRemote{Port: listener.Port, Protocol: listener.Protocol})
+		}
+
 		return &OpenvpnConfigNegotiator{
 			natEventGetter: natEventGetter,
 			vpnConfig: openvpn_service.VPNConfig{
@@ -127,6 +139,8 @@ func newSessionConfigNegotiatorFactory(networkOptions node.OptionsNetwork, servi
 				RemoteProtocol:  serviceOptions.Protocol,
 				TLSPresharedKey: secPrimitives.PresharedKey.ToPEMFormat(),
 				CACertificate:   secPrimitives.CertificateAuthority.ToPEMFormat(),
+				Remotes:         remotes,
+				Options:         serviceOptions.SessionOptions,
 			},
 		}
 	}
@@ -19,10 +19,13 @@ package service
 
 import (
 	"encoding/json"
+	"net"
+	"sync"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
+	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -35,8 +38,8 @@ import (
 
 const logPrefix = "[service-openvpn] "
 
-// ServerConfigFactory callback generates session config for remote client
-type ServerConfigFactory func(*tls.Primitives) *openvpn_service.ServerConfig
+// ServerConfigFactory callback generates server config for given listener and its subnet
+type ServerConfigFactory func(secPrimitives *tls.Primitives, listener Listener, subnet net.IPNet) *openvpn_service.ServerConfig
 
 // ServerFactory initiates Openvpn server instance during runtime
 type ServerFactory func(*openvpn_service.ServerConfig) openvpn.Process
@@ -62,10 +65,18 @@ type NATEventGetter interface {
 // Manager represents entrypoint for Openvpn service with top level components
 type Manager struct {
 	natService     nat.NATService
-	mapPort        func() (releasePortMapping func())
-	releasePorts   func()
+	mapPort        func(protocol string, port int) (releasePortMapping func())
 	natPinger      NATPinger
 	natEventGetter NATEventGetter
+	subnetPool     *ip.SubnetAllocator
+
+	// lock guards resources reserved by Serve, which Stop may release concurrently
+	lock          sync.Mutex
+	releasePorts  []func()
+	firewallRules []Listener
+	subnets       []net.IPNet
+	natRules      []nat.RuleForwarding
+	vpnServers    []openvpn.Process
 
 	sessionConfigNegotiatorFactory SessionConfigNegotiatorFactory
 	consumerConfig                 openvpn_service.ConsumerConfig
@@ -73,7 +84,7 @@ type Manager struct {
 	vpnServerConfigFactory   ServerConfigFactory
 	vpnServiceConfigProvider session.ConfigNegotiator
 	vpnServerFactory         ServerFactory
-	vpnServer                openvpn.Process
+	vpnListenerServerFactory ServerFactory
 
 	publicIP        string
 	outboundIP      string
@@ -83,57 +94,151 @@ type Manager struct {
 
 // Serve starts service - does block
 func (m *Manager) Serve(providerID identity.Identity) (err error) {
-	err = m.natService.Add(nat.RuleForwarding{
-		SourceAddress: "10.8.0.0/24",
-		TargetIP:      m.outboundIP,
-	})
-	if err != nil {
-		return errors.Wrap(err, "failed to add NAT forwarding rule")
+	if err := m.serviceOptions.SessionOptions.Validate(); err != nil {
+		return errors.Wrap(err, "invalid session options")
+	}
+
+	if err := m.start(providerID); err != nil {
+		m.release()
+		return err
 	}
 
-	m.releasePorts = m.mapPort()
+	log.Info(logPrefix, "openvpn server waiting")
+	return m.waitServers()
+}
+
+// start reserves resources for every listener and starts its openvpn server
+func (m *Manager) start(providerID identity.Identity) error {
+	listeners := m.serviceOptions.AllListeners()
+	for _, listener := range listeners {
+		subnet, err := m.subnetPool.Allocate(24)
+		if err != nil {
+			return errors.Wrap(err, "failed to allocate subnet")
+		}
+		m.lock.Lock()
+		m.subnets = append(m.subnets, subnet)
+		m.lock.Unlock()
+
+		natRule := nat.RuleForwarding{
+			SourceAddress: subnet.String(),
+			TargetIP:      m.outboundIP,
+		}
+		if err := m.natService.Add(natRule); err != nil {
+			return errors.Wrap(err, "failed to add NAT forwarding rule")
+		}
+		releasePorts := m.mapPort(listener.Protocol, listener.Port)
+
+		m.lock.Lock()
+		m.natRules = append(m.natRules, natRule)
+		m.releasePorts = append(m.releasePorts, releasePorts)
+		m.lock.Unlock()
+	}
 
 	primitives, err := primitiveFactory(m.currentLocation, providerID.Address)
 	if err != nil {
-		return
+		return err
 	}
 
 	m.vpnServiceConfigProvider = m.sessionConfigNegotiatorFactory(primitives, m.outboundIP, m.publicIP)
 
-	vpnServerConfig := m.vpnServerConfigFactory(primitives)
-	m.vpnServer = m.vpnServerFactory(vpnServerConfig)
+	subnets := m.Subnets()
+	servers := make([]openvpn.Process, len(listeners))
+	for i, listener := range listeners {
+		vpnServerConfig := m.vpnServerConfigFactory(primitives, listener, subnets[i])
+		// only the primary listener takes part in NAT hole punching
+		if i == 0 {
+			servers[i] = m.vpnServerFactory(vpnServerConfig)
+		} else {
+			servers[i] = m.vpnListenerServerFactory(vpnServerConfig)
+		}
+	}
 
 	// block until NATPinger punches the hole in NAT for first incoming connect or continues if service not behind NAT
 	m.natPinger.BindPort(m.serviceOptions.Port)
 
-	log.Info(logPrefix, "starting openvpn server")
-	if err := firewall.AddInboundRule(m.serviceOptions.Protocol, m.serviceOptions.Port); err != nil {
-		return errors.Wrap(err, "failed to add firewall rule")
-	}
+	for i, listener := range listeners {
+		log.Infof("%sstarting openvpn server on %s/%d", logPrefix, listener.Protocol, listener.Port)
+		if err := firewall.AddInboundRule(listener.Protocol, listener.Port); err != nil {
+			return errors.Wrap(err, "failed to add firewall rule")
+		}
+		m.lock.Lock()
+		m.firewallRules = append(m.firewallRules, listener)
+		m.lock.Unlock()
 
-	if err = m.vpnServer.Start(); err != nil {
-		return
+		if err := servers[i].Start(); err != nil {
+			return err
+		}
+		m.lock.Lock()
+		m.vpnServers = append(m.vpnServers, servers[i])
+		m.lock.Unlock()
 	}
-	log.Info(logPrefix, "openvpn server waiting")
+	return nil
+}
+
+// waitServers blocks until any of the listening servers exits
+func (m *Manager) waitServers() error {
+	m.lock.Lock()
+	servers := m.vpnServers
+	m.lock.Unlock()
 
-	return m.vpnServer.Wait()
+	exits := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server openvpn.Process) {
+			exits <- server.Wait()
+		}(server)
+	}
+	return <-exits
 }
 
 // Stop stops service
 func (m *Manager) Stop() (err error) {
-	if m.releasePorts != nil {
-		m.releasePorts()
+	m.release()
+	return nil
+}
+
+// release frees everything reserved by the service so far
+func (m *Manager) release() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, releasePorts := range m.releasePorts {
+		releasePorts()
 	}
+	m.releasePorts = nil
 
-	if err := firewall.RemoveInboundRule(m.serviceOptions.Protocol, m.serviceOptions.Port); err != nil {
-		log.Error(logPrefix, "Failed to delete firewall rule for OpenVPN", err)
+	for _, listener := range m.firewallRules {
+		if err := firewall.RemoveInboundRule(listener.Protocol, listener.Port); err != nil {
+			log.Error(logPrefix, "Failed to delete firewall rule for OpenVPN", err)
+		}
 	}
+	m.firewallRules = nil
 
-	if m.vpnServer != nil {
-		m.vpnServer.Stop()
+	for _, server := range m.vpnServers {
+		server.Stop()
 	}
+	m.vpnServers = nil
 
-	return nil
+	for _, natRule := range m.natRules {
+		if err := m.natService.Del(natRule); err != nil {
+			log.Error(logPrefix, "Failed to delete NAT forwarding rule: ", err)
+		}
+	}
+	m.natRules = nil
+
+	for _, subnet := range m.subnets {
+		if err := m.subnetPool.Release(subnet); err != nil {
+			log.Error(logPrefix, "Failed to release subnet: ", err)
+		}
+	}
+	m.subnets = nil
+}
+
+// Subnets returns VPN subnets reserved for the service listeners
+func (m *Manager) Subnets() []net.IPNet {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]net.IPNet(nil), m.subnets...)
 }
 
 // ProvideConfig takes session creation config from end consumer and provides the service configuration to the end consumer
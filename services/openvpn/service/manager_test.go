@@ -18,8 +18,20 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/mysteriumnetwork/go-openvpn/openvpn"
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/nat"
+	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn"
+	"github.com/mysteriumnetwork/node/session"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +40,191 @@ func TestManager_StopNotPanic(t *testing.T) {
 	err := m.Stop()
 	assert.NoError(t, err)
 }
+
+func TestManager_ServeStartsEveryListener(t *testing.T) {
+	natService := &mockNATService{}
+	ports := &mockPortMapper{}
+	servers := []*MockOpenvpnProcess{
+		{stop: make(chan struct{})},
+		{stop: make(chan struct{})},
+	}
+	m := newTestManager(natService, ports, servers)
+
+	// servers exit right away so that Serve does not block
+	for _, server := range servers {
+		server.Stop()
+	}
+	err := m.Serve(identity.FromAddress("0x1"))
+	assert.NoError(t, err)
+
+	assert.Len(t, m.Subnets(), 2)
+	assert.Len(t, natService.added, 2)
+	assert.Equal(t, []string{"udp/1194", "tcp/443"}, ports.mapped)
+	assert.Len(t, m.vpnServers, 2)
+
+	assert.NoError(t, m.Stop())
+	assert.Empty(t, m.Subnets())
+	assert.Empty(t, m.subnetPool.Allocated())
+	assert.Len(t, natService.deleted, 2)
+	assert.Equal(t, 2, ports.released)
+}
+
+func TestManager_ServeReleasesResourcesWhenListenerFails(t *testing.T) {
+	natService := &mockNATService{}
+	ports := &mockPortMapper{}
+	servers := []*MockOpenvpnProcess{
+		{stop: make(chan struct{})},
+		{stop: make(chan struct{}), startError: errors.New("bind failed")},
+	}
+	m := newTestManager(natService, ports, servers)
+
+	err := m.Serve(identity.FromAddress("0x1"))
+	assert.EqualError(t, err, "bind failed")
+
+	assert.Empty(t, m.Subnets())
+	assert.Empty(t, m.subnetPool.Allocated())
+	assert.Len(t, natService.added, 2)
+	assert.Equal(t, natService.added, natService.deleted)
+	assert.Equal(t, 2, ports.released)
+	assert.Empty(t, m.vpnServers)
+
+	// the primary server was started and has to be stopped again
+	select {
+	case <-servers[0].stop:
+	default:
+		t.Error("primary server not stopped")
+	}
+}
+
+func newTestManager(natService nat.NATService, ports *mockPortMapper, servers []*MockOpenvpnProcess) *Manager {
+	serverFactory := func(config *openvpn_service.ServerConfig) openvpn.Process {
+		server := servers[0]
+		servers = servers[1:]
+		return server
+	}
+
+	return &Manager{
+		natService:     natService,
+		mapPort:        ports.mapPort,
+		natPinger:      &MockNATPinger{},
+		subnetPool:     ip.NewSubnetAllocator(net.IPNet{IP: net.ParseIP("10.8.0.0").To4(), Mask: net.CIDRMask(16, 32)}),
+		outboundIP:     "192.168.1.1",
+		serviceOptions: Options{Protocol: "udp", Port: 1194, Listeners: []Listener{{Protocol: "tcp", Port: 443}}},
+		sessionConfigNegotiatorFactory: func(secPrimitives *tls.Primitives, outboundIP, publicIP string) session.ConfigNegotiator {
+			return &mockConfigNegotiator{}
+		},
+		vpnServerConfigFactory: func(secPrimitives *tls.Primitives, listener Listener, subnet net.IPNet) *openvpn_service.ServerConfig {
+			return &openvpn_service.ServerConfig{}
+		},
+		vpnServerFactory:         serverFactory,
+		vpnListenerServerFactory: serverFactory,
+	}
+}
+
+type mockNATService struct {
+	added   []nat.RuleForwarding
+	deleted []nat.RuleForwarding
+}
+
+func (service *mockNATService) Enable() error { return nil }
+
+func (service *mockNATService) Add(rule nat.RuleForwarding) error {
+	service.added = append(service.added, rule)
+	return nil
+}
+
+func (service *mockNATService) Del(rule nat.RuleForwarding) error {
+	service.deleted = append(service.deleted, rule)
+	return nil
+}
+
+func (service *mockNATService) Disable() error { return nil }
+
+type mockPortMapper struct {
+	mapped   []string
+	released int
+}
+
+func (mapper *mockPortMapper) mapPort(protocol string, port int) func() {
+	mapper.mapped = append(mapper.mapped, fmt.Sprintf("%s/%d", protocol, port))
+	return func() {
+		mapper.released++
+	}
+}
+
+type mockConfigNegotiator struct{}
+
+func (negotiator *mockConfigNegotiator) ProvideConfig(consumerKey json.RawMessage) (session.ServiceConfiguration, session.DestroyCallback, error) {
+	return nil, nil, nil
+}
+
+func TestManager_SubnetsReturnsCopy(t *testing.T) {
+	servers := []*MockOpenvpnProcess{
+		{stop: make(chan struct{})},
+		{stop: make(chan struct{})},
+	}
+	m := newTestManager(&mockNATService{}, &mockPortMapper{}, servers)
+
+	for _, server := range servers {
+		server.Stop()
+	}
+	assert.NoError(t, m.Serve(identity.FromAddress("0x1")))
+
+	subnets := m.Subnets()
+	subnets[0] = net.IPNet{}
+	assert.NotEqual(t, net.IPNet{}, m.Subnets()[0])
+}
+
+func TestManager_SubnetsWhileServing(t *testing.T) {
+	servers := []*MockOpenvpnProcess{
+		{stop: make(chan struct{})},
+		{stop: make(chan struct{})},
+	}
+	m := newTestManager(&mockNATService{}, &mockPortMapper{}, servers)
+
+	served := make(chan error)
+	go func() {
+		served <- m.Serve(identity.FromAddress("0x1"))
+	}()
+
+	for len(m.Subnets()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	servers[0].Stop()
+	assert.NoError(t, <-served)
+
+	assert.NoError(t, m.Stop())
+	assert.Empty(t, m.Subnets())
+}
+
+func TestManager_StopWhileServingReleasesResources(t *testing.T) {
+	natService := &mockNATService{}
+	ports := &mockPortMapper{}
+	servers := []*MockOpenvpnProcess{
+		{stop: make(chan struct{})},
+		{stop: make(chan struct{})},
+	}
+	m := newTestManager(natService, ports, servers)
+
+	served := make(chan error)
+	go func() {
+		served <- m.Serve(identity.FromAddress("0x1"))
+	}()
+
+	for m.startedServers() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, m.Stop())
+	assert.NoError(t, <-served)
+
+	assert.Empty(t, m.Subnets())
+	assert.Equal(t, natService.added, natService.deleted)
+	assert.Equal(t, 2, ports.released)
+}
+
+func (m *Manager) startedServers() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.vpnServers)
+}
@@ -19,8 +19,13 @@ package service
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/mysteriumnetwork/node/core/service"
+	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
 
@@ -28,6 +33,24 @@ import (
 type Options struct {
 	Protocol string `json:"protocol"`
 	Port     int    `json:"port"`
+
+	// Listeners are additional protocol and port pairs served by the same service instance
+	Listeners []Listener `json:"listeners,omitempty"`
+
+	// SessionOptions are tunnel parameters pushed to the consumers
+	openvpn_service.SessionOptions
+}
+
+// Listener describes a single Openvpn server listening socket
+type Listener struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port"`
+}
+
+// AllListeners returns primary listener followed by the additional ones
+func (o Options) AllListeners() []Listener {
+	listeners := []Listener{{Protocol: o.Protocol, Port: o.Port}}
+	return append(listeners, o.Listeners...)
 }
 
 var (
@@ -41,6 +64,31 @@ var (
 		Usage: "Openvpn port to use. Default 1194",
 		Value: defaultOptions.Port,
 	}
+	listenersFlag = cli.StringFlag{
+		Name:  "openvpn.listeners",
+		Usage: "Additional Openvpn listeners served alongside the main one, e.g. tcp:443,udp:1195",
+	}
+	dnsFlag = cli.StringFlag{
+		Name:  "openvpn.dns",
+		Usage: "Comma separated list of DNS servers pushed to consumers",
+	}
+	mtuFlag = cli.IntFlag{
+		Name:  "openvpn.mtu",
+		Usage: "Tunnel MTU pushed to consumers (1280..1500). Openvpn default if not set",
+	}
+	mssFixFlag = cli.IntFlag{
+		Name:  "openvpn.mssfix",
+		Usage: "Maximum TCP segment size pushed to consumers. Openvpn default if not set",
+	}
+	keepAlivePingFlag = cli.IntFlag{
+		Name:  "openvpn.keepalive-ping",
+		Usage: "Keepalive ping interval in seconds. Default 10",
+	}
+	keepAliveTimeoutFlag = cli.IntFlag{
+		Name:  "openvpn.keepalive-timeout",
+		Usage: "Keepalive timeout in seconds after which tunnel is restarted. Default 60",
+	}
+
 	defaultOptions = Options{
 		Protocol: "udp",
 		Port:     1194,
@@ -49,15 +97,43 @@ var (
 
 // RegisterFlags function register Openvpn flags to flag list
 func RegisterFlags(flags *[]cli.Flag) {
-	*flags = append(*flags, protocolFlag, portFlag)
+	*flags = append(
+		*flags,
+		protocolFlag, portFlag, listenersFlag,
+		dnsFlag, mtuFlag, mssFixFlag, keepAlivePingFlag, keepAliveTimeoutFlag,
+	)
 }
 
 // ParseFlags function fills in Openvpn options from CLI context
-func ParseFlags(ctx *cli.Context) service.Options {
-	return Options{
-		Protocol: ctx.String(protocolFlag.Name),
-		Port:     ctx.Int(portFlag.Name),
+func ParseFlags(ctx *cli.Context) (service.Options, error) {
+	listeners, err := parseListeners(ctx.String(listenersFlag.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid "+listenersFlag.Name)
+	}
+
+	var dns []string
+	if value := ctx.String(dnsFlag.Name); value != "" {
+		for _, server := range strings.Split(value, ",") {
+			dns = append(dns, strings.TrimSpace(server))
+		}
 	}
+
+	opts := Options{
+		Protocol:  ctx.String(protocolFlag.Name),
+		Port:      ctx.Int(portFlag.Name),
+		Listeners: listeners,
+		SessionOptions: openvpn_service.SessionOptions{
+			DNS:              dns,
+			MTU:              ctx.Int(mtuFlag.Name),
+			MSSFix:           ctx.Int(mssFixFlag.Name),
+			KeepAlivePing:    ctx.Int(keepAlivePingFlag.Name),
+			KeepAliveTimeout: ctx.Int(keepAliveTimeoutFlag.Name),
+		},
+	}
+	if err := opts.validateListeners(); err != nil {
+		return nil, err
+	}
+	return opts, nil
 }
 
 // ParseJSONOptions function fills in Openvpn options from JSON request
@@ -67,6 +143,62 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 	}
 
 	opts := defaultOptions
-	err := json.Unmarshal(*request, &opts)
-	return opts, err
+	if err := json.Unmarshal(*request, &opts); err != nil {
+		return opts, err
+	}
+	if err := opts.validateListeners(); err != nil {
+		return opts, err
+	}
+	return opts, opts.SessionOptions.Validate()
+}
+
+// validateListeners checks that every listener, the primary one included, has a valid protocol and a valid and unique port
+func (o Options) validateListeners() error {
+	seen := make(map[Listener]bool)
+	for _, listener := range o.AllListeners() {
+		if !validProtocol(listener.Protocol) {
+			return fmt.Errorf("invalid listener protocol %q", listener.Protocol)
+		}
+		if listener.Port < 1 || listener.Port > 65535 {
+			return fmt.Errorf("listener port %d out of range 1..65535", listener.Port)
+		}
+		if seen[listener] {
+			return fmt.Errorf("duplicate listener %s:%d", listener.Protocol, listener.Port)
+		}
+		seen[listener] = true
+	}
+	return nil
+}
+
+// parseListeners parses comma separated list of protocol:port pairs
+func parseListeners(value string) ([]Listener, error) {
+	var listeners []Listener
+	if value == "" {
+		return listeners, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid listener %q, expected protocol:port", entry)
+		}
+
+		protocol := strings.ToLower(parts[0])
+		if !validProtocol(protocol) {
+			return nil, fmt.Errorf("invalid listener protocol %q", parts[0])
+		}
+
+		port, err := strconv.Atoi(parts[1])
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid listener port %q", parts[1])
+		}
+
+		listeners = append(listeners, Listener{Protocol: protocol, Port: port})
+	}
+	return listeners, nil
+}
+
+// validProtocol tells whether openvpn can serve the listener on given protocol
+func validProtocol(protocol string) bool {
+	return protocol == "udp" || protocol == "tcp"
 }
@@ -44,5 +44,79 @@ func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 	options, err := ParseJSONOptions(&request)
 
 	assert.NoError(t, err)
-	assert.Equal(t, Options{"udp", 1123}, options)
+	assert.Equal(t, Options{Protocol: "udp", Port: 1123}, options)
+}
+
+func Test_ParseJSONOptions_ValidRequestWithListeners(t *testing.T) {
+	request := json.RawMessage(`{"port": 1123, "protocol": "udp", "listeners": [{"port": 443, "protocol": "tcp"}]}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]Listener{{Protocol: "udp", Port: 1123}, {Protocol: "tcp", Port: 443}},
+		options.(Options).AllListeners(),
+	)
+}
+
+func Test_ParseJSONOptions_ValidRequestWithSessionOptions(t *testing.T) {
+	request := json.RawMessage(`{"port": 1123, "protocol": "udp", "dns": ["1.1.1.1"], "mtu": 1400}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.1.1.1"}, options.(Options).DNS)
+	assert.Equal(t, 1400, options.(Options).MTU)
+}
+
+func Test_ParseJSONOptions_InvalidSessionOptions(t *testing.T) {
+	request := json.RawMessage(`{"port": 1123, "protocol": "udp", "mtu": 9000}`)
+	_, err := ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_parseListeners(t *testing.T) {
+	listeners, err := parseListeners("tcp:443, udp:1195")
+	assert.NoError(t, err)
+	assert.Equal(t, []Listener{{Protocol: "tcp", Port: 443}, {Protocol: "udp", Port: 1195}}, listeners)
+
+	_, err = parseListeners("sctp:443")
+	assert.Error(t, err)
+
+	_, err = parseListeners("tcp")
+	assert.Error(t, err)
+}
+
+func Test_parseListeners_RejectsPortOutOfRange(t *testing.T) {
+	_, err := parseListeners("tcp:0")
+	assert.Error(t, err)
+
+	_, err = parseListeners("udp:65536")
+	assert.Error(t, err)
+}
+
+func Test_ParseJSONOptions_DuplicateListeners(t *testing.T) {
+	request := json.RawMessage(`{"protocol":"udp","port":1194,"listeners":[{"protocol":"udp","port":1194}]}`)
+	_, err := ParseJSONOptions(&request)
+	assert.EqualError(t, err, "duplicate listener udp:1194")
+
+	request = json.RawMessage(`{"protocol":"udp","port":1194,"listeners":[{"protocol":"tcp","port":443},{"protocol":"tcp","port":443}]}`)
+	_, err = ParseJSONOptions(&request)
+	assert.EqualError(t, err, "duplicate listener tcp:443")
+}
+
+func Test_ParseJSONOptions_ListenerPortOutOfRange(t *testing.T) {
+	request := json.RawMessage(`{"protocol":"udp","port":1194,"listeners":[{"protocol":"tcp","port":70000}]}`)
+	_, err := ParseJSONOptions(&request)
+	assert.EqualError(t, err, "listener port 70000 out of range 1..65535")
+}
+
+func Test_ParseJSONOptions_ListenerProtocolInvalid(t *testing.T) {
+	request := json.RawMessage(`{"protocol":"udp","port":1194,"listeners":[{"protocol":"sctp","port":443}]}`)
+	_, err := ParseJSONOptions(&request)
+	assert.EqualError(t, err, `invalid listener protocol "sctp"`)
+
+	request = json.RawMessage(`{"protocol":"sctp","port":1194}`)
+	_, err = ParseJSONOptions(&request)
+	assert.EqualError(t, err, `invalid listener protocol "sctp"`)
 }
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package openvpn
+
+import (
+	"fmt"
+	"net"
+)
+
+const (
+	maxDNSServers = 4
+
+	minMTU = 1280
+	maxMTU = 1500
+
+	minMSSFix = 1000
+
+	maxKeepAlivePing    = 300
+	maxKeepAliveTimeout = 3600
+)
+
+var defaultSessionOptions = SessionOptions{
+	DNS:              []string{"208.67.222.222", "208.67.220.220"},
+	KeepAlivePing:    10,
+	KeepAliveTimeout: 60,
+}
+
+// SessionOptions represents tunnel parameters which provider sets for the session.
+// Zero values mean that defaults of both sides are used.
+type SessionOptions struct {
+	DNS              []string `json:"dns,omitempty"`
+	MTU              int      `json:"mtu,omitempty"`
+	MSSFix           int      `json:"mssfix,omitempty"`
+	KeepAlivePing    int      `json:"keepalivePing,omitempty"`
+	KeepAliveTimeout int      `json:"keepaliveTimeout,omitempty"`
+}
+
+// Validate checks if session options fall within the allowed ranges
+func (o SessionOptions) Validate() error {
+	if len(o.DNS) > maxDNSServers {
+		return fmt.Errorf("too many DNS servers, at most %d are allowed", maxDNSServers)
+	}
+	for _, dns := range o.DNS {
+		parsed := net.ParseIP(dns)
+		if parsed == nil || parsed.To4() == nil {
+			return fmt.Errorf("invalid DNS server address %q", dns)
+		}
+	}
+
+	if o.MTU != 0 && (o.MTU < minMTU || o.MTU > maxMTU) {
+		return fmt.Errorf("invalid MTU, should fall within %d .. %d range", minMTU, maxMTU)
+	}
+	if o.MSSFix != 0 && (o.MSSFix < minMSSFix || o.MSSFix > o.mtu()) {
+		return fmt.Errorf("invalid mssfix, should fall within %d .. %d range", minMSSFix, o.mtu())
+	}
+
+	if o.KeepAlivePing < 0 || o.KeepAlivePing > maxKeepAlivePing {
+		return fmt.Errorf("invalid keepalive ping, should fall within 1 .. %d range", maxKeepAlivePing)
+	}
+	if o.KeepAliveTimeout < 0 || o.KeepAliveTimeout > maxKeepAliveTimeout {
+		return fmt.Errorf("invalid keepalive timeout, should fall within 1 .. %d range", maxKeepAliveTimeout)
+	}
+	if o.keepAliveTimeout() < 2*o.keepAlivePing() {
+		return fmt.Errorf("keepalive timeout should be at least twice the ping interval")
+	}
+
+	return nil
+}
+
+func (o SessionOptions) dns() []string {
+	if len(o.DNS) == 0 {
+		return defaultSessionOptions.DNS
+	}
+	return o.DNS
+}
+
+func (o SessionOptions) mtu() int {
+	if o.MTU == 0 {
+		return maxMTU
+	}
+	return o.MTU
+}
+
+func (o SessionOptions) keepAlivePing() int {
+	if o.KeepAlivePing == 0 {
+		return defaultSessionOptions.KeepAlivePing
+	}
+	return o.KeepAlivePing
+}
+
+func (o SessionOptions) keepAliveTimeout() int {
+	if o.KeepAliveTimeout == 0 {
+		return defaultSessionOptions.KeepAliveTimeout
+	}
+	return o.KeepAliveTimeout
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	"github.com/pkg/errors"
+)
+
+const (
+	logPrefix    = "[connection-proxy] "
+	probeTimeout = 10 * time.Second
+)
+
+// DefaultLocalAddress is the address where consumer applications reach the local proxy by default
+const DefaultLocalAddress = "127.0.0.1:1080"
+
+// Connection exposes local SOCKS5 and HTTP CONNECT proxy forwarding traffic through the provider proxy
+type Connection struct {
+	// counters are accessed atomically and must stay 64-bit aligned
+	bytesSent     uint64
+	bytesReceived uint64
+
+	connection    sync.WaitGroup
+	stopChannel   chan struct{}
+	statsDone     chan struct{}
+	localAddress  string
+	statsInterval time.Duration
+
+	stateChannel      connection.StateChannel
+	statisticsChannel connection.StatisticsChannel
+
+	config proxy.ServiceConfig
+	server *proxy.Server
+}
+
+// Start starts the local proxy forwarding to the service provider.
+func (c *Connection) Start(options connection.ConnectOptions) error {
+	if err := json.Unmarshal(options.SessionConfig, &c.config); err != nil {
+		return errors.Wrap(err, "failed to unmarshal connection config")
+	}
+
+	c.connection.Add(1)
+	c.stateChannel <- connection.Connecting
+
+	probe, err := net.DialTimeout("tcp", c.config.Address, probeTimeout)
+	if err != nil {
+		c.stateChannel <- connection.NotConnected
+		c.connection.Done()
+		return errors.Wrap(err, "failed to reach provider proxy")
+	}
+	probe.Close()
+
+	listener, err := net.Listen("tcp", c.localAddress)
+	if err != nil {
+		c.stateChannel <- connection.NotConnected
+		c.connection.Done()
+		return errors.Wrap(err, "failed to start local proxy")
+	}
+	c.localAddress = listener.Addr().String()
+
+	c.server = proxy.NewServer(nil, c.dialProvider)
+	go func() {
+		if err := c.server.Serve(listener); err != nil {
+			log.Error(logPrefix, "Local proxy failed: ", err)
+		}
+	}()
+	c.statsDone = make(chan struct{})
+	go c.runPeriodically(c.statsInterval)
+
+	log.Info(logPrefix, "Local proxy is listening on ", c.localAddress)
+	c.stateChannel <- connection.Connected
+	return nil
+}
+
+// Wait blocks until proxy connection not stopped.
+func (c *Connection) Wait() error {
+	c.connection.Wait()
+	return nil
+}
+
+// GetConfig returns the consumer configuration for session creation
+func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
+	return nil, nil
+}
+
+// Stop stops the local proxy and closes all forwarded connections.
+func (c *Connection) Stop() {
+	c.stateChannel <- connection.Disconnecting
+
+	if c.server != nil {
+		if err := c.server.Close(); err != nil {
+			log.Error(logPrefix, "Failed to close local proxy: ", err)
+		}
+	}
+
+	close(c.stopChannel)
+	if c.statsDone != nil {
+		<-c.statsDone
+	} else {
+		close(c.statisticsChannel)
+	}
+
+	c.stateChannel <- connection.NotConnected
+	c.connection.Done()
+	close(c.stateChannel)
+}
+
+func (c *Connection) dialProvider(address string) (net.Conn, error) {
+	conn, err := proxy.DialSOCKS5(c.config.Address, c.config.Username, c.config.Password, address)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, sent: &c.bytesSent, received: &c.bytesReceived}, nil
+}
+
+// runPeriodically reports the traffic until stopped, the last report is sent before closing statistics channel
+func (c *Connection) runPeriodically(duration time.Duration) {
+	defer close(c.statsDone)
+	defer close(c.statisticsChannel)
+
+	for {
+		select {
+		case <-time.After(duration):
+			c.sendStats()
+
+		case <-c.stopChannel:
+			c.sendStats()
+			return
+		}
+	}
+}
+
+func (c *Connection) sendStats() {
+	c.statisticsChannel <- consumer.SessionStatistics{
+		BytesSent:     atomic.LoadUint64(&c.bytesSent),
+		BytesReceived: atomic.LoadUint64(&c.bytesReceived),
+	}
+}
+
+// countingConn accounts the traffic forwarded through the provider
+type countingConn struct {
+	net.Conn
+	sent     *uint64
+	received *uint64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(c.received, uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(c.sent, uint64(n))
+	return n, err
+}
+
+// CloseWrite shuts down the writing side of the provider connection
+func (c *countingConn) CloseWrite() error {
+	if conn, ok := c.Conn.(*net.TCPConn); ok {
+		return conn.CloseWrite()
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+)
+
+// Factory is the proxy connection factory
+type Factory struct {
+	localAddress string
+}
+
+// Create creates a new proxy connection
+func (f *Factory) Create(stateChannel connection.StateChannel, statisticsChannel connection.StatisticsChannel) (connection.Connection, error) {
+	return &Connection{
+		stopChannel:       make(chan struct{}),
+		localAddress:      f.localAddress,
+		statsInterval:     time.Second,
+		stateChannel:      stateChannel,
+		statisticsChannel: statisticsChannel,
+	}, nil
+}
+
+// NewConnectionCreator creates proxy connections exposing local proxy on the given address
+func NewConnectionCreator(localAddress string) connection.Factory {
+	return &Factory{localAddress: localAddress}
+}
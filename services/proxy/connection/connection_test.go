@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+// startProvider runs provider proxy which, unlike the proxy service, may reach loopback test targets
+func startProvider(t *testing.T) (*proxy.Server, json.RawMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	authenticate := func(username, password string) bool {
+		return username == "consumer" && password == "secret"
+	}
+	dial := func(address string) (net.Conn, error) {
+		return net.Dial("tcp", address)
+	}
+	server := proxy.NewServer(authenticate, dial)
+	go server.Serve(listener)
+
+	configJSON, err := json.Marshal(proxy.ServiceConfig{
+		Address:  listener.Addr().String(),
+		Username: "consumer",
+		Password: "secret",
+	})
+	assert.NoError(t, err)
+	return server, configJSON
+}
+
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+	return listener
+}
+
+func newTestConnection() *Connection {
+	return &Connection{
+		stopChannel:       make(chan struct{}),
+		localAddress:      "127.0.0.1:0",
+		statsInterval:     time.Millisecond,
+		stateChannel:      make(connection.StateChannel, 10),
+		statisticsChannel: make(connection.StatisticsChannel, 10),
+	}
+}
+
+func Test_Connection_ForwardsTrafficThroughProvider(t *testing.T) {
+	echo := startEchoServer(t)
+	defer echo.Close()
+	provider, configJSON := startProvider(t)
+	defer provider.Close()
+
+	conn := newTestConnection()
+	err := conn.Start(connection.ConnectOptions{SessionConfig: configJSON})
+	assert.NoError(t, err)
+	assert.Equal(t, connection.Connecting, <-conn.stateChannel)
+	assert.Equal(t, connection.Connected, <-conn.stateChannel)
+
+	local, err := proxy.DialSOCKS5(conn.localAddress, "", "", echo.Addr().String())
+	assert.NoError(t, err)
+
+	_, err = local.Write([]byte("ping"))
+	assert.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(local, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+	local.Close()
+
+	go func() {
+		for range conn.statisticsChannel {
+		}
+	}()
+	conn.Stop()
+	assert.NoError(t, conn.Wait())
+	assert.Equal(t, uint64(4), conn.bytesSent)
+	assert.Equal(t, uint64(4), conn.bytesReceived)
+}
+
+func Test_Connection_FailsWhenProviderIsUnreachable(t *testing.T) {
+	provider, configJSON := startProvider(t)
+	provider.Close()
+
+	conn := newTestConnection()
+	err := conn.Start(connection.ConnectOptions{SessionConfig: configJSON})
+	assert.Error(t, err)
+	assert.Equal(t, connection.Connecting, <-conn.stateChannel)
+	assert.Equal(t, connection.NotConnected, <-conn.stateChannel)
+}
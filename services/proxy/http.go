@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+func (s *Server) handleHTTP(client *bufferedConn) (net.Conn, error) {
+	request, err := http.ReadRequest(client.reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read HTTP request")
+	}
+	if request.Method != http.MethodConnect {
+		writeHTTPStatus(client, http.StatusMethodNotAllowed)
+		return nil, errors.Errorf("unsupported HTTP method: %s", request.Method)
+	}
+
+	if s.authenticate != nil {
+		username, password, ok := proxyBasicAuth(request)
+		if !ok || !s.authorize(client.Conn, username, password) {
+			fmt.Fprintf(client, "HTTP/1.1 %d %s\r\nProxy-Authenticate: Basic realm=\"mysterium\"\r\n\r\n",
+				http.StatusProxyAuthRequired, http.StatusText(http.StatusProxyAuthRequired))
+			return nil, errors.New("invalid credentials")
+		}
+	}
+
+	target, err := s.dial(request.Host)
+	if err != nil {
+		writeHTTPStatus(client, http.StatusBadGateway)
+		return nil, errors.Wrap(err, "failed to connect to "+request.Host)
+	}
+	if _, err := fmt.Fprint(client, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		target.Close()
+		return nil, err
+	}
+	return target, nil
+}
+
+// proxyBasicAuth returns credentials from the Proxy-Authorization header
+func proxyBasicAuth(request *http.Request) (username, password string, ok bool) {
+	header := request.Header.Get("Proxy-Authorization")
+	if header == "" {
+		return "", "", false
+	}
+	auth := &http.Request{Header: http.Header{"Authorization": []string{header}}}
+	return auth.BasicAuth()
+}
+
+func writeHTTPStatus(client *bufferedConn, status int) {
+	fmt.Fprintf(client, "HTTP/1.1 %d %s\r\n\r\n", status, http.StatusText(status))
+}
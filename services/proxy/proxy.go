@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// ServiceType indicates "proxy" service type
+const ServiceType = "proxy"
+
+// ServiceDefinition structure represents "proxy" service parameters
+type ServiceDefinition struct {
+	// Approximate information on location where the service is provided from
+	Location market.Location `json:"location"`
+}
+
+// GetLocation returns geographic location of service definition provider
+func (service ServiceDefinition) GetLocation() market.Location {
+	return service.Location
+}
+
+// ServiceConfig represents proxy service configuration sent to consumer
+type ServiceConfig struct {
+	// Address of the provider proxy in host:port form
+	Address string `json:"address"`
+	// Username is the session bound login of the provider proxy
+	Username string `json:"username"`
+	// Password is the session bound secret of the provider proxy
+	Password string `json:"password"`
+}
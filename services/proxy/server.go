@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const logPrefix = "[proxy] "
+
+// handshakeTimeout limits the time client has to negotiate the proxied destination
+const handshakeTimeout = 30 * time.Second
+
+// ErrServerClosed is returned by Serve after the server was closed
+var ErrServerClosed = errors.New("proxy server closed")
+
+// Authenticator checks credentials presented by the proxy client
+type Authenticator func(username, password string) bool
+
+// Dialer opens connection to the destination requested by the proxy client
+type Dialer func(address string) (net.Conn, error)
+
+// Server is a SOCKS5 and HTTP CONNECT proxy sharing a single listener
+type Server struct {
+	authenticate Authenticator
+	dial         Dialer
+
+	lock        sync.Mutex
+	listener    net.Listener
+	connections map[net.Conn]string
+	closed      bool
+	handlers    sync.WaitGroup
+}
+
+// NewServer creates proxy server. Clients are not authenticated if authenticator is nil.
+func NewServer(authenticate Authenticator, dial Dialer) *Server {
+	return &Server{
+		authenticate: authenticate,
+		dial:         dial,
+		connections:  make(map[net.Conn]string),
+	}
+}
+
+// Serve accepts proxy clients on the given listener - does block.
+// The listener is closed when the server is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	s.lock.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+			return errors.Wrap(err, "failed to accept proxy client")
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+
+		s.handlers.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// Disconnect closes all client connections authenticated with the given username
+func (s *Server) Disconnect(username string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for conn, owner := range s.connections {
+		if owner == username {
+			conn.Close()
+		}
+	}
+}
+
+// Close stops accepting clients and closes all active connections
+func (s *Server) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.connections {
+		conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.handlers.Wait()
+	return err
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.handlers.Done()
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return
+	}
+
+	client := &bufferedConn{Conn: conn, reader: bufio.NewReader(conn)}
+	version, err := client.reader.Peek(1)
+	if err != nil {
+		return
+	}
+
+	var target net.Conn
+	if version[0] == socks5Version {
+		target, err = s.handleSOCKS5(client)
+	} else {
+		target, err = s.handleHTTP(client)
+	}
+	if err != nil {
+		log.Debug(logPrefix, "proxy request from ", conn.RemoteAddr(), " failed: ", err)
+		return
+	}
+	defer target.Close()
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+	relay(client, target)
+}
+
+// authorize checks client credentials and binds connection to the username
+func (s *Server) authorize(conn net.Conn, username, password string) bool {
+	if s.authenticate != nil && !s.authenticate(username, password) {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.connections[conn]; ok {
+		s.connections[conn] = username
+	}
+	return true
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.connections[conn] = ""
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.connections, conn)
+}
+
+func (s *Server) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.closed
+}
+
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// bufferedConn keeps the bytes read ahead while sniffing the proxy protocol
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// CloseWrite shuts down the writing side of connection if it supports it
+func (c *bufferedConn) CloseWrite() error {
+	if conn, ok := c.Conn.(closeWriter); ok {
+		return conn.CloseWrite()
+	}
+	return c.Conn.Close()
+}
+
+// relay copies data in both directions until both sides are finished
+func relay(client, target net.Conn) {
+	results := make(chan error, 2)
+	pipe := func(dst, src net.Conn) {
+		_, err := io.Copy(dst, src)
+		if err == nil {
+			if conn, ok := dst.(closeWriter); ok {
+				err = conn.CloseWrite()
+			} else {
+				err = io.EOF
+			}
+		}
+		results <- err
+	}
+
+	go pipe(target, client)
+	go pipe(client, target)
+
+	if err := <-results; err != nil {
+		client.Close()
+		target.Close()
+	}
+	<-results
+}
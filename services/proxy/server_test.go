@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener
+}
+
+func startProxyServer(t *testing.T) (*Server, string) {
+	authenticate := func(username, password string) bool {
+		return username == "session" && password == "secret"
+	}
+	dial := func(address string) (net.Conn, error) {
+		return net.Dial("tcp", address)
+	}
+	server := NewServer(authenticate, dial)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(listener)
+
+	return server, listener.Addr().String()
+}
+
+func assertEcho(t *testing.T, conn net.Conn, reader io.Reader) {
+	_, err := conn.Write([]byte("ping"))
+	assert.NoError(t, err)
+
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(reader, reply)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+}
+
+func connectHTTP(t *testing.T, proxyAddress, target, authorization string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", proxyAddress)
+	assert.NoError(t, err)
+
+	request := "CONNECT " + target + " HTTP/1.1\r\nHost: " + target + "\r\n"
+	if authorization != "" {
+		request += "Proxy-Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(authorization)) + "\r\n"
+	}
+	_, err = conn.Write([]byte(request + "\r\n"))
+	assert.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	assert.NoError(t, err)
+	return conn, reader, response
+}
+
+func Test_Server_ProxiesSOCKS5Connection(t *testing.T) {
+	echo := startEchoServer(t)
+	defer echo.Close()
+	server, address := startProxyServer(t)
+	defer server.Close()
+
+	conn, err := DialSOCKS5(address, "session", "secret", echo.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assertEcho(t, conn, conn)
+}
+
+func Test_Server_RejectsSOCKS5InvalidCredentials(t *testing.T) {
+	echo := startEchoServer(t)
+	defer echo.Close()
+	server, address := startProxyServer(t)
+	defer server.Close()
+
+	_, err := DialSOCKS5(address, "session", "wrong", echo.Addr().String())
+	assert.EqualError(t, err, "proxy rejected credentials")
+}
+
+func Test_Server_ProxiesHTTPConnect(t *testing.T) {
+	echo := startEchoServer(t)
+	defer echo.Close()
+	server, address := startProxyServer(t)
+	defer server.Close()
+
+	conn, reader, response := connectHTTP(t, address, echo.Addr().String(), "session:secret")
+	defer conn.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assertEcho(t, conn, reader)
+}
+
+func Test_Server_HTTPConnectRequiresCredentials(t *testing.T) {
+	echo := startEchoServer(t)
+	defer echo.Close()
+	server, address := startProxyServer(t)
+	defer server.Close()
+
+	conn, _, response := connectHTTP(t, address, echo.Addr().String(), "")
+	defer conn.Close()
+
+	assert.Equal(t, http.StatusProxyAuthRequired, response.StatusCode)
+}
+
+func Test_Server_DisconnectClosesUserConnections(t *testing.T) {
+	echo := startEchoServer(t)
+	defer echo.Close()
+	server, address := startProxyServer(t)
+	defer server.Close()
+
+	conn, err := DialSOCKS5(address, "session", "secret", echo.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	assertEcho(t, conn, conn)
+
+	server.Disconnect("session")
+
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// restrictedNetworks are address ranges consumers must not reach through the provider proxy
+var restrictedNetworks = mustParseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+// publicDialer connects consumers to public internet hosts only
+type publicDialer struct {
+	lookupIP func(host string) ([]net.IP, error)
+	dial     func(network, address string) (net.Conn, error)
+}
+
+func newPublicDialer() *publicDialer {
+	return &publicDialer{
+		lookupIP: net.LookupIP,
+		dial:     net.Dial,
+	}
+}
+
+// Dial resolves the host of given address and connects to its first public IP.
+// The checked IP is dialed directly, so the host can not be rebound to a local address in between.
+func (dialer *publicDialer) Dial(address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid proxy target")
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = dialer.lookupIP(host); err != nil {
+			return nil, errors.Wrap(err, "failed to resolve proxy target")
+		}
+	}
+
+	for _, ip := range ips {
+		if isRestrictedIP(ip) {
+			continue
+		}
+		return dialer.dial("tcp", net.JoinHostPort(ip.String(), port))
+	}
+	return nil, errors.Errorf("proxy target %s is not a public address", host)
+}
+
+func isRestrictedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range restrictedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return ip.Equal(net.IPv4bcast)
+}
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDialerStub(hosts map[string][]net.IP) (*publicDialer, *[]string) {
+	var dialed []string
+	dialer := &publicDialer{
+		lookupIP: func(host string) ([]net.IP, error) {
+			if ips, ok := hosts[host]; ok {
+				return ips, nil
+			}
+			return nil, errors.New("no such host")
+		},
+		dial: func(network, address string) (net.Conn, error) {
+			dialed = append(dialed, address)
+			return nil, nil
+		},
+	}
+	return dialer, &dialed
+}
+
+func Test_publicDialer_RefusesLocalAddresses(t *testing.T) {
+	dialer, dialed := newDialerStub(nil)
+
+	for _, address := range []string{
+		"127.0.0.1:4050",
+		"[::1]:4050",
+		"10.1.2.3:80",
+		"172.16.0.1:80",
+		"192.168.1.1:80",
+		"169.254.169.254:80",
+		"[fe80::1]:80",
+		"224.0.0.1:80",
+		"0.0.0.0:4050",
+		"[::]:4050",
+		"[::ffff:127.0.0.1]:4050",
+	} {
+		_, err := dialer.Dial(address)
+		assert.Error(t, err, address)
+	}
+	assert.Empty(t, *dialed)
+}
+
+func Test_publicDialer_RefusesHostnameResolvingToLoopback(t *testing.T) {
+	dialer, dialed := newDialerStub(map[string][]net.IP{
+		"rebind.example": {net.ParseIP("127.0.0.1")},
+	})
+
+	_, err := dialer.Dial("rebind.example:4050")
+	assert.EqualError(t, err, "proxy target rebind.example is not a public address")
+	assert.Empty(t, *dialed)
+}
+
+func Test_publicDialer_DialsResolvedPublicIP(t *testing.T) {
+	dialer, dialed := newDialerStub(map[string][]net.IP{
+		"mysterium.example": {net.ParseIP("10.0.0.1"), net.ParseIP("93.184.216.34")},
+	})
+
+	_, err := dialer.Dial("mysterium.example:443")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"93.184.216.34:443"}, *dialed)
+}
+
+func Test_publicDialer_DialsPublicIP(t *testing.T) {
+	dialer, dialed := newDialerStub(nil)
+
+	_, err := dialer.Dial("[2606:2800:220:1::]:80")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"[2606:2800:220:1::]:80"}, *dialed)
+}
+
+func Test_publicDialer_FailsOnUnresolvedHost(t *testing.T) {
+	dialer, _ := newDialerStub(nil)
+
+	_, err := dialer.Dial("unknown.example:80")
+	assert.Error(t, err)
+}
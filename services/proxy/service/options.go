@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/urfave/cli"
+)
+
+// Options describes options which are required to start proxy service
+type Options struct {
+	Port int `json:"port"`
+}
+
+var (
+	portFlag = cli.IntFlag{
+		Name:  "proxy.port",
+		Usage: "Proxy server port (SOCKS5 and HTTP CONNECT)",
+		Value: defaultOptions.Port,
+	}
+	defaultOptions = Options{
+		Port: 10800,
+	}
+)
+
+// RegisterFlags function register proxy flags to flag list
+func RegisterFlags(flags *[]cli.Flag) {
+	*flags = append(*flags, portFlag)
+}
+
+// ParseFlags function fills in proxy options from CLI context
+func ParseFlags(ctx *cli.Context) (service.Options, error) {
+	return Options{
+		Port: ctx.Int(portFlag.Name),
+	}, nil
+}
+
+// ParseJSONOptions function fills in proxy options from JSON request
+func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
+	if request == nil {
+		return defaultOptions, nil
+	}
+
+	opts := defaultOptions
+	err := json.Unmarshal(*request, &opts)
+	return opts, err
+}
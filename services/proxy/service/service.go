@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/location"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/pkg/errors"
+)
+
+const logPrefix = "[service-proxy] "
+
+// GetProposal returns the proposal for proxy service
+func GetProposal(country string) market.ServiceProposal {
+	return market.ServiceProposal{
+		ServiceType: proxy.ServiceType,
+		ServiceDefinition: proxy.ServiceDefinition{
+			Location: market.Location{Country: country},
+		},
+		PaymentMethodType: dto.PaymentMethodPerTime,
+		PaymentMethod: dto.PaymentPerTime{
+			// 15 MYST/month = 0,5 MYST/day = 0,125 MYST/hour
+			Price:    money.NewMoney(0.125, money.CurrencyMyst),
+			Duration: 1 * time.Hour,
+		},
+	}
+}
+
+// NewManager creates new instance of proxy service
+func NewManager(location location.ServiceLocationInfo, mapPort func(port int) (releasePortMapping func()), options Options) *Manager {
+	manager := &Manager{
+		publicIP:    location.PubIP,
+		options:     options,
+		mapPort:     mapPort,
+		credentials: make(map[string]string),
+	}
+	manager.server = proxy.NewServer(manager.authenticate, newPublicDialer().Dial)
+	return manager
+}
+
+// Manager represents an instance of proxy service
+type Manager struct {
+	publicIP string
+	options  Options
+	mapPort  func(port int) (releasePortMapping func())
+	server   *proxy.Server
+
+	lock        sync.Mutex
+	port        int
+	releasePort func()
+	credentials map[string]string
+}
+
+// ProvideConfig issues session bound proxy credentials for consumer
+func (manager *Manager) ProvideConfig(_ json.RawMessage) (session.ServiceConfiguration, session.DestroyCallback, error) {
+	username, err := randomToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	password, err := randomToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manager.lock.Lock()
+	port := manager.port
+	manager.credentials[username] = password
+	manager.lock.Unlock()
+
+	destroy := func() {
+		manager.lock.Lock()
+		delete(manager.credentials, username)
+		manager.lock.Unlock()
+
+		manager.server.Disconnect(username)
+	}
+
+	config := proxy.ServiceConfig{
+		Address:  net.JoinHostPort(manager.publicIP, strconv.Itoa(port)),
+		Username: username,
+		Password: password,
+	}
+	return config, destroy, nil
+}
+
+// Serve starts service - does block
+func (manager *Manager) Serve(providerID identity.Identity) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", manager.options.Port))
+	if err != nil {
+		return errors.Wrap(err, "failed to start proxy listener")
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	manager.lock.Lock()
+	manager.port = port
+	manager.releasePort = manager.mapPort(port)
+	manager.lock.Unlock()
+
+	log.Info(logPrefix, "Proxy service started successfully on port ", port)
+	return manager.server.Serve(listener)
+}
+
+// Stop stops service.
+func (manager *Manager) Stop() error {
+	err := manager.server.Close()
+
+	manager.lock.Lock()
+	if manager.releasePort != nil {
+		manager.releasePort()
+		manager.releasePort = nil
+	}
+	manager.lock.Unlock()
+
+	log.Info(logPrefix, "Proxy service stopped")
+	return err
+}
+
+func (manager *Manager) authenticate(username, password string) bool {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	expected, ok := manager.credentials[username]
+	return ok && subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
+func randomToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", errors.Wrap(err, "failed to generate proxy credentials")
+	}
+	return hex.EncodeToString(token), nil
+}
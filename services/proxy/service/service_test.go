@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/location"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	providerID = identity.FromAddress("provider-id")
+	country    = "LT"
+)
+
+func newManagerStub() *Manager {
+	noopMapPort := func(port int) func() {
+		return func() {}
+	}
+	return NewManager(location.ServiceLocationInfo{PubIP: "127.0.0.1"}, noopMapPort, Options{Port: 0})
+}
+
+func startManager(t *testing.T) *Manager {
+	manager := newManagerStub()
+	// test targets listen on loopback, which the public dialer refuses
+	manager.server = proxy.NewServer(manager.authenticate, func(address string) (net.Conn, error) {
+		return net.Dial("tcp", address)
+	})
+	go func() {
+		assert.NoError(t, manager.Serve(providerID))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	return manager
+}
+
+func Test_GetProposal(t *testing.T) {
+	assert.Exactly(
+		t,
+		market.ServiceProposal{
+			ServiceType: "proxy",
+			ServiceDefinition: proxy.ServiceDefinition{
+				Location: market.Location{Country: country},
+			},
+			PaymentMethodType: "PER_TIME",
+			PaymentMethod: dto.PaymentPerTime{
+				Price:    money.Money{Amount: 12500000, Currency: money.Currency("MYST")},
+				Duration: 60 * time.Minute,
+			},
+		},
+		GetProposal(country),
+	)
+}
+
+func Test_Manager_ProvidesSessionCredentials(t *testing.T) {
+	manager := startManager(t)
+	defer manager.Stop()
+
+	sessionConfig, destroy, err := manager.ProvideConfig(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, destroy)
+
+	config := sessionConfig.(proxy.ServiceConfig)
+	assert.NotEmpty(t, config.Username)
+	assert.NotEmpty(t, config.Password)
+	assert.True(t, manager.authenticate(config.Username, config.Password))
+	assert.False(t, manager.authenticate(config.Username, "wrong"))
+}
+
+func Test_Manager_DestroyRevokesCredentials(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+
+	manager := startManager(t)
+	defer manager.Stop()
+
+	sessionConfig, destroy, err := manager.ProvideConfig(nil)
+	assert.NoError(t, err)
+	config := sessionConfig.(proxy.ServiceConfig)
+
+	conn, err := proxy.DialSOCKS5(config.Address, config.Username, config.Password, target.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	destroy()
+
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+
+	_, err = proxy.DialSOCKS5(config.Address, config.Username, config.Password, target.Addr().String())
+	assert.Error(t, err)
+}
+
+func Test_Manager_RefusesLoopbackTarget(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer target.Close()
+
+	manager := newManagerStub()
+	go func() {
+		assert.NoError(t, manager.Serve(providerID))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	defer manager.Stop()
+
+	sessionConfig, _, err := manager.ProvideConfig(nil)
+	assert.NoError(t, err)
+	config := sessionConfig.(proxy.ServiceConfig)
+
+	_, err = proxy.DialSOCKS5(config.Address, config.Username, config.Password, target.Addr().String())
+	assert.Error(t, err)
+}
@@ -0,0 +1,268 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SOCKS5 protocol constants as defined by RFC 1928 and RFC 1929
+const (
+	socks5Version        = 0x05
+	socks5AuthVersion    = 0x01
+	socks5MethodNoAuth   = 0x00
+	socks5MethodPassword = 0x02
+	socks5MethodNone     = 0xff
+	socks5CommandConnect = 0x01
+	socks5AddressIPv4    = 0x01
+	socks5AddressDomain  = 0x03
+	socks5AddressIPv6    = 0x04
+
+	socks5ReplySuccess            = 0x00
+	socks5ReplyFailure            = 0x01
+	socks5ReplyHostUnreachable    = 0x04
+	socks5ReplyCommandUnsupported = 0x07
+)
+
+// dialTimeout limits the time of establishing connection to the provider proxy
+const dialTimeout = 10 * time.Second
+
+func (s *Server) handleSOCKS5(client *bufferedConn) (net.Conn, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(client, header); err != nil {
+		return nil, errors.Wrap(err, "failed to read greeting")
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(client, methods); err != nil {
+		return nil, errors.Wrap(err, "failed to read authentication methods")
+	}
+
+	method := byte(socks5MethodNoAuth)
+	if s.authenticate != nil {
+		method = socks5MethodPassword
+	}
+	if !containsByte(methods, method) {
+		client.Write([]byte{socks5Version, socks5MethodNone})
+		return nil, errors.New("no acceptable authentication method")
+	}
+	if _, err := client.Write([]byte{socks5Version, method}); err != nil {
+		return nil, err
+	}
+
+	if method == socks5MethodPassword {
+		username, password, err := readSOCKS5Credentials(client)
+		if err != nil {
+			return nil, err
+		}
+		if !s.authorize(client.Conn, username, password) {
+			client.Write([]byte{socks5AuthVersion, socks5ReplyFailure})
+			return nil, errors.New("invalid credentials")
+		}
+		if _, err := client.Write([]byte{socks5AuthVersion, socks5ReplySuccess}); err != nil {
+			return nil, err
+		}
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(client, request); err != nil {
+		return nil, errors.Wrap(err, "failed to read request")
+	}
+	address, err := readSOCKS5Address(client, request[3])
+	if err != nil {
+		return nil, err
+	}
+	if request[1] != socks5CommandConnect {
+		writeSOCKS5Reply(client, socks5ReplyCommandUnsupported)
+		return nil, errors.Errorf("unsupported command: %d", request[1])
+	}
+
+	target, err := s.dial(address)
+	if err != nil {
+		writeSOCKS5Reply(client, socks5ReplyHostUnreachable)
+		return nil, errors.Wrap(err, "failed to connect to "+address)
+	}
+	if err := writeSOCKS5Reply(client, socks5ReplySuccess); err != nil {
+		target.Close()
+		return nil, err
+	}
+	return target, nil
+}
+
+// DialSOCKS5 connects to the target address through the SOCKS5 proxy.
+// Username/password authentication is used unless username is empty.
+func DialSOCKS5(proxyAddress, username, password, target string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", proxyAddress, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := socks5Connect(conn, username, password, target); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func socks5Connect(conn net.Conn, username, password, target string) error {
+	if len(username) > 255 || len(password) > 255 {
+		return errors.New("credentials are too long")
+	}
+	host, portString, err := net.SplitHostPort(target)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return errors.Wrap(err, "invalid port")
+	}
+	if len(host) > 255 {
+		return errors.New("host name is too long")
+	}
+
+	method := byte(socks5MethodPassword)
+	if username == "" {
+		method = socks5MethodNoAuth
+	}
+	if _, err := conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return errors.Wrap(err, "failed to read authentication method")
+	}
+	if reply[1] != method {
+		return errors.New("proxy refused authentication method")
+	}
+
+	if method == socks5MethodPassword {
+		auth := []byte{socks5AuthVersion, byte(len(username))}
+		auth = append(auth, username...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return errors.Wrap(err, "failed to read authentication status")
+		}
+		if reply[1] != socks5ReplySuccess {
+			return errors.New("proxy rejected credentials")
+		}
+	}
+
+	request := []byte{socks5Version, socks5CommandConnect, 0, socks5AddressDomain, byte(len(host))}
+	request = append(request, host...)
+	request = append(request, byte(port>>8), byte(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	response := make([]byte, 4)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return errors.Wrap(err, "failed to read connect reply")
+	}
+	if response[1] != socks5ReplySuccess {
+		return errors.Errorf("proxy failed to connect to %s: reply %d", target, response[1])
+	}
+	_, err = readSOCKS5Address(conn, response[3])
+	return err
+}
+
+func readSOCKS5Credentials(r io.Reader) (username, password string, err error) {
+	header := make([]byte, 2)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return "", "", errors.Wrap(err, "failed to read credentials")
+	}
+	if header[0] != socks5AuthVersion {
+		return "", "", errors.Errorf("unsupported authentication version: %d", header[0])
+	}
+	user := make([]byte, header[1])
+	if _, err = io.ReadFull(r, user); err != nil {
+		return "", "", errors.Wrap(err, "failed to read username")
+	}
+	length := make([]byte, 1)
+	if _, err = io.ReadFull(r, length); err != nil {
+		return "", "", errors.Wrap(err, "failed to read password")
+	}
+	pass := make([]byte, length[0])
+	if _, err = io.ReadFull(r, pass); err != nil {
+		return "", "", errors.Wrap(err, "failed to read password")
+	}
+	return string(user), string(pass), nil
+}
+
+func readSOCKS5Address(r io.Reader, addressType byte) (string, error) {
+	var host string
+	switch addressType {
+	case socks5AddressIPv4, socks5AddressIPv6:
+		size := net.IPv4len
+		if addressType == socks5AddressIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", errors.Wrap(err, "failed to read address")
+		}
+		host = net.IP(ip).String()
+	case socks5AddressDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return "", errors.Wrap(err, "failed to read address")
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", errors.Wrap(err, "failed to read address")
+		}
+		host = string(domain)
+	default:
+		return "", errors.Errorf("unsupported address type: %d", addressType)
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", errors.Wrap(err, "failed to read port")
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+func writeSOCKS5Reply(w io.Writer, reply byte) error {
+	_, err := w.Write([]byte{socks5Version, reply, 0, socks5AddressIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+func containsByte(values []byte, value byte) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -57,7 +57,7 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 	c.config.Provider = config.Provider
 	c.config.Consumer.IPAddress = config.Consumer.IPAddress
 
-	resourceAllocator := resources.NewAllocator()
+	resourceAllocator := resources.NewAllocator(resources.DefaultSubnet)
 
 	// We do not need port mapping for consumer, since it initiates the session
 	fakePortMapper := func(port int) (releasePortMapping func()) {
@@ -125,6 +125,21 @@ func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
 	}, nil
 }
 
+// ResumeState returns the consumer private key the provider peer of the session is configured for
+func (c *Connection) ResumeState() interface{} {
+	return c.config.Consumer.PrivateKey
+}
+
+// Resume reuses the consumer private key of the previous connection to the same session
+func (c *Connection) Resume(state interface{}) error {
+	privateKey, ok := state.(string)
+	if !ok {
+		return errors.New("unexpected wireguard connection state")
+	}
+	c.config.Consumer.PrivateKey = privateKey
+	return nil
+}
+
 // Stop stops wireguard connection and closes connection endpoint.
 func (c *Connection) Stop() {
 	c.stateChannel <- connection.Disconnecting
@@ -26,25 +26,31 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/mysteriumnetwork/node/core/ip"
 )
 
 // MaxResources sets the limit to the maximum number of wireguard connections.
 const MaxResources = 255
 
+// DefaultSubnet is the range of IP addresses used when none is assigned to the service.
+var DefaultSubnet = net.IPNet{IP: net.IPv4(10, 182, 0, 0).To4(), Mask: net.CIDRMask(16, 32)}
+
 // Allocator is mock wireguard resource handler.
 // It will manage lists of network interfaces names, IP addresses and port for endpoints.
 type Allocator struct {
 	Ifaces      map[int]struct{}
-	IPAddresses map[int]struct{}
+	IPAddresses *ip.SubnetAllocator
 	Ports       map[int]struct{}
 	mu          sync.Mutex
 }
 
 // NewAllocator creates new resource pool for wireguard connection.
-func NewAllocator() *Allocator {
+// Connection subnets are allocated within the given range of IP addresses.
+func NewAllocator(subnet net.IPNet) *Allocator {
 	return &Allocator{
 		Ifaces:      make(map[int]struct{}),
-		IPAddresses: make(map[int]struct{}),
+		IPAddresses: ip.NewSubnetAllocator(subnet),
 		Ports:       make(map[int]struct{}),
 	}
 }
@@ -101,20 +107,7 @@ func (a *Allocator) AllocateInterface() (string, error) {
 
 // AllocateIPNet provides available IP address for the wireguard connection.
 func (a *Allocator) AllocateIPNet() (net.IPNet, error) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	var s string
-	for i := 0; i < MaxResources; i++ {
-		if _, ok := a.IPAddresses[i]; !ok {
-			a.IPAddresses[i] = struct{}{}
-			s = fmt.Sprintf("10.182.%d.0/24", i)
-			break
-		}
-	}
-
-	_, subnet, err := net.ParseCIDR(s)
-	return *subnet, err
+	return a.IPAddresses.Allocate(24)
 }
 
 // AllocatePort provides available UDP port for the wireguard endpoint.
@@ -152,21 +145,7 @@ func (a *Allocator) ReleaseInterface(iface string) error {
 
 // ReleaseIPNet releases IP address.
 func (a *Allocator) ReleaseIPNet(ipnet net.IPNet) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	ip4 := ipnet.IP.To4()
-	if ip4 == nil {
-		return errors.New("allocated subnet not found")
-	}
-
-	i := int(ip4[2])
-	if _, ok := a.IPAddresses[i]; !ok {
-		return errors.New("allocated subnet not found")
-	}
-
-	delete(a.IPAddresses, i)
-	return nil
+	return a.IPAddresses.Release(ipnet)
 }
 
 // ReleasePort releases UDP port.
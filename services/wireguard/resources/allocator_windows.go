@@ -27,17 +27,23 @@ import (
 // MaxResources sets the limit to the maximum number of wireguard connections.
 const MaxResources = 255
 
+// DefaultSubnet is the range of IP addresses used when none is assigned to the service.
+var DefaultSubnet = net.IPNet{IP: net.IPv4(10, 182, 0, 0).To4(), Mask: net.CIDRMask(24, 32)}
+
 // Allocator is mock wireguard resource handler.
 // It will manage lists of network interfaces names, IP addresses and port for endpoints.
 type Allocator struct {
 	IPAddresses map[int]struct{}
+	subnet      net.IPNet
 	mu          sync.Mutex
 }
 
 // NewAllocator creates new resource pool for wireguard connection.
-func NewAllocator() *Allocator {
+// All connections share single /24 network taken from the beginning of the given range.
+func NewAllocator(subnet net.IPNet) *Allocator {
 	return &Allocator{
 		IPAddresses: make(map[int]struct{}),
+		subnet:      net.IPNet{IP: subnet.IP.Mask(subnet.Mask).To4(), Mask: net.CIDRMask(24, 32)},
 	}
 }
 
@@ -57,10 +63,11 @@ func (a *Allocator) AllocateIPNet() (net.IPNet, error) {
 	defer a.mu.Unlock()
 
 	var s string
+	base := a.subnet.IP
 	for i := 1; i < MaxResources; i++ {
 		if _, ok := a.IPAddresses[i]; !ok {
 			a.IPAddresses[i] = struct{}{}
-			s = fmt.Sprintf("10.182.0.%d/24", i)
+			s = fmt.Sprintf("%d.%d.%d.%d/24", base[0], base[1], base[2], i)
 			break
 		}
 	}
@@ -46,10 +46,10 @@ func RegisterFlags(flags *[]cli.Flag) {
 }
 
 // ParseFlags function fills in Wireguard options from CLI context
-func ParseFlags(ctx *cli.Context) service.Options {
+func ParseFlags(ctx *cli.Context) (service.Options, error) {
 	return Options{
 		ConnectDelay: ctx.Int(delayFlag.Name),
-	}
+	}, nil
 }
 
 // ParseJSONOptions function fills in Openvpn options from JSON request
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
@@ -87,6 +88,18 @@ func Test_Manager_Stop(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_Manager_StopAfterServeFailedToAllocateSubnet(t *testing.T) {
+	pool := ip.NewSubnetAllocator(net.IPNet{IP: net.ParseIP("10.182.0.0").To4(), Mask: net.CIDRMask(16, 32)})
+	_, err := pool.Allocate(16)
+	assert.NoError(t, err)
+
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.subnetPool = pool
+
+	assert.Error(t, manager.Serve(providerID))
+	assert.NoError(t, manager.Stop())
+}
+
 // usually time.Sleep call gives a chance for other goroutines to kick in important when testing async code
 func waitABit() {
 	time.Sleep(10 * time.Millisecond)
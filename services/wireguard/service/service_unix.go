@@ -21,9 +21,11 @@ package service
 
 import (
 	"encoding/json"
+	"net"
 	"sync"
 
 	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/nat"
@@ -39,20 +41,25 @@ func NewManager(
 	location location.ServiceLocationInfo,
 	natService nat.NATService,
 	portMap func(port int) (releasePortMapping func()),
-	options Options) *Manager {
+	options Options,
+	subnetPool *ip.SubnetAllocator) *Manager {
 
-	resourceAllocator := resources.NewAllocator()
-	return &Manager{
+	manager := &Manager{
 		natService: natService,
+		subnetPool: subnetPool,
 
 		publicIP:        location.PubIP,
 		outboundIP:      location.OutIP,
 		currentLocation: location.Country,
-
-		connectionEndpointFactory: func() (wg.ConnectionEndpoint, error) {
-			return endpoint.NewConnectionEndpoint(location, resourceAllocator, portMap, options.ConnectDelay)
-		},
 	}
+	manager.connectionEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		resourceAllocator, err := manager.allocator()
+		if err != nil {
+			return nil, err
+		}
+		return endpoint.NewConnectionEndpoint(location, resourceAllocator, portMap, options.ConnectDelay)
+	}
+	return manager
 }
 
 // Manager represents an instance of Wireguard service
@@ -60,6 +67,12 @@ type Manager struct {
 	wg         sync.WaitGroup
 	natService nat.NATService
 
+	subnetPool        *ip.SubnetAllocator
+	subnet            *net.IPNet
+	resourceAllocator *resources.Allocator
+	serving           bool
+	resourceLock      sync.Mutex
+
 	connectionEndpointFactory func() (wg.ConnectionEndpoint, error)
 
 	publicIP        string
@@ -112,7 +125,14 @@ func (manager *Manager) ProvideConfig(publicKey json.RawMessage) (session.Servic
 
 // Serve starts service - does block
 func (manager *Manager) Serve(providerID identity.Identity) error {
+	if _, err := manager.allocator(); err != nil {
+		return errors.Wrap(err, "failed to allocate subnet")
+	}
+
+	manager.resourceLock.Lock()
 	manager.wg.Add(1)
+	manager.serving = true
+	manager.resourceLock.Unlock()
 	log.Info(logPrefix, "Wireguard service started successfully")
 
 	manager.wg.Wait()
@@ -121,8 +141,61 @@ func (manager *Manager) Serve(providerID identity.Identity) error {
 
 // Stop stops service.
 func (manager *Manager) Stop() error {
-	manager.wg.Done()
+	manager.resourceLock.Lock()
+	if manager.serving {
+		manager.serving = false
+		manager.wg.Done()
+	}
+	manager.resourceLock.Unlock()
+	manager.releaseSubnet()
 
 	log.Info(logPrefix, "Wireguard service stopped")
 	return nil
 }
+
+// Subnets returns the range of IP addresses reserved for the service connections
+func (manager *Manager) Subnets() []net.IPNet {
+	manager.resourceLock.Lock()
+	defer manager.resourceLock.Unlock()
+
+	if manager.subnet == nil {
+		return nil
+	}
+	return []net.IPNet{*manager.subnet}
+}
+
+// allocator reserves range of IP addresses for the service on the first use
+func (manager *Manager) allocator() (*resources.Allocator, error) {
+	manager.resourceLock.Lock()
+	defer manager.resourceLock.Unlock()
+
+	if manager.resourceAllocator != nil {
+		return manager.resourceAllocator, nil
+	}
+
+	subnet := resources.DefaultSubnet
+	if manager.subnetPool != nil {
+		allocated, err := manager.subnetPool.Allocate(16)
+		if err != nil {
+			return nil, err
+		}
+		subnet = allocated
+	}
+
+	manager.subnet = &subnet
+	manager.resourceAllocator = resources.NewAllocator(subnet)
+	return manager.resourceAllocator, nil
+}
+
+func (manager *Manager) releaseSubnet() {
+	manager.resourceLock.Lock()
+	defer manager.resourceLock.Unlock()
+
+	if manager.subnetPool != nil && manager.subnet != nil {
+		if err := manager.subnetPool.Release(*manager.subnet); err != nil {
+			log.Error(logPrefix, "failed to release subnet: ", err)
+		}
+	}
+	manager.subnet = nil
+	manager.resourceAllocator = nil
+}
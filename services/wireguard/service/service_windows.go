@@ -19,9 +19,11 @@ package service
 
 import (
 	"encoding/json"
+	"net"
 	"sync"
 
 	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
@@ -38,15 +40,15 @@ func NewManager(
 	location location.ServiceLocationInfo,
 	natService nat.NATService,
 	portMap func(port int) (releasePortMapping func()),
-	options Options) *Manager {
+	options Options,
+	subnetPool *ip.SubnetAllocator) *Manager {
 
-	resourceAllocator := resources.NewAllocator()
 	return &Manager{
-		natService:        natService,
-		resourceAllocator: resourceAllocator,
-		portMap:           portMap,
-		location:          location,
-		options:           options,
+		natService: natService,
+		subnetPool: subnetPool,
+		portMap:    portMap,
+		location:   location,
+		options:    options,
 	}
 }
 
@@ -57,6 +59,9 @@ type Manager struct {
 
 	connectionEndpoint wg.ConnectionEndpoint
 
+	subnetPool        *ip.SubnetAllocator
+	subnet            *net.IPNet
+	subnetLock        sync.Mutex
 	resourceAllocator *resources.Allocator
 
 	location location.ServiceLocationInfo
@@ -94,9 +99,28 @@ func (manager *Manager) ProvideConfig(publicKey json.RawMessage) (session.Servic
 }
 
 // Serve starts service - does block
-func (manager *Manager) Serve(providerID identity.Identity) error {
+func (manager *Manager) Serve(providerID identity.Identity) (err error) {
 	manager.wg.Add(1)
 
+	subnet := resources.DefaultSubnet
+	if manager.subnetPool != nil {
+		allocated, allocErr := manager.subnetPool.Allocate(24)
+		if allocErr != nil {
+			return errors.Wrap(allocErr, "failed to allocate subnet")
+		}
+		subnet = allocated
+		manager.subnetLock.Lock()
+		manager.subnet = &allocated
+		manager.subnetLock.Unlock()
+
+		defer func() {
+			if err != nil {
+				manager.releaseSubnet()
+			}
+		}()
+	}
+	manager.resourceAllocator = resources.NewAllocator(subnet)
+
 	connectionEndpoint, err := endpoint.NewConnectionEndpoint(manager.location, manager.resourceAllocator, manager.portMap, manager.options.ConnectDelay)
 	if err != nil {
 		return err
@@ -136,8 +160,36 @@ func (manager *Manager) Serve(providerID identity.Identity) error {
 func (manager *Manager) Stop() error {
 	manager.wg.Done()
 
-	manager.connectionEndpoint.Stop()
+	if manager.connectionEndpoint != nil {
+		manager.connectionEndpoint.Stop()
+	}
+
+	manager.releaseSubnet()
 
 	log.Info(logPrefix, "Wireguard service stopped")
 	return nil
 }
+
+// releaseSubnet returns the reserved range of IP addresses back to the pool
+func (manager *Manager) releaseSubnet() {
+	manager.subnetLock.Lock()
+	defer manager.subnetLock.Unlock()
+
+	if manager.subnet != nil {
+		if err := manager.subnetPool.Release(*manager.subnet); err != nil {
+			log.Error(logPrefix, "failed to release subnet: ", err)
+		}
+		manager.subnet = nil
+	}
+}
+
+// Subnets returns the range of IP addresses reserved for the service connections
+func (manager *Manager) Subnets() []net.IPNet {
+	manager.subnetLock.Lock()
+	defer manager.subnetLock.Unlock()
+
+	if manager.subnet == nil {
+		return nil
+	}
+	return []net.IPNet{*manager.subnet}
+}
@@ -18,12 +18,43 @@
 package session
 
 import (
+	"errors"
+	"math/big"
 	"time"
 
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 )
 
+// ErrUnsupportedPaymentMethod indicates that the price of proposal is not metered by time
+var ErrUnsupportedPaymentMethod = errors.New("payment method is not metered by time")
+
+// PaymentDefinition returns the price per time consumers are charged for the service of given proposal.
+// Free payment methods are accepted regardless of their type, priced ones have to be metered by time.
+func PaymentDefinition(proposal market.ServiceProposal) (dto.PaymentPerTime, error) {
+	definition := dto.PaymentPerTime{
+		Price:    money.Money{Currency: money.CurrencyMyst},
+		Duration: time.Minute,
+	}
+	switch method := proposal.PaymentMethod.(type) {
+	case nil:
+		return definition, nil
+	case market.UnsupportedPaymentMethod:
+		return definition, ErrUnsupportedPaymentMethod
+	case dto.PaymentPerTime:
+		if method.Duration > 0 {
+			return method, nil
+		}
+		definition.Price = method.Price
+	default:
+		if method.GetPrice().Amount > 0 {
+			return definition, ErrUnsupportedPaymentMethod
+		}
+	}
+	return definition, nil
+}
+
 // AmountCalc calculates the pay required given the amount
 type AmountCalc struct {
 	PaymentDef dto.PaymentPerTime
@@ -42,3 +73,25 @@ func (ac AmountCalc) TotalAmount(duration time.Duration) money.Money {
 		Currency: ac.PaymentDef.Price.Currency,
 	}
 }
+
+// CoveringAmount gets the amount of money that pays for the given duration, priced in proportion to the payment duration.
+// Fractions of the smallest money unit are rounded up, amounts not fitting into money are capped.
+func (ac AmountCalc) CoveringAmount(duration time.Duration) money.Money {
+	covering := money.Money{Currency: ac.PaymentDef.Price.Currency}
+	if duration <= 0 || ac.PaymentDef.Duration <= 0 {
+		return covering
+	}
+
+	amount := new(big.Int).SetUint64(ac.PaymentDef.Price.Amount)
+	amount.Mul(amount, big.NewInt(int64(duration)))
+	period := big.NewInt(int64(ac.PaymentDef.Duration))
+	amount.Add(amount, new(big.Int).Sub(period, big.NewInt(1)))
+	amount.Div(amount, period)
+
+	if !amount.IsUint64() {
+		covering.Amount = ^uint64(0)
+		return covering
+	}
+	covering.Amount = amount.Uint64()
+	return covering
+}
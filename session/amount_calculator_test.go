@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/stretchr/testify/assert"
@@ -43,3 +44,82 @@ func Test_CorrectMoneyValueIsReturnedForTotalAmount(t *testing.T) {
 
 	assert.Equal(t, uint64(300), totalAmount.Amount)
 }
+
+func Test_CoveringAmountIsProportionalToDuration(t *testing.T) {
+	aCalc := AmountCalc{
+		PaymentDef: dto.PaymentPerTime{
+			Duration: time.Minute,
+			Price: money.Money{
+				Amount:   100,
+				Currency: money.CurrencyMyst,
+			},
+		},
+	}
+
+	assert.Equal(t, uint64(0), aCalc.CoveringAmount(0).Amount)
+	assert.Equal(t, uint64(34), aCalc.CoveringAmount(20*time.Second).Amount)
+	assert.Equal(t, uint64(100), aCalc.CoveringAmount(time.Minute).Amount)
+	assert.Equal(t, uint64(342), aCalc.CoveringAmount(3*time.Minute+25*time.Second).Amount)
+}
+
+func Test_CoveringAmountOfHourlyPriceForShortPeriod(t *testing.T) {
+	aCalc := AmountCalc{
+		PaymentDef: dto.PaymentPerTime{
+			Duration: time.Hour,
+			Price:    money.Money{Amount: 12500000, Currency: money.CurrencyMyst},
+		},
+	}
+
+	assert.Equal(t, uint64(69445), aCalc.CoveringAmount(20*time.Second).Amount)
+	assert.Equal(t, uint64(12500000), aCalc.CoveringAmount(time.Hour).Amount)
+}
+
+func Test_CoveringAmountIsCappedOnOverflow(t *testing.T) {
+	aCalc := AmountCalc{
+		PaymentDef: dto.PaymentPerTime{
+			Duration: time.Nanosecond,
+			Price:    money.Money{Amount: ^uint64(0) / 2, Currency: money.CurrencyMyst},
+		},
+	}
+
+	assert.Equal(t, ^uint64(0), aCalc.CoveringAmount(time.Hour).Amount)
+}
+
+type paymentPerBytes struct {
+	price money.Money
+}
+
+func (method paymentPerBytes) GetPrice() money.Money {
+	return method.price
+}
+
+func Test_PaymentDefinition(t *testing.T) {
+	price := money.Money{Amount: 50, Currency: money.CurrencyMyst}
+	free := dto.PaymentPerTime{Price: money.Money{Currency: money.CurrencyMyst}, Duration: time.Minute}
+
+	tests := []struct {
+		method   market.PaymentMethod
+		expected dto.PaymentPerTime
+	}{
+		{nil, free},
+		{dto.PaymentPerTime{Price: price, Duration: time.Hour}, dto.PaymentPerTime{Price: price, Duration: time.Hour}},
+		{dto.PaymentPerTime{Price: price}, dto.PaymentPerTime{Price: price, Duration: time.Minute}},
+		{paymentPerBytes{price: money.Money{Currency: money.CurrencyMyst}}, free},
+	}
+	for _, test := range tests {
+		definition, err := PaymentDefinition(market.ServiceProposal{PaymentMethod: test.method})
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, definition)
+	}
+}
+
+func Test_PaymentDefinitionRejectsPricesNotMeteredByTime(t *testing.T) {
+	methods := []market.PaymentMethod{
+		market.UnsupportedPaymentMethod{},
+		paymentPerBytes{price: money.Money{Amount: 50, Currency: money.CurrencyMyst}},
+	}
+	for _, method := range methods {
+		_, err := PaymentDefinition(market.ServiceProposal{PaymentMethod: method})
+		assert.Equal(t, ErrUnsupportedPaymentMethod, err)
+	}
+}
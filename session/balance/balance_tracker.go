@@ -59,6 +59,10 @@ func (bt *BalanceTracker) calculateBalance() {
 	bt.Lock()
 	defer bt.Unlock()
 	cost := bt.amountCalculator.TotalAmount(bt.timeKeeper.Elapsed())
+	if cost.Amount > bt.totalPromised {
+		bt.balance = 0
+		return
+	}
 	bt.balance = bt.totalPromised - cost.Amount
 }
 
@@ -53,6 +53,14 @@ func Test_BalanceTracker(t *testing.T) {
 	assert.Equal(t, tracker.totalPromised, promisedAmount+initialBalance)
 }
 
+func Test_BalanceTracker_DoesNotGoBelowZero(t *testing.T) {
+	mtk := &mockTimeKeeper{elapsed: time.Minute}
+	mac := &mockAmountCalculator{toReturn: money.Money{Amount: 10, Currency: money.CurrencyMyst}}
+	tracker := NewBalanceTracker(mtk, mac, 5)
+
+	assert.Equal(t, uint64(0), tracker.GetBalance())
+}
+
 type mockTimeKeeper struct {
 	elapsed     time.Duration
 	startCalled bool
@@ -20,6 +20,7 @@ package session
 import (
 	"encoding/json"
 
+	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/session/promise"
@@ -39,11 +40,13 @@ type createConsumer struct {
 	peerID         identity.Identity
 	configProvider ConfigProvider
 	promiseLoader  PromiseLoader
+	denylist       *Denylist
 }
 
 // Creator defines method for session creation
 type Creator interface {
 	Create(consumerID, issuerID identity.Identity, proposalID int, config ServiceConfiguration, requestConfig json.RawMessage) (Session, error)
+	Resume(consumerID, issuerID identity.Identity, proposalID int, sessionID string, config ServiceConfiguration) (Session, error)
 }
 
 // GetMessageEndpoint returns endpoint there to receive messages
@@ -61,33 +64,59 @@ func (consumer *createConsumer) NewRequest() (requestPtr interface{}) {
 func (consumer *createConsumer) Consume(requestPtr interface{}) (response interface{}, err error) {
 	request := requestPtr.(*CreateRequest)
 
+	issuerID := consumer.peerID
+	if request.ConsumerInfo != nil {
+		issuerID = request.ConsumerInfo.IssuerID
+	}
+
+	// denied consumer must not get any service resources allocated
+	if consumer.denylist != nil && consumer.denylist.Contains(consumer.peerID) {
+		return responseConsumerDenied, nil
+	}
+
 	config, destroyCallback, err := consumer.configProvider(request.Config)
 	if err != nil {
 		return responseInternalError, err
 	}
 
-	issuerID := consumer.peerID
-	if request.ConsumerInfo != nil {
-		issuerID = request.ConsumerInfo.IssuerID
+	sessionInstance, err := consumer.resumeOrCreate(request, issuerID, config)
+	if err != nil && destroyCallback != nil {
+		destroyCallback()
 	}
-
-	sessionInstance, err := consumer.sessionCreator.Create(consumer.peerID, issuerID, request.ProposalID, config, request.Config)
 	switch err {
 	case nil:
 		if destroyCallback != nil {
+			// suspended session must not be served, resume provides the service configuration again
 			go func() {
-				<-sessionInstance.done
+				select {
+				case <-sessionInstance.done:
+				case <-sessionInstance.detached:
+				}
 				destroyCallback()
 			}()
 		}
 		return responseWithSession(sessionInstance, config, consumer.promiseLoader.LoadPaymentInfo(consumer.peerID, consumer.receiverID, issuerID)), nil
 	case ErrorInvalidProposal:
 		return responseInvalidProposal, nil
+	case ErrorConsumerDenied:
+		return responseConsumerDenied, nil
 	default:
 		return responseInternalError, nil
 	}
 }
 
+// resumeOrCreate resumes the requested session, falling back to the new session if it can not be resumed
+func (consumer *createConsumer) resumeOrCreate(request *CreateRequest, issuerID identity.Identity, config ServiceConfiguration) (Session, error) {
+	if request.SessionID != "" {
+		sessionInstance, err := consumer.sessionCreator.Resume(consumer.peerID, issuerID, request.ProposalID, string(request.SessionID), config)
+		if err == nil {
+			return sessionInstance, nil
+		}
+		log.Info(consumerLogPrefix, "Failed to resume session ", request.SessionID, ", creating a new one: ", err)
+	}
+	return consumer.sessionCreator.Create(consumer.peerID, issuerID, request.ProposalID, config, request.Config)
+}
+
 func responseWithSession(sessionInstance Session, config ServiceConfiguration, pi *promise.PaymentInfo) CreateResponse {
 	serializedConfig, err := json.Marshal(config)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/session/promise"
@@ -88,6 +89,47 @@ func TestConsumer_ErrorInvalidProposal(t *testing.T) {
 	assert.Exactly(t, responseInvalidProposal, sessionResponse)
 }
 
+func TestConsumer_ErrorConsumerDenied_ReleasesConfig(t *testing.T) {
+	mockManager := &managerFake{
+		returnError: ErrorConsumerDenied,
+	}
+	released := false
+	consumer := createConsumer{
+		sessionCreator: mockManager,
+		configProvider: func(json.RawMessage) (ServiceConfiguration, DestroyCallback, error) {
+			return config, func() { released = true }, nil
+		},
+		promiseLoader: mpl,
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.Exactly(t, responseConsumerDenied, sessionResponse)
+	assert.True(t, released)
+}
+
+func TestConsumer_DeniedConsumerGetsNoConfig(t *testing.T) {
+	denylist := NewDenylist()
+	denylist.Add(identity.FromAddress("peer-id"))
+	consumer := createConsumer{
+		sessionCreator: &managerFake{},
+		peerID:         identity.FromAddress("peer-id"),
+		configProvider: func(json.RawMessage) (ServiceConfiguration, DestroyCallback, error) {
+			return nil, nil, errors.New("config should not be provided for denied consumer")
+		},
+		promiseLoader: mpl,
+		denylist:      denylist,
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.Exactly(t, responseConsumerDenied, sessionResponse)
+}
+
 func TestConsumer_ErrorFatal(t *testing.T) {
 	mockManager := &managerFake{
 		returnError: errors.New("fatality"),
@@ -131,13 +173,112 @@ func TestConsumer_UsesIssuerID(t *testing.T) {
 	assert.Equal(t, issuerID, mockManager.lastIssuerID)
 }
 
+func TestConsumer_ResumesSession(t *testing.T) {
+	mockManager := &managerFake{
+		returnSession: Session{
+			ID:         "old-id",
+			ConsumerID: identity.FromAddress("peer-id"),
+			Config:     config,
+		},
+	}
+	consumer := createConsumer{
+		sessionCreator: mockManager,
+		peerID:         identity.FromAddress("peer-id"),
+		configProvider: mockConsumer,
+		promiseLoader:  mpl,
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	request.ProposalID = 101
+	request.SessionID = "old-id"
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "old-id", mockManager.lastResumedID)
+	assert.Exactly(
+		t,
+		CreateResponse{
+			Success: true,
+			Session: SessionDto{
+				ID:     "old-id",
+				Config: config,
+			},
+		},
+		sessionResponse,
+	)
+}
+
+func TestConsumer_ReleasesConfigWhenSessionSuspended(t *testing.T) {
+	detached := make(chan struct{})
+	mockManager := &managerFake{
+		returnSession: Session{
+			ID:       "new-id",
+			done:     make(chan struct{}),
+			detached: detached,
+		},
+	}
+	released := make(chan struct{})
+	consumer := createConsumer{
+		sessionCreator: mockManager,
+		peerID:         identity.FromAddress("peer-id"),
+		configProvider: func(json.RawMessage) (ServiceConfiguration, DestroyCallback, error) {
+			return config, func() { close(released) }, nil
+		},
+		promiseLoader: mpl,
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	_, err := consumer.Consume(request)
+	assert.NoError(t, err)
+
+	select {
+	case <-released:
+		t.Fatal("config released while session is attached")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(detached)
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("config not released for suspended session")
+	}
+}
+
+func TestConsumer_CreatesNewSessionWhenResumeFails(t *testing.T) {
+	mockManager := &managerFake{
+		returnSession: Session{
+			ID:         "new-id",
+			ConsumerID: identity.FromAddress("peer-id"),
+		},
+		returnResumeError: ErrorSessionNotExists,
+	}
+	consumer := createConsumer{
+		sessionCreator: mockManager,
+		peerID:         identity.FromAddress("peer-id"),
+		configProvider: mockConsumer,
+		promiseLoader:  mpl,
+	}
+
+	request := consumer.NewRequest().(*CreateRequest)
+	request.ProposalID = 101
+	request.SessionID = "expired-id"
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ID("new-id"), sessionResponse.(CreateResponse).Session.ID)
+}
+
 // managerFake represents fake Manager usually useful in tests
 type managerFake struct {
 	lastConsumerID identity.Identity
 	lastIssuerID   identity.Identity
 	lastProposalID int
+	lastResumedID  string
 	returnSession  Session
 	returnError    error
+
+	returnResumeError error
 }
 
 // Create function creates and returns fake session
@@ -148,6 +289,15 @@ func (manager *managerFake) Create(consumerID, issuerID identity.Identity, propo
 	return manager.returnSession, manager.returnError
 }
 
+// Resume function resumes and returns fake session
+func (manager *managerFake) Resume(consumerID, issuerID identity.Identity, proposalID int, sessionID string, config ServiceConfiguration) (Session, error) {
+	manager.lastConsumerID = consumerID
+	manager.lastIssuerID = issuerID
+	manager.lastProposalID = proposalID
+	manager.lastResumedID = sessionID
+	return manager.returnSession, manager.returnResumeError
+}
+
 // Destroy fake destroy function
 func (manager *managerFake) Destroy(consumerID identity.Identity, sessionID string) error {
 	return nil
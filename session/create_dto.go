@@ -30,6 +30,7 @@ const endpointSessionCreate = communication.RequestEndpoint("session-create")
 var (
 	responseInvalidProposal = CreateResponse{Success: false, Message: "Invalid Proposal"}
 	responseInternalError   = CreateResponse{Success: false, Message: "Internal Error"}
+	responseConsumerDenied  = CreateResponse{Success: false, Message: "Consumer Denied"}
 )
 
 // CreateRequest structure represents message from service consumer to initiate session for given proposal id
@@ -37,6 +38,8 @@ type CreateRequest struct {
 	ProposalID   int             `json:"proposal_id"`
 	Config       json.RawMessage `json:"config"`
 	ConsumerInfo *ConsumerInfo   `json:"consumer_info,omitempty"`
+	// SessionID of the suspended session consumer wants to resume, new session is created if it can not be resumed
+	SessionID ID `json:"session_id,omitempty"`
 }
 
 // CreateResponse structure represents service provider response to given session request from consumer
@@ -18,6 +18,7 @@
 package session
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -29,6 +30,7 @@ type createProducer struct {
 	ProposalID   int
 	Config       json.RawMessage
 	ConsumerInfo *ConsumerInfo
+	SessionID    ID
 }
 
 func (producer *createProducer) GetRequestEndpoint() communication.RequestEndpoint {
@@ -44,20 +46,28 @@ func (producer *createProducer) Produce() (requestPtr interface{}) {
 		ProposalID:   producer.ProposalID,
 		Config:       producer.Config,
 		ConsumerInfo: producer.ConsumerInfo,
+		SessionID:    producer.SessionID,
 	}
 }
 
-// RequestSessionCreate requests session creation and returns session DTO
-func RequestSessionCreate(sender communication.Sender, proposalID int, config interface{}, ci ConsumerInfo) (session SessionDto, pi *promise.PaymentInfo, err error) {
+// RequestSessionCreate requests session creation and returns session DTO, waiting for provider until ctx is done
+func RequestSessionCreate(ctx context.Context, sender communication.Sender, proposalID int, config interface{}, ci ConsumerInfo) (session SessionDto, pi *promise.PaymentInfo, err error) {
+	return RequestSessionResume(ctx, sender, "", proposalID, config, ci)
+}
+
+// RequestSessionResume requests to resume the suspended session and returns session DTO.
+// Provider creates a new session if the given one can not be resumed anymore.
+func RequestSessionResume(ctx context.Context, sender communication.Sender, sessionID ID, proposalID int, config interface{}, ci ConsumerInfo) (session SessionDto, pi *promise.PaymentInfo, err error) {
 	sessionCreateConfigJSON, err := json.Marshal(config)
 	if err != nil {
 		return
 	}
 
-	responsePtr, err := sender.Request(&createProducer{
+	responsePtr, err := sender.RequestContext(ctx, &createProducer{
 		ProposalID:   proposalID,
 		Config:       sessionCreateConfigJSON,
 		ConsumerInfo: &ci,
+		SessionID:    sessionID,
 	})
 	if err != nil {
 		return
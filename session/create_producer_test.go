@@ -18,6 +18,7 @@
 package session
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -45,13 +46,21 @@ type fakeSessionConfig struct {
 
 func TestProducer_RequestSessionCreate(t *testing.T) {
 	sender := &fakeSender{}
-	sessionData, paymentInfo, err := RequestSessionCreate(sender, 123, []byte{}, ConsumerInfo{})
+	sessionData, paymentInfo, err := RequestSessionCreate(context.Background(), sender, 123, []byte{}, ConsumerInfo{})
 	assert.NoError(t, err)
 	assert.Exactly(t, successfullSessionID, sessionData.ID)
 	assert.Exactly(t, successfullSessionConfig, sessionData.Config)
 	assert.Nil(t, paymentInfo)
 }
 
+func TestProducer_RequestSessionCreateCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := RequestSessionCreate(ctx, &fakeSender{}, 123, []byte{}, ConsumerInfo{})
+	assert.Equal(t, context.Canceled, err)
+}
+
 type fakeSender struct {
 	lastRequest communication.RequestProducer
 }
@@ -60,6 +69,17 @@ func (sender *fakeSender) Send(producer communication.MessageProducer) error {
 	return nil
 }
 
+func (sender *fakeSender) SendContext(ctx context.Context, producer communication.MessageProducer) error {
+	return sender.Send(producer)
+}
+
+func (sender *fakeSender) RequestContext(ctx context.Context, producer communication.RequestProducer) (responsePtr interface{}, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return sender.Request(producer)
+}
+
 func (sender *fakeSender) Request(producer communication.RequestProducer) (responsePtr interface{}, err error) {
 	sender.lastRequest = producer
 	return &CreateResponse{
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
+)
+
+const (
+	denylistLogPrefix = "[session-denylist] "
+	denylistBucket    = "session-denylist"
+)
+
+// DenylistStorage keeps denied consumers across node restarts
+type DenylistStorage interface {
+	Store(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+}
+
+// deniedConsumer is the stored denylist entry
+type deniedConsumer struct {
+	Address  string `storm:"id"`
+	DeniedAt time.Time
+}
+
+// Denylist keeps consumers provider refuses to serve
+type Denylist struct {
+	consumers map[identity.Identity]struct{}
+	lock      sync.RWMutex
+	storage   DenylistStorage
+}
+
+// NewDenylist returns empty consumer denylist
+func NewDenylist() *Denylist {
+	return &Denylist{
+		consumers: make(map[identity.Identity]struct{}),
+	}
+}
+
+// NewPersistentDenylist returns consumer denylist loaded from the storage, which keeps further denied consumers too
+func NewPersistentDenylist(storage DenylistStorage) (*Denylist, error) {
+	var denied []deniedConsumer
+	if err := storage.GetAllFrom(denylistBucket, &denied); err != nil {
+		return nil, errors.Wrap(err, "failed to load consumer denylist")
+	}
+
+	denylist := NewDenylist()
+	denylist.storage = storage
+	for _, consumer := range denied {
+		denylist.consumers[identity.FromAddress(consumer.Address)] = struct{}{}
+	}
+	return denylist, nil
+}
+
+// Add denies the consumer any further sessions
+func (denylist *Denylist) Add(consumerID identity.Identity) {
+	denylist.lock.Lock()
+	defer denylist.lock.Unlock()
+
+	denylist.consumers[consumerID] = struct{}{}
+	if denylist.storage == nil {
+		return
+	}
+	err := denylist.storage.Store(denylistBucket, &deniedConsumer{Address: consumerID.Address, DeniedAt: time.Now().UTC()})
+	if err != nil {
+		log.Error(denylistLogPrefix, "failed to store denied consumer ", consumerID.Address, ": ", err)
+	}
+}
+
+// Contains checks if the consumer is denied
+func (denylist *Denylist) Contains(consumerID identity.Identity) bool {
+	denylist.lock.RLock()
+	defer denylist.lock.RUnlock()
+
+	_, found := denylist.consumers[consumerID]
+	return found
+}
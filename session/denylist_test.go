@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDenylistStorage struct {
+	stored []deniedConsumer
+	err    error
+}
+
+func (storage *mockDenylistStorage) Store(bucket string, object interface{}) error {
+	storage.stored = append(storage.stored, *object.(*deniedConsumer))
+	return nil
+}
+
+func (storage *mockDenylistStorage) GetAllFrom(bucket string, array interface{}) error {
+	*array.(*[]deniedConsumer) = append([]deniedConsumer(nil), storage.stored...)
+	return storage.err
+}
+
+func TestPersistentDenylist_KeepsDeniedConsumersAcrossRestarts(t *testing.T) {
+	storage := &mockDenylistStorage{}
+	denylist, err := NewPersistentDenylist(storage)
+	assert.NoError(t, err)
+
+	denylist.Add(identity.FromAddress("0x1"))
+	assert.Len(t, storage.stored, 1)
+
+	restored, err := NewPersistentDenylist(storage)
+	assert.NoError(t, err)
+	assert.True(t, restored.Contains(identity.FromAddress("0x1")))
+	assert.False(t, restored.Contains(identity.FromAddress("0x2")))
+}
+
+func TestPersistentDenylist_FailsOnStorageError(t *testing.T) {
+	_, err := NewPersistentDenylist(&mockDenylistStorage{err: errors.New("broken")})
+	assert.EqualError(t, err, "failed to load consumer denylist: broken")
+}
@@ -31,6 +31,7 @@ type destroyConsumer struct {
 // Destroyer interface for destroying session
 type Destroyer interface {
 	Destroy(consumerID identity.Identity, sessionID string) error
+	Suspend(consumerID identity.Identity, sessionID string) error
 }
 
 // GetMessageEndpoint returns endpoint where to receive messages
@@ -47,7 +48,11 @@ func (consumer *destroyConsumer) NewRequest() (requestPtr interface{}) {
 func (consumer *destroyConsumer) Consume(requestPtr interface{}) (response interface{}, err error) {
 	request := requestPtr.(*DestroyRequest)
 
-	err = consumer.SessionDestroyer.Destroy(consumer.PeerID, request.SessionID)
+	if request.Resumable {
+		err = consumer.SessionDestroyer.Suspend(consumer.PeerID, request.SessionID)
+	} else {
+		err = consumer.SessionDestroyer.Destroy(consumer.PeerID, request.SessionID)
+	}
 	return destroyResponse(), err
 }
 
@@ -28,6 +28,7 @@ import (
 type managerDestroyFake struct {
 	returnSession Session
 	returnError   error
+	suspended     bool
 }
 
 func TestDestroyConsumer_Success(t *testing.T) {
@@ -55,6 +56,22 @@ func TestDestroyConsumer_Success(t *testing.T) {
 	)
 }
 
+func TestDestroyConsumer_SuspendsResumableSession(t *testing.T) {
+	mockDestroyer := &managerDestroyFake{}
+	consumer := destroyConsumer{
+		SessionDestroyer: mockDestroyer,
+		PeerID:           identity.FromAddress("peer-id"),
+	}
+
+	request := consumer.NewRequest().(*DestroyRequest)
+	request.Resumable = true
+	sessionResponse, err := consumer.Consume(request)
+
+	assert.NoError(t, err)
+	assert.True(t, mockDestroyer.suspended)
+	assert.Exactly(t, destroyResponse(), sessionResponse)
+}
+
 func TestDestroyConsumer_ErrorInvalidSession(t *testing.T) {
 	mockDestroyer := &managerDestroyFake{
 		returnError: ErrorSessionNotExists,
@@ -85,3 +102,9 @@ func TestDestroyConsumer_ErrorNotSessionOwner(t *testing.T) {
 func (manager *managerDestroyFake) Destroy(consumerID identity.Identity, sessionID string) error {
 	return manager.returnError
 }
+
+// Suspend fake suspend function
+func (manager *managerDestroyFake) Suspend(consumerID identity.Identity, sessionID string) error {
+	manager.suspended = true
+	return manager.returnError
+}
@@ -26,6 +26,8 @@ const endpointSessionDestroy = communication.RequestEndpoint("session-destroy")
 // DestroyRequest structure represents message from service consumer to destroy session for given session id
 type DestroyRequest struct {
 	SessionID string `json:"session_id"`
+	// Resumable asks provider to keep the session for a while, so the consumer could resume it after reconnect
+	Resumable bool `json:"resumable,omitempty"`
 }
 
 // DestroyResponse structure represents service provider response to given session request from consumer
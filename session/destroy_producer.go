@@ -29,6 +29,7 @@ const sessionDestroyPrefix = "[session.DestroyProducer] "
 
 type destroyProducer struct {
 	SessionID string
+	Resumable bool
 }
 
 func (producer *destroyProducer) GetRequestEndpoint() communication.RequestEndpoint {
@@ -42,14 +43,22 @@ func (producer *destroyProducer) NewResponse() (responsePtr interface{}) {
 func (producer *destroyProducer) Produce() (requestPtr interface{}) {
 	return &DestroyRequest{
 		SessionID: producer.SessionID,
+		Resumable: producer.Resumable,
 	}
 }
 
 // RequestSessionDestroy requests session destruction and returns response data
 func RequestSessionDestroy(sender communication.Sender, sessionID ID) error {
-	responsePtr, err := sender.Request(&destroyProducer{
-		SessionID: string(sessionID),
-	})
+	return requestSessionDestroy(sender, &destroyProducer{SessionID: string(sessionID)})
+}
+
+// RequestSessionSuspend asks provider to keep the session resumable for a grace period instead of destroying it
+func RequestSessionSuspend(sender communication.Sender, sessionID ID) error {
+	return requestSessionDestroy(sender, &destroyProducer{SessionID: string(sessionID), Resumable: true})
+}
+
+func requestSessionDestroy(sender communication.Sender, producer *destroyProducer) error {
+	responsePtr, err := sender.Request(producer)
 	if err != nil {
 		log.Info(sessionDestroyPrefix, fmt.Sprintf("Session destroy request failed: %#v", err.Error()))
 		return err
@@ -18,20 +18,27 @@
 package session
 
 import (
+	"encoding/json"
+	"sync"
+
+	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
 )
 
+const dialogHandlerLogPrefix = "[session-dialog-handler] "
+
 // ManagerFactory initiates session Manager instance during runtime
 type ManagerFactory func(dialog communication.Dialog) *Manager
 
 // NewDialogHandler constructs handler which gets all incoming dialogs and starts handling them
-func NewDialogHandler(sessionManagerFactory ManagerFactory, configProvider ConfigProvider, promiseLoader PromiseLoader, receiverID identity.Identity) *handler {
+func NewDialogHandler(sessionManagerFactory ManagerFactory, configProvider ConfigProvider, promiseLoader PromiseLoader, receiverID identity.Identity, denylist *Denylist) *handler {
 	return &handler{
 		sessionManagerFactory: sessionManagerFactory,
 		configProvider:        configProvider,
 		promiseLoader:         promiseLoader,
 		receiverID:            receiverID,
+		denylist:              denylist,
 	}
 }
 
@@ -40,21 +47,33 @@ type handler struct {
 	configProvider        ConfigProvider
 	promiseLoader         PromiseLoader
 	receiverID            identity.Identity
+	denylist              *Denylist
 }
 
 // Handle starts serving services in given Dialog instance
 func (handler *handler) Handle(dialog communication.Dialog) error {
-	return handler.subscribeSessionRequests(dialog)
+	sessions := &dialogSessions{
+		Creator:   handler.sessionManagerFactory(dialog),
+		resumable: communication.CapabilitiesOf(dialog).Has(communication.CapabilitySessionResume),
+	}
+	destroyer := handler.sessionManagerFactory(dialog)
+	if err := handler.subscribeSessionRequests(dialog, sessions, destroyer); err != nil {
+		return err
+	}
+
+	go suspendDialogSessions(dialog, sessions, destroyer)
+	return nil
 }
 
-func (handler *handler) subscribeSessionRequests(dialog communication.Dialog) error {
+func (handler *handler) subscribeSessionRequests(dialog communication.Dialog, sessionCreator Creator, destroyer Destroyer) error {
 	err := dialog.Respond(
 		&createConsumer{
-			sessionCreator: handler.sessionManagerFactory(dialog),
+			sessionCreator: sessionCreator,
 			peerID:         dialog.PeerID(),
 			configProvider: handler.configProvider,
 			promiseLoader:  handler.promiseLoader,
 			receiverID:     handler.receiverID,
+			denylist:       handler.denylist,
 		},
 	)
 
@@ -62,13 +81,19 @@ func (handler *handler) subscribeSessionRequests(dialog communication.Dialog) er
 		return err
 	}
 
+	sd := &sessionDestroyer{
+		destroyer:   destroyer,
+		unsubscribe: dialog.Unsubscribe,
+	}
+	if !communication.CapabilitiesOf(dialog).Has(communication.CapabilityKeepalive) {
+		// peers without keepalive never end the dialog themselves, so it is closed to give its place back
+		sd.closeDialog = dialog.Close
+	}
+
 	return dialog.Respond(
 		&destroyConsumer{
-			SessionDestroyer: &sessionDestroyer{
-				destroyer:   handler.sessionManagerFactory(dialog),
-				unsubscribe: dialog.Unsubscribe,
-			},
-			PeerID: dialog.PeerID(),
+			SessionDestroyer: sd,
+			PeerID:           dialog.PeerID(),
 		},
 	)
 }
@@ -76,9 +101,88 @@ func (handler *handler) subscribeSessionRequests(dialog communication.Dialog) er
 type sessionDestroyer struct {
 	destroyer   Destroyer
 	unsubscribe func()
+	// closeDialog ends the dialog once its session is destroyed, nil if peer ends it
+	closeDialog func() error
 }
 
 func (sd *sessionDestroyer) Destroy(consumerID identity.Identity, sessionID string) error {
 	sd.unsubscribe()
-	return sd.destroyer.Destroy(consumerID, sessionID)
+	err := sd.destroyer.Destroy(consumerID, sessionID)
+	if sd.closeDialog != nil {
+		if err := sd.closeDialog(); err != nil {
+			log.Warn(dialogHandlerLogPrefix, "Failed to close dialog of destroyed session ", sessionID, ": ", err)
+		}
+	}
+	return err
+}
+
+func (sd *sessionDestroyer) Suspend(consumerID identity.Identity, sessionID string) error {
+	sd.unsubscribe()
+	return sd.destroyer.Suspend(consumerID, sessionID)
+}
+
+// attachmentSuspender suspends sessions still attached the way they were over the dialog
+type attachmentSuspender interface {
+	SuspendAttachment(sessionInstance Session) error
+}
+
+// suspendDialogSessions suspends sessions of the dialog once it ends, so that consumer could resume them over a new one.
+// Sessions already resumed over another dialog are left attached to it.
+// Dialogs without keepalive end only when closed by provider, so their sessions are left to the consumer and the reaper.
+func suspendDialogSessions(dialog communication.Dialog, sessions *dialogSessions, suspender attachmentSuspender) {
+	if !communication.CapabilitiesOf(dialog).Has(communication.CapabilityKeepalive) {
+		return
+	}
+	<-dialog.Done()
+
+	for _, sessionInstance := range sessions.list() {
+		err := suspender.SuspendAttachment(sessionInstance)
+		if err != nil && err != ErrorSessionNotExists {
+			log.Warn(dialogHandlerLogPrefix, "Failed to suspend session ", sessionInstance.ID, " of ended dialog: ", err)
+		}
+	}
+}
+
+// dialogSessions remembers sessions created and resumed over the dialog, as they were attached to it
+type dialogSessions struct {
+	Creator
+	// resumable tells whether peers agreed on resuming sessions over the dialog
+	resumable bool
+
+	lock     sync.Mutex
+	attached []Session
+}
+
+func (sessions *dialogSessions) Create(consumerID, issuerID identity.Identity, proposalID int, config ServiceConfiguration, requestConfig json.RawMessage) (Session, error) {
+	sessionInstance, err := sessions.Creator.Create(consumerID, issuerID, proposalID, config, requestConfig)
+	if err == nil {
+		sessions.add(sessionInstance)
+	}
+	return sessionInstance, err
+}
+
+func (sessions *dialogSessions) Resume(consumerID, issuerID identity.Identity, proposalID int, sessionID string, config ServiceConfiguration) (Session, error) {
+	if !sessions.resumable {
+		return Session{}, ErrorSessionNotExists
+	}
+
+	sessionInstance, err := sessions.Creator.Resume(consumerID, issuerID, proposalID, sessionID, config)
+	if err == nil {
+		sessions.add(sessionInstance)
+	}
+	return sessionInstance, err
+}
+
+func (sessions *dialogSessions) add(sessionInstance Session) {
+	sessions.lock.Lock()
+	defer sessions.lock.Unlock()
+
+	sessions.attached = append(sessions.attached, sessionInstance)
+}
+
+func (sessions *dialogSessions) list() []Session {
+	sessions.lock.Lock()
+	defer sessions.lock.Unlock()
+
+	return append([]Session{}, sessions.attached...)
 }
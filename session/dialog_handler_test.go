@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialogSessions_RemembersCreatedAndResumed(t *testing.T) {
+	creator := &managerFake{returnSession: Session{ID: "created-id"}}
+	sessions := &dialogSessions{Creator: creator, resumable: true}
+
+	_, err := sessions.Create(identity.FromAddress("consumer"), identity.FromAddress("issuer"), 1, nil, nil)
+	assert.NoError(t, err)
+
+	creator.returnSession = Session{ID: "resumed-id"}
+	_, err = sessions.Resume(identity.FromAddress("consumer"), identity.FromAddress("issuer"), 1, "resumed-id", nil)
+	assert.NoError(t, err)
+
+	creator.returnResumeError = ErrorSessionNotExists
+	_, err = sessions.Resume(identity.FromAddress("consumer"), identity.FromAddress("issuer"), 1, "unknown-id", nil)
+	assert.Equal(t, ErrorSessionNotExists, err)
+
+	assert.Equal(t, []Session{{ID: "created-id"}, {ID: "resumed-id"}}, sessions.list())
+}
+
+func TestDialogSessions_ResumesOnlyWhenAgreed(t *testing.T) {
+	sessions := &dialogSessions{Creator: &managerFake{returnSession: Session{ID: "resumed-id"}}}
+
+	_, err := sessions.Resume(identity.FromAddress("consumer"), identity.FromAddress("issuer"), 1, "resumed-id", nil)
+	assert.Equal(t, ErrorSessionNotExists, err)
+	assert.Len(t, sessions.list(), 0)
+}
+
+func TestSuspendDialogSessions_SuspendsWhenDialogEnds(t *testing.T) {
+	dialog := &dialogDoneFake{
+		peerID:       identity.FromAddress("consumer"),
+		done:         make(chan struct{}),
+		capabilities: communication.NewCapabilities(communication.CapabilityKeepalive),
+	}
+	sessions := &dialogSessions{Creator: &managerFake{returnSession: Session{ID: "session-id"}}}
+	_, err := sessions.Create(dialog.peerID, identity.FromAddress("issuer"), 1, nil, nil)
+	assert.NoError(t, err)
+
+	destroyer := &managerSuspendFake{suspended: make(chan string, 1)}
+	go suspendDialogSessions(dialog, sessions, destroyer)
+
+	select {
+	case <-destroyer.suspended:
+		t.Fatal("Session suspended before dialog ended")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(dialog.done)
+	select {
+	case sessionID := <-destroyer.suspended:
+		assert.Equal(t, "session-id", sessionID)
+	case <-time.After(time.Second):
+		t.Fatal("Session was not suspended after dialog ended")
+	}
+}
+
+func TestSuspendDialogSessions_LeavesSessionResumedOverNewDialog(t *testing.T) {
+	manager := NewManager(currentProposal, generateSessionID, NewStorageMemory(), mockBalanceTrackerFactory, func(json.RawMessage) {}, nil, &MockNatEventTracker{}, time.Minute, nil, nil)
+	keepalive := communication.NewCapabilities(communication.CapabilityKeepalive)
+	oldDialog := &dialogDoneFake{peerID: consumerID, done: make(chan struct{}), capabilities: keepalive}
+	newDialog := &dialogDoneFake{peerID: consumerID, done: make(chan struct{}), capabilities: keepalive}
+
+	oldSessions := &dialogSessions{Creator: manager, resumable: true}
+	created, err := oldSessions.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+
+	// consumer resumes over a new dialog before the old one is noticed to be gone
+	newSessions := &dialogSessions{Creator: manager, resumable: true}
+	resumed, err := newSessions.Resume(consumerID, consumerID, currentProposalID, string(created.ID), config)
+	assert.NoError(t, err)
+
+	close(oldDialog.done)
+	suspendDialogSessions(oldDialog, oldSessions, manager)
+	select {
+	case <-resumed.detached:
+		t.Fatal("Session resumed over new dialog was suspended")
+	default:
+	}
+
+	close(newDialog.done)
+	suspendDialogSessions(newDialog, newSessions, manager)
+	select {
+	case <-resumed.detached:
+	default:
+		t.Fatal("Session was not suspended after new dialog ended")
+	}
+}
+
+func TestSuspendDialogSessions_ReturnsWithoutKeepalive(t *testing.T) {
+	dialog := &dialogDoneFake{peerID: identity.FromAddress("consumer"), done: make(chan struct{})}
+	sessions := &dialogSessions{Creator: &managerFake{returnSession: Session{ID: "session-id"}}}
+	_, err := sessions.Create(dialog.peerID, identity.FromAddress("issuer"), 1, nil, nil)
+	assert.NoError(t, err)
+
+	destroyer := &managerSuspendFake{suspended: make(chan string, 1)}
+	returned := make(chan struct{})
+	go func() {
+		suspendDialogSessions(dialog, sessions, destroyer)
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Waiting for dialog without keepalive to end")
+	}
+	assert.Len(t, destroyer.suspended, 0)
+}
+
+func TestSessionDestroyer_ClosesDialogWithoutKeepalive(t *testing.T) {
+	unsubscribed, closed := false, false
+	sd := &sessionDestroyer{
+		destroyer:   &managerSuspendFake{},
+		unsubscribe: func() { unsubscribed = true },
+		closeDialog: func() error {
+			closed = true
+			return nil
+		},
+	}
+
+	assert.NoError(t, sd.Destroy(identity.FromAddress("consumer"), "session-id"))
+	assert.True(t, unsubscribed)
+	assert.True(t, closed)
+}
+
+// dialogDoneFake is a dialog which ends when done is closed
+type dialogDoneFake struct {
+	communication.Dialog
+	peerID       identity.Identity
+	done         chan struct{}
+	capabilities communication.Capabilities
+}
+
+func (dialog *dialogDoneFake) Capabilities() communication.Capabilities {
+	return dialog.capabilities
+}
+
+func (dialog *dialogDoneFake) PeerID() identity.Identity {
+	return dialog.peerID
+}
+
+func (dialog *dialogDoneFake) Done() <-chan struct{} {
+	return dialog.done
+}
+
+// managerSuspendFake reports suspended sessions to the channel
+type managerSuspendFake struct {
+	suspended chan string
+}
+
+func (manager *managerSuspendFake) Destroy(consumerID identity.Identity, sessionID string) error {
+	return nil
+}
+
+func (manager *managerSuspendFake) Suspend(consumerID identity.Identity, sessionID string) error {
+	manager.suspended <- sessionID
+	return nil
+}
+
+func (manager *managerSuspendFake) SuspendAttachment(sessionInstance Session) error {
+	manager.suspended <- string(sessionInstance.ID)
+	return nil
+}
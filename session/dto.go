@@ -18,8 +18,10 @@
 package session
 
 import (
+	"sync"
 	"time"
 
+	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
 )
 
@@ -32,6 +34,11 @@ type BalanceTracker interface {
 	Stop()
 }
 
+// ActivityTracker is implemented by balance trackers knowing when the consumer paid for the session last time
+type ActivityTracker interface {
+	LastActivity() time.Time
+}
+
 // Session structure holds all required information about current session between service consumer and provider
 type Session struct {
 	ID         ID
@@ -40,6 +47,40 @@ type Session struct {
 	CreatedAt  time.Time
 	Last       bool
 	done       chan struct{}
+	// detached is closed when consumer leaves the dialog the session is attached to
+	detached chan struct{}
+	// expiry destroys the session once resume grace period is over, set while session is suspended
+	expiry *time.Timer
+	// finish guards session resources from being released twice
+	finish *sync.Once
+	// destroyer is the manager of the session, which releases it on provider's demand
+	destroyer Destroyer
+
+	sender         communication.Sender
+	balanceTracker BalanceTracker
+	attachedAt     time.Time
+}
+
+// lastActivity returns the time consumer was last seen using the session
+func (s Session) lastActivity() time.Time {
+	lastActivity := s.attachedAt
+	if tracker, ok := s.balanceTracker.(ActivityTracker); ok {
+		if paidAt := tracker.LastActivity(); paidAt.After(lastActivity) {
+			lastActivity = paidAt
+		}
+	}
+	return lastActivity
+}
+
+// terminate removes the session from storage and releases its resources
+func terminate(storage Storage, s Session) {
+	s.finish.Do(func() {
+		if s.expiry != nil {
+			s.expiry.Stop()
+		}
+		storage.Remove(s.ID)
+		close(s.done)
+	})
 }
 
 // ServiceConfiguration defines service configuration from underlying transport mechanism to be passed to remote party
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat/traversal"
@@ -36,8 +37,13 @@ var (
 	ErrorSessionNotExists = errors.New("session does not exists")
 	// ErrorWrongSessionOwner returned when consumer tries to destroy session that does not belongs to him
 	ErrorWrongSessionOwner = errors.New("wrong session owner")
+	// ErrorConsumerDenied returned when consumer denied by provider tries to create or resume a session
+	ErrorConsumerDenied = errors.New("consumer is denied")
 )
 
+// DefaultResumeGracePeriod is the time suspended session is kept for the consumer to resume it
+const DefaultResumeGracePeriod = 2 * time.Minute
+
 const managerLogPrefix = "[session-manager] "
 
 // IDGenerator defines method for session id generation
@@ -86,6 +92,9 @@ func NewManager(
 	natPingerChan func(json.RawMessage),
 	lastSessionShutdown chan struct{},
 	natEventGetter NATEventGetter,
+	resumeGracePeriod time.Duration,
+	sender communication.Sender,
+	denylist *Denylist,
 ) *Manager {
 	return &Manager{
 		currentProposal:       currentProposal,
@@ -95,6 +104,9 @@ func NewManager(
 		natPingerChan:         natPingerChan,
 		lastSessionShutdown:   lastSessionShutdown,
 		natEventGetter:        natEventGetter,
+		resumeGracePeriod:     resumeGracePeriod,
+		sender:                sender,
+		denylist:              denylist,
 
 		creationLock: sync.Mutex{},
 	}
@@ -110,6 +122,9 @@ type Manager struct {
 	natPingerChan         func(json.RawMessage)
 	lastSessionShutdown   chan struct{}
 	natEventGetter        NATEventGetter
+	resumeGracePeriod     time.Duration
+	sender                communication.Sender
+	denylist              *Denylist
 
 	creationLock sync.Mutex
 }
@@ -123,6 +138,10 @@ func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.I
 		err = ErrorInvalidProposal
 		return
 	}
+	if manager.isDenied(consumerID) {
+		err = ErrorConsumerDenied
+		return
+	}
 
 	sessionInstance.ID, err = manager.generateID()
 	if err != nil {
@@ -130,31 +149,15 @@ func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.I
 	}
 	sessionInstance.ConsumerID = consumerID
 	sessionInstance.done = make(chan struct{})
+	sessionInstance.finish = &sync.Once{}
+	sessionInstance.destroyer = manager
 	sessionInstance.Config = config
 	sessionInstance.CreatedAt = time.Now().UTC()
 
-	balanceTracker, err := manager.balanceTrackerFactory(consumerID, identity.FromAddress(manager.currentProposal.ProviderID), issuerID)
-	if err != nil {
+	if err = manager.attach(&sessionInstance, issuerID); err != nil {
 		return
 	}
 
-	// stop the balance tracker once the session is finished
-	go func() {
-		<-sessionInstance.done
-		balanceTracker.Stop()
-	}()
-
-	go func() {
-		err := balanceTracker.Start()
-		if err != nil {
-			log.Error(managerLogPrefix, "balance tracker error: ", err)
-			destroyErr := manager.Destroy(consumerID, string(sessionInstance.ID))
-			if destroyErr != nil {
-				log.Error(managerLogPrefix, "session cleanup failed: ", err)
-			}
-		}
-	}()
-
 	// start NAT pinger here, do not block - configuration should be returned to consumer
 	// start NAT pinger, get hole punched, launch service.
 	//  on session-destroy - shutdown service and wait for session-create
@@ -168,6 +171,109 @@ func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.I
 	return sessionInstance, nil
 }
 
+// Resume re-attaches the consumer to the previously created session, possibly over the new dialog.
+// Session gets the newly provided service configuration, while balance tracking continues from the last known promise.
+func (manager *Manager) Resume(consumerID identity.Identity, issuerID identity.Identity, proposalID int, sessionID string, config ServiceConfiguration) (sessionInstance Session, err error) {
+	manager.creationLock.Lock()
+	defer manager.creationLock.Unlock()
+
+	if manager.currentProposal.ID != proposalID {
+		return Session{}, ErrorInvalidProposal
+	}
+	if manager.isDenied(consumerID) {
+		return Session{}, ErrorConsumerDenied
+	}
+
+	sessionInstance, found := manager.sessionStorage.Find(ID(sessionID))
+	if !found {
+		return Session{}, ErrorSessionNotExists
+	}
+	if sessionInstance.ConsumerID != consumerID {
+		return Session{}, ErrorWrongSessionOwner
+	}
+
+	if sessionInstance.expiry != nil {
+		if !sessionInstance.expiry.Stop() {
+			// grace period is already over, expiry is about to destroy the session
+			return Session{}, ErrorSessionNotExists
+		}
+		sessionInstance.expiry = nil
+	} else {
+		// consumer reconnected before the previous dialog was noticed to be gone
+		close(sessionInstance.detached)
+	}
+
+	sessionInstance.Config = config
+	if err = manager.attach(&sessionInstance, issuerID); err != nil {
+		terminate(manager.sessionStorage, sessionInstance)
+		return Session{}, err
+	}
+	manager.sessionStorage.Add(sessionInstance)
+
+	log.Info(managerLogPrefix, "session resumed: ", sessionID)
+	return sessionInstance, nil
+}
+
+// Suspend detaches the consumer from the session keeping it resumable for the grace period.
+// Balance tracking stops and service resources of the session are released until it is resumed,
+// session is destroyed if it is not resumed in time.
+func (manager *Manager) Suspend(consumerID identity.Identity, sessionID string) error {
+	manager.creationLock.Lock()
+	defer manager.creationLock.Unlock()
+
+	sessionInstance, err := manager.find(consumerID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	manager.suspend(sessionInstance)
+	return nil
+}
+
+// SuspendAttachment suspends the session like Suspend, but only while it is still attached the way it was in the given instance.
+// Session resumed in the meantime, e.g. over a new dialog before the previous one was noticed to be gone, is left attached.
+func (manager *Manager) SuspendAttachment(attached Session) error {
+	manager.creationLock.Lock()
+	defer manager.creationLock.Unlock()
+
+	sessionInstance, err := manager.find(attached.ConsumerID, string(attached.ID))
+	if err != nil {
+		return err
+	}
+	if sessionInstance.detached != attached.detached {
+		return nil
+	}
+
+	manager.suspend(sessionInstance)
+	return nil
+}
+
+// suspend detaches the consumer from the session or destroys it if sessions are not kept resumable, creationLock must be held
+func (manager *Manager) suspend(sessionInstance Session) {
+	if manager.resumeGracePeriod <= 0 {
+		manager.destroy(sessionInstance)
+		return
+	}
+	if sessionInstance.expiry != nil {
+		return
+	}
+
+	sessionID := sessionInstance.ID
+	close(sessionInstance.detached)
+	var expiry *time.Timer
+	expiry = time.AfterFunc(manager.resumeGracePeriod, func() {
+		// expiry is read under the lock, after Suspend has assigned it
+		manager.creationLock.Lock()
+		defer manager.creationLock.Unlock()
+
+		manager.expire(sessionID, expiry)
+	})
+	sessionInstance.expiry = expiry
+	manager.sessionStorage.Add(sessionInstance)
+
+	log.Info(managerLogPrefix, "session suspended: ", sessionID)
+}
+
 // Destroy destroys session by given sessionID
 func (manager *Manager) Destroy(consumerID identity.Identity, sessionID string) error {
 	manager.creationLock.Lock()
@@ -183,6 +289,75 @@ func (manager *Manager) Destroy(consumerID identity.Identity, sessionID string)
 		return ErrorWrongSessionOwner
 	}
 
+	manager.destroy(sessionInstance)
+	return nil
+}
+
+// find returns the session of given consumer, creationLock must be held
+func (manager *Manager) find(consumerID identity.Identity, sessionID string) (Session, error) {
+	sessionInstance, found := manager.sessionStorage.Find(ID(sessionID))
+	if !found {
+		return Session{}, ErrorSessionNotExists
+	}
+	if sessionInstance.ConsumerID != consumerID {
+		return Session{}, ErrorWrongSessionOwner
+	}
+	return sessionInstance, nil
+}
+
+func (manager *Manager) isDenied(consumerID identity.Identity) bool {
+	return manager.denylist != nil && manager.denylist.Contains(consumerID)
+}
+
+// attach starts tracking the balance of session consumer over the current dialog
+func (manager *Manager) attach(sessionInstance *Session, issuerID identity.Identity) error {
+	consumerID := sessionInstance.ConsumerID
+	sessionID := string(sessionInstance.ID)
+
+	balanceTracker, err := manager.balanceTrackerFactory(consumerID, identity.FromAddress(manager.currentProposal.ProviderID), issuerID)
+	if err != nil {
+		return err
+	}
+
+	// stop the balance tracker once the session is finished or consumer is detached from it
+	done, detached := sessionInstance.done, make(chan struct{})
+	sessionInstance.detached = detached
+	sessionInstance.sender = manager.sender
+	sessionInstance.balanceTracker = balanceTracker
+	sessionInstance.attachedAt = time.Now().UTC()
+	go func() {
+		select {
+		case <-done:
+		case <-detached:
+		}
+		balanceTracker.Stop()
+	}()
+
+	go func() {
+		err := balanceTracker.Start()
+		if err != nil {
+			log.Error(managerLogPrefix, "balance tracker error: ", err)
+			destroyErr := manager.Destroy(consumerID, sessionID)
+			if destroyErr != nil {
+				log.Error(managerLogPrefix, "session cleanup failed: ", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// expire destroys the suspended session unless it was resumed in the meantime, creationLock must be held
+func (manager *Manager) expire(sessionID ID, expiry *time.Timer) {
+	sessionInstance, found := manager.sessionStorage.Find(sessionID)
+	if !found || sessionInstance.expiry != expiry {
+		return
+	}
+
+	log.Info(managerLogPrefix, "suspended session expired: ", sessionID)
+	manager.destroy(sessionInstance)
+}
+
+func (manager *Manager) destroy(sessionInstance Session) {
 	if sessionInstance.Last && manager.lastSessionShutdown != nil {
 		log.Info("attempting to stop service")
 		if manager.natEventGetter.LastEvent().Type == traversal.FailureEventType {
@@ -192,8 +367,5 @@ func (manager *Manager) Destroy(consumerID identity.Identity, sessionID string)
 		}
 	}
 
-	manager.sessionStorage.Remove(ID(sessionID))
-	close(sessionInstance.done)
-
-	return nil
+	terminate(manager.sessionStorage, sessionInstance)
 }
@@ -20,6 +20,7 @@ package session
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -67,7 +68,7 @@ func TestManager_Create_StoresSession(t *testing.T) {
 	sessionStore := NewStorageMemory()
 	natPinger := func(json.RawMessage) {}
 
-	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{})
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, DefaultResumeGracePeriod, nil, nil)
 
 	requestConfig := json.RawMessage{}
 	sessionInstance, err := manager.Create(consumerID, consumerID, currentProposalID, nil, requestConfig)
@@ -86,7 +87,7 @@ func TestManager_Create_RejectsUnknownProposal(t *testing.T) {
 	sessionStore := NewStorageMemory()
 	natPinger := func(json.RawMessage) {}
 
-	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{})
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, DefaultResumeGracePeriod, nil, nil)
 
 	requestConfig := json.RawMessage{}
 	sessionInstance, err := manager.Create(consumerID, consumerID, 69, nil, requestConfig)
@@ -94,9 +95,154 @@ func TestManager_Create_RejectsUnknownProposal(t *testing.T) {
 	assert.Exactly(t, Session{}, sessionInstance)
 }
 
+func TestManager_Suspend_KeepsSessionForResume(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, time.Minute, nil, nil)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+
+	err = manager.Suspend(consumerID, string(created.ID))
+	assert.NoError(t, err)
+	_, found := sessionStore.Find(created.ID)
+	assert.True(t, found)
+
+	resumed, err := manager.Resume(consumerID, consumerID, currentProposalID, string(created.ID), config)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, resumed.ID)
+	assert.Equal(t, config, resumed.Config)
+	assert.Nil(t, resumed.expiry)
+}
+
+func TestManager_Suspend_DestroysSessionAfterGracePeriod(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, time.Millisecond, nil, nil)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+
+	err = manager.Suspend(consumerID, string(created.ID))
+	assert.NoError(t, err)
+
+	select {
+	case <-created.done:
+	case <-time.After(time.Second):
+		t.Fatal("suspended session was not destroyed")
+	}
+	_, found := sessionStore.Find(created.ID)
+	assert.False(t, found)
+
+	_, err = manager.Resume(consumerID, consumerID, currentProposalID, string(created.ID), config)
+	assert.Equal(t, ErrorSessionNotExists, err)
+}
+
+func TestManager_Resume_RejectsSessionWithFiredExpiry(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, time.Millisecond, nil, nil)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Suspend(consumerID, string(created.ID)))
+
+	// expiry fires while session is still being resumed
+	manager.creationLock.Lock()
+	time.Sleep(10 * time.Millisecond)
+	manager.creationLock.Unlock()
+
+	_, err = manager.Resume(consumerID, consumerID, currentProposalID, string(created.ID), config)
+	assert.Equal(t, ErrorSessionNotExists, err)
+
+	select {
+	case <-created.done:
+	case <-time.After(time.Second):
+		t.Fatal("expired session was not destroyed")
+	}
+}
+
+func TestManager_Suspend_KeepsLastSession(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+	lastSessionShutdown := make(chan struct{}, 1)
+	natEvents := &MockNatEventTracker{event: traversal.Event{Type: traversal.FailureEventType}}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, lastSessionShutdown, natEvents, time.Millisecond, nil, nil)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Suspend(consumerID, string(created.ID)))
+
+	select {
+	case <-lastSessionShutdown:
+	case <-time.After(time.Second):
+		t.Fatal("expiry of the last session did not shutdown the service")
+	}
+}
+
+func TestManager_SuspendAttachment_LeavesSessionResumedInTheMeantime(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, time.Minute, nil, nil)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+
+	// consumer resumes over a new dialog before the previous one is noticed to be gone
+	resumed, err := manager.Resume(consumerID, consumerID, currentProposalID, string(created.ID), config)
+	assert.NoError(t, err)
+
+	assert.NoError(t, manager.SuspendAttachment(created))
+	stored, found := sessionStore.Find(created.ID)
+	assert.True(t, found)
+	assert.Nil(t, stored.expiry)
+	select {
+	case <-resumed.detached:
+		t.Fatal("resumed session was detached")
+	default:
+	}
+
+	assert.NoError(t, manager.SuspendAttachment(resumed))
+	stored, _ = sessionStore.Find(created.ID)
+	assert.NotNil(t, stored.expiry)
+}
+
+func TestManager_Resume_RejectsOtherConsumer(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, time.Minute, nil, nil)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Suspend(consumerID, string(created.ID)))
+
+	otherConsumer := identity.FromAddress("other")
+	_, err = manager.Resume(otherConsumer, otherConsumer, currentProposalID, string(created.ID), config)
+	assert.Equal(t, ErrorWrongSessionOwner, err)
+}
+
 type MockNatEventTracker struct {
+	event traversal.Event
 }
 
 func (mnet *MockNatEventTracker) LastEvent() traversal.Event {
-	return traversal.Event{}
+	return mnet.event
+}
+
+func TestManager_DeniedConsumerCannotCreateOrResume(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(json.RawMessage) {}
+	denylist := NewDenylist()
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger, nil, &MockNatEventTracker{}, time.Minute, nil, denylist)
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Suspend(consumerID, string(created.ID)))
+
+	denylist.Add(consumerID)
+
+	_, err = manager.Resume(consumerID, consumerID, currentProposalID, string(created.ID), config)
+	assert.Equal(t, ErrorConsumerDenied, err)
+	_, err = manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.Equal(t, ErrorConsumerDenied, err)
 }
@@ -20,6 +20,7 @@ package factory
 import (
 	"time"
 
+	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/node"
@@ -34,55 +35,99 @@ import (
 	"github.com/pkg/errors"
 )
 
+const paymentsLogPrefix = "[payment-factory] "
+
 // PromiseWaitTimeout is the time that the provider waits for the promise to arrive
 const PromiseWaitTimeout = time.Second * 10
 
 // BalanceSendPeriod is how often the provider will send balance messages to the consumer
 const BalanceSendPeriod = time.Second * 20
 
+// MaxPrepayWindow is the most service time consumers pay in advance
+const MaxPrepayWindow = time.Hour
+
+// PromiseAmountIncreaseLimit is the least provider accepts a promise to grow at once, regardless of the service price
+const PromiseAmountIncreaseLimit uint64 = 1000
+
+// MaxPromiseAmountIncrease returns the most provider accepts a promise to grow at once,
+// enough to cover the longest prepay window consumers top up for
+func MaxPromiseAmountIncrease(amountCalc session.AmountCalc) uint64 {
+	prepayAmount := amountCalc.CoveringAmount(MaxPrepayWindow).Amount
+	if prepayAmount > PromiseAmountIncreaseLimit {
+		return prepayAmount
+	}
+	return PromiseAmountIncreaseLimit
+}
+
+// NewPromiseSizing sizes consumer promises to keep the service paid for the prepay window in advance.
+// Prepay window is kept between the provider charge period and MaxPrepayWindow.
+func NewPromiseSizing(amountCalc session.AmountCalc, prepayWindow time.Duration) payment.PromiseSizing {
+	if prepayWindow < BalanceSendPeriod {
+		prepayWindow = BalanceSendPeriod
+	}
+	if prepayWindow > MaxPrepayWindow {
+		prepayWindow = MaxPrepayWindow
+	}
+	return payment.PromiseSizing{
+		ChargeAmount: amountCalc.CoveringAmount(BalanceSendPeriod).Amount,
+		PrepayAmount: amountCalc.CoveringAmount(prepayWindow).Amount,
+	}
+}
+
 // PaymentIssuerFactoryFunc returns a factory for payment issuer. It will be noop if the experimental payment flag is not set
 func PaymentIssuerFactoryFunc(nodeOptions node.Options, signerFactory identity.SignerFactory) func(
 	initialState promise.PaymentInfo,
 	paymentDefinition dto.PaymentPerTime,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
-	return paymentIssuerFactory(signerFactory)
+	consumer, provider, issuer identity.Identity,
+	spendingGuard connection.SpendingGuard) (connection.PaymentIssuer, error) {
+	return paymentIssuerFactory(signerFactory, nodeOptions.Payments.PrepayWindow)
 }
 
 func noopPaymentIssuerFactory(initialState promise.PaymentInfo,
 	paymentDefinition dto.PaymentPerTime,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
+	consumer, provider, issuer identity.Identity,
+	spendingGuard connection.SpendingGuard) (connection.PaymentIssuer, error) {
 	return noop.NewSessionBalance(), nil
 
 }
 
-func paymentIssuerFactory(signerFactory identity.SignerFactory) func(
+func paymentIssuerFactory(signerFactory identity.SignerFactory, prepayWindow time.Duration) func(
 	initialState promise.PaymentInfo,
 	paymentDefinition dto.PaymentPerTime,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
+	consumer, provider, issuer identity.Identity,
+	spendingGuard connection.SpendingGuard) (connection.PaymentIssuer, error) {
 	return func(
 		initialState promise.PaymentInfo,
 		paymentDefinition dto.PaymentPerTime,
 		messageChan chan balance.Message,
 		dialog communication.Dialog,
-		consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
+		consumer, provider, issuer identity.Identity,
+		spendingGuard connection.SpendingGuard) (connection.PaymentIssuer, error) {
+
+		if !communication.CapabilitiesOf(dialog).Has(communication.CapabilityPaymentPromises) {
+			log.Warn(paymentsLogPrefix, "Provider does not accept promises, service is not paid for")
+			return noopPaymentIssuerFactory(initialState, paymentDefinition, messageChan, dialog, consumer, provider, issuer, spendingGuard)
+		}
 
 		bl := balance.NewListener(messageChan)
 		ps := promise.NewSender(dialog)
-		issuer := issuers.NewLocalIssuer(signerFactory(consumer))
+		// promises are signed by the issuer, while the consumer is only named in the promise extra data
+		localIssuer := issuers.NewLocalIssuer(signerFactory(issuer))
 
 		promiseState := mapInitialStateToPromiseState(initialState)
-		tracker := promise.NewConsumerTracker(promiseState, consumer, provider, issuer)
+		tracker := promise.NewConsumerTracker(promiseState, consumer, provider, localIssuer)
 		timeTracker := session.NewTracker(time.Now)
 		amountCalc := session.AmountCalc{PaymentDef: paymentDefinition}
 
 		balanceTracker := balance.NewBalanceTracker(&timeTracker, amountCalc, initialState.FreeCredit)
-		payments := payment.NewSessionPayments(messageChan, ps, tracker, balanceTracker)
+		sizing := NewPromiseSizing(amountCalc, prepayWindow)
+		payments := payment.NewSessionPayments(messageChan, ps, tracker, balanceTracker, spendingGuard, spendingGuard, sizing)
 		err := dialog.Receive(bl.GetConsumer())
 		return payments, errors.Wrap(err, "fail to receive from consumer")
 	}
@@ -19,9 +19,14 @@ package factory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
+	"github.com/mysteriumnetwork/node/session/payment/noop"
 	"github.com/mysteriumnetwork/node/session/promise"
 )
 
@@ -36,3 +41,23 @@ func Test_MapInitialStateToPromiseState(t *testing.T) {
 	assert.Equal(t, paymentInfo.LastPromise.Amount, state.Amount)
 	assert.Equal(t, paymentInfo.LastPromise.SequenceID, state.Seq)
 }
+
+func Test_PaymentIssuerFactory_IsNoopWhenPromisesAreNotAgreedOn(t *testing.T) {
+	factory := paymentIssuerFactory(func(id identity.Identity) identity.Signer {
+		return &identity.SignerFake{}
+	}, time.Minute)
+	dialog := &capableDialogFake{capabilities: communication.NewCapabilities(communication.CapabilitySessionResume)}
+
+	issuer, err := factory(promise.PaymentInfo{}, dto.PaymentPerTime{}, nil, dialog, identity.Identity{}, identity.Identity{}, identity.Identity{}, nil)
+	assert.NoError(t, err)
+	assert.IsType(t, &noop.SessionBalance{}, issuer)
+}
+
+type capableDialogFake struct {
+	communication.Dialog
+	capabilities communication.Capabilities
+}
+
+func (dialog *capableDialogFake) Capabilities() communication.Capabilities {
+	return dialog.capabilities
+}
@@ -62,6 +62,18 @@ var ErrPromiseWaitTimeout = errors.New("did not get a new promise")
 // ErrPromiseValidationFailed indicates that an invalid promise was sent
 var ErrPromiseValidationFailed = errors.New("promise validation failed")
 
+// ErrPromiseSequenceMismatch indicates that a promise was sent for a different sequence than provider requested
+var ErrPromiseSequenceMismatch = errors.New("promise sequence mismatch")
+
+// ErrPromiseAmountDecreased indicates that a promise was sent with a lower amount than the previous one
+var ErrPromiseAmountDecreased = errors.New("promise amount decreased")
+
+// ErrPromiseAmountJump indicates that a promise was sent with an amount increased more than allowed at once
+var ErrPromiseAmountJump = errors.New("promise amount increased too much")
+
+// ErrPromisesNotNegotiated indicates that a paid session was requested over a dialog which did not agree on payment promises
+var ErrPromisesNotNegotiated = errors.New("payment promises not negotiated")
+
 // errBoltNotFound indicates that bolt did not find a record
 var errBoltNotFound = errors.New("not found")
 
@@ -82,6 +94,8 @@ type SessionBalance struct {
 	promiseWaitTimeout time.Duration
 	promiseValidator   PromiseValidator
 	promiseStorage     PromiseStorage
+	violations         ViolationRecorder
+	maxAmountIncrease  uint64
 	issuerID           identity.Identity
 	consumerID         identity.Identity
 	receiverID         identity.Identity
@@ -89,6 +103,7 @@ type SessionBalance struct {
 	sequenceID              uint64
 	notReceivedPromiseCount uint64
 	maxNotReceivedPromises  uint64
+	lastPromiseAt           int64
 }
 
 // NewSessionBalance creates a new instance of provider payment orchestrator
@@ -100,6 +115,8 @@ func NewSessionBalance(
 	promiseWaitTimeout time.Duration,
 	promiseValidator PromiseValidator,
 	promiseStorage PromiseStorage,
+	violations ViolationRecorder,
+	maxAmountIncrease uint64,
 	consumerID, receiverID, issuerID identity.Identity) *SessionBalance {
 	return &SessionBalance{
 		stop:                   make(chan struct{}),
@@ -110,6 +127,8 @@ func NewSessionBalance(
 		promiseWaitTimeout:     promiseWaitTimeout,
 		promiseValidator:       promiseValidator,
 		promiseStorage:         promiseStorage,
+		violations:             violations,
+		maxAmountIncrease:      maxAmountIncrease,
 		consumerID:             consumerID,
 		receiverID:             receiverID,
 		issuerID:               issuerID,
@@ -258,11 +277,35 @@ func (sb *SessionBalance) calculateAmountToAdd(pm promise.Message, p promise.Sto
 	return amountToAdd
 }
 
+// checkPromise detects consumers trying to trick provider with promises, which are signed correctly but do not follow the previous one
+func (sb *SessionBalance) checkPromise(pm promise.Message, p promise.StoredPromise) error {
+	if pm.SequenceID != sb.sequenceID {
+		return ErrPromiseSequenceMismatch
+	}
+
+	var previousAmount uint64
+	if p.Message != nil {
+		previousAmount = p.Message.Amount
+	}
+	if pm.Amount < previousAmount {
+		return ErrPromiseAmountDecreased
+	}
+	if pm.Amount-previousAmount > sb.maxAmountIncrease {
+		return ErrPromiseAmountJump
+	}
+	return nil
+}
+
 func (sb *SessionBalance) storePromiseAndUpdateBalance(pm promise.Message) error {
 	p, err := sb.promiseStorage.FindPromiseForConsumer(sb.consumerID, sb.receiverID, sb.issuerID)
 	if err != nil {
 		return err
 	}
+	if err := sb.checkPromise(pm, p); err != nil {
+		sb.violations.Record(sb.consumerID, err)
+		return err
+	}
+
 	amount := sb.calculateAmountToAdd(pm, p)
 	sb.balanceTracker.Add(amount)
 
@@ -276,20 +319,30 @@ func (sb *SessionBalance) receivePromiseOrTimeout() error {
 	select {
 	case pm := <-sb.promiseChan:
 		if !sb.promiseValidator.Validate(pm) {
+			sb.violations.Record(sb.consumerID, ErrPromiseValidationFailed)
 			return ErrPromiseValidationFailed
 		}
 
-		// TODO: check for consumer sending fishy sequenceIDs and amounts
 		err := sb.storePromiseAndUpdateBalance(pm)
 		if err != nil {
 			return err
 		}
+		atomic.StoreInt64(&sb.lastPromiseAt, time.Now().UnixNano())
 	case <-time.After(sb.promiseWaitTimeout):
 		return ErrPromiseWaitTimeout
 	}
 	return nil
 }
 
+// LastActivity returns the time the last valid promise was received from the consumer
+func (sb *SessionBalance) LastActivity() time.Time {
+	lastPromiseAt := atomic.LoadInt64(&sb.lastPromiseAt)
+	if lastPromiseAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, lastPromiseAt).UTC()
+}
+
 // Stop stops the payment orchestrator
 func (sb *SessionBalance) Stop() {
 	close(sb.stop)
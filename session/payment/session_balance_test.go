@@ -57,6 +57,8 @@ func NewMockSessionBalance(balanceSender *MockPeerBalanceSender, mpv *MockPromis
 		time.Millisecond*1,
 		mpv,
 		mps,
+		&MockViolationRecorder{},
+		100,
 		consumer,
 		receiver,
 		issuer,
@@ -139,6 +141,7 @@ func Test_SessionBalanceSendsBalance_DoesNotTimeoutIfReceivesAPromise(t *testing
 
 	<-testDone
 	assert.Equal(t, uint64(0), orch.notReceivedPromiseCount)
+	assert.False(t, orch.LastActivity().IsZero())
 }
 
 func Test_SessionBalanceInvalidPromise(t *testing.T) {
@@ -166,6 +169,49 @@ func Test_SessionBalanceInvalidPromise(t *testing.T) {
 
 	// TODO: need a happy path test for this.
 	<-testDone
+	assert.Equal(t, []error{ErrPromiseValidationFailed}, orch.violations.(*MockViolationRecorder).violations)
+}
+
+func Test_SessionBalanceRecordsFishyPromise(t *testing.T) {
+	bs := newMockPeerBalanceSender()
+	orch := NewMockSessionBalance(bs, MPV, MPS, MBT)
+	defer orch.Stop()
+
+	testDone := make(chan struct{})
+	go func() {
+		err := orch.Start()
+		assert.Equal(t, ErrPromiseSequenceMismatch, err)
+		testDone <- struct{}{}
+	}()
+
+	<-bs.balanceMessages
+	orch.promiseChan <- promise.Message{
+		Amount:     100,
+		SequenceID: 2,
+		Signature:  "0x1111",
+	}
+
+	<-testDone
+	recorder := orch.violations.(*MockViolationRecorder)
+	assert.Equal(t, []identity.Identity{consumer}, recorder.consumers)
+	assert.Equal(t, []error{ErrPromiseSequenceMismatch}, recorder.violations)
+}
+
+func Test_SessionBalance_CheckPromise(t *testing.T) {
+	orch := NewMockSessionBalance(newMockPeerBalanceSender(), MPV, MPS, MBT)
+	orch.sequenceID = 1
+	lp := promise.StoredPromise{
+		SequenceID: 1,
+		Message:    &promise.Message{Amount: 200, SequenceID: 1},
+	}
+
+	assert.NoError(t, orch.checkPromise(promise.Message{Amount: 200, SequenceID: 1}, lp))
+	assert.NoError(t, orch.checkPromise(promise.Message{Amount: 300, SequenceID: 1}, lp))
+	assert.NoError(t, orch.checkPromise(promise.Message{Amount: 100, SequenceID: 1}, promise.StoredPromise{SequenceID: 1}))
+
+	assert.Equal(t, ErrPromiseAmountDecreased, orch.checkPromise(promise.Message{Amount: 199, SequenceID: 1}, lp))
+	assert.Equal(t, ErrPromiseAmountJump, orch.checkPromise(promise.Message{Amount: 301, SequenceID: 1}, lp))
+	assert.Equal(t, ErrPromiseSequenceMismatch, orch.checkPromise(promise.Message{Amount: 200, SequenceID: 2}, lp))
 }
 
 func Test_SessionBalance_LoadInitialPromiseState_WithExistingPromise(t *testing.T) {
@@ -296,3 +342,13 @@ type MockPromiseValidator struct {
 func (mpv *MockPromiseValidator) Validate(promise.Message) bool {
 	return mpv.isValid
 }
+
+type MockViolationRecorder struct {
+	consumers  []identity.Identity
+	violations []error
+}
+
+func (mvr *MockViolationRecorder) Record(consumerID identity.Identity, violation error) {
+	mvr.consumers = append(mvr.consumers, consumerID)
+	mvr.violations = append(mvr.violations, violation)
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/session/balance"
@@ -39,6 +40,24 @@ type PromiseTracker interface {
 	ExtendPromise(amountToAdd uint64) (promises.IssuedPromise, error)
 }
 
+// SpendingGuard approves the amounts consumer promises to pay to the provider
+type SpendingGuard interface {
+	Approve(amount uint64) error
+}
+
+// DriftReporter receives the difference between the balances provider and consumer account for the session
+type DriftReporter interface {
+	ReportDrift(drift int64)
+}
+
+// PromiseSizing determines how much consumer pays in advance with its promises
+type PromiseSizing struct {
+	// ChargeAmount is the most provider charges between two balance messages
+	ChargeAmount uint64
+	// PrepayAmount is the balance consumer tops the provider up to, once it drops to the charge amount
+	PrepayAmount uint64
+}
+
 // SessionPayments orchestrates the ping pong of balance received from provider -> promise sent to provider flow
 type SessionPayments struct {
 	stop              chan struct{}
@@ -46,25 +65,36 @@ type SessionPayments struct {
 	peerPromiseSender PeerPromiseSender
 	promiseTracker    PromiseTracker
 	balanceTracker    BalanceTracker
+	spendingGuard     SpendingGuard
+	driftReporter     DriftReporter
+	sizing            PromiseSizing
 }
 
 // NewSessionPayments returns a new instance of consumer payment orchestrator
-func NewSessionPayments(balanceChan chan balance.Message, peerPromiseSender PeerPromiseSender, promiseTracker PromiseTracker, balanceTracker BalanceTracker) *SessionPayments {
+func NewSessionPayments(
+	balanceChan chan balance.Message,
+	peerPromiseSender PeerPromiseSender,
+	promiseTracker PromiseTracker,
+	balanceTracker BalanceTracker,
+	spendingGuard SpendingGuard,
+	driftReporter DriftReporter,
+	sizing PromiseSizing,
+) *SessionPayments {
 	return &SessionPayments{
 		stop:              make(chan struct{}),
 		balanceChan:       balanceChan,
 		peerPromiseSender: peerPromiseSender,
 		promiseTracker:    promiseTracker,
 		balanceTracker:    balanceTracker,
+		spendingGuard:     spendingGuard,
+		driftReporter:     driftReporter,
+		sizing:            sizing,
 	}
 }
 
-// balanceDifferenceThreshold determines the threshold where we'll cancel the session if there's a missmatch larger than the threshold provided between the provider and the consumer balances
-const balanceDifferenceThreshold uint64 = 20
-
 const sessionPaymentsLogPrefix = "[session-payments] "
 
-// ErrBalanceMissmatch represents an error that occurs when balances do not match
+// ErrBalanceMissmatch represents an error that occurs when provider balance drifts from ours by more than the prepay amount
 var ErrBalanceMissmatch = errors.New("balance missmatch")
 
 // Start starts the payment orchestrator. Blocks.
@@ -75,7 +105,7 @@ func (cpo *SessionPayments) Start() error {
 		case <-cpo.stop:
 			return nil
 		case balance := <-cpo.balanceChan:
-			err := cpo.validateBalanceDifference(balance.Balance)
+			err := cpo.checkDrift(balance.Balance)
 			if err != nil {
 				return err
 			}
@@ -98,9 +128,14 @@ func (cpo *SessionPayments) issuePromise(balance balance.Message) error {
 	}
 
 	var amountToExtend uint64
-	if balance.Balance == 0 {
-		// TODO: this should probably not be hardcoded.
-		amountToExtend = 100
+	if balance.Balance <= cpo.sizing.ChargeAmount && balance.Balance < cpo.sizing.PrepayAmount {
+		amountToExtend = cpo.sizing.PrepayAmount - balance.Balance
+	}
+	if amountToExtend > 0 {
+		// signed promise is a commitment to pay, so the amount is spent even if the promise fails to reach provider
+		if err := cpo.spendingGuard.Approve(amountToExtend); err != nil {
+			return err
+		}
 	}
 	issuedPromise, err := cpo.promiseTracker.ExtendPromise(amountToExtend)
 	if err != nil {
@@ -121,20 +156,37 @@ func (cpo *SessionPayments) issuePromise(balance balance.Message) error {
 	return nil
 }
 
-func (cpo *SessionPayments) validateBalanceDifference(balance uint64) error {
+// checkDrift reports the drift of provider balance from ours and fails once it grows over the prepay amount,
+// as consumer would otherwise keep topping up a provider accounting for more than it was paid in advance.
+// Free services are not prepaid, so drift of their balance, e.g. by credit granted by provider, is only reported.
+func (cpo *SessionPayments) checkDrift(providerBalance uint64) error {
 	myBalance := cpo.balanceTracker.GetBalance()
-	diff := calculateBalanceDifference(balance, myBalance)
-	if diff >= balanceDifferenceThreshold {
+	var difference uint64
+	var drift int64
+	if providerBalance > myBalance {
+		difference = providerBalance - myBalance
+		drift = int64(capDrift(difference))
+	} else {
+		difference = myBalance - providerBalance
+		drift = -int64(capDrift(difference))
+	}
+	if drift != 0 {
+		log.Debug(sessionPaymentsLogPrefix, "Provider balance ", providerBalance, " drifted from ours ", myBalance)
+	}
+	cpo.driftReporter.ReportDrift(drift)
+
+	if cpo.sizing.PrepayAmount > 0 && difference > cpo.sizing.PrepayAmount {
+		log.Warn(sessionPaymentsLogPrefix, "Provider balance ", providerBalance, " drifted from ours ", myBalance, " over the prepay amount ", cpo.sizing.PrepayAmount)
 		return ErrBalanceMissmatch
 	}
 	return nil
 }
 
-func calculateBalanceDifference(yourBalance, myBalance uint64) uint64 {
-	if yourBalance > myBalance {
-		return yourBalance - myBalance
+func capDrift(difference uint64) uint64 {
+	if difference > math.MaxInt64 {
+		return math.MaxInt64
 	}
-	return myBalance - yourBalance
+	return difference
 }
 
 // Stop stops the payment orchestrator
@@ -19,6 +19,7 @@ package payment
 
 import (
 	"errors"
+	"math"
 	"testing"
 	"time"
 
@@ -44,6 +45,7 @@ func (mpps *MockPeerPromiseSender) Send(p promise.Message) error {
 type MockPromiseTracker struct {
 	promiseToReturn promises.IssuedPromise
 	errToReturn     error
+	extendedBy      uint64
 }
 
 func (mpt *MockPromiseTracker) AlignStateWithProvider(providerState promise.State) error {
@@ -52,6 +54,7 @@ func (mpt *MockPromiseTracker) AlignStateWithProvider(providerState promise.Stat
 }
 
 func (mpt *MockPromiseTracker) ExtendPromise(amountToAdd uint64) (promises.IssuedPromise, error) {
+	mpt.extendedBy = amountToAdd
 	return mpt.promiseToReturn, mpt.errToReturn
 }
 
@@ -66,6 +69,31 @@ var (
 	balanceTracker = &MockBalanceTracker{balanceToReturn: 0}
 )
 
+type MockSpendingGuard struct {
+	approved    uint64
+	errToReturn error
+}
+
+func (msg *MockSpendingGuard) Approve(amount uint64) error {
+	if msg.errToReturn != nil {
+		return msg.errToReturn
+	}
+	msg.approved += amount
+	return nil
+}
+
+type MockDriftReporter struct {
+	drifts chan int64
+}
+
+func (mdr *MockDriftReporter) ReportDrift(drift int64) {
+	if mdr.drifts != nil {
+		mdr.drifts <- drift
+	}
+}
+
+var testSizing = PromiseSizing{ChargeAmount: 20, PrepayAmount: 60}
+
 func newPromiseSender() *MockPeerPromiseSender {
 	return &MockPeerPromiseSender{chanToWriteTo: make(chan promise.Message, 1)}
 }
@@ -76,6 +104,9 @@ func NewTestSessionPayments(bm chan balance.Message, ps PeerPromiseSender, pt Pr
 		ps,
 		pt,
 		bt,
+		&MockSpendingGuard{},
+		&MockDriftReporter{},
+		testSizing,
 	)
 }
 
@@ -120,17 +151,113 @@ func Test_SessionPayments_ReportsIssuingErrors(t *testing.T) {
 	<-testDone
 }
 
-func Test_SessionPayments_ErrsOnBalanceMissmatch(t *testing.T) {
+func Test_SessionPayments_StopsWhenSpendingIsNotApproved(t *testing.T) {
 	balanceChannel := make(chan balance.Message, 1)
-	cpo := NewTestSessionPayments(balanceChannel, newPromiseSender(), promiseTracker, balanceTracker)
-	testDone := make(chan struct{})
+	promiseSender := newPromiseSender()
+	guardErr := errors.New("limit reached")
+	cpo := NewSessionPayments(balanceChannel, promiseSender, promiseTracker, balanceTracker, &MockSpendingGuard{errToReturn: guardErr}, &MockDriftReporter{}, testSizing)
 
+	testDone := make(chan struct{})
 	go func() {
 		err := cpo.Start()
-		assert.Equal(t, ErrBalanceMissmatch, err)
+		assert.Equal(t, guardErr, err)
 		testDone <- struct{}{}
 	}()
 
-	balanceChannel <- balance.Message{Balance: 100, SequenceID: 1}
+	balanceChannel <- balance.Message{Balance: 0, SequenceID: 1}
 	<-testDone
+	assert.Len(t, promiseSender.chanToWriteTo, 0)
+}
+
+func Test_SessionPayments_TopsUpToPrepayAmount(t *testing.T) {
+	tests := []struct {
+		providerBalance uint64
+		expectedExtend  uint64
+	}{
+		{0, 60},
+		{15, 45},
+		{20, 40},
+		{21, 0},
+		{60, 0},
+	}
+	for _, test := range tests {
+		balanceChannel := make(chan balance.Message, 1)
+		promiseSender := newPromiseSender()
+		customTracker := *promiseTracker
+		guard := &MockSpendingGuard{}
+		cpo := NewSessionPayments(balanceChannel, promiseSender, &customTracker, &MockBalanceTracker{}, guard, &MockDriftReporter{}, testSizing)
+		go cpo.Start()
+
+		balanceChannel <- balance.Message{Balance: test.providerBalance, SequenceID: 1}
+		<-promiseSender.chanToWriteTo
+		cpo.Stop()
+
+		assert.Equal(t, test.expectedExtend, customTracker.extendedBy, "provider balance %v", test.providerBalance)
+		assert.Equal(t, test.expectedExtend, guard.approved, "provider balance %v", test.providerBalance)
+	}
+}
+
+func Test_SessionPayments_DoesNotExtendFreeService(t *testing.T) {
+	balanceChannel := make(chan balance.Message, 1)
+	promiseSender := newPromiseSender()
+	customTracker := *promiseTracker
+	cpo := NewSessionPayments(balanceChannel, promiseSender, &customTracker, &MockBalanceTracker{}, &MockSpendingGuard{}, &MockDriftReporter{}, PromiseSizing{})
+	go cpo.Start()
+	defer cpo.Stop()
+
+	balanceChannel <- balance.Message{Balance: 0, SequenceID: 1}
+	<-promiseSender.chanToWriteTo
+	assert.Equal(t, uint64(0), customTracker.extendedBy)
+}
+
+func Test_SessionPayments_ReportsBalanceDrift(t *testing.T) {
+	balanceChannel := make(chan balance.Message, 1)
+	reporter := &MockDriftReporter{drifts: make(chan int64, 1)}
+	cpo := NewSessionPayments(balanceChannel, &MockPeerPromiseSender{}, promiseTracker, &MockBalanceTracker{balanceToReturn: 50}, &MockSpendingGuard{}, reporter, testSizing)
+	go cpo.Start()
+	defer cpo.Stop()
+
+	balanceChannel <- balance.Message{Balance: 30, SequenceID: 1}
+	assert.Equal(t, int64(-20), <-reporter.drifts)
+
+	balanceChannel <- balance.Message{Balance: 100, SequenceID: 1}
+	assert.Equal(t, int64(50), <-reporter.drifts)
+}
+
+func Test_SessionPayments_ErrsOnDriftOverPrepayAmount(t *testing.T) {
+	tests := []struct {
+		providerBalance uint64
+		consumerBalance uint64
+		expectedDrift   int64
+	}{
+		{150, 50, 100},
+		{0, 61, -61},
+		{math.MaxUint64, 0, math.MaxInt64},
+		{0, math.MaxUint64, -math.MaxInt64},
+	}
+	for _, test := range tests {
+		balanceChannel := make(chan balance.Message, 1)
+		promiseSender := newPromiseSender()
+		reporter := &MockDriftReporter{drifts: make(chan int64, 1)}
+		cpo := NewSessionPayments(balanceChannel, promiseSender, promiseTracker, &MockBalanceTracker{balanceToReturn: test.consumerBalance}, &MockSpendingGuard{}, reporter, testSizing)
+
+		balanceChannel <- balance.Message{Balance: test.providerBalance, SequenceID: 1}
+		assert.Equal(t, ErrBalanceMissmatch, cpo.Start())
+		assert.Equal(t, test.expectedDrift, <-reporter.drifts)
+		assert.Len(t, promiseSender.chanToWriteTo, 0)
+	}
+}
+
+func Test_SessionPayments_ReportsDriftOfFreeService(t *testing.T) {
+	balanceChannel := make(chan balance.Message, 1)
+	promiseSender := newPromiseSender()
+	reporter := &MockDriftReporter{drifts: make(chan int64, 1)}
+	cpo := NewSessionPayments(balanceChannel, promiseSender, promiseTracker, &MockBalanceTracker{}, &MockSpendingGuard{}, reporter, PromiseSizing{})
+	go cpo.Start()
+	defer cpo.Stop()
+
+	// provider granted trial credit consumer does not know about
+	balanceChannel <- balance.Message{Balance: 100, SequenceID: 1}
+	assert.Equal(t, int64(100), <-reporter.drifts)
+	<-promiseSender.chanToWriteTo
 }
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package payment
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+// Denylist denies consumers any further sessions
+type Denylist interface {
+	Add(consumerID identity.Identity)
+}
+
+// ViolationRecorder records promise violations committed by consumers
+type ViolationRecorder interface {
+	Record(consumerID identity.Identity, violation error)
+}
+
+const violationsLogPrefix = "[promise-violations] "
+
+const (
+	// violationExpiry is the time after the last violation consumer is forgiven the violations
+	violationExpiry = 24 * time.Hour
+	// maxTrackedConsumers is how many consumers are tracked before the forgiven ones are forgotten
+	maxTrackedConsumers = 1024
+)
+
+// ViolationTracker counts promise violations per consumer and denies consumers crossing the threshold
+type ViolationTracker struct {
+	threshold  uint64
+	denylist   Denylist
+	violations map[identity.Identity]*consumerViolations
+	now        func() time.Time
+	lock       sync.Mutex
+}
+
+// consumerViolations is the violation record of a single consumer
+type consumerViolations struct {
+	count  uint64
+	lastAt time.Time
+	denied bool
+}
+
+// NewViolationTracker returns new violation tracker, zero threshold never denies consumers
+func NewViolationTracker(threshold uint64, denylist Denylist) *ViolationTracker {
+	return &ViolationTracker{
+		threshold:  threshold,
+		denylist:   denylist,
+		violations: make(map[identity.Identity]*consumerViolations),
+		now:        time.Now,
+	}
+}
+
+// Record counts the violation of consumer and denies the consumer once the threshold is reached
+func (tracker *ViolationTracker) Record(consumerID identity.Identity, violation error) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	now := tracker.now()
+	tracker.forgetExpired(now)
+
+	record, ok := tracker.violations[consumerID]
+	if !ok {
+		record = &consumerViolations{}
+		tracker.violations[consumerID] = record
+	}
+	if record.expired(now) {
+		*record = consumerViolations{}
+	}
+	record.count++
+	record.lastAt = now
+
+	log.Warn(violationsLogPrefix, "consumer ", consumerID.Address, " violation #", record.count, ": ", violation)
+	if tracker.threshold > 0 && record.count >= tracker.threshold && !record.denied {
+		log.Warn(violationsLogPrefix, "consumer ", consumerID.Address, " denied after ", record.count, " violations")
+		tracker.denylist.Add(consumerID)
+		record.denied = true
+	}
+}
+
+// Violations returns the count of violations recorded for consumer
+func (tracker *ViolationTracker) Violations(consumerID identity.Identity) uint64 {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if record, ok := tracker.violations[consumerID]; ok && !record.expired(tracker.now()) {
+		return record.count
+	}
+	return 0
+}
+
+// expired tells whether consumer is already forgiven the recorded violations
+func (record *consumerViolations) expired(now time.Time) bool {
+	return now.Sub(record.lastAt) > violationExpiry
+}
+
+// forgetExpired drops records of consumers forgiven their violations, once too many consumers are tracked
+func (tracker *ViolationTracker) forgetExpired(now time.Time) {
+	if len(tracker.violations) < maxTrackedConsumers {
+		return
+	}
+	for consumerID, record := range tracker.violations {
+		if record.expired(now) {
+			delete(tracker.violations, consumerID)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ViolationTracker_DeniesConsumerAtThreshold(t *testing.T) {
+	denylist := &mockDenylist{}
+	tracker := NewViolationTracker(2, denylist)
+
+	tracker.Record(consumer, ErrPromiseAmountDecreased)
+	assert.Equal(t, uint64(1), tracker.Violations(consumer))
+	assert.Empty(t, denylist.denied)
+
+	tracker.Record(consumer, ErrPromiseSequenceMismatch)
+	assert.Equal(t, uint64(2), tracker.Violations(consumer))
+	assert.Equal(t, []identity.Identity{consumer}, denylist.denied)
+
+	tracker.Record(consumer, ErrPromiseSequenceMismatch)
+	assert.Equal(t, []identity.Identity{consumer}, denylist.denied)
+	assert.Equal(t, uint64(0), tracker.Violations(receiver))
+}
+
+func Test_ViolationTracker_ZeroThresholdNeverDenies(t *testing.T) {
+	denylist := &mockDenylist{}
+	tracker := NewViolationTracker(0, denylist)
+
+	for i := 0; i < 10; i++ {
+		tracker.Record(consumer, errors.New("violation"))
+	}
+	assert.Equal(t, uint64(10), tracker.Violations(consumer))
+	assert.Empty(t, denylist.denied)
+}
+
+func Test_ViolationTracker_DeniesConsumerOverThreshold(t *testing.T) {
+	denylist := &mockDenylist{}
+	tracker := NewViolationTracker(2, denylist)
+	tracker.violations[consumer] = &consumerViolations{count: 5, lastAt: time.Now()}
+
+	tracker.Record(consumer, ErrPromiseAmountDecreased)
+	assert.Equal(t, []identity.Identity{consumer}, denylist.denied)
+}
+
+func Test_ViolationTracker_ForgetsExpiredViolations(t *testing.T) {
+	now := time.Now()
+	tracker := NewViolationTracker(2, &mockDenylist{})
+	tracker.now = func() time.Time { return now }
+
+	for i := 0; i < maxTrackedConsumers; i++ {
+		tracker.Record(identity.FromAddress(fmt.Sprintf("0x%d", i)), ErrPromiseAmountDecreased)
+	}
+	assert.Len(t, tracker.violations, maxTrackedConsumers)
+
+	now = now.Add(violationExpiry + time.Second)
+	tracker.Record(consumer, ErrPromiseAmountDecreased)
+	assert.Len(t, tracker.violations, 1)
+	assert.Equal(t, uint64(1), tracker.Violations(consumer))
+}
+
+func Test_ViolationTracker_ForgivesViolationsAfterExpiry(t *testing.T) {
+	now := time.Now()
+	denylist := &mockDenylist{}
+	tracker := NewViolationTracker(2, denylist)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Record(consumer, ErrPromiseAmountDecreased)
+	now = now.Add(violationExpiry + time.Second)
+	assert.Equal(t, uint64(0), tracker.Violations(consumer))
+
+	tracker.Record(consumer, ErrPromiseAmountDecreased)
+	assert.Equal(t, uint64(1), tracker.Violations(consumer))
+	assert.Empty(t, denylist.denied)
+}
+
+type mockDenylist struct {
+	denied []identity.Identity
+}
+
+func (denylist *mockDenylist) Add(consumerID identity.Identity) {
+	denylist.denied = append(denylist.denied, consumerID)
+}
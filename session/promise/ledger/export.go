@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format defines how ledger entries are exported
+type Format string
+
+const (
+	// FormatJSON exports entries as JSON document
+	FormatJSON = Format("json")
+	// FormatCSV exports entries as CSV table with a header row
+	FormatCSV = Format("csv")
+)
+
+// ParseFormat returns export format by its name
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatJSON, FormatCSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, expected %q or %q", name, FormatJSON, FormatCSV)
+}
+
+// ContentType returns MIME type of the exported document
+func (format Format) ContentType() string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// Export writes entries to the writer in the given format
+func Export(writer io.Writer, format Format, entries []Entry) error {
+	if format == FormatCSV {
+		return exportCSV(writer, entries)
+	}
+	return exportJSON(writer, entries)
+}
+
+// entryList defines exported promises representable as json
+// swagger:model PromiseListDTO
+type entryList struct {
+	Promises []Entry `json:"promises"`
+}
+
+func exportJSON(writer io.Writer, entries []Entry) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entryList{Promises: entries})
+}
+
+var csvHeader = []string{
+	"issuer", "consumer", "receiver", "sequence_id", "amount", "unconsumed_amount",
+	"signature", "signature_valid", "cleared", "added_at", "updated_at",
+}
+
+func exportCSV(writer io.Writer, entries []Entry) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := csvWriter.Write([]string{
+			entry.Issuer,
+			entry.Consumer,
+			entry.Receiver,
+			strconv.FormatUint(entry.SequenceID, 10),
+			strconv.FormatUint(entry.Amount, 10),
+			strconv.FormatUint(entry.UnconsumedAmount, 10),
+			entry.Signature,
+			strconv.FormatBool(entry.SignatureValid),
+			strconv.FormatBool(entry.Cleared),
+			entry.AddedAt.UTC().Format(time.RFC3339),
+			entry.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var exportedEntry = Entry{
+	Issuer:         "0x0000000000000000000000000000000000000001",
+	Consumer:       "0x0000000000000000000000000000000000000002",
+	Receiver:       "0x0000000000000000000000000000000000000003",
+	SequenceID:     7,
+	Amount:         100,
+	Signature:      "0x1f",
+	SignatureValid: true,
+	AddedAt:        time.Date(2019, 6, 6, 11, 4, 43, 0, time.UTC),
+	UpdatedAt:      time.Date(2019, 6, 6, 12, 4, 43, 0, time.UTC),
+}
+
+func Test_ParseFormat(t *testing.T) {
+	format, err := ParseFormat("csv")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = ParseFormat("json")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.EqualError(t, err, `unknown export format "xml", expected "json" or "csv"`)
+}
+
+func Test_Export_CSV(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, Export(&buffer, FormatCSV, []Entry{exportedEntry}))
+
+	assert.Equal(
+		t,
+		"issuer,consumer,receiver,sequence_id,amount,unconsumed_amount,signature,signature_valid,cleared,added_at,updated_at\n"+
+			"0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002,0x0000000000000000000000000000000000000003,"+
+			"7,100,0,0x1f,true,false,2019-06-06T11:04:43Z,2019-06-06T12:04:43Z\n",
+		buffer.String(),
+	)
+}
+
+func Test_Export_JSON(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, Export(&buffer, FormatJSON, []Entry{exportedEntry}))
+
+	assert.JSONEq(
+		t,
+		`{
+			"promises": [{
+				"issuer": "0x0000000000000000000000000000000000000001",
+				"consumer": "0x0000000000000000000000000000000000000002",
+				"receiver": "0x0000000000000000000000000000000000000003",
+				"sequenceId": 7,
+				"amount": 100,
+				"unconsumedAmount": 0,
+				"signature": "0x1f",
+				"signatureValid": true,
+				"cleared": false,
+				"addedAt": "2019-06-06T11:04:43Z",
+				"updatedAt": "2019-06-06T12:04:43Z"
+			}]
+		}`,
+		buffer.String(),
+	)
+}
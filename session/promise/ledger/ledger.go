@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package ledger lists promises received by provider for accounting and dispute resolution
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/mysteriumnetwork/node/session/promise/validators"
+)
+
+// Storage provides promises stored by provider
+type Storage interface {
+	GetAllKnownIssuers() []identity.Identity
+	GetAllPromisesFromIssuer(issuerID identity.Identity) ([]promise.StoredPromise, error)
+}
+
+// Entry represents a single stored promise with its signature re-checked
+// swagger:model PromiseDTO
+type Entry struct {
+	// example: 0x0000000000000000000000000000000000000001
+	Issuer string `json:"issuer"`
+	// example: 0x0000000000000000000000000000000000000002
+	Consumer string `json:"consumer"`
+	// example: 0x0000000000000000000000000000000000000003
+	Receiver string `json:"receiver"`
+	// example: 1
+	SequenceID uint64 `json:"sequenceId"`
+	// example: 100
+	Amount uint64 `json:"amount"`
+	// example: 0
+	UnconsumedAmount uint64 `json:"unconsumedAmount"`
+	// example: 0x1f
+	Signature string `json:"signature"`
+	// example: true
+	SignatureValid bool `json:"signatureValid"`
+	// example: false
+	Cleared bool `json:"cleared"`
+	// example: 2019-06-06T11:04:43.910035Z
+	AddedAt time.Time `json:"addedAt"`
+	// example: 2019-06-06T11:04:43.910035Z
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Filter narrows down listed promises, empty fields match everything
+type Filter struct {
+	Issuer   identity.Identity
+	Consumer identity.Identity
+}
+
+func (filter Filter) matches(issuerID identity.Identity, sp promise.StoredPromise) bool {
+	if filter.Issuer.Address != "" && filter.Issuer != issuerID {
+		return false
+	}
+	if filter.Consumer.Address != "" && filter.Consumer != sp.ConsumerID {
+		return false
+	}
+	return true
+}
+
+// Ledger lists stored promises
+type Ledger struct {
+	storage Storage
+}
+
+// NewLedger returns new ledger of promises kept in the given storage
+func NewLedger(storage Storage) *Ledger {
+	return &Ledger{storage: storage}
+}
+
+// Entries returns promises matching the filter ordered by issuer, consumer and sequence
+func (ledger *Ledger) Entries(filter Filter) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	for _, issuerID := range ledger.storage.GetAllKnownIssuers() {
+		if filter.Issuer.Address != "" && filter.Issuer != issuerID {
+			continue
+		}
+
+		storedPromises, err := ledger.storage.GetAllPromisesFromIssuer(issuerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, sp := range storedPromises {
+			if filter.matches(issuerID, sp) {
+				entries = append(entries, newEntry(issuerID, sp))
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Issuer != entries[j].Issuer {
+			return entries[i].Issuer < entries[j].Issuer
+		}
+		if entries[i].Consumer != entries[j].Consumer {
+			return entries[i].Consumer < entries[j].Consumer
+		}
+		return entries[i].SequenceID < entries[j].SequenceID
+	})
+	return entries, nil
+}
+
+func newEntry(issuerID identity.Identity, sp promise.StoredPromise) Entry {
+	entry := Entry{
+		Issuer:           issuerID.Address,
+		Consumer:         sp.ConsumerID.Address,
+		Receiver:         sp.Receiver.Address,
+		SequenceID:       sp.SequenceID,
+		UnconsumedAmount: sp.UnconsumedAmount,
+		Cleared:          sp.Cleared,
+		AddedAt:          sp.AddedAt,
+		UpdatedAt:        sp.UpdatedAt,
+	}
+	if sp.Message != nil {
+		entry.Amount = sp.Message.Amount
+		entry.Signature = sp.Message.Signature
+		entry.SignatureValid = validators.NewIssuedPromiseValidator(sp.ConsumerID, sp.Receiver, issuerID).Validate(*sp.Message)
+	}
+	return entry
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledger
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/mysteriumnetwork/payments/promises"
+	"github.com/mysteriumnetwork/payments/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	issuerKey, _ = crypto.ToECDSA(common.FromHex("0x0b4eef4e99796ebfffe5046488525cd906ebc87b30f86ca6bd21b19dc2b319db"))
+	issuerID     = identity.FromAddress(crypto.PubkeyToAddress(issuerKey.PublicKey).Hex())
+	consumerID   = identity.FromAddress("0x0000000000000000000000000000000000000001")
+	otherID      = identity.FromAddress("0x0000000000000000000000000000000000000002")
+	receiverID   = identity.FromAddress("0x0000000000000000000000000000000000000003")
+	addedAt      = time.Date(2019, 6, 6, 11, 4, 43, 0, time.UTC)
+)
+
+func signedPromise(t *testing.T, consumer identity.Identity, sequenceID, amount uint64) promise.StoredPromise {
+	issued, err := promises.SignByPayer(&promises.Promise{
+		Extra:    promise.ExtraData{ConsumerAddress: common.HexToAddress(consumer.Address)},
+		Receiver: common.HexToAddress(receiverID.Address),
+		SeqNo:    sequenceID,
+		Amount:   amount,
+	}, test_utils.NewPrivateKeySigner(issuerKey))
+	assert.NoError(t, err)
+
+	return promise.StoredPromise{
+		SequenceID: sequenceID,
+		Message: &promise.Message{
+			Amount:     amount,
+			SequenceID: sequenceID,
+			Signature:  "0x" + hex.EncodeToString(issued.IssuerSignature),
+		},
+		ConsumerID: consumer,
+		Receiver:   receiverID,
+		AddedAt:    addedAt,
+	}
+}
+
+func Test_Ledger_EntriesAreOrderedAndVerified(t *testing.T) {
+	tampered := signedPromise(t, consumerID, 1, 100)
+	tampered.Message.Amount = 1000
+	storage := &mockStorage{promises: map[identity.Identity][]promise.StoredPromise{
+		issuerID: {
+			signedPromise(t, otherID, 3, 10),
+			signedPromise(t, consumerID, 2, 50),
+			tampered,
+			{SequenceID: 4, ConsumerID: consumerID, Receiver: receiverID},
+		},
+	}}
+
+	entries, err := NewLedger(storage).Entries(Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+
+	assert.Equal(t, uint64(1), entries[0].SequenceID)
+	assert.Equal(t, uint64(1000), entries[0].Amount)
+	assert.False(t, entries[0].SignatureValid)
+
+	assert.Equal(t, uint64(2), entries[1].SequenceID)
+	assert.Equal(t, issuerID.Address, entries[1].Issuer)
+	assert.Equal(t, consumerID.Address, entries[1].Consumer)
+	assert.Equal(t, receiverID.Address, entries[1].Receiver)
+	assert.Equal(t, uint64(50), entries[1].Amount)
+	assert.True(t, entries[1].SignatureValid)
+
+	assert.Equal(t, uint64(4), entries[2].SequenceID)
+	assert.Equal(t, uint64(0), entries[2].Amount)
+	assert.False(t, entries[2].SignatureValid)
+
+	assert.Equal(t, otherID.Address, entries[3].Consumer)
+	assert.True(t, entries[3].SignatureValid)
+}
+
+func Test_Ledger_EntriesAreFiltered(t *testing.T) {
+	storage := &mockStorage{promises: map[identity.Identity][]promise.StoredPromise{
+		issuerID: {signedPromise(t, consumerID, 1, 100), signedPromise(t, otherID, 2, 10)},
+		otherID:  {{SequenceID: 1, ConsumerID: consumerID, Receiver: receiverID}},
+	}}
+	ledger := NewLedger(storage)
+
+	entries, err := ledger.Entries(Filter{Issuer: issuerID})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = ledger.Entries(Filter{Consumer: consumerID})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = ledger.Entries(Filter{Issuer: issuerID, Consumer: otherID})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, uint64(2), entries[0].SequenceID)
+}
+
+type mockStorage struct {
+	promises map[identity.Identity][]promise.StoredPromise
+}
+
+func (storage *mockStorage) GetAllKnownIssuers() []identity.Identity {
+	issuers := make([]identity.Identity, 0, len(storage.promises))
+	for issuer := range storage.promises {
+		issuers = append(issuers, issuer)
+	}
+	return issuers
+}
+
+func (storage *mockStorage) GetAllPromisesFromIssuer(issuer identity.Identity) ([]promise.StoredPromise, error) {
+	return storage.promises[issuer], nil
+}
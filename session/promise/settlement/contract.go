@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package settlement
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/payments/contracts/abigen"
+	payments_identity "github.com/mysteriumnetwork/payments/identity"
+	"github.com/mysteriumnetwork/payments/promises"
+	"github.com/pkg/errors"
+)
+
+// Transactor returns options for transactions sent from the given address
+type Transactor func(from common.Address) *bind.TransactOpts
+
+// NewKeystoreTransactor returns transactor signing transactions with keys of the node keystore
+func NewKeystoreTransactor(keystore identity.Keystore) Transactor {
+	return func(from common.Address) *bind.TransactOpts {
+		return &bind.TransactOpts{
+			From: from,
+			Signer: func(signer types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+				signature, err := keystore.SignHash(accounts.Account{Address: address}, signer.Hash(tx).Bytes())
+				if err != nil {
+					return nil, err
+				}
+				return tx.WithSignature(signer, signature)
+			},
+		}
+	}
+}
+
+// ContractClearer clears promises through the payments contract
+type ContractClearer struct {
+	contract   *abigen.IdentityPromises
+	transactor Transactor
+}
+
+// NewContractClearer returns clearer bound to the payments contract at the given address
+func NewContractClearer(contractAddress common.Address, backend bind.ContractBackend, transactor Transactor) (*ContractClearer, error) {
+	contract, err := abigen.NewIdentityPromises(contractAddress, backend)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to bind payments contract")
+	}
+
+	return &ContractClearer{
+		contract:   contract,
+		transactor: transactor,
+	}, nil
+}
+
+// ClearPromise submits the promise to the payments contract, transaction is sent from the promise receiver
+func (clearer *ContractClearer) ClearPromise(promise promises.ReceivedPromise) (*types.Transaction, error) {
+	issuerSignature, err := payments_identity.DecomposeSignature(promise.IssuerSignature)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid issuer signature")
+	}
+	receiverSignature, err := payments_identity.DecomposeSignature(promise.ReceiverSignature)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid receiver signature")
+	}
+
+	// receiver address is packed together with signature recovery bytes into a single word
+	var receiverAndSigns [32]byte
+	copy(receiverAndSigns[10:], append([]byte{issuerSignature.V, receiverSignature.V}, promise.Receiver.Bytes()...))
+
+	var extraDataHash [32]byte
+	copy(extraDataHash[:], promise.Extra.Hash())
+
+	return clearer.contract.ClearPromise(
+		clearer.transactor(promise.Receiver),
+		receiverAndSigns,
+		extraDataHash,
+		new(big.Int).SetUint64(promise.SeqNo),
+		new(big.Int).SetUint64(promise.Amount),
+		issuerSignature.R,
+		issuerSignature.S,
+		receiverSignature.R,
+		receiverSignature.S,
+	)
+}
+
+// LastClearedSequence returns the sequence of the last promise cleared between the issuer and receiver
+func (clearer *ContractClearer) LastClearedSequence(issuer, receiver common.Address) (uint64, error) {
+	sequence, err := clearer.contract.ClearedPromises(&bind.CallOpts{}, issuer, receiver)
+	if err != nil {
+		return 0, err
+	}
+	return sequence.Uint64(), nil
+}
@@ -0,0 +1,290 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package settlement redeems promises received by provider in the payments contract
+package settlement
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/mysteriumnetwork/payments/promises"
+	"github.com/pkg/errors"
+)
+
+const settlerLogPrefix = "[promise-settler] "
+
+// DefaultInterval is how often settler looks for promises to settle
+const DefaultInterval = time.Hour
+
+// settleAfterIdle leaves promises of active sessions alone, provider updates them on every balance sent to consumer
+const settleAfterIdle = 10 * time.Minute
+
+const receiptTimeout = 10 * time.Second
+
+// PromiseStorage keeps promises received by provider
+type PromiseStorage interface {
+	GetAllKnownIssuers() []identity.Identity
+	GetAllPromisesFromIssuer(issuerID identity.Identity) ([]promise.StoredPromise, error)
+	MarkSubmitted(issuerID identity.Identity, sequenceID uint64) (promise.StoredPromise, error)
+	MarkCleared(issuerID identity.Identity, sequenceID uint64) error
+}
+
+// PromiseClearer submits promises to the payments contract
+type PromiseClearer interface {
+	ClearPromise(promise promises.ReceivedPromise) (*types.Transaction, error)
+	LastClearedSequence(issuer, receiver common.Address) (uint64, error)
+}
+
+// ReceiptReader fetches receipts of mined transactions
+type ReceiptReader interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Settler periodically redeems the latest uncleared promises in the payments contract
+type Settler struct {
+	storage       PromiseStorage
+	clearer       PromiseClearer
+	receipts      ReceiptReader
+	signerFactory identity.SignerFactory
+	interval      time.Duration
+	settleAfter   time.Duration
+	now           func() time.Time
+
+	// pending holds settlement transactions not yet mined, one per issuer and receiver so that sequences are cleared in order
+	pending map[string]pendingSettlement
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type pendingSettlement struct {
+	issuerID   identity.Identity
+	sequenceID uint64
+	tx         common.Hash
+}
+
+// NewSettler returns new promise settler signing promises on behalf of receivers with signers of the given factory
+func NewSettler(storage PromiseStorage, clearer PromiseClearer, receipts ReceiptReader, signerFactory identity.SignerFactory, interval time.Duration) *Settler {
+	return &Settler{
+		storage:       storage,
+		clearer:       clearer,
+		receipts:      receipts,
+		signerFactory: signerFactory,
+		interval:      interval,
+		settleAfter:   settleAfterIdle,
+		now:           time.Now,
+		pending:       make(map[string]pendingSettlement),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start starts settling promises periodically, does not block
+func (settler *Settler) Start() {
+	go func() {
+		for {
+			select {
+			case <-time.After(settler.interval):
+				settler.settle()
+			case <-settler.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops settling promises
+func (settler *Settler) Stop() {
+	settler.stopOnce.Do(func() {
+		close(settler.stop)
+	})
+}
+
+func (settler *Settler) settle() {
+	settler.checkPending()
+
+	for _, issuerID := range settler.storage.GetAllKnownIssuers() {
+		storedPromises, err := settler.storage.GetAllPromisesFromIssuer(issuerID)
+		if err != nil {
+			log.Error(settlerLogPrefix, "failed to load promises of issuer ", issuerID.Address, ": ", err)
+			continue
+		}
+
+		sort.Slice(storedPromises, func(i, j int) bool {
+			return storedPromises[i].SequenceID < storedPromises[j].SequenceID
+		})
+		for _, sp := range storedPromises {
+			if !settler.isSettleable(sp) {
+				continue
+			}
+			if _, pending := settler.pending[pendingKey(issuerID, sp.Receiver)]; pending {
+				continue
+			}
+			if err := settler.submit(issuerID, sp); err != nil {
+				log.Error(settlerLogPrefix, "failed to settle promise ", sp.SequenceID, " of issuer ", issuerID.Address, ": ", err)
+			}
+		}
+	}
+}
+
+func (settler *Settler) isSettleable(sp promise.StoredPromise) bool {
+	if sp.Cleared || sp.Message == nil || sp.Message.Amount == 0 {
+		return false
+	}
+
+	lastUpdate := sp.AddedAt
+	if sp.UpdatedAt.After(lastUpdate) {
+		lastUpdate = sp.UpdatedAt
+	}
+	return settler.now().Sub(lastUpdate) >= settler.settleAfter
+}
+
+func (settler *Settler) submit(issuerID identity.Identity, sp promise.StoredPromise) error {
+	issued, err := toIssuedPromise(sp)
+	if err != nil {
+		return err
+	}
+
+	issuerAddress, err := issued.IssuerAddress()
+	if err != nil {
+		return errors.Wrap(err, "failed to recover issuer")
+	}
+	lastCleared, err := settler.clearer.LastClearedSequence(issuerAddress, issued.Receiver)
+	if err != nil {
+		return errors.Wrap(err, "failed to get last cleared promise")
+	}
+	if lastCleared >= sp.SequenceID {
+		// settled earlier, e.g. before the node was restarted
+		return settler.markCleared(issuerID, sp.SequenceID)
+	}
+
+	// promise is marked before submitting, so that sessions of the consumer move on to a new sequence
+	// instead of extending the one which becomes spent once the transaction is mined
+	sp, err = settler.storage.MarkSubmitted(issuerID, sp.SequenceID)
+	if err != nil {
+		return errors.Wrap(err, "failed to mark promise submitted")
+	}
+	if issued, err = toIssuedPromise(sp); err != nil {
+		return err
+	}
+
+	signer := &paymentsSigner{signer: settler.signerFactory(sp.Receiver)}
+	received, err := promises.SignByReceiver(&issued, signer)
+	if err != nil {
+		return errors.Wrap(err, "failed to sign promise")
+	}
+
+	tx, err := settler.clearer.ClearPromise(*received)
+	if err != nil {
+		return errors.Wrap(err, "failed to submit promise")
+	}
+
+	log.Info(settlerLogPrefix, "promise ", sp.SequenceID, " of issuer ", issuerID.Address, " submitted in transaction ", tx.Hash().Hex())
+	settler.pending[pendingKey(issuerID, sp.Receiver)] = pendingSettlement{
+		issuerID:   issuerID,
+		sequenceID: sp.SequenceID,
+		tx:         tx.Hash(),
+	}
+	return nil
+}
+
+func (settler *Settler) checkPending() {
+	for key, settlement := range settler.pending {
+		ctx, cancel := context.WithTimeout(context.Background(), receiptTimeout)
+		receipt, err := settler.receipts.TransactionReceipt(ctx, settlement.tx)
+		cancel()
+		if err == ethereum.NotFound || (err == nil && receipt == nil) {
+			continue
+		}
+		if err != nil {
+			log.Warn(settlerLogPrefix, "failed to get receipt of transaction ", settlement.tx.Hex(), ": ", err)
+			continue
+		}
+
+		delete(settler.pending, key)
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			// promise stays uncleared and is submitted again on the next round
+			log.Warn(settlerLogPrefix, "settlement transaction ", settlement.tx.Hex(), " failed")
+			continue
+		}
+
+		if err := settler.markCleared(settlement.issuerID, settlement.sequenceID); err != nil {
+			log.Error(settlerLogPrefix, "failed to mark promise ", settlement.sequenceID, " cleared: ", err)
+		}
+	}
+}
+
+// markCleared sets only the cleared flag, the rest of the promise may have been updated since it was submitted
+func (settler *Settler) markCleared(issuerID identity.Identity, sequenceID uint64) error {
+	if err := settler.storage.MarkCleared(issuerID, sequenceID); err != nil {
+		return err
+	}
+
+	log.Info(settlerLogPrefix, "promise ", sequenceID, " of issuer ", issuerID.Address, " cleared")
+	return nil
+}
+
+func toIssuedPromise(sp promise.StoredPromise) (promises.IssuedPromise, error) {
+	signature, err := hex.DecodeString(strings.TrimPrefix(sp.Message.Signature, "0x"))
+	if err != nil {
+		return promises.IssuedPromise{}, errors.Wrap(err, "invalid promise signature")
+	}
+
+	return promises.IssuedPromise{
+		Promise: promises.Promise{
+			Extra: promise.ExtraData{
+				ConsumerAddress: common.HexToAddress(sp.ConsumerID.Address),
+			},
+			Receiver: common.HexToAddress(sp.Receiver.Address),
+			SeqNo:    sp.Message.SequenceID,
+			Amount:   sp.Message.Amount,
+		},
+		IssuerSignature: signature,
+	}, nil
+}
+
+func pendingKey(issuerID, receiverID identity.Identity) string {
+	return issuerID.Address + ":" + receiverID.Address
+}
+
+// paymentsSigner makes node signer usable for signing promises
+type paymentsSigner struct {
+	signer identity.Signer
+}
+
+func (ps *paymentsSigner) Sign(data ...[]byte) ([]byte, error) {
+	var message []byte
+	for _, dataSlice := range data {
+		message = append(message, dataSlice...)
+	}
+
+	signature, err := ps.signer.Sign(message)
+	if err != nil {
+		return nil, err
+	}
+	return signature.Bytes(), nil
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package settlement
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/mysteriumnetwork/payments/mysttoken"
+	"github.com/mysteriumnetwork/payments/promises"
+	"github.com/mysteriumnetwork/payments/test_utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type settlementTestSetup struct {
+	backend  test_utils.TransactionalBackend
+	clearing *promises.PromiseClearing
+	clearer  *ContractClearer
+	payer    *test_utils.MystIdentity
+	receiver *test_utils.MystIdentity
+	storage  *mockPromiseStorage
+	settler  *Settler
+}
+
+func newSettlementTestSetup(t *testing.T) *settlementTestSetup {
+	backend := test_utils.NewSimulatedBackend(test_utils.Deployer.Address, 10000000000)
+
+	mystErc20, err := mysttoken.DeployMystERC20(test_utils.Deployer.Transactor, 1000000, backend)
+	assert.NoError(t, err)
+
+	clearing, err := promises.DeployPromiseClearer(test_utils.Deployer.Transactor, mystErc20.Address, 1000, backend)
+	assert.NoError(t, err)
+	backend.Commit()
+
+	_, err = mystErc20.Approve(clearing.Address, big.NewInt(3000))
+	assert.NoError(t, err)
+	backend.Commit()
+
+	payer, err := test_utils.NewMystIdentity()
+	assert.NoError(t, err)
+	receiver, err := test_utils.NewMystIdentity()
+	assert.NoError(t, err)
+
+	assert.NoError(t, clearing.RegisterIdentities(*payer, *receiver))
+	backend.Commit()
+	_, err = clearing.TopUp(payer.Address, big.NewInt(1000))
+	assert.NoError(t, err)
+	backend.Commit()
+
+	// receiver has no ether in simulated chain, so deployer pays for settlement transactions
+	clearer, err := NewContractClearer(clearing.Address, backend, func(_ common.Address) *bind.TransactOpts {
+		return test_utils.Deployer.Transactor
+	})
+	assert.NoError(t, err)
+
+	storage := &mockPromiseStorage{promises: make(map[identity.Identity][]promise.StoredPromise)}
+	signerFactory := func(_ identity.Identity) identity.Signer {
+		return &receiverSigner{receiver: receiver}
+	}
+	settler := NewSettler(storage, clearer, backend, signerFactory, time.Minute)
+	settler.now = func() time.Time {
+		return time.Now().Add(settleAfterIdle)
+	}
+
+	return &settlementTestSetup{
+		backend:  backend,
+		clearing: clearing,
+		clearer:  clearer,
+		payer:    payer,
+		receiver: receiver,
+		storage:  storage,
+		settler:  settler,
+	}
+}
+
+func (setup *settlementTestSetup) storePromise(t *testing.T, sequenceID, amount uint64, cleared bool) identity.Identity {
+	consumer := identity.FromAddress("0x0000000000000000000000000000000000000001")
+	issued, err := promises.SignByPayer(&promises.Promise{
+		Extra:    promise.ExtraData{ConsumerAddress: common.HexToAddress(consumer.Address)},
+		Receiver: setup.receiver.Address,
+		SeqNo:    sequenceID,
+		Amount:   amount,
+	}, setup.payer)
+	assert.NoError(t, err)
+
+	issuerID := identity.FromAddress(setup.payer.Address.Hex())
+	setup.storage.promises[issuerID] = append(setup.storage.promises[issuerID], promise.StoredPromise{
+		SequenceID: sequenceID,
+		Message: &promise.Message{
+			Amount:     amount,
+			SequenceID: sequenceID,
+			Signature:  "0x" + hex.EncodeToString(issued.IssuerSignature),
+		},
+		AddedAt:    time.Now(),
+		ConsumerID: consumer,
+		Receiver:   identity.FromAddress(setup.receiver.Address.Hex()),
+		Cleared:    cleared,
+	})
+	return issuerID
+}
+
+func Test_Settler_ClearsPromiseAfterTransactionIsMined(t *testing.T) {
+	setup := newSettlementTestSetup(t)
+	issuerID := setup.storePromise(t, 1, 100, false)
+
+	setup.settler.settle()
+	assert.Len(t, setup.settler.pending, 1)
+	assert.True(t, setup.storage.promises[issuerID][0].Submitted)
+	assert.False(t, setup.storage.promises[issuerID][0].Cleared)
+
+	setup.backend.Commit()
+	setup.settler.settle()
+	assert.Len(t, setup.settler.pending, 0)
+	assert.True(t, setup.storage.promises[issuerID][0].Cleared)
+
+	lastCleared, err := setup.clearing.LastClearedPromise(setup.payer.Address, setup.receiver.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), lastCleared)
+
+	balance, err := setup.clearing.Balances(setup.payer.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(900), balance)
+}
+
+func Test_Settler_SettlesPromisesInSequenceOrder(t *testing.T) {
+	setup := newSettlementTestSetup(t)
+	setup.storePromise(t, 2, 50, false)
+	issuerID := setup.storePromise(t, 1, 100, false)
+
+	setup.settler.settle()
+	assert.Len(t, setup.settler.pending, 1)
+	setup.backend.Commit()
+
+	setup.settler.settle()
+	setup.backend.Commit()
+	setup.settler.settle()
+
+	for _, sp := range setup.storage.promises[issuerID] {
+		assert.True(t, sp.Cleared)
+	}
+	lastCleared, err := setup.clearer.LastClearedSequence(setup.payer.Address, setup.receiver.Address)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), lastCleared)
+}
+
+func Test_Settler_SkipsClearedAndRecentPromises(t *testing.T) {
+	setup := newSettlementTestSetup(t)
+	issuerID := setup.storePromise(t, 1, 100, true)
+	setup.storePromise(t, 2, 100, false)
+	setup.settler.now = time.Now
+
+	setup.settler.settle()
+	assert.Len(t, setup.settler.pending, 0)
+	assert.False(t, setup.storage.promises[issuerID][1].Cleared)
+}
+
+func Test_Settler_MarksAlreadySettledPromiseCleared(t *testing.T) {
+	setup := newSettlementTestSetup(t)
+	issuerID := setup.storePromise(t, 1, 100, false)
+
+	setup.settler.settle()
+	setup.backend.Commit()
+
+	// settler restarted before the transaction receipt was checked
+	setup.settler.pending = make(map[string]pendingSettlement)
+	setup.settler.settle()
+
+	assert.Len(t, setup.settler.pending, 0)
+	assert.True(t, setup.storage.promises[issuerID][0].Cleared)
+}
+
+func Test_Settler_KeepsPromiseUpdatesWhenMarkingCleared(t *testing.T) {
+	setup := newSettlementTestSetup(t)
+	issuerID := setup.storePromise(t, 1, 100, false)
+
+	setup.settler.settle()
+	// session updated the promise while the transaction was being mined
+	setup.storage.promises[issuerID][0].UnconsumedAmount = 42
+
+	setup.backend.Commit()
+	setup.settler.settle()
+	assert.True(t, setup.storage.promises[issuerID][0].Cleared)
+	assert.Equal(t, uint64(42), setup.storage.promises[issuerID][0].UnconsumedAmount)
+}
+
+type receiverSigner struct {
+	receiver *test_utils.MystIdentity
+}
+
+func (signer *receiverSigner) Sign(message []byte) (identity.Signature, error) {
+	signature, err := signer.receiver.Sign(message)
+	return identity.SignatureBytes(signature), err
+}
+
+type mockPromiseStorage struct {
+	promises map[identity.Identity][]promise.StoredPromise
+}
+
+func (storage *mockPromiseStorage) GetAllKnownIssuers() []identity.Identity {
+	issuers := make([]identity.Identity, 0, len(storage.promises))
+	for issuerID := range storage.promises {
+		issuers = append(issuers, issuerID)
+	}
+	return issuers
+}
+
+func (storage *mockPromiseStorage) GetAllPromisesFromIssuer(issuerID identity.Identity) ([]promise.StoredPromise, error) {
+	return append([]promise.StoredPromise{}, storage.promises[issuerID]...), nil
+}
+
+func (storage *mockPromiseStorage) MarkSubmitted(issuerID identity.Identity, sequenceID uint64) (promise.StoredPromise, error) {
+	for i := range storage.promises[issuerID] {
+		if storage.promises[issuerID][i].SequenceID == sequenceID {
+			storage.promises[issuerID][i].Submitted = true
+			return storage.promises[issuerID][i], nil
+		}
+	}
+	return promise.StoredPromise{}, promise.ErrPromiseNotFound
+}
+
+func (storage *mockPromiseStorage) MarkCleared(issuerID identity.Identity, sequenceID uint64) error {
+	for i := range storage.promises[issuerID] {
+		if storage.promises[issuerID][i].SequenceID == sequenceID {
+			storage.promises[issuerID][i].Cleared = true
+			return nil
+		}
+	}
+	return promise.ErrPromiseNotFound
+}
@@ -73,6 +73,8 @@ type StoredPromise struct {
 	ConsumerID       identity.Identity
 	Receiver         identity.Identity
 	Cleared          bool
+	// Submitted promise is being settled, so its sequence must not be extended any more
+	Submitted bool
 }
 
 // GetNewSeqIDForIssuer returns a new sequenceID for the provided issuer.
@@ -128,6 +130,35 @@ func (s *Storage) Update(issuerID identity.Identity, sp StoredPromise) error {
 	return s.update(issuerID, sp)
 }
 
+// MarkSubmitted marks the promise as submitted for settlement, so that the consumer gets a new sequence for further payments.
+// It returns the promise as stored at the moment of marking.
+func (s *Storage) MarkSubmitted(issuerID identity.Identity, sequenceID uint64) (StoredPromise, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	sp, err := s.getPromiseByID(issuerID, sequenceID)
+	if err != nil {
+		return StoredPromise{}, err
+	}
+
+	sp.Submitted = true
+	return sp, s.update(issuerID, sp)
+}
+
+// MarkCleared marks the promise as cleared in the payments contract, leaving the rest of it as currently stored
+func (s *Storage) MarkCleared(issuerID identity.Identity, sequenceID uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	sp, err := s.getPromiseByID(issuerID, sequenceID)
+	if err != nil {
+		return err
+	}
+
+	sp.Cleared = true
+	return s.update(issuerID, sp)
+}
+
 // GetLastPromise fetches the last promise for the provider
 func (s *Storage) GetLastPromise(issuerID identity.Identity) (StoredPromise, error) {
 	s.Lock()
@@ -175,8 +206,8 @@ func (s *Storage) findPromiseForConsumer(consumerID, receiverID, issuerID identi
 
 	// Iterate from the last promise to the first
 	for i := 0; i < len(promises); i++ {
-		// if we find a cleared promise, it means we've done our job here - we'll need to issue a new id
-		if promises[i].Cleared {
+		// if we find a cleared or submitted promise, it means we've done our job here - we'll need to issue a new id
+		if promises[i].Cleared || promises[i].Submitted {
 			return StoredPromise{}, errNoPromiseForConsumer
 		}
 		// otherwise, we're free to extend if the receiver matches
@@ -202,6 +233,28 @@ func (s *Storage) GetAllPromisesFromIssuer(issuerID identity.Identity) ([]Stored
 	return s.getAllPromisesForIssuer(issuerID)
 }
 
+// AddCredit adds the amount to the unconsumed amount of consumer's promise, so the consumer can use it before paying.
+// If consumer has no promise yet, a new sequenceID is issued for it.
+func (s *Storage) AddCredit(consumerID, receiverID, issuerID identity.Identity, amount uint64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	sp, err := s.findPromiseForConsumer(consumerID, receiverID, issuerID)
+	if err == errNoPromiseForConsumer {
+		var seq uint64
+		if seq, err = s.getNewSeqIDForIssuer(consumerID, receiverID, issuerID); err != nil {
+			return err
+		}
+		sp, err = s.getPromiseByID(issuerID, seq)
+	}
+	if err != nil {
+		return err
+	}
+
+	sp.UnconsumedAmount += amount
+	return s.update(issuerID, sp)
+}
+
 // LoadPaymentInfo returns the last know payment information for issuer
 func (s *Storage) LoadPaymentInfo(consumerID, receiverID, issuerID identity.Identity) *PaymentInfo {
 	s.Lock()
@@ -411,6 +411,82 @@ func Test_FindPromiseForConsumer_FindsClearerd(t *testing.T) {
 	assert.Equal(t, errNoPromiseForConsumer, err)
 }
 
+func Test_FindPromiseForConsumer_SkipsSubmitted(t *testing.T) {
+	mock := map[string][]StoredPromise{
+		getBucketNameFromIssuer(issuerID): {
+			{
+				SequenceID: 1,
+				AddedAt:    timeMock,
+				UpdatedAt:  timeMock,
+				ConsumerID: consumerID,
+				Receiver:   receiverID,
+			},
+		},
+	}
+	ms := newMockStorage(&mock)
+	s := NewStorage(ms)
+
+	submitted, err := s.MarkSubmitted(issuerID, 1)
+	assert.Nil(t, err)
+	assert.True(t, submitted.Submitted)
+
+	_, err = s.FindPromiseForConsumer(consumerID, receiverID, issuerID)
+	assert.Equal(t, errNoPromiseForConsumer, err)
+	assert.Equal(t, uint64(2), s.LoadPaymentInfo(consumerID, receiverID, issuerID).LastPromise.SequenceID)
+}
+
+func Test_Storage_MarkCleared_KeepsPromiseUpdates(t *testing.T) {
+	mock := map[string][]StoredPromise{
+		getBucketNameFromIssuer(issuerID): {
+			{
+				SequenceID: 1,
+				AddedAt:    timeMock,
+				UpdatedAt:  timeMock,
+				ConsumerID: consumerID,
+				Receiver:   receiverID,
+			},
+		},
+	}
+	ms := newMockStorage(&mock)
+	s := NewStorage(ms)
+
+	assert.Nil(t, s.AddCredit(consumerID, receiverID, issuerID, 100))
+	assert.Nil(t, s.MarkCleared(issuerID, 1))
+
+	promises, err := s.GetAllPromisesFromIssuer(issuerID)
+	assert.Nil(t, err)
+	assert.True(t, promises[0].Cleared)
+	assert.Equal(t, uint64(100), promises[0].UnconsumedAmount)
+}
+
+func Test_Storage_AddCredit_ExtendsExistingPromise(t *testing.T) {
+	ms := newMockStorage(&mock)
+	s := NewStorage(ms)
+
+	assert.Nil(t, s.AddCredit(consumerID, receiverID, issuerID, 100))
+	assert.Nil(t, s.AddCredit(consumerID, receiverID, issuerID, 50))
+
+	sp, err := s.FindPromiseForConsumer(consumerID, receiverID, issuerID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), sp.SequenceID)
+	assert.Equal(t, uint64(150), sp.UnconsumedAmount)
+	assert.Equal(t, uint64(150), s.LoadPaymentInfo(consumerID, receiverID, issuerID).FreeCredit)
+}
+
+func Test_Storage_AddCredit_IssuesSequenceForNewConsumer(t *testing.T) {
+	ms := newMockStorage(&mock)
+	s := NewStorage(ms)
+	newConsumerID := identity.FromAddress("0x1")
+
+	assert.Nil(t, s.AddCredit(newConsumerID, receiverID, issuerID, 100))
+
+	sp, err := s.FindPromiseForConsumer(newConsumerID, receiverID, issuerID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), sp.SequenceID)
+	assert.Equal(t, uint64(100), sp.UnconsumedAmount)
+	assert.Nil(t, sp.Message)
+}
+
 func Test_MockStorage(t *testing.T) {
 	ms := newMockStorage(nil)
 
@@ -553,7 +629,11 @@ func (ms *mockStorage) Update(bucket string, data interface{}) error {
 		for i := range ms.inMemStorage[bucket] {
 			if ms.inMemStorage[bucket][i].SequenceID == casted.SequenceID {
 				ms.inMemStorage[bucket][i].Message = casted.Message
+				ms.inMemStorage[bucket][i].UnconsumedAmount = casted.UnconsumedAmount
 				ms.inMemStorage[bucket][i].UpdatedAt = casted.UpdatedAt
+				// like storm, flags are updated only when set
+				ms.inMemStorage[bucket][i].Cleared = ms.inMemStorage[bucket][i].Cleared || casted.Cleared
+				ms.inMemStorage[bucket][i].Submitted = ms.inMemStorage[bucket][i].Submitted || casted.Submitted
 				break
 			}
 		}
@@ -67,3 +67,36 @@ func TestPromiseSignatureValidatorReturnsTrueForReceivedValidMessage(t *testing.
 
 	assert.True(t, validator.Validate(promiseMessage))
 }
+
+func TestPromiseSignatureValidatorReturnsFalseForPromiseOfAnotherConsumer(t *testing.T) {
+	serviceConsumer := common.HexToAddress("0x1122334455")
+	otherConsumer := common.HexToAddress("0x5544332211")
+	serviceProvider := common.HexToAddress("0x66778899")
+	//payer issues promise for another consumer, which tries to use it in its own session
+	issuedPromise, err := promises.SignByPayer(
+		&promises.Promise{
+			Extra: promise.ExtraData{
+				ConsumerAddress: otherConsumer,
+			},
+			Receiver: serviceProvider,
+			Amount:   1000,
+			SeqNo:    10,
+		},
+		payerSigner,
+	)
+	assert.NoError(t, err)
+
+	promiseMessage := promise.Message{
+		Amount:     uint64(issuedPromise.Amount),
+		SequenceID: uint64(issuedPromise.SeqNo),
+		Signature:  hexutil.Encode(issuedPromise.IssuerSignature),
+	}
+
+	validator := IssuedPromiseValidator{
+		consumer: serviceConsumer,
+		receiver: serviceProvider,
+		issuer:   payerAddress,
+	}
+
+	assert.False(t, validator.Validate(promiseMessage))
+}
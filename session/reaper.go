@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	reaperLogPrefix = "[session-reaper] "
+
+	reapInterval    = 30 * time.Second
+	minReapInterval = time.Second
+)
+
+// ReaperStorage is the session storage the reaper sweeps
+type ReaperStorage interface {
+	Storage
+	GetAll() []Session
+}
+
+// Reaper destroys provider sessions which are idle or last longer than allowed
+type Reaper struct {
+	storage     ReaperStorage
+	idleTimeout time.Duration
+	maxDuration time.Duration
+	interval    time.Duration
+	now         func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewReaper returns new session reaper, zero idle timeout or maximum duration disables the limit
+func NewReaper(storage ReaperStorage, idleTimeout, maxDuration time.Duration) *Reaper {
+	return &Reaper{
+		storage:     storage,
+		idleTimeout: idleTimeout,
+		maxDuration: maxDuration,
+		interval:    reapIntervalFor(idleTimeout, maxDuration),
+		now:         time.Now,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start starts sweeping sessions periodically, does not block
+func (reaper *Reaper) Start() {
+	if reaper.idleTimeout <= 0 && reaper.maxDuration <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			select {
+			case <-time.After(reaper.interval):
+				reaper.reap()
+			case <-reaper.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops sweeping sessions
+func (reaper *Reaper) Stop() {
+	reaper.stopOnce.Do(func() {
+		close(reaper.stop)
+	})
+}
+
+func (reaper *Reaper) reap() {
+	now := reaper.now()
+	for _, sessionInstance := range reaper.storage.GetAll() {
+		reason := reaper.terminationReason(sessionInstance, now)
+		if reason == "" {
+			continue
+		}
+
+		log.Info(reaperLogPrefix, "destroying session ", sessionInstance.ID, ", reason: ", reason)
+		notifyAndTerminate(reaper.storage, sessionInstance, reason)
+	}
+}
+
+func (reaper *Reaper) terminationReason(sessionInstance Session, now time.Time) string {
+	if reaper.maxDuration > 0 && now.Sub(sessionInstance.CreatedAt) > reaper.maxDuration {
+		return TerminateReasonMaxDuration
+	}
+	// suspended sessions are limited by the resume grace period instead
+	if reaper.idleTimeout > 0 && sessionInstance.expiry == nil && now.Sub(sessionInstance.lastActivity()) > reaper.idleTimeout {
+		return TerminateReasonIdle
+	}
+	return ""
+}
+
+// reapIntervalFor checks sessions often enough to keep the shortest limit reasonably precise
+func reapIntervalFor(limits ...time.Duration) time.Duration {
+	interval := reapInterval
+	for _, limit := range limits {
+		if limit > 0 && limit/2 < interval {
+			interval = limit / 2
+		}
+	}
+	if interval < minReapInterval {
+		interval = minReapInterval
+	}
+	return interval
+}
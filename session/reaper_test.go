@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/nat/traversal"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockActivityTracker struct {
+	mockBalanceTracker
+	lastActivity time.Time
+}
+
+func (m mockActivityTracker) LastActivity() time.Time {
+	return m.lastActivity
+}
+
+type mockSender struct {
+	sent []interface{}
+}
+
+func (m *mockSender) Send(producer communication.MessageProducer) error {
+	m.sent = append(m.sent, producer.Produce())
+	return nil
+}
+
+func (m *mockSender) SendContext(ctx context.Context, producer communication.MessageProducer) error {
+	return m.Send(producer)
+}
+
+func (m *mockSender) Request(producer communication.RequestProducer) (interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockSender) RequestContext(ctx context.Context, producer communication.RequestProducer) (interface{}, error) {
+	return m.Request(producer)
+}
+
+func newReaperTestSession(id ID, createdAt time.Time, tracker BalanceTracker, sender communication.Sender) Session {
+	return Session{
+		ID:             id,
+		CreatedAt:      createdAt,
+		done:           make(chan struct{}),
+		finish:         &sync.Once{},
+		sender:         sender,
+		balanceTracker: tracker,
+		attachedAt:     createdAt,
+	}
+}
+
+func TestReaper_DestroysIdleSessions(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageMemory()
+	sender := &mockSender{}
+
+	idle := newReaperTestSession("idle", now.Add(-time.Hour), &mockActivityTracker{lastActivity: now.Add(-20 * time.Minute)}, sender)
+	active := newReaperTestSession("active", now.Add(-time.Hour), &mockActivityTracker{lastActivity: now.Add(-time.Minute)}, sender)
+	storage.Add(idle)
+	storage.Add(active)
+
+	reaper := NewReaper(storage, 10*time.Minute, 0)
+	reaper.now = func() time.Time { return now }
+	reaper.reap()
+
+	_, found := storage.Find("idle")
+	assert.False(t, found)
+	_, found = storage.Find("active")
+	assert.True(t, found)
+	assert.Equal(t, []interface{}{&TerminateMessage{SessionID: "idle", Reason: TerminateReasonIdle}}, sender.sent)
+
+	select {
+	case <-idle.done:
+	default:
+		t.Error("idle session resources were not released")
+	}
+}
+
+func TestReaper_DestroysSessionsExceedingMaxDuration(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageMemory()
+	sender := &mockSender{}
+
+	storage.Add(newReaperTestSession("long", now.Add(-25*time.Hour), &mockActivityTracker{lastActivity: now}, sender))
+	storage.Add(newReaperTestSession("short", now.Add(-time.Hour), &mockActivityTracker{lastActivity: now}, sender))
+
+	reaper := NewReaper(storage, 0, 24*time.Hour)
+	reaper.now = func() time.Time { return now }
+	reaper.reap()
+
+	_, found := storage.Find("long")
+	assert.False(t, found)
+	_, found = storage.Find("short")
+	assert.True(t, found)
+	assert.Equal(t, []interface{}{&TerminateMessage{SessionID: "long", Reason: TerminateReasonMaxDuration}}, sender.sent)
+}
+
+func TestReaper_LeavesSuspendedSessionsToResumeGracePeriod(t *testing.T) {
+	now := time.Now()
+	storage := NewStorageMemory()
+
+	suspended := newReaperTestSession("suspended", now.Add(-time.Hour), &mockBalanceTracker{}, nil)
+	suspended.expiry = time.NewTimer(time.Hour)
+	defer suspended.expiry.Stop()
+	storage.Add(suspended)
+
+	reaper := NewReaper(storage, time.Minute, 0)
+	reaper.now = func() time.Time { return now }
+	reaper.reap()
+
+	_, found := storage.Find("suspended")
+	assert.True(t, found)
+}
+
+func TestReaper_IntervalFollowsShortestLimit(t *testing.T) {
+	assert.Equal(t, reapInterval, reapIntervalFor(0, 0))
+	assert.Equal(t, reapInterval, reapIntervalFor(time.Hour, 24*time.Hour))
+	assert.Equal(t, 10*time.Second, reapIntervalFor(20*time.Second, 0))
+	assert.Equal(t, minReapInterval, reapIntervalFor(time.Millisecond, 0))
+}
+
+func TestReaper_DestroysThroughSessionManager(t *testing.T) {
+	storage := NewStorageMemory()
+	lastSessionShutdown := make(chan struct{}, 1)
+	natEvents := &MockNatEventTracker{event: traversal.Event{Type: traversal.FailureEventType}}
+	manager := NewManager(currentProposal, generateSessionID, storage, mockBalanceTrackerFactory, func(json.RawMessage) {}, lastSessionShutdown, natEvents, time.Minute, nil, nil)
+
+	created, err := manager.Create(consumerID, consumerID, currentProposalID, config, json.RawMessage{})
+	assert.NoError(t, err)
+
+	reaper := NewReaper(storage, 0, time.Minute)
+	reaper.now = func() time.Time { return created.CreatedAt.Add(time.Hour) }
+	reaper.reap()
+
+	_, found := storage.Find(created.ID)
+	assert.False(t, found)
+	select {
+	case <-lastSessionShutdown:
+	default:
+		t.Error("reaping the last session did not shutdown the service")
+	}
+}
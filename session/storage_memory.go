@@ -66,9 +66,7 @@ func (storage *StorageMemory) Find(id ID) (Session, bool) {
 	defer storage.lock.Unlock()
 
 	if instance, found := storage.sessions[id]; found {
-		if len(storage.sessions) == 1 {
-			instance.Last = true
-		}
+		instance.Last = len(storage.sessions) == 1
 		return instance, true
 	}
 
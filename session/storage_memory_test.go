@@ -41,6 +41,20 @@ func TestStorage_FindSession_Existing(t *testing.T) {
 	assert.Exactly(t, sessionExisting, sessionInstance)
 }
 
+func TestStorage_FindSession_LastIsNotStale(t *testing.T) {
+	storage := mockStorage(sessionExisting)
+	storage.Add(Session{ID: ID("other-id")})
+
+	sessionInstance, found := storage.Find(sessionExisting.ID)
+	assert.True(t, found)
+	assert.False(t, sessionInstance.Last)
+
+	storage.Remove(ID("other-id"))
+	sessionInstance, found = storage.Find(sessionExisting.ID)
+	assert.True(t, found)
+	assert.True(t, sessionInstance.Last)
+}
+
 func TestStorage_FindSession_Unknown(t *testing.T) {
 	storage := mockStorage(sessionExisting)
 
@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"github.com/mysteriumnetwork/node/communication"
+)
+
+const endpointSessionTerminate = communication.MessageEndpoint("session-terminate")
+
+// Reasons of provider terminating the session on its own
+const (
+	// TerminateReasonIdle is reported when consumer stopped using the session
+	TerminateReasonIdle = "idle"
+	// TerminateReasonMaxDuration is reported when session lasted longer than provider allows
+	TerminateReasonMaxDuration = "max-duration"
+	// TerminateReasonProvider is reported when provider operator terminated the session
+	TerminateReasonProvider = "provider"
+)
+
+// TerminateMessage structure represents message from service provider notifying that the session is being destroyed
+type TerminateMessage struct {
+	SessionID ID     `json:"session_id"`
+	Reason    string `json:"reason"`
+}
+
+// NotifySessionTerminate tells the consumer that provider destroys the session
+func NotifySessionTerminate(sender communication.Sender, sessionID ID, reason string) error {
+	return sender.Send(&terminateProducer{
+		message: TerminateMessage{
+			SessionID: sessionID,
+			Reason:    reason,
+		},
+	})
+}
+
+// NewTerminateConsumer returns consumer of session termination messages passing them to the given callback
+func NewTerminateConsumer(callback func(TerminateMessage)) communication.MessageConsumer {
+	return &terminateConsumer{callback: callback}
+}
+
+type terminateConsumer struct {
+	callback func(TerminateMessage)
+}
+
+// GetMessageEndpoint returns endpoint where to receive messages
+func (consumer *terminateConsumer) GetMessageEndpoint() communication.MessageEndpoint {
+	return endpointSessionTerminate
+}
+
+// NewMessage creates struct where message from endpoint will be serialized
+func (consumer *terminateConsumer) NewMessage() (messagePtr interface{}) {
+	return &TerminateMessage{}
+}
+
+// Consume handles messages from endpoint
+func (consumer *terminateConsumer) Consume(messagePtr interface{}) error {
+	consumer.callback(*messagePtr.(*TerminateMessage))
+	return nil
+}
+
+type terminateProducer struct {
+	message TerminateMessage
+}
+
+// GetMessageEndpoint returns endpoint where to send messages
+func (producer *terminateProducer) GetMessageEndpoint() communication.MessageEndpoint {
+	return endpointSessionTerminate
+}
+
+// Produce produces a message
+func (producer *terminateProducer) Produce() (messagePtr interface{}) {
+	return &producer.message
+}
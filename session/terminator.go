@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	log "github.com/cihub/seelog"
+)
+
+const terminatorLogPrefix = "[session-terminator] "
+
+// Terminator destroys sessions on provider's demand
+type Terminator struct {
+	storage  Storage
+	denylist *Denylist
+}
+
+// NewTerminator returns new session terminator
+func NewTerminator(storage Storage, denylist *Denylist) *Terminator {
+	return &Terminator{
+		storage:  storage,
+		denylist: denylist,
+	}
+}
+
+// Terminate destroys the session and notifies its consumer about it, optionally denying the consumer further sessions
+func (terminator *Terminator) Terminate(id ID, deny bool) error {
+	sessionInstance, found := terminator.storage.Find(id)
+	if !found {
+		return ErrorSessionNotExists
+	}
+
+	if deny {
+		log.Info(terminatorLogPrefix, "denying consumer ", sessionInstance.ConsumerID.Address)
+		terminator.denylist.Add(sessionInstance.ConsumerID)
+	}
+
+	log.Info(terminatorLogPrefix, "terminating session ", id)
+	notifyAndTerminate(terminator.storage, sessionInstance, TerminateReasonProvider)
+	return nil
+}
+
+// notifyAndTerminate tells the consumer that session is being destroyed and destroys it
+func notifyAndTerminate(storage Storage, sessionInstance Session, reason string) {
+	if sessionInstance.sender != nil {
+		if err := NotifySessionTerminate(sessionInstance.sender, sessionInstance.ID, reason); err != nil {
+			log.Warn(terminatorLogPrefix, "failed to notify consumer about session termination: ", err)
+		}
+	}
+
+	// session manager takes care of the service shutdown after the last session
+	if sessionInstance.destroyer != nil {
+		err := sessionInstance.destroyer.Destroy(sessionInstance.ConsumerID, string(sessionInstance.ID))
+		if err != nil && err != ErrorSessionNotExists {
+			log.Warn(terminatorLogPrefix, "failed to destroy session ", sessionInstance.ID, ": ", err)
+		}
+		return
+	}
+	terminate(storage, sessionInstance)
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminator_Terminate_NotifiesConsumerAndDestroysSession(t *testing.T) {
+	storage := NewStorageMemory()
+	sender := &mockSender{}
+	sessionInstance := newReaperTestSession("session", time.Now(), &mockBalanceTracker{}, sender)
+	storage.Add(sessionInstance)
+	denylist := NewDenylist()
+
+	err := NewTerminator(storage, denylist).Terminate("session", false)
+	assert.NoError(t, err)
+
+	_, found := storage.Find("session")
+	assert.False(t, found)
+	assert.Equal(t, []interface{}{&TerminateMessage{SessionID: "session", Reason: TerminateReasonProvider}}, sender.sent)
+	assert.False(t, denylist.Contains(sessionInstance.ConsumerID))
+	<-sessionInstance.done
+}
+
+func TestTerminator_Terminate_DeniesConsumer(t *testing.T) {
+	storage := NewStorageMemory()
+	sessionInstance := newReaperTestSession("session", time.Now(), &mockBalanceTracker{}, nil)
+	sessionInstance.ConsumerID = consumerID
+	storage.Add(sessionInstance)
+	denylist := NewDenylist()
+
+	err := NewTerminator(storage, denylist).Terminate("session", true)
+	assert.NoError(t, err)
+	assert.True(t, denylist.Contains(consumerID))
+}
+
+func TestTerminator_Terminate_UnknownSession(t *testing.T) {
+	err := NewTerminator(NewStorageMemory(), NewDenylist()).Terminate("unknown", true)
+	assert.Equal(t, ErrorSessionNotExists, err)
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package trial lets new consumers try provider's services before paying
+package trial
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+const grantBucket = "trial-grants"
+const granterLogPrefix = "[trial-granter] "
+
+// errBoltNotFound represents the bolts not found error
+var errBoltNotFound = errors.New("not found")
+
+// Grant records trial credit granted to consumer and the promise of receiver and issuer it was credited to.
+// Grants are keyed by consumer alone on purpose: keying them by the whole promise would let consumer collect
+// a new trial with every issuer it pays through. Consumer switching issuer leaves the credit on the former promise.
+type Grant struct {
+	ConsumerID string `storm:"id"`
+	ReceiverID string
+	IssuerID   string
+	Amount     uint64
+	GrantedAt  time.Time
+}
+
+// Storer keeps trial grants
+type Storer interface {
+	Store(bucket string, object interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+}
+
+// CreditStorage credits consumers with amounts they can use without paying
+type CreditStorage interface {
+	AddCredit(consumerID, receiverID, issuerID identity.Identity, amount uint64) error
+}
+
+// Granter grants trial credit once per consumer identity, whichever issuer the consumer pays through
+type Granter struct {
+	storage Storer
+	credits CreditStorage
+	lock    sync.Mutex
+}
+
+// NewGranter returns new trial granter
+func NewGranter(storage Storer, credits CreditStorage) *Granter {
+	return &Granter{
+		storage: storage,
+		credits: credits,
+	}
+}
+
+// Grant credits consumer with the trial amount, unless consumer was granted a trial before
+func (granter *Granter) Grant(consumerID, receiverID, issuerID identity.Identity, amount uint64) (bool, error) {
+	if amount == 0 {
+		return false, nil
+	}
+
+	granter.lock.Lock()
+	defer granter.lock.Unlock()
+
+	var grant Grant
+	err := granter.storage.GetOneByField(grantBucket, "ConsumerID", consumerID.Address, &grant)
+	if err == nil {
+		return false, nil
+	}
+	if err.Error() != errBoltNotFound.Error() {
+		return false, err
+	}
+
+	// record the grant first, failing to credit consumer is better than granting the trial twice
+	grant = Grant{
+		ConsumerID: consumerID.Address,
+		ReceiverID: receiverID.Address,
+		IssuerID:   issuerID.Address,
+		Amount:     amount,
+		GrantedAt:  time.Now().UTC(),
+	}
+	if err := granter.storage.Store(grantBucket, &grant); err != nil {
+		return false, err
+	}
+	if err := granter.credits.AddCredit(consumerID, receiverID, issuerID, amount); err != nil {
+		return false, err
+	}
+
+	log.Info(granterLogPrefix, "granted trial of ", amount, " to consumer ", consumerID.Address)
+	return true, nil
+}
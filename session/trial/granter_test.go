@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package trial
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	consumerID = identity.FromAddress("0x1")
+	receiverID = identity.FromAddress("0x2")
+	issuerID   = identity.FromAddress("0x3")
+)
+
+func newTestGranter(t *testing.T, credits *mockCreditStorage) (*Granter, func()) {
+	dir := boltdbtest.CreateTempDir(t)
+	storage, err := boltdb.NewStorage(dir)
+	assert.Nil(t, err)
+
+	return NewGranter(storage, credits), func() {
+		storage.Close()
+		boltdbtest.RemoveTempDir(t, dir)
+	}
+}
+
+func Test_Granter_GrantsTrialOnce(t *testing.T) {
+	credits := &mockCreditStorage{}
+	granter, cleanup := newTestGranter(t, credits)
+	defer cleanup()
+
+	granted, err := granter.Grant(consumerID, receiverID, issuerID, 100)
+	assert.Nil(t, err)
+	assert.True(t, granted)
+	assert.Equal(t, uint64(100), credits.credited[consumerID])
+
+	granted, err = granter.Grant(consumerID, receiverID, issuerID, 100)
+	assert.Nil(t, err)
+	assert.False(t, granted)
+	assert.Equal(t, uint64(100), credits.credited[consumerID])
+
+	otherConsumerID := identity.FromAddress("0x4")
+	granted, err = granter.Grant(otherConsumerID, receiverID, issuerID, 100)
+	assert.Nil(t, err)
+	assert.True(t, granted)
+	assert.Equal(t, uint64(100), credits.credited[otherConsumerID])
+}
+
+func Test_Granter_GrantsTrialOncePerConsumerOnly(t *testing.T) {
+	credits := &mockCreditStorage{}
+	granter, cleanup := newTestGranter(t, credits)
+	defer cleanup()
+
+	granted, err := granter.Grant(consumerID, receiverID, issuerID, 100)
+	assert.Nil(t, err)
+	assert.True(t, granted)
+
+	granted, err = granter.Grant(consumerID, receiverID, identity.FromAddress("0x5"), 100)
+	assert.Nil(t, err)
+	assert.False(t, granted)
+
+	var grant Grant
+	assert.Nil(t, granter.storage.GetOneByField(grantBucket, "ConsumerID", consumerID.Address, &grant))
+	assert.Equal(t, receiverID.Address, grant.ReceiverID)
+	assert.Equal(t, issuerID.Address, grant.IssuerID)
+}
+
+func Test_Granter_SkipsZeroAmount(t *testing.T) {
+	credits := &mockCreditStorage{}
+	granter, cleanup := newTestGranter(t, credits)
+	defer cleanup()
+
+	granted, err := granter.Grant(consumerID, receiverID, issuerID, 0)
+	assert.Nil(t, err)
+	assert.False(t, granted)
+
+	granted, err = granter.Grant(consumerID, receiverID, issuerID, 100)
+	assert.Nil(t, err)
+	assert.True(t, granted)
+}
+
+func Test_Granter_DoesNotRegrantAfterCreditFailure(t *testing.T) {
+	credits := &mockCreditStorage{err: errors.New("credit failed")}
+	granter, cleanup := newTestGranter(t, credits)
+	defer cleanup()
+
+	_, err := granter.Grant(consumerID, receiverID, issuerID, 100)
+	assert.EqualError(t, err, "credit failed")
+
+	credits.err = nil
+	granted, err := granter.Grant(consumerID, receiverID, issuerID, 100)
+	assert.Nil(t, err)
+	assert.False(t, granted)
+}
+
+type mockCreditStorage struct {
+	credited map[identity.Identity]uint64
+	err      error
+}
+
+func (mcs *mockCreditStorage) AddCredit(consumerID, receiverID, issuerID identity.Identity, amount uint64) error {
+	if mcs.err != nil {
+		return mcs.err
+	}
+	if mcs.credited == nil {
+		mcs.credited = make(map[identity.Identity]uint64)
+	}
+	mcs.credited[consumerID] += amount
+	return nil
+}
@@ -31,7 +31,7 @@ type tequilapiTestSuite struct {
 }
 
 func (testSuite *tequilapiTestSuite) SetupSuite() {
-	testSuite.server = NewServer("localhost", 0, NewAPIRouter(), RegexpCorsPolicy{})
+	testSuite.server = NewServer("localhost", 0, NewAPIRouter(nil), RegexpCorsPolicy{})
 
 	assert.NoError(testSuite.T(), testSuite.server.StartServing())
 	address, err := testSuite.server.Address()
@@ -347,6 +347,18 @@ func (client *Client) ServiceSessions() (ServiceSessionListDTO, error) {
 	return sessions, err
 }
 
+// ServiceSessionTerminate terminates the service session by the requested id, optionally denying its consumer.
+func (client *Client) ServiceSessionTerminate(id string, deny bool) error {
+	path := fmt.Sprintf("service-sessions/%s?deny=%t", id, deny)
+	response, err := client.http.Delete(path, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
 // filterSessionsByType removes all sessions of irrelevant types
 func filterSessionsByType(serviceType string, sessions ConnectionSessionListDTO) ConnectionSessionListDTO {
 	matches := 0
@@ -22,11 +22,12 @@ import (
 	"fmt"
 )
 
-// StatusDTO holds connection status and session id
+// StatusDTO holds connection status, session id and capabilities consumer and provider agreed on
 type StatusDTO struct {
-	Status    string      `json:"status"`
-	SessionID string      `json:"sessionId"`
-	Proposal  ProposalDTO `json:"proposal"`
+	Status       string      `json:"status"`
+	SessionID    string      `json:"sessionId"`
+	Proposal     ProposalDTO `json:"proposal"`
+	Capabilities []string    `json:"capabilities"`
 }
 
 // StatisticsDTO holds statistics about connection
@@ -34,6 +35,17 @@ type StatisticsDTO struct {
 	BytesSent     uint64 `json:"bytesSent"`
 	BytesReceived uint64 `json:"bytesReceived"`
 	Duration      int    `json:"duration"`
+
+	Spent          MoneyDTO `json:"spent"`
+	SpentToday     MoneyDTO `json:"spentToday"`
+	SpentThisMonth MoneyDTO `json:"spentThisMonth"`
+	BalanceDrift   int64    `json:"balanceDrift"`
+}
+
+// MoneyDTO holds amount in smallest units of the currency
+type MoneyDTO struct {
+	Amount   uint64 `json:"amount,omitempty"`
+	Currency string `json:"currency,omitempty"`
 }
 
 // ProposalList describes list of proposals
@@ -79,6 +91,7 @@ type HealthcheckDTO struct {
 	Process   int          `json:"process"`
 	Version   string       `json:"version"`
 	BuildInfo BuildInfoDTO `json:"buildInfo"`
+	Broker    string       `json:"broker"`
 }
 
 // BuildInfoDTO holds info about build
@@ -111,6 +124,12 @@ type SignatureDTO struct {
 // ConnectOptions copied from tequilapi endpoint
 type ConnectOptions struct {
 	DisableKillSwitch bool `json:"killSwitch"`
+
+	SessionSpendingLimit uint64 `json:"sessionSpendingLimit,omitempty"`
+	DailySpendingLimit   uint64 `json:"dailySpendingLimit,omitempty"`
+	MonthlySpendingLimit uint64 `json:"monthlySpendingLimit,omitempty"`
+
+	IssuerID string `json:"issuerId,omitempty"`
 }
 
 // ConnectionSessionListDTO copied from tequilapi endpoint
@@ -141,6 +160,7 @@ type ServiceInfoDTO struct {
 	ServiceType string          `json:"type"`
 	Options     json.RawMessage `json:"options"`
 	Status      string          `json:"status"`
+	Subnets     []string        `json:"subnets,omitempty"`
 	Proposal    ProposalDTO     `json:"proposal"`
 }
 
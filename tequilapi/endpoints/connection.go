@@ -24,11 +24,13 @@ import (
 	"time"
 
 	log "github.com/cihub/seelog"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 	"github.com/mysteriumnetwork/node/tequilapi/validation"
 )
@@ -44,6 +46,26 @@ type ConnectOptions struct {
 	// required: false
 	// example: true
 	DisableKillSwitch bool `json:"killSwitch"`
+
+	// maximum amount to pay for the session in smallest MYST units, overrides the global limit
+	// required: false
+	// example: 100000000
+	SessionSpendingLimit uint64 `json:"sessionSpendingLimit,omitempty"`
+
+	// maximum amount to pay during the day in smallest MYST units, overrides the global limit
+	// required: false
+	// example: 500000000
+	DailySpendingLimit uint64 `json:"dailySpendingLimit,omitempty"`
+
+	// maximum amount to pay during the month in smallest MYST units, overrides the global limit
+	// required: false
+	// example: 5000000000
+	MonthlySpendingLimit uint64 `json:"monthlySpendingLimit,omitempty"`
+
+	// identity paying for the session instead of the consumer, it has to be unlocked
+	// required: false
+	// example: 0x0000000000000000000000000000000000000003
+	IssuerID string `json:"issuerId,omitempty"`
 }
 
 // swagger:model ConnectionRequestDTO
@@ -58,7 +80,7 @@ type connectionRequest struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"providerId"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "proxy" and "noop"
 	// required: false
 	// default: openvpn
 	// example: openvpn
@@ -79,6 +101,10 @@ type connectionResponse struct {
 
 	// example: {"id":1,"providerId":"0x71ccbdee7f6afe85a5bc7106323518518cd23b94","serviceType":"openvpn","serviceDefinition":{"locationOriginate":{"asn":"","country":"CA"}}}
 	Proposal *proposalRes `json:"proposal,omitempty"`
+
+	// capabilities consumer and provider agreed on
+	// example: ["encryption","keepalive","payment-promises","replay-protection","session-resume"]
+	Capabilities []string `json:"capabilities,omitempty"`
 }
 
 // swagger:model IPDTO
@@ -99,6 +125,19 @@ type statisticsResponse struct {
 	// connection duration in seconds
 	// example: 60
 	Duration int `json:"duration"`
+
+	// amount paid for the current session
+	Spent money.Money `json:"spent"`
+
+	// amount paid today
+	SpentToday money.Money `json:"spentToday"`
+
+	// amount paid this month
+	SpentThisMonth money.Money `json:"spentThisMonth"`
+
+	// how much more provider accounts left of the session prepayment than consumer does
+	// example: 0
+	BalanceDrift int64 `json:"balanceDrift"`
 }
 
 // SessionStatisticsTracker represents the session stat keeper
@@ -107,11 +146,17 @@ type SessionStatisticsTracker interface {
 	GetSessionDuration() time.Duration
 }
 
+// SpendingTracker keeps track of consumer spending
+type SpendingTracker interface {
+	Retrieve() connection.SpendingStatistics
+}
+
 // ConnectionEndpoint struct represents /connection resource and it's subresources
 type ConnectionEndpoint struct {
 	manager           connection.Manager
 	ipResolver        ip.Resolver
 	statisticsTracker SessionStatisticsTracker
+	spendingTracker   SpendingTracker
 	//TODO connection should use concrete proposal from connection params and avoid going to marketplace
 	proposalProvider ProposalProvider
 }
@@ -119,11 +164,12 @@ type ConnectionEndpoint struct {
 const connectionLogPrefix = "[Connection] "
 
 // NewConnectionEndpoint creates and returns connection endpoint
-func NewConnectionEndpoint(manager connection.Manager, ipResolver ip.Resolver, statsKeeper SessionStatisticsTracker, proposalProvider ProposalProvider) *ConnectionEndpoint {
+func NewConnectionEndpoint(manager connection.Manager, ipResolver ip.Resolver, statsKeeper SessionStatisticsTracker, spendingTracker SpendingTracker, proposalProvider ProposalProvider) *ConnectionEndpoint {
 	return &ConnectionEndpoint{
 		manager:           manager,
 		ipResolver:        ipResolver,
 		statisticsTracker: statsKeeper,
+		spendingTracker:   spendingTracker,
 		proposalProvider:  proposalProvider,
 	}
 }
@@ -164,7 +210,7 @@ func (ce *ConnectionEndpoint) Status(resp http.ResponseWriter, _ *http.Request,
 //     schema:
 //       "$ref": "#/definitions/ConnectionStatusDTO"
 //   400:
-//     description: Bad request
+//     description: Bad request or issuer identity is locked
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 //   409:
@@ -217,6 +263,8 @@ func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request
 			utils.SendError(resp, err, http.StatusConflict)
 		case connection.ErrConnectionCancelled:
 			utils.SendError(resp, err, statusConnectCancelled)
+		case connection.ErrIssuerLocked:
+			utils.SendError(resp, err, http.StatusBadRequest)
 		default:
 			log.Error(connectionLogPrefix, err)
 			utils.SendError(resp, err, http.StatusInternalServerError)
@@ -307,11 +355,16 @@ func (ce *ConnectionEndpoint) GetStatistics(writer http.ResponseWriter, request
 	st := ce.statisticsTracker.Retrieve()
 
 	duration := ce.statisticsTracker.GetSessionDuration()
+	spending := ce.spendingTracker.Retrieve()
 
 	response := statisticsResponse{
-		BytesSent:     st.BytesSent,
-		BytesReceived: st.BytesReceived,
-		Duration:      int(duration.Seconds()),
+		BytesSent:      st.BytesSent,
+		BytesReceived:  st.BytesReceived,
+		Duration:       int(duration.Seconds()),
+		Spent:          spending.Session,
+		SpentToday:     spending.Day,
+		SpentThisMonth: spending.Month,
+		BalanceDrift:   spending.BalanceDrift,
 	}
 
 	utils.WriteAsJSON(response, writer)
@@ -319,8 +372,8 @@ func (ce *ConnectionEndpoint) GetStatistics(writer http.ResponseWriter, request
 
 // AddRoutesForConnection adds connections routes to given router
 func AddRoutesForConnection(router *httprouter.Router, manager connection.Manager, ipResolver ip.Resolver,
-	statsKeeper SessionStatisticsTracker, proposalProvider ProposalProvider) {
-	connectionEndpoint := NewConnectionEndpoint(manager, ipResolver, statsKeeper, proposalProvider)
+	statsKeeper SessionStatisticsTracker, spendingTracker SpendingTracker, proposalProvider ProposalProvider) {
+	connectionEndpoint := NewConnectionEndpoint(manager, ipResolver, statsKeeper, spendingTracker, proposalProvider)
 	router.GET("/connection", connectionEndpoint.Status)
 	router.PUT("/connection", connectionEndpoint.Create)
 	router.DELETE("/connection", connectionEndpoint.Kill)
@@ -342,7 +395,20 @@ func toConnectionRequest(req *http.Request) (*connectionRequest, error) {
 }
 
 func getConnectOptions(cr *connectionRequest) connection.ConnectParams {
-	return connection.ConnectParams{DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch}
+	var issuerID identity.Identity
+	if cr.ConnectOptions.IssuerID != "" {
+		issuerID = identity.FromAddress(cr.ConnectOptions.IssuerID)
+	}
+
+	return connection.ConnectParams{
+		DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch,
+		SpendingLimits: connection.SpendingLimits{
+			PerSession: money.Money{Amount: cr.ConnectOptions.SessionSpendingLimit, Currency: money.CurrencyMyst},
+			PerDay:     money.Money{Amount: cr.ConnectOptions.DailySpendingLimit, Currency: money.CurrencyMyst},
+			PerMonth:   money.Money{Amount: cr.ConnectOptions.MonthlySpendingLimit, Currency: money.CurrencyMyst},
+		},
+		IssuerID: issuerID,
+	}
 }
 
 func validateConnectionRequest(cr *connectionRequest) *validation.FieldErrorMap {
@@ -353,6 +419,9 @@ func validateConnectionRequest(cr *connectionRequest) *validation.FieldErrorMap
 	if len(cr.ProviderID) == 0 {
 		errs.ForField("providerId").AddError("required", "Field is required")
 	}
+	if cr.ConnectOptions.IssuerID != "" && !common.IsHexAddress(cr.ConnectOptions.IssuerID) {
+		errs.ForField("issuerId").AddError("invalid", "Invalid identity address")
+	}
 	return errs
 }
 
@@ -361,6 +430,9 @@ func toConnectionResponse(status connection.Status) connectionResponse {
 		Status:    string(status.State),
 		SessionID: string(status.SessionID),
 	}
+	if len(status.Capabilities) > 0 {
+		response.Capabilities = status.Capabilities.Strings()
+	}
 
 	if status.Proposal.ProviderID != "" {
 		proposalRes := proposalToRes(status.Proposal)
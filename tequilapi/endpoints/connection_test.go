@@ -26,11 +26,13 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,12 +44,14 @@ type mockConnectionManager struct {
 	requestedConsumerID  identity.Identity
 	requestedProvider    identity.Identity
 	requestedServiceType string
+	requestedParams      connection.ConnectParams
 }
 
 func (cm *mockConnectionManager) Connect(consumerID identity.Identity, proposal market.ServiceProposal, options connection.ConnectParams) error {
 	cm.requestedConsumerID = consumerID
 	cm.requestedProvider = identity.FromAddress(proposal.ProviderID)
 	cm.requestedServiceType = proposal.ServiceType
+	cm.requestedParams = options
 	return cm.onConnectReturn
 }
 
@@ -100,7 +104,7 @@ func TestAddRoutesForConnectionAddsRoutes(t *testing.T) {
 	ipResolver := ip.NewResolverMock("123.123.123.123")
 
 	mockedProposalProvider := getMockProposalProviderWithSpecifiedProposal("node1", "noop")
-	AddRoutesForConnection(router, &fakeManager, ipResolver, statsKeeper, mockedProposalProvider)
+	AddRoutesForConnection(router, &fakeManager, ipResolver, statsKeeper, &mockSpendingTracker{}, mockedProposalProvider)
 
 	tests := []struct {
 		method         string
@@ -130,7 +134,11 @@ func TestAddRoutesForConnectionAddsRoutes(t *testing.T) {
 			http.StatusOK, `{
 				"bytesSent": 0,
 				"bytesReceived": 0,
-				"duration": 60
+				"duration": 60,
+				"spent": {},
+				"spentToday": {},
+				"spentThisMonth": {},
+				"balanceDrift": 0
 			}`,
 		},
 	}
@@ -155,7 +163,7 @@ func TestDisconnectingState(t *testing.T) {
 		SessionID: "",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -177,7 +185,7 @@ func TestNotConnectedStateIsReturnedWhenNoConnection(t *testing.T) {
 		SessionID: "",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -199,7 +207,7 @@ func TestStateConnectingIsReturnedWhenIsConnectionInProgress(t *testing.T) {
 		State: connection.Connecting,
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -222,7 +230,7 @@ func TestConnectedStateAndSessionIdIsReturnedWhenIsConnected(t *testing.T) {
 		SessionID: "My-super-session",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -239,10 +247,38 @@ func TestConnectedStateAndSessionIdIsReturnedWhenIsConnected(t *testing.T) {
 
 }
 
+func TestConnectedStateReturnsNegotiatedCapabilities(t *testing.T) {
+	var fakeManager = mockConnectionManager{}
+	fakeManager.onStatusReturn = connection.Status{
+		State:     connection.Connected,
+		SessionID: "My-super-session",
+		Capabilities: communication.NewCapabilities(
+			communication.CapabilityEncryption,
+			communication.CapabilitySessionResume,
+		),
+	}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "Connected",
+			"sessionId" : "My-super-session",
+			"capabilities" : ["encryption", "session-resume"]
+		}`,
+		resp.Body.String())
+}
+
 func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("a"))
 	resp := httptest.NewRecorder()
 
@@ -261,7 +297,7 @@ func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 func TestPutReturns422ErrorIfRequestBodyIsMissingFieldValues(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("{}"))
 	resp := httptest.NewRecorder()
 
@@ -284,7 +320,7 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, proposalProvider)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, proposalProvider)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -304,11 +340,122 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	assert.Equal(t, "openvpn", fakeManager.requestedServiceType)
 }
 
+func TestPutWithSpendingLimitsPassesThemToManager(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, proposalProvider)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {
+					"sessionSpendingLimit": 100,
+					"monthlySpendingLimit": 300
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(
+		t,
+		connection.SpendingLimits{
+			PerSession: money.Money{Amount: 100, Currency: money.CurrencyMyst},
+			PerDay:     money.Money{Amount: 0, Currency: money.CurrencyMyst},
+			PerMonth:   money.Money{Amount: 300, Currency: money.CurrencyMyst},
+		},
+		fakeManager.requestedParams.SpendingLimits,
+	)
+}
+
+func TestPutWithIssuerPassesItToManager(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, proposalProvider)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {
+					"issuerId": "0x0000000000000000000000000000000000000003"
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, identity.FromAddress("my-identity"), fakeManager.requestedConsumerID)
+	assert.Equal(t, identity.FromAddress("0x0000000000000000000000000000000000000003"), fakeManager.requestedParams.IssuerID)
+}
+
+func TestPutReturns422ErrorIfIssuerIsNotAnAddress(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {
+					"issuerId": "company-identity"
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "validation_error",
+			"errors" : {
+				"issuerId" : [ { "code" : "invalid" , "message" : "Invalid identity address" } ]
+			}
+		}`, resp.Body.String())
+}
+
+func TestPutReturns400ErrorIfIssuerIsLocked(t *testing.T) {
+	fakeManager := mockConnectionManager{onConnectReturn: connection.ErrIssuerLocked}
+
+	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, proposalProvider)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {
+					"issuerId": "0x0000000000000000000000000000000000000003"
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
 func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	mystAPI := getMockProposalProviderWithSpecifiedProposal("required-node", "noop")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, mystAPI)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, mystAPI)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -332,7 +479,7 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 func TestDeleteCallsDisconnect(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	req := httptest.NewRequest(http.MethodDelete, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -346,7 +493,7 @@ func TestDeleteCallsDisconnect(t *testing.T) {
 func TestGetIPEndpointSucceeds(t *testing.T) {
 	manager := mockConnectionManager{}
 	ipResolver := ip.NewResolverMock("123.123.123.123")
-	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	resp := httptest.NewRecorder()
 
 	connEndpoint.GetIP(resp, nil, nil)
@@ -364,7 +511,7 @@ func TestGetIPEndpointSucceeds(t *testing.T) {
 func TestGetIPEndpointReturnsErrorWhenIPDetectionFails(t *testing.T) {
 	manager := mockConnectionManager{}
 	ipResolver := ip.NewResolverMockFailing(errors.New("fake error"))
-	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 	resp := httptest.NewRecorder()
 
 	connEndpoint.GetIP(resp, nil, nil)
@@ -385,8 +532,18 @@ func TestGetStatisticsEndpointReturnsStatistics(t *testing.T) {
 		stats:    consumer.SessionStatistics{BytesSent: 1, BytesReceived: 2},
 	}
 
+	spendingTracker := &mockSpendingTracker{
+		spending: connection.SpendingStatistics{
+			Session: money.Money{Amount: 100, Currency: money.CurrencyMyst},
+			Day:     money.Money{Amount: 200, Currency: money.CurrencyMyst},
+			Month:   money.Money{Amount: 300, Currency: money.CurrencyMyst},
+
+			BalanceDrift: -5,
+		},
+	}
+
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, spendingTracker, &mockProposalProvider{})
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -395,7 +552,11 @@ func TestGetStatisticsEndpointReturnsStatistics(t *testing.T) {
 		`{
 			"bytesSent": 1,
 			"bytesReceived": 2,
-			"duration": 60
+			"duration": 60,
+			"spent": {"amount": 100, "currency": "MYST"},
+			"spentToday": {"amount": 200, "currency": "MYST"},
+			"spentThisMonth": {"amount": 300, "currency": "MYST"},
+			"balanceDrift": -5
 		}`,
 		resp.Body.String(),
 	)
@@ -407,7 +568,7 @@ func TestGetStatisticsEndpointReturnsStatisticsWhenSessionIsNotStarted(t *testin
 	}
 
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockSpendingTracker{}, &mockProposalProvider{})
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -416,7 +577,11 @@ func TestGetStatisticsEndpointReturnsStatisticsWhenSessionIsNotStarted(t *testin
 		`{
 			"bytesSent": 1,
 			"bytesReceived": 2,
-			"duration": 0
+			"duration": 0,
+			"spent": {},
+			"spentToday": {},
+			"spentThisMonth": {},
+			"balanceDrift": 0
 		}`,
 		resp.Body.String(),
 	)
@@ -427,7 +592,7 @@ func TestEndpointReturnsConflictStatusIfConnectionAlreadyExists(t *testing.T) {
 	manager.onConnectReturn = connection.ErrAlreadyExists
 
 	mystAPI := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, mystAPI)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockSpendingTracker{}, mystAPI)
 
 	req := httptest.NewRequest(
 		http.MethodPut,
@@ -455,7 +620,7 @@ func TestDisconnectReturnsConflictStatusIfConnectionDoesNotExist(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onDisconnectReturn = connection.ErrNoConnection
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockProposalProvider{})
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{})
 
 	req := httptest.NewRequest(
 		http.MethodDelete,
@@ -481,7 +646,7 @@ func TestConnectReturnsConnectCancelledStatusWhenErrConnectionCancelledIsEncount
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
 	mockProposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, mockProposalProvider)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockSpendingTracker{}, mockProposalProvider)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -508,7 +673,7 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockProposalProvider{proposals: make([]market.ServiceProposal, 0)})
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockSpendingTracker{}, &mockProposalProvider{proposals: make([]market.ServiceProposal, 0)})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -530,3 +695,11 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 		resp.Body.String(),
 	)
 }
+
+type mockSpendingTracker struct {
+	spending connection.SpendingStatistics
+}
+
+func (mst *mockSpendingTracker) Retrieve() connection.SpendingStatistics {
+	return mst.spending
+}
@@ -37,6 +37,10 @@ type healthCheckData struct {
 	// example: 0.0.6
 	Version   string    `json:"version"`
 	BuildInfo buildInfo `json:"buildInfo"`
+
+	// connection status of provided services to message broker, omitted when node provides none
+	// example: Connected
+	Broker string `json:"broker,omitempty"`
 }
 
 // swagger:model BuildInfoDTO
@@ -51,22 +55,29 @@ type buildInfo struct {
 	BuildNumber string `json:"buildNumber"`
 }
 
+// BrokerStatusProvider reports connection status of the node to message broker
+type BrokerStatusProvider interface {
+	Status() string
+}
+
 type healthCheckEndpoint struct {
 	startTime       time.Time
 	currentTimeFunc func() time.Time
 	processNumber   int
+	brokerStatus    BrokerStatusProvider
 }
 
 /*
 HealthCheckEndpointFactory creates a structure with single HealthCheck method for healthcheck serving as http,
 currentTimeFunc is injected for easier testing
 */
-func HealthCheckEndpointFactory(currentTimeFunc func() time.Time, procID func() int) *healthCheckEndpoint {
+func HealthCheckEndpointFactory(currentTimeFunc func() time.Time, procID func() int, brokerStatus BrokerStatusProvider) *healthCheckEndpoint {
 	startTime := currentTimeFunc()
 	return &healthCheckEndpoint{
 		startTime,
 		currentTimeFunc,
 		procID(),
+		brokerStatus,
 	}
 }
 
@@ -94,5 +105,8 @@ func (hce *healthCheckEndpoint) HealthCheck(writer http.ResponseWriter, request
 			metadata.BuildNumber,
 		},
 	}
+	if hce.brokerStatus != nil {
+		status.Broker = hce.brokerStatus.Status()
+	}
 	utils.WriteAsJSON(status, writer)
 }
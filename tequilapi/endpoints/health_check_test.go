@@ -18,6 +18,7 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -42,6 +43,7 @@ func TestHealthCheckReturnsExpectedJSONObject(t *testing.T) {
 	handlerFunc := HealthCheckEndpointFactory(
 		newMockTimer([]time.Time{tick1, tick2}).Now,
 		func() int { return 1 },
+		nil,
 	).HealthCheck
 	handlerFunc(resp, req, httprouter.Params{})
 
@@ -60,6 +62,30 @@ func TestHealthCheckReturnsExpectedJSONObject(t *testing.T) {
 		resp.Body.String())
 }
 
+func TestHealthCheckReturnsBrokerStatus(t *testing.T) {
+	req := httptest.NewRequest("GET", "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	handlerFunc := HealthCheckEndpointFactory(
+		newMockTimer([]time.Time{time.Unix(0, 0)}).Now,
+		func() int { return 1 },
+		&mockBrokerStatus{status: "Disconnected"},
+	).HealthCheck
+	handlerFunc(resp, req, httprouter.Params{})
+
+	var status healthCheckData
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	assert.Equal(t, "Disconnected", status.Broker)
+}
+
+type mockBrokerStatus struct {
+	status string
+}
+
+func (mbs *mockBrokerStatus) Status() string {
+	return mbs.status
+}
+
 type mockTimer struct {
 	values  []time.Time
 	current int
@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise/ledger"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+type promiseLedger interface {
+	Entries(filter ledger.Filter) ([]ledger.Entry, error)
+}
+
+type promisesEndpoint struct {
+	ledger promiseLedger
+}
+
+// NewPromisesEndpoint creates and returns promises endpoint
+func NewPromisesEndpoint(ledger promiseLedger) *promisesEndpoint {
+	return &promisesEndpoint{
+		ledger: ledger,
+	}
+}
+
+// swagger:operation GET /promises Promises listPromises
+// ---
+// summary: Returns received promises
+// description: Returns promises received by provider with their signatures re-checked
+// parameters:
+// - in: query
+//   name: issuer
+//   description: only list promises of the given issuer
+//   type: string
+// - in: query
+//   name: consumer
+//   description: only list promises of the given consumer
+//   type: string
+// - in: query
+//   name: format
+//   description: export format, json (default) or csv
+//   type: string
+// responses:
+//   200:
+//     description: List of promises
+//     schema:
+//       "$ref": "#/definitions/PromiseListDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *promisesEndpoint) List(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	query := request.URL.Query()
+
+	format := ledger.FormatJSON
+	if value := query.Get("format"); value != "" {
+		var err error
+		if format, err = ledger.ParseFormat(value); err != nil {
+			utils.SendError(resp, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := endpoint.ledger.Entries(ledger.Filter{
+		Issuer:   identity.FromAddress(query.Get("issuer")),
+		Consumer: identity.FromAddress(query.Get("consumer")),
+	})
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp.Header().Set("Content-type", format.ContentType())
+	if err := ledger.Export(resp, format, entries); err != nil {
+		http.Error(resp, "Http response write error", http.StatusInternalServerError)
+	}
+}
+
+// AddRoutesForPromises attaches promises endpoints to router
+func AddRoutesForPromises(router *httprouter.Router, promiseLedger promiseLedger) {
+	promisesEndpoint := NewPromisesEndpoint(promiseLedger)
+	router.GET("/promises", promisesEndpoint.List)
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise/ledger"
+	"github.com/stretchr/testify/assert"
+)
+
+var promiseEntryMock = ledger.Entry{
+	Issuer:         "0x0000000000000000000000000000000000000001",
+	Consumer:       "0x0000000000000000000000000000000000000002",
+	Receiver:       "0x0000000000000000000000000000000000000003",
+	SequenceID:     1,
+	Amount:         100,
+	SignatureValid: true,
+}
+
+func Test_PromisesEndpoint_ListJSON(t *testing.T) {
+	promiseLedger := &promiseLedgerMock{entries: []ledger.Entry{promiseEntryMock}}
+	req := httptest.NewRequest(http.MethodGet, "/promises?issuer=0x0000000000000000000000000000000000000001", nil)
+	resp := httptest.NewRecorder()
+
+	NewPromisesEndpoint(promiseLedger).List(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header().Get("Content-type"))
+	assert.Equal(t, identity.FromAddress("0x0000000000000000000000000000000000000001"), promiseLedger.filter.Issuer)
+	assert.Equal(t, identity.FromAddress(""), promiseLedger.filter.Consumer)
+	assert.JSONEq(
+		t,
+		`{
+			"promises": [{
+				"issuer": "0x0000000000000000000000000000000000000001",
+				"consumer": "0x0000000000000000000000000000000000000002",
+				"receiver": "0x0000000000000000000000000000000000000003",
+				"sequenceId": 1,
+				"amount": 100,
+				"unconsumedAmount": 0,
+				"signature": "",
+				"signatureValid": true,
+				"cleared": false,
+				"addedAt": "0001-01-01T00:00:00Z",
+				"updatedAt": "0001-01-01T00:00:00Z"
+			}]
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func Test_PromisesEndpoint_ListCSV(t *testing.T) {
+	promiseLedger := &promiseLedgerMock{entries: []ledger.Entry{promiseEntryMock}}
+	req := httptest.NewRequest(http.MethodGet, "/promises?consumer=0x0000000000000000000000000000000000000002&format=csv", nil)
+	resp := httptest.NewRecorder()
+
+	NewPromisesEndpoint(promiseLedger).List(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header().Get("Content-type"))
+	assert.Equal(t, identity.FromAddress("0x0000000000000000000000000000000000000002"), promiseLedger.filter.Consumer)
+	assert.Contains(t, resp.Body.String(), "0x0000000000000000000000000000000000000001,0x0000000000000000000000000000000000000002")
+}
+
+func Test_PromisesEndpoint_ListRejectsUnknownFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/promises?format=xml", nil)
+	resp := httptest.NewRecorder()
+
+	NewPromisesEndpoint(&promiseLedgerMock{}).List(resp, req, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func Test_PromisesEndpoint_ListFailsOnLedgerError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/promises", nil)
+	resp := httptest.NewRecorder()
+
+	NewPromisesEndpoint(&promiseLedgerMock{err: errors.New("storage failure")}).List(resp, req, nil)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"message": "storage failure"}`, resp.Body.String())
+}
+
+type promiseLedgerMock struct {
+	entries []ledger.Entry
+	err     error
+	filter  ledger.Filter
+}
+
+func (mock *promiseLedgerMock) Entries(filter ledger.Filter) ([]ledger.Entry, error) {
+	mock.filter = filter
+	return mock.entries, mock.err
+}
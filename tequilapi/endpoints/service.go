@@ -19,6 +19,7 @@ package endpoints
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -35,7 +36,7 @@ type serviceRequest struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"providerId"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "proxy" and "noop"
 	// required: true
 	// example: openvpn
 	Type string `json:"type"`
@@ -58,7 +59,7 @@ type serviceInfo struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"providerId"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "proxy" and "noop"
 	// example: openvpn
 	Type string `json:"type"`
 
@@ -69,6 +70,10 @@ type serviceInfo struct {
 	// example: Running
 	Status string `json:"status"`
 
+	// VPN subnets reserved by the service
+	// example: ["10.8.0.0/24"]
+	Subnets []string `json:"subnets,omitempty"`
+
 	Proposal proposalRes `json:"proposal"`
 }
 
@@ -312,10 +317,19 @@ func toServiceInfoResponse(id service.ID, instance *service.Instance) serviceInf
 		Type:       proposal.ServiceType,
 		Options:    instance.Options(),
 		Status:     string(instance.State()),
+		Subnets:    toSubnetsResponse(instance.Subnets()),
 		Proposal:   proposalToRes(instance.Proposal()),
 	}
 }
 
+func toSubnetsResponse(subnets []net.IPNet) []string {
+	var res []string
+	for _, subnet := range subnets {
+		res = append(res, subnet.String())
+	}
+	return res
+}
+
 func toServiceListResponse(instances map[service.ID]*service.Instance) serviceList {
 	res := make([]serviceInfo, 0)
 	for id, instance := range instances {
@@ -20,6 +20,7 @@ package endpoints
 import (
 	"net/http"
 	"sort"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/session"
@@ -46,14 +47,20 @@ type serviceSessionStorage interface {
 	GetAll() []session.Session
 }
 
+type serviceSessionTerminator interface {
+	Terminate(id session.ID, deny bool) error
+}
+
 type serviceSessionsEndpoint struct {
-	sessionStorage serviceSessionStorage
+	sessionStorage    serviceSessionStorage
+	sessionTerminator serviceSessionTerminator
 }
 
 // NewServiceSessionsEndpoint creates and returns sessions endpoint
-func NewServiceSessionsEndpoint(sessionStorage serviceSessionStorage) *serviceSessionsEndpoint {
+func NewServiceSessionsEndpoint(sessionStorage serviceSessionStorage, sessionTerminator serviceSessionTerminator) *serviceSessionsEndpoint {
 	return &serviceSessionsEndpoint{
-		sessionStorage: sessionStorage,
+		sessionStorage:    sessionStorage,
+		sessionTerminator: sessionTerminator,
 	}
 }
 
@@ -77,10 +84,61 @@ func (endpoint *serviceSessionsEndpoint) List(resp http.ResponseWriter, request
 	utils.WriteAsJSON(sessionsSerializable, resp)
 }
 
+// swagger:operation DELETE /service-sessions/:id Service serviceSessionTerminate
+// ---
+// summary: Terminates session
+// description: Destroys the session of service consumer and notifies the consumer about it
+// parameters:
+// - in: path
+//   name: id
+//   description: id of session to terminate
+//   type: string
+//   required: true
+// - in: query
+//   name: deny
+//   description: deny the session consumer any further sessions
+//   type: boolean
+// responses:
+//   202:
+//     description: Session terminated
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Session not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceSessionsEndpoint) Terminate(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	deny := false
+	if value := request.URL.Query().Get("deny"); value != "" {
+		var err error
+		if deny, err = strconv.ParseBool(value); err != nil {
+			utils.SendErrorMessage(resp, "Invalid deny parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := endpoint.sessionTerminator.Terminate(session.ID(params.ByName("id")), deny)
+	switch err {
+	case nil:
+		resp.WriteHeader(http.StatusAccepted)
+	case session.ErrorSessionNotExists:
+		utils.SendErrorMessage(resp, "Session not found", http.StatusNotFound)
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+	}
+}
+
 // AddRoutesForServiceSessions attaches service sessions endpoints to router
-func AddRoutesForServiceSessions(router *httprouter.Router, sessionStorage serviceSessionStorage) {
-	sessionsEndpoint := NewServiceSessionsEndpoint(sessionStorage)
+func AddRoutesForServiceSessions(router *httprouter.Router, sessionStorage serviceSessionStorage, sessionTerminator serviceSessionTerminator) {
+	sessionsEndpoint := NewServiceSessionsEndpoint(sessionStorage, sessionTerminator)
 	router.GET("/service-sessions", sessionsEndpoint.List)
+	router.DELETE("/service-sessions/:id", sessionsEndpoint.Terminate)
 }
 
 func serviceSessionToDto(se session.Session) serviceSession {
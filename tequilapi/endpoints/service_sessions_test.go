@@ -24,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mysteriumnetwork/node/identity"
@@ -67,7 +68,7 @@ func Test_ServiceSessionsEndpoint_List(t *testing.T) {
 	}
 
 	resp := httptest.NewRecorder()
-	handlerFunc := NewServiceSessionsEndpoint(ssm).List
+	handlerFunc := NewServiceSessionsEndpoint(ssm, nil).List
 	handlerFunc(resp, req, nil)
 
 	parsedResponse := &serviceSessionsList{}
@@ -84,3 +85,58 @@ type serviceSessionStorageMock struct {
 func (ssm *serviceSessionStorageMock) GetAll() []session.Session {
 	return ssm.sessionsToReturn
 }
+
+func Test_ServiceSessionsEndpoint_Terminate(t *testing.T) {
+	terminator := &serviceSessionTerminatorMock{}
+	router := httprouter.New()
+	AddRoutesForServiceSessions(router, &serviceSessionStorageMock{}, terminator)
+
+	req, err := http.NewRequest(http.MethodDelete, "/service-sessions/session1?deny=true", nil)
+	assert.Nil(t, err)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, session.ID("session1"), terminator.terminatedID)
+	assert.True(t, terminator.denied)
+}
+
+func Test_ServiceSessionsEndpoint_TerminateUnknownSession(t *testing.T) {
+	terminator := &serviceSessionTerminatorMock{errorToReturn: session.ErrorSessionNotExists}
+	router := httprouter.New()
+	AddRoutesForServiceSessions(router, &serviceSessionStorageMock{}, terminator)
+
+	req, err := http.NewRequest(http.MethodDelete, "/service-sessions/unknown", nil)
+	assert.Nil(t, err)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.False(t, terminator.denied)
+}
+
+func Test_ServiceSessionsEndpoint_TerminateInvalidDeny(t *testing.T) {
+	terminator := &serviceSessionTerminatorMock{}
+	router := httprouter.New()
+	AddRoutesForServiceSessions(router, &serviceSessionStorageMock{}, terminator)
+
+	req, err := http.NewRequest(http.MethodDelete, "/service-sessions/session1?deny=maybe", nil)
+	assert.Nil(t, err)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, terminator.terminatedID)
+}
+
+type serviceSessionTerminatorMock struct {
+	terminatedID  session.ID
+	denied        bool
+	errorToReturn error
+}
+
+func (sst *serviceSessionTerminatorMock) Terminate(id session.ID, deny bool) error {
+	sst.terminatedID = id
+	sst.denied = deny
+	return sst.errorToReturn
+}